@@ -24,6 +24,7 @@ func newChartCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.
 	cmd.AddCommand(newChartUploadCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartPackCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartLintCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newChartTestRenderCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartSecretCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartTSCommand(ctx, afterAllCommandsBuiltFuncs))
 
@@ -20,6 +20,7 @@ func newChartTSCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobr
 
 	cmd.AddCommand(newChartTSInitCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartTSBuildCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newChartTSTypesCommand(ctx, afterAllCommandsBuiltFuncs))
 
 	return cmd
 }
@@ -83,6 +83,12 @@ func newChartTSBuildCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.Watch, "watch", false, "Rebuild the chart on changes in TypeScript sources and values files", cli.AddFlagOptions{
+			Group: mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		return nil
 	}
 
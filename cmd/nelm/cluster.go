@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/cli"
+)
+
+func newClusterCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.Command]func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := cli.NewGroupCommand(
+		ctx,
+		"cluster",
+		"Work with Kubernetes clusters.",
+		"Work with Kubernetes clusters.",
+		clusterCmdGroup,
+		cli.GroupCommandOptions{},
+	)
+
+	cmd.AddCommand(newClusterSnapshotCommand(ctx, afterAllCommandsBuiltFuncs))
+
+	return cmd
+}
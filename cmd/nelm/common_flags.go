@@ -87,6 +87,28 @@ func AddChartRepoConnectionFlags(cmd *cobra.Command, cfg *common.ChartRepoConnec
 	return nil
 }
 
+func AddKubeRecordFlags(cmd *cobra.Command, cfg *common.KubeConnectionOptions) error {
+	if err := cli.AddFlag(cmd, &cfg.KubeRecordDir, "kube-record", "", "Save all requests to Kubernetes API and responses to them into the directory, with Secrets redacted, to replay them later with --kube-replay", cli.AddFlagOptions{
+		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+		Group:                kubeConnectionFlagGroup,
+		Type:                 cli.FlagTypeDir,
+	}); err != nil {
+		return fmt.Errorf("add flag: %w", err)
+	}
+
+	if err := cli.AddFlag(cmd, &cfg.KubeReplayDir, "kube-replay", "", "Don't connect to Kubernetes, serve requests to Kubernetes API from responses saved into the directory with --kube-record", cli.AddFlagOptions{
+		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+		Group:                kubeConnectionFlagGroup,
+		Type:                 cli.FlagTypeDir,
+	}); err != nil {
+		return fmt.Errorf("add flag: %w", err)
+	}
+
+	cmd.MarkFlagsMutuallyExclusive("kube-record", "kube-replay")
+
+	return nil
+}
+
 func AddKubeConnectionFlags(cmd *cobra.Command, cfg *common.KubeConnectionOptions) error {
 	if err := cli.AddFlag(cmd, &cfg.KubeAPIServerAddress, "kube-api-server", "", "Kubernetes API server address", cli.AddFlagOptions{
 		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
@@ -346,6 +368,21 @@ func AddResourceValidationFlags(cmd *cobra.Command, cfg *common.ResourceValidati
 		return fmt.Errorf("add flag: %w", err)
 	}
 
+	if err := cli.AddFlag(cmd, &cfg.ValidationSchemaBundle, "resource-validation-schema-bundle", "", "Use json schemas from the bundle created with \"nelm schema pull\" instead of default json schema sources, without network access", cli.AddFlagOptions{
+		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+		Group:                resourceValidationGroup,
+		Type:                 cli.FlagTypeFile,
+	}); err != nil {
+		return fmt.Errorf("add flag: %w", err)
+	}
+
+	if err := cli.AddFlag(cmd, &cfg.ValidationClusterSchemas, "resource-validation-cluster-schemas", false, "Validate resources against OpenAPI v3 schemas of the cluster, which include schemas of installed CRDs (preferred over json schema sources). Only used when cluster access is allowed", cli.AddFlagOptions{
+		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+		Group:                resourceValidationGroup,
+	}); err != nil {
+		return fmt.Errorf("add flag: %w", err)
+	}
+
 	if err := cli.AddFlag(cmd, &cfg.ValidationSchemaCacheLifetime, "resource-validation-cache-lifetime", common.DefaultResourceValidationCacheLifetime, "How long local schema cache will be valid", cli.AddFlagOptions{
 		GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
 		Group:                resourceValidationGroup,
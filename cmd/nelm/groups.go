@@ -11,6 +11,8 @@ var (
 	dependencyCmdGroup      = cli.NewCommandGroup("dependency", "Dependency commands:", 70)
 	tsCmdGroup              = cli.NewCommandGroup("ts", "TypeScript commands:", 60)
 	repoCmdGroup            = cli.NewCommandGroup("repo", "Repo commands:", 60)
+	schemaCmdGroup          = cli.NewCommandGroup("schema", "Schema commands:", 50)
+	clusterCmdGroup         = cli.NewCommandGroup("cluster", "Cluster commands:", 40)
 	miscCmdGroup            = cli.NewCommandGroup("misc", "Other commands:", 0)
 	mainFlagGroup           = cli.NewFlagGroup("main", "Options:", 100)
 	valuesFlagGroup         = cli.NewFlagGroup("values", "Values options:", 90)
@@ -20,6 +20,7 @@ func newReleaseCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobr
 	)
 
 	cmd.AddCommand(newReleaseInstallCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseApplySetCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newReleaseRollbackCommand(ctx, afterAllCommandsBuiltFuncs))
 
 	if featgate.FeatGateNativeReleaseUninstall.Enabled() || featgate.FeatGatePreviewV2.Enabled() {
@@ -37,7 +38,14 @@ func newReleaseCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobr
 	}
 
 	cmd.AddCommand(newReleaseGetCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseOrphansCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseReapCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseCheckAPIsCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newPlanCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseStorageCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseLockCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseProtectCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseUnprotectCommand(ctx, afterAllCommandsBuiltFuncs))
 
 	return cmd
 }
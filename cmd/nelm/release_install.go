@@ -17,10 +17,13 @@ import (
 type releaseInstallConfig struct {
 	action.ReleaseInstallOptions
 
-	LogColorMode     string
-	LogLevel         string
-	ReleaseName      string
-	ReleaseNamespace string
+	KubeContexts       []string
+	LogColorMode       string
+	LogLevel           string
+	ReleaseName        string
+	ReleaseNamespace   string
+	TargetsFilePath    string
+	TargetsParallelism int
 }
 
 func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.Command]func(cmd *cobra.Command) error) *cobra.Command {
@@ -59,8 +62,33 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 				}
 			}
 
-			if err := action.ReleaseInstall(ctx, cfg.ReleaseName, cfg.ReleaseNamespace, cfg.ReleaseInstallOptions); err != nil {
-				return fmt.Errorf("install: %w", err)
+			if cfg.TargetsFilePath == "" && len(cfg.KubeContexts) == 0 {
+				if err := action.ReleaseInstall(ctx, cfg.ReleaseName, cfg.ReleaseNamespace, cfg.ReleaseInstallOptions); err != nil {
+					return fmt.Errorf("install: %w", err)
+				}
+
+				return nil
+			}
+
+			var (
+				targets *action.ReleaseTargets
+				err     error
+			)
+			if cfg.TargetsFilePath != "" {
+				targets, err = action.LoadReleaseTargets(cfg.TargetsFilePath, cfg.ReleaseNamespace)
+			} else {
+				targets, err = action.NewReleaseTargetsFromKubeContexts(cfg.KubeContexts, cfg.ReleaseNamespace)
+			}
+
+			if err != nil {
+				return fmt.Errorf("build targets: %w", err)
+			}
+
+			if err := action.ReleaseInstallTargets(ctx, cfg.ReleaseName, targets, action.ReleaseInstallTargetsOptions{
+				ReleaseInstallOptions: cfg.ReleaseInstallOptions,
+				Parallelism:           cfg.TargetsParallelism,
+			}); err != nil {
+				return fmt.Errorf("install into targets: %w", err)
 			}
 
 			return nil
@@ -72,6 +100,10 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add kube connection flags: %w", err)
 		}
 
+		if err := AddKubeRecordFlags(cmd, &cfg.KubeConnectionOptions); err != nil {
+			return fmt.Errorf("add kube record flags: %w", err)
+		}
+
 		if err := AddChartRepoConnectionFlags(cmd, &cfg.ChartRepoConnectionOptions); err != nil {
 			return fmt.Errorf("add chart repo connection flags: %w", err)
 		}
@@ -145,6 +177,13 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.FieldManager, "field-manager", common.DefaultFieldManager, "Field manager name for server-side apply. Fields owned by the default field manager are migrated to it", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		if err := cli.AddFlag(cmd, &cfg.ExtraAnnotations, "annotations", map[string]string{}, "Add annotations to all resources", cli.AddFlagOptions{
 			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalMultiEnvVarRegexes,
 			Group:                patchFlagGroup,
@@ -173,6 +212,14 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.AdoptResources, "adopt-resource", []string{}, "Allow adopting resources with specified attributes, even if they belong to a different Helm release or to no release. Format: key1=value1,key2=value2. Supported keys: group, version, kind, name, namespace. Example: kind=Deployment,name=my-app", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+			NoSplitOnCommas:      true,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		if err := cli.AddFlag(cmd, &cfg.ForceAdoption, "force-adoption", false, "Always adopt resources, even if they belong to a different Helm release", cli.AddFlagOptions{
 			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
 			Group:                mainFlagGroup,
@@ -201,6 +248,13 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.NoDryApplyCache, "no-dry-apply-cache", false, "Don't reuse results of server-side dry-run applies from recent plans and installs, and don't save new ones. Results are only reused while neither the resource manifest nor the resource in the cluster have changed", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+			Group:                performanceFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		if err := cli.AddFlag(cmd, &cfg.NoInstallStandaloneCRDs, "no-install-crds", false, `Don't install CRDs from "crds/" directories of installed charts`, cli.AddFlagOptions{
 			GetEnvVarRegexesFunc: cli.GetFlagLocalEnvVarRegexes,
 			Group:                mainFlagGroup,
@@ -215,6 +269,13 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.OverrideProtection, "override-protection", false, "Allow deleting resources marked with werf.io/protect annotation", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		if err := cli.AddFlag(cmd, &cfg.NoCreateNamespace, "no-create-namespace", false, "Don't create the release namespace", cli.AddFlagOptions{
 			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
 			Group:                mainFlagGroup,
@@ -256,8 +317,23 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.ReleaseTTL, "ttl", 0, "Record in the release metadata that the release expires after this duration, e.g. 72h. Expired releases are uninstalled by \"release reap\". Installing without --ttl makes the release never expire", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalAndLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
+		// TODO: restrict allowed values
+		if err := cli.AddFlag(cmd, &cfg.ReleaseLocker, "release-locker", "", "How releases should be locked during the operation: configmap (werf-synchronization ConfigMap) or lease (a Lease per release, recording the lock holder)", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalEnvVarRegexes,
+			Group:                miscFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		// TODO: restrict allowed values
-		if err := cli.AddFlag(cmd, &cfg.ReleaseStorageDriver, "release-storage", "", "How releases should be stored", cli.AddFlagOptions{
+		if err := cli.AddFlag(cmd, &cfg.ReleaseStorageDriver, "release-storage", "", "How releases should be stored: secret, configmap, sql, filesystem or s3", cli.AddFlagOptions{
 			GetEnvVarRegexesFunc: cli.GetFlagGlobalEnvVarRegexes,
 			Group:                miscFlagGroup,
 		}); err != nil {
@@ -271,6 +347,21 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.ReleaseStorageDir, "release-storage-dir", "", "Directory to store releases in for filesystem release storage driver", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalEnvVarRegexes,
+			Group:                miscFlagGroup,
+			Type:                 cli.FlagTypeDir,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
+		if err := cli.AddFlag(cmd, &cfg.ReleaseStorageS3URL, "release-storage-s3-url", "", "Bucket URL for s3 release storage driver: s3://<bucket>[/<prefix>] for AWS S3 or http[s]://<host>/<bucket>[/<prefix>] for S3-compatible storages. Credentials are taken from $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagGlobalEnvVarRegexes,
+			Group:                miscFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		if err := cli.AddFlag(cmd, &cfg.RollbackGraphPath, "save-rollback-graph-to", "", "Save the Graphviz rollback graph to a file", cli.AddFlagOptions{
 			Group: mainFlagGroup,
 			Type:  cli.FlagTypeFile,
@@ -365,8 +456,31 @@ func newReleaseInstallCommand(ctx context.Context, afterAllCommandsBuiltFuncs ma
 			return fmt.Errorf("add flag: %w", err)
 		}
 
+		if err := cli.AddFlag(cmd, &cfg.KubeContexts, "kube-contexts", []string{}, "Install the release into each of these kube contexts instead of the current one", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
+		if err := cli.AddFlag(cmd, &cfg.TargetsFilePath, "targets-file", "", "Install the release into each target from the file. The file has a list of targets under the `targets` key, each with `name`, `kubeContext`, `kubeConfig`, `namespace`, `values`, `secretValues`, `set` and `setString` fields", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+			Type:                 cli.FlagTypeFile,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
+		if err := cli.AddFlag(cmd, &cfg.TargetsParallelism, "targets-parallelism", action.DefaultReleaseInstallTargetsParallelism, "Limit of targets to install in parallel", cli.AddFlagOptions{
+			GetEnvVarRegexesFunc: cli.GetFlagLocalEnvVarRegexes,
+			Group:                mainFlagGroup,
+		}); err != nil {
+			return fmt.Errorf("add flag: %w", err)
+		}
+
 		cmd.MarkFlagsOneRequired("use-plan", "release")
-		cmd.MarkFlagsOneRequired("use-plan", "namespace")
+		cmd.MarkFlagsOneRequired("use-plan", "namespace", "targets-file")
+		cmd.MarkFlagsMutuallyExclusive("use-plan", "kube-contexts", "targets-file")
 
 		return nil
 	}
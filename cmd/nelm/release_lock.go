@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/cli"
+)
+
+func newReleaseLockCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.Command]func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := cli.NewGroupCommand(
+		ctx,
+		"lock",
+		"Inspect and break release locks.",
+		"Inspect and break release locks.",
+		releaseCmdGroup,
+		cli.GroupCommandOptions{},
+	)
+
+	cmd.AddCommand(newReleaseLockStatusCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newReleaseLockBreakCommand(ctx, afterAllCommandsBuiltFuncs))
+
+	return cmd
+}
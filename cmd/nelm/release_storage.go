@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/cli"
+)
+
+func newReleaseStorageCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.Command]func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := cli.NewGroupCommand(
+		ctx,
+		"storage",
+		"Manage release storages.",
+		"Manage release storages.",
+		releaseCmdGroup,
+		cli.GroupCommandOptions{},
+	)
+
+	cmd.AddCommand(newReleaseStorageMigrateCommand(ctx, afterAllCommandsBuiltFuncs))
+
+	return cmd
+}
@@ -27,6 +27,8 @@ func NewRootCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.C
 	cmd.AddCommand(newReleaseCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newChartCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newRepoCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newSchemaCommand(ctx, afterAllCommandsBuiltFuncs))
+	cmd.AddCommand(newClusterCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newVersionCommand(ctx, afterAllCommandsBuiltFuncs))
 	cmd.AddCommand(newGenerateReferenceCommand(ctx, afterAllCommandsBuiltFuncs))
 
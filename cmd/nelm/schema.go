@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/werf/common-go/pkg/cli"
+)
+
+func newSchemaCommand(ctx context.Context, afterAllCommandsBuiltFuncs map[*cobra.Command]func(cmd *cobra.Command) error) *cobra.Command {
+	cmd := cli.NewGroupCommand(
+		ctx,
+		"schema",
+		"Manage json schemas for resource validation.",
+		"Manage json schemas for resource validation.",
+		schemaCmdGroup,
+		cli.GroupCommandOptions{},
+	)
+
+	cmd.AddCommand(newSchemaPullCommand(ctx, afterAllCommandsBuiltFuncs))
+
+	return cmd
+}
@@ -77,6 +77,12 @@ type ChartLintOptions struct {
 	// ExtraRuntimeLabels are additional labels to add to resources during validation.
 	// These are used for the validation dry-run but not stored.
 	ExtraRuntimeLabels map[string]string
+	// FailOnRemovedAPIs, when true, fails linting if resources use APIs removed in the target
+	// Kubernetes version. Otherwise, only a warning is printed.
+	FailOnRemovedAPIs bool
+	// FieldManager is the field manager name used for server-side dry-run apply of resources.
+	// Defaults to DefaultFieldManager if not specified.
+	FieldManager string
 	// ForceAdoption, when true, allows adopting resources during validation that belong to a different Helm release.
 	// Used during the validation phase to check if resources could be adopted.
 	ForceAdoption bool
@@ -120,10 +126,16 @@ type ChartLintOptions struct {
 	// ReleaseNamespace is the namespace where the release would be installed for linting purposes.
 	// Available as .Release.Namespace in chart templates. Defaults to a stub value if not specified.
 	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata would be stored (affects validation).
 	// Valid values: "secret" (default), "configmap", "sql", "memory".
 	// Set to "memory" automatically when Remote is false.
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -207,6 +219,8 @@ func ChartLint(ctx context.Context, opts ChartLintOptions) error {
 	}
 
 	releaseStorageOptions := release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	}
 
@@ -344,10 +358,32 @@ func ChartLint(ctx context.Context, opts ChartLintOptions) error {
 		return fmt.Errorf("build resources: %w", err)
 	}
 
-	log.Default.Debug(ctx, "Locally validate resources")
+	log.Default.Debug(ctx, "Validate resources")
 
-	if err := resource.ValidateLocal(ctx, opts.ReleaseNamespace, instResources, opts.ResourceValidationOptions); err != nil {
-		return fmt.Errorf("locally validate resources: %w", err)
+	if opts.Remote {
+		err = resource.ValidateWithCluster(ctx, opts.ReleaseNamespace, instResources, clientFactory.Discovery(), opts.ResourceValidationOptions)
+	} else {
+		err = resource.ValidateLocal(ctx, opts.ReleaseNamespace, instResources, opts.ResourceValidationOptions)
+	}
+
+	if err != nil {
+		return fmt.Errorf("validate resources: %w", err)
+	}
+
+	log.Default.Debug(ctx, "Check deprecated APIs")
+
+	var serverVersion string
+	if opts.Remote {
+		version, err := clientFactory.Discovery().ServerVersion()
+		if err != nil {
+			return fmt.Errorf("get kubernetes server version: %w", err)
+		}
+
+		serverVersion = version.GitVersion
+	}
+
+	if err := checkAPIDeprecations(ctx, apiDeprecationsKubeVersion(opts.ValidationKubeVersion, serverVersion, opts.LocalKubeVersion), opts.ReleaseNamespace, newRelResSpecs, prevRelease, opts.FailOnRemovedAPIs); err != nil {
+		return fmt.Errorf("check deprecated APIs: %w", err)
 	}
 
 	if !opts.Remote {
@@ -367,6 +403,7 @@ func ChartLint(ctx context.Context, opts ChartLintOptions) error {
 	}
 
 	instResInfos, delResInfos, err := plan.BuildResourceInfos(ctx, deployType, opts.ReleaseName, opts.ReleaseNamespace, instResources, delResources, prevReleaseFailed, clientFactory, plan.BuildResourceInfosOptions{
+		FieldManager:                       opts.FieldManager,
 		NetworkParallelism:                 opts.NetworkParallelism,
 		NoRemoveManualChanges:              opts.NoRemoveManualChanges,
 		LastDeployedOrLastRelResourceSpecs: lastDeployedOrLastRelResSpecs,
@@ -377,7 +414,9 @@ func ChartLint(ctx context.Context, opts ChartLintOptions) error {
 
 	log.Default.Debug(ctx, "Remotely validate resources")
 
-	if err := plan.ValidateRemote(opts.ReleaseName, opts.ReleaseNamespace, instResInfos, opts.ForceAdoption); err != nil {
+	if err := plan.ValidateRemote(ctx, opts.ReleaseName, opts.ReleaseNamespace, instResInfos, plan.ValidateRemoteOptions{
+		ForceAdoption: opts.ForceAdoption,
+	}); err != nil {
 		return fmt.Errorf("remotely validate resources: %w", err)
 	}
 
@@ -391,6 +430,7 @@ func ChartLint(ctx context.Context, opts ChartLintOptions) error {
 	log.Default.Debug(ctx, "Build install plan")
 
 	if _, err := plan.BuildPlan(instResInfos, delResInfos, relInfos, plan.BuildPlanOptions{
+		FieldManager:    opts.FieldManager,
 		NoFinalTracking: opts.NoFinalTracking,
 	}); err != nil {
 		return fmt.Errorf("%w: install: %w", ErrBuildPlan, err)
@@ -456,5 +496,9 @@ func applyChartLintOptionsDefaults(opts ChartLintOptions, currentDir, homeDir st
 		opts.DefaultDeletePropagation = string(common.DefaultDeletePropagation)
 	}
 
+	if opts.FieldManager == "" {
+		opts.FieldManager = common.DefaultFieldManager
+	}
+
 	return opts, nil
 }
@@ -23,6 +23,8 @@ import (
 	"github.com/werf/nelm/pkg/log"
 	"github.com/werf/nelm/pkg/release"
 	"github.com/werf/nelm/pkg/resource/spec"
+	"github.com/werf/nelm/pkg/ts"
+	"github.com/werf/nelm/pkg/util"
 )
 
 const DefaultChartRenderLogLevel = log.ErrorLevel
@@ -121,9 +123,15 @@ type ChartRenderOptions struct {
 	// ReleaseNamespace is the namespace where the release would be installed.
 	// Available as .Release.Namespace in chart templates.
 	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata would be stored (affects template rendering).
 	// Valid values: "secret" (default), "configmap", "sql", "memory".
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -142,6 +150,11 @@ type ChartRenderOptions struct {
 	// TemplatesAllowDNS, when true, enables DNS lookups in chart templates using template functions.
 	// WARNING: This can make template rendering non-deterministic and slower.
 	TemplatesAllowDNS bool
+	// Watch, when true, re-renders the chart on changes to the chart files (templates, values,
+	// TypeScript sources, subcharts) and to the values files, until the context is canceled. After
+	// the first render only resources with changed rendered output are printed. TypeScript charts
+	// are re-bundled only when their sources change. Requires a local chart directory.
+	Watch bool
 }
 
 type ChartRenderResultV2 struct {
@@ -149,6 +162,11 @@ type ChartRenderResultV2 struct {
 	Resources  []*spec.ResourceSpec `json:"resources,omitempty"`
 }
 
+type renderedManifest struct {
+	IDHuman  string
+	Manifest string
+}
+
 // Render the Helm chart.
 func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResultV2, error) {
 	currentDir, err := os.Getwd()
@@ -219,6 +237,8 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 	}
 
 	releaseStorageOptions := release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	}
 
@@ -290,11 +310,84 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 		DenoBinaryPath:             opts.DenoBinaryPath,
 	}
 
+	if opts.Watch {
+		return watchChartRender(ctx, opts, newRevision, deployType, helmRegistryClient, clientFactory, chartTreeOptions)
+	}
+
+	result, showFiles, err := renderChartForRelease(ctx, opts, newRevision, deployType, helmRegistryClient, clientFactory, chartTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := printChartRenderResult(result, showFiles, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func applyChartRenderOptionsDefaults(opts ChartRenderOptions, currentDir, homeDir string) (ChartRenderOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ChartRenderOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+	opts.ChartRepoConnectionOptions.ApplyDefaults()
+	opts.ValuesOptions.ApplyDefaults()
+	opts.SecretValuesOptions.ApplyDefaults(currentDir)
+
+	if opts.Chart == "" && opts.ChartDirPath != "" {
+		opts.Chart = opts.ChartDirPath
+	} else if opts.ChartDirPath == "" && opts.Chart == "" {
+		opts.Chart = currentDir
+	}
+
+	if opts.ReleaseName == "" {
+		opts.ReleaseName = common.StubReleaseName
+	}
+
+	if opts.ReleaseNamespace == "" {
+		opts.ReleaseNamespace = common.StubReleaseNamespace
+	}
+
+	if opts.LegacyLogRegistryStreamOut == nil {
+		opts.LegacyLogRegistryStreamOut = io.Discard
+	}
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	if opts.ReleaseStorageDriver == common.ReleaseStorageDriverDefault {
+		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	}
+
+	if opts.LocalKubeVersion == "" {
+		// TODO(major): update default local version
+		opts.LocalKubeVersion = common.DefaultLocalKubeVersion
+	}
+
+	if opts.RegistryCredentialsPath == "" {
+		opts.RegistryCredentialsPath = common.DefaultRegistryCredentialsPath
+	}
+
+	if opts.ChartProvenanceStrategy == "" {
+		opts.ChartProvenanceStrategy = common.DefaultChartProvenanceStrategy
+	}
+
+	return opts, nil
+}
+
+func renderChartForRelease(ctx context.Context, opts ChartRenderOptions, newRevision int, deployType common.DeployType, helmRegistryClient *registry.Client, clientFactory *kube.ClientFactory, chartTreeOptions chart.RenderChartOptions) (*ChartRenderResultV2, []string, error) {
 	log.Default.Debug(ctx, "Render chart")
 
 	renderChartResult, err := chart.RenderChart(ctx, opts.Chart, opts.ReleaseName, opts.ReleaseNamespace, newRevision, deployType, helmRegistryClient, clientFactory, chartTreeOptions)
 	if err != nil {
-		return nil, fmt.Errorf("render chart: %w", err)
+		return nil, nil, fmt.Errorf("render chart: %w", err)
 	}
 
 	log.Default.Debug(ctx, "Build transformed resource specs")
@@ -304,7 +397,7 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 		spec.NewDropInvalidAnnotationsAndLabelsTransformer(),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("build transformed resource specs: %w", err)
+		return nil, nil, fmt.Errorf("build transformed resource specs: %w", err)
 	}
 
 	log.Default.Debug(ctx, "Build releasable resource specs")
@@ -314,19 +407,19 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 		spec.NewSecretStringDataPatcher(),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("build releasable resource specs: %w", err)
+		return nil, nil, fmt.Errorf("build releasable resource specs: %w", err)
 	}
 
 	newRelease, err := release.NewRelease(opts.ReleaseName, opts.ReleaseNamespace, newRevision, deployType, releasableResSpecs, renderChartResult.Chart, renderChartResult.ReleaseConfig, release.ReleaseOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("construct new release: %w", err)
+		return nil, nil, fmt.Errorf("construct new release: %w", err)
 	}
 
 	log.Default.Debug(ctx, "Convert new release to resource specs")
 
 	resSpecs, err := release.ReleaseToResourceSpecs(newRelease, opts.ReleaseNamespace, true)
 	if err != nil {
-		return nil, fmt.Errorf("convert new release to resource specs: %w", err)
+		return nil, nil, fmt.Errorf("convert new release to resource specs: %w", err)
 	}
 
 	var showFiles []string
@@ -334,13 +427,13 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 	for _, file := range opts.ShowOnlyFiles {
 		absFile, err := filepath.Abs(file)
 		if err != nil {
-			return nil, fmt.Errorf("get absolute path for %q: %w", file, err)
+			return nil, nil, fmt.Errorf("get absolute path for %q: %w", file, err)
 		}
 
 		if strings.HasPrefix(absFile, opts.Chart) {
 			f, err := filepath.Rel(opts.Chart, absFile)
 			if err != nil {
-				return nil, fmt.Errorf("get relative path for %q: %w", absFile, err)
+				return nil, nil, fmt.Errorf("get relative path for %q: %w", absFile, err)
 			}
 
 			if !strings.HasPrefix(f, renderChartResult.Chart.Name()) {
@@ -357,6 +450,22 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 		}
 	}
 
+	result := &ChartRenderResultV2{
+		APIVersion: "v2",
+		Resources:  resSpecs,
+	}
+
+	sort.SliceStable(result.Resources, func(i, j int) bool {
+		return spec.ResourceSpecSortHandler(result.Resources[i], result.Resources[j])
+	})
+
+	return result, showFiles, nil
+}
+
+// Print the rendered resources. If prevManifests is not nil, only the resources with changed or new
+// manifests are printed to stdout, as well as the list of removed resources. Returns the manifests
+// of all the resources matching the filters, keyed by the resource ID.
+func printChartRenderResult(result *ChartRenderResultV2, showFiles []string, prevManifests map[string]*renderedManifest, opts ChartRenderOptions) (map[string]*renderedManifest, error) {
 	var (
 		renderOutStream  io.Writer
 		renderColorLevel color.Level
@@ -371,6 +480,8 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 
 		renderOutStream = file
 		renderColorLevel = color.LevelNo
+		// The output file must always have all the resources.
+		prevManifests = nil
 	} else {
 		renderOutStream = os.Stdout
 
@@ -379,15 +490,7 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 		}
 	}
 
-	result := &ChartRenderResultV2{
-		APIVersion: "v2",
-		Resources:  resSpecs,
-	}
-
-	sort.SliceStable(result.Resources, func(i, j int) bool {
-		return spec.ResourceSpecSortHandler(result.Resources[i], result.Resources[j])
-	})
-
+	manifests := make(map[string]*renderedManifest)
 	for _, res := range result.Resources {
 		if len(showFiles) > 0 && !lo.Contains(showFiles, res.FilePath) {
 			continue
@@ -398,87 +501,153 @@ func ChartRender(ctx context.Context, opts ChartRenderOptions) (*ChartRenderResu
 			continue
 		}
 
-		if err := renderResource(res.Unstruct, res.FilePath, renderOutStream, renderColorLevel); err != nil {
+		manifest, err := buildResourceManifest(res.Unstruct, res.FilePath)
+		if err != nil {
 			return nil, fmt.Errorf("render resource %q: %w", res.IDHuman(), err)
 		}
-	}
 
-	return result, nil
-}
+		manifests[res.ID()] = &renderedManifest{
+			IDHuman:  res.IDHuman(),
+			Manifest: manifest,
+		}
 
-func applyChartRenderOptionsDefaults(opts ChartRenderOptions, currentDir, homeDir string) (ChartRenderOptions, error) {
-	var err error
-	if opts.TempDirPath == "" {
-		opts.TempDirPath, err = os.MkdirTemp("", "")
-		if err != nil {
-			return ChartRenderOptions{}, fmt.Errorf("create temp dir: %w", err)
+		if prevManifest, found := prevManifests[res.ID()]; found && prevManifest.Manifest == manifest {
+			continue
+		}
+
+		if err := writeWithSyntaxHighlight(renderOutStream, manifest, "yaml", renderColorLevel); err != nil {
+			return nil, fmt.Errorf("write resource %q to output: %w", res.IDHuman(), err)
 		}
 	}
 
-	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
-	opts.ChartRepoConnectionOptions.ApplyDefaults()
-	opts.ValuesOptions.ApplyDefaults()
-	opts.SecretValuesOptions.ApplyDefaults(currentDir)
+	if prevManifests != nil {
+		var removedManifests []*renderedManifest
+		for id, manifest := range prevManifests {
+			if _, found := manifests[id]; !found {
+				removedManifests = append(removedManifests, manifest)
+			}
+		}
 
-	if opts.Chart == "" && opts.ChartDirPath != "" {
-		opts.Chart = opts.ChartDirPath
-	} else if opts.ChartDirPath == "" && opts.Chart == "" {
-		opts.Chart = currentDir
-	}
+		sort.Slice(removedManifests, func(i, j int) bool {
+			return removedManifests[i].IDHuman < removedManifests[j].IDHuman
+		})
 
-	if opts.ReleaseName == "" {
-		opts.ReleaseName = common.StubReleaseName
+		for _, manifest := range removedManifests {
+			if _, err := fmt.Fprintf(renderOutStream, "---\n# Removed: %s\n", manifest.IDHuman); err != nil {
+				return nil, fmt.Errorf("write removed resource %q to output: %w", manifest.IDHuman, err)
+			}
+		}
 	}
 
-	if opts.ReleaseNamespace == "" {
-		opts.ReleaseNamespace = common.StubReleaseNamespace
+	return manifests, nil
+}
+
+func buildResourceManifest(unstruct *unstructured.Unstructured, path string) (string, error) {
+	resourceJSONBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, unstruct)
+	if err != nil {
+		return "", fmt.Errorf("encode to JSON: %w", err)
 	}
 
-	if opts.LegacyLogRegistryStreamOut == nil {
-		opts.LegacyLogRegistryStreamOut = io.Discard
+	resourceYamlBytes, err := yaml.JSONToYAML(resourceJSONBytes)
+	if err != nil {
+		return "", fmt.Errorf("marshal JSON to YAML: %w", err)
 	}
 
-	if opts.NetworkParallelism <= 0 {
-		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	return fmt.Sprintf("---\n# Source: %s\n", path) + string(resourceYamlBytes), nil
+}
+
+func watchChartRender(ctx context.Context, opts ChartRenderOptions, newRevision int, deployType common.DeployType, helmRegistryClient *registry.Client, clientFactory *kube.ClientFactory, chartTreeOptions chart.RenderChartOptions) (*ChartRenderResultV2, error) {
+	if info, err := os.Stat(opts.Chart); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("watch mode requires a local chart directory, got %q", opts.Chart)
 	}
 
-	if opts.ReleaseStorageDriver == common.ReleaseStorageDriverDefault {
-		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	chartDir, err := filepath.Abs(opts.Chart)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path for %q: %w", opts.Chart, err)
 	}
 
-	if opts.LocalKubeVersion == "" {
-		// TODO(major): update default local version
-		opts.LocalKubeVersion = common.DefaultLocalKubeVersion
+	watchPaths := []string{chartDir}
+	for _, path := range lo.Flatten([][]string{opts.ValuesFiles, opts.SecretValuesFiles, opts.LocalLookupResourcesPaths}) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("get absolute path for %q: %w", path, err)
+		}
+
+		watchPaths = append(watchPaths, absPath)
 	}
 
-	if opts.RegistryCredentialsPath == "" {
-		opts.RegistryCredentialsPath = common.DefaultRegistryCredentialsPath
+	var outputFilePath string
+	if opts.OutputFilePath != "" {
+		outputFilePath, err = filepath.Abs(opts.OutputFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("get absolute path for %q: %w", opts.OutputFilePath, err)
+		}
 	}
 
-	if opts.ChartProvenanceStrategy == "" {
-		opts.ChartProvenanceStrategy = common.DefaultChartProvenanceStrategy
+	watcher := util.NewFileWatcher(lo.Uniq(watchPaths), util.FileWatcherOptions{
+		Ignore: func(path string, isDir bool) bool {
+			if !isDir {
+				return path == outputFilePath
+			}
+
+			switch filepath.Base(path) {
+			case ".git", "node_modules":
+				return true
+			case "dist", "vendor":
+				// Built bundles and vendored dependencies of TypeScript charts.
+				return filepath.Base(filepath.Dir(path))+"/" == common.ChartTSSourceDir
+			}
+
+			return false
+		},
+	})
+
+	// Remember the state of files before the first render, so that changes made during the render
+	// are not missed.
+	if _, err := watcher.Changes(); err != nil {
+		return nil, fmt.Errorf("watch chart files: %w", err)
 	}
 
-	return opts, nil
-}
+	chartTreeOptions.TSBundleCache = ts.NewBundleCache()
 
-func renderResource(unstruct *unstructured.Unstructured, path string, outStream io.Writer, colorLevel color.Level) error {
-	resourceJSONBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, unstruct)
+	result, showFiles, err := renderChartForRelease(ctx, opts, newRevision, deployType, helmRegistryClient, clientFactory, chartTreeOptions)
 	if err != nil {
-		return fmt.Errorf("encode to JSON: %w", err)
+		return nil, err
 	}
 
-	resourceYamlBytes, err := yaml.JSONToYAML(resourceJSONBytes)
+	manifests, err := printChartRenderResult(result, showFiles, nil, opts)
 	if err != nil {
-		return fmt.Errorf("marshal JSON to YAML: %w", err)
+		return nil, err
 	}
 
-	prefix := fmt.Sprintf("---\n# Source: %s\n", path)
-	manifest := prefix + string(resourceYamlBytes)
+	// The bundles are cached now and rebuilt only when TypeScript sources change.
+	chartTreeOptions.IgnoreBundleJS = false
+
+	log.Default.Info(ctx, "Watching for changes in %s", strings.Join(watchPaths, ", "))
 
-	if err := writeWithSyntaxHighlight(outStream, manifest, "yaml", colorLevel); err != nil {
-		return fmt.Errorf("write resource to output: %w", err)
+	if err := watcher.Watch(ctx, func(ctx context.Context, changedPaths []string) error {
+		log.Default.Info(ctx, "Changed: %s", strings.Join(changedPaths, ", "))
+
+		chartTreeOptions.TSBundleCache.InvalidateForChangedFiles(changedPaths)
+
+		newResult, newShowFiles, err := renderChartForRelease(ctx, opts, newRevision, deployType, helmRegistryClient, clientFactory, chartTreeOptions)
+		if err != nil {
+			log.Default.Error(ctx, "Error: %s", err)
+			return nil
+		}
+
+		newManifests, err := printChartRenderResult(newResult, newShowFiles, manifests, opts)
+		if err != nil {
+			return err
+		}
+
+		result = newResult
+		manifests = newManifests
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("watch chart files: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
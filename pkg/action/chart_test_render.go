@@ -0,0 +1,252 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/nelm/pkg/chart"
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/helm/pkg/registry"
+	"github.com/werf/nelm/pkg/helm/pkg/werf/helmopts"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+const DefaultChartTestRenderLogLevel = log.InfoLevel
+
+type ChartTestRenderOptions struct {
+	common.SecretValuesOptions
+
+	// ChartDirPath is the path to the local chart directory with the tests/ directory.
+	// Defaults to current directory if not specified.
+	ChartDirPath string
+	// DenoBinaryPath, if specified, uses this path as the Deno binary instead of auto-downloading.
+	DenoBinaryPath string
+	// ExtraAPIVersions is a list of additional Kubernetes API versions to include when rendering.
+	ExtraAPIVersions []string
+	// LocalKubeVersion specifies the Kubernetes version to use for template rendering.
+	// Defaults to DefaultLocalKubeVersion if not set.
+	LocalKubeVersion string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+	// TestSuiteFiles, if specified, runs only these test suite files instead of discovering all
+	// *_test.yaml files in the tests/ directory of the chart.
+	TestSuiteFiles []string
+	// UpdateSnapshots, when true, creates missing snapshots, overwrites stored snapshots that don't
+	// match and removes unused snapshots instead of failing matchSnapshot assertions.
+	UpdateSnapshots bool
+}
+
+// Render the local chart with the values from test suites in the tests/ directory of the chart and
+// check the assertions against the rendered manifests. No cluster access needed.
+func ChartTestRender(ctx context.Context, opts ChartTestRenderOptions) error {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current working directory: %w", err)
+	}
+
+	opts, err = applyChartTestRenderOptionsDefaults(opts, currentDir)
+	if err != nil {
+		return fmt.Errorf("build chart test render options: %w", err)
+	}
+
+	if opts.SecretKey != "" {
+		lo.Must0(os.Setenv("WERF_SECRET_KEY", opts.SecretKey))
+	}
+
+	suites, err := chart.LoadRenderTestSuites(opts.ChartDirPath, opts.TestSuiteFiles)
+	if err != nil {
+		return fmt.Errorf("load render test suites: %w", err)
+	}
+
+	if len(suites) == 0 {
+		log.Default.Warn(ctx, "No render test suites found in %q", filepath.Join(opts.ChartDirPath, chart.RenderTestsDir))
+		return nil
+	}
+
+	helmRegistryClient, err := registry.NewClient(
+		registry.ClientOptDebug(log.Default.AcceptLevel(ctx, log.DebugLevel)),
+		registry.ClientOptWriter(io.Discard),
+	)
+	if err != nil {
+		return fmt.Errorf("construct registry client: %w", err)
+	}
+
+	var passed, failed int
+	for _, suite := range suites {
+		snapshots, err := chart.LoadRenderTestSnapshots(suite)
+		if err != nil {
+			return fmt.Errorf("load snapshots for suite %q: %w", suite.Name, err)
+		}
+
+		for i, test := range suite.Tests {
+			docs, err := renderChartForTest(ctx, suite, test, filepath.Join(opts.TempDirPath, "render-tests", suite.Name, fmt.Sprint(i)), helmRegistryClient, opts)
+
+			var failures []string
+			if err != nil {
+				failures = []string{fmt.Sprintf("render chart: %s", err)}
+				test.KeepSnapshots(snapshots)
+			} else if failures, err = test.Check(docs, snapshots, opts.UpdateSnapshots); err != nil {
+				return fmt.Errorf("check test %q of suite %q: %w", test.Name, suite.Name, err)
+			}
+
+			if len(failures) == 0 {
+				passed++
+
+				log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render("PASS")+" %s: %s", suite.Name, test.Name)
+
+				continue
+			}
+
+			failed++
+
+			log.Default.Error(ctx, color.Style{color.Bold, color.Red}.Render("FAIL")+" %s: %s", suite.Name, test.Name)
+
+			for _, failure := range failures {
+				log.Default.Error(ctx, "  - %s", strings.ReplaceAll(failure, "\n", "\n    "))
+			}
+		}
+
+		if err := snapshots.Save(opts.UpdateSnapshots); err != nil {
+			return fmt.Errorf("save snapshots for suite %q: %w", suite.Name, err)
+		}
+	}
+
+	log.Default.Info(ctx, "")
+	log.Default.Info(ctx, color.Bold.Render("Render tests:")+" %d passed, %d failed", passed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d render tests failed", failed, passed+failed)
+	}
+
+	return nil
+}
+
+func applyChartTestRenderOptionsDefaults(opts ChartTestRenderOptions, currentDir string) (ChartTestRenderOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ChartTestRenderOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.SecretValuesOptions.ApplyDefaults(currentDir)
+
+	if opts.ChartDirPath == "" {
+		opts.ChartDirPath = currentDir
+	}
+
+	opts.ChartDirPath, err = filepath.Abs(opts.ChartDirPath)
+	if err != nil {
+		return ChartTestRenderOptions{}, fmt.Errorf("get absolute path of chart: %w", err)
+	}
+
+	if opts.LocalKubeVersion == "" {
+		opts.LocalKubeVersion = common.DefaultLocalKubeVersion
+	}
+
+	return opts, nil
+}
+
+func renderChartForTest(ctx context.Context, suite *chart.RenderTestSuite, test *chart.RenderTestCase, tempDir string, registryClient *registry.Client, opts ChartTestRenderOptions) ([]*spec.ResourceSpec, error) {
+	if err := os.MkdirAll(tempDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create temp dir %q: %w", tempDir, err)
+	}
+
+	var valuesFiles []string
+	for _, file := range lo.Flatten([][]string{suite.ValuesFiles, test.ValuesFiles}) {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(opts.ChartDirPath, file)
+		}
+
+		valuesFiles = append(valuesFiles, file)
+	}
+
+	// Inline values go after values files, test values override suite values.
+	for _, inlineValues := range []struct {
+		file   string
+		values map[string]interface{}
+	}{
+		{file: "suite-values.yaml", values: suite.Values},
+		{file: "test-values.yaml", values: test.Values},
+	} {
+		if len(inlineValues.values) == 0 {
+			continue
+		}
+
+		data, err := yaml.Marshal(inlineValues.values)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", inlineValues.file, err)
+		}
+
+		valuesFile := filepath.Join(tempDir, inlineValues.file)
+		if err := os.WriteFile(valuesFile, data, 0o644); err != nil {
+			return nil, fmt.Errorf("write %q: %w", valuesFile, err)
+		}
+
+		valuesFiles = append(valuesFiles, valuesFile)
+	}
+
+	releaseName := lo.CoalesceOrEmpty(suite.Release.Name, common.StubReleaseName)
+	releaseNamespace := lo.CoalesceOrEmpty(suite.Release.Namespace, common.StubReleaseNamespace)
+
+	renderChartResult, err := chart.RenderChart(ctx, opts.ChartDirPath, releaseName, releaseNamespace, 1, common.DeployTypeInitial, registryClient, nil, chart.RenderChartOptions{
+		ValuesOptions: common.ValuesOptions{
+			ValuesFiles: valuesFiles,
+			ValuesSet:   lo.Flatten([][]string{suite.ValuesSet, test.ValuesSet}),
+		},
+		ChartProvenanceStrategy: common.DefaultChartProvenanceStrategy,
+		ChartRepoNoUpdate:       true,
+		DenoBinaryPath:          opts.DenoBinaryPath,
+		ExtraAPIVersions:        opts.ExtraAPIVersions,
+		HelmOptions: helmopts.HelmOptions{
+			ChartLoadOpts: helmopts.ChartLoadOptions{
+				DefaultSecretValuesDisable: opts.DefaultSecretValuesDisable,
+				SecretKeyIgnore:            opts.SecretKeyIgnore,
+				SecretValuesFiles:          opts.SecretValuesFiles,
+				SecretWorkDir:              opts.SecretWorkDir,
+			},
+		},
+		LocalKubeVersion: opts.LocalKubeVersion,
+		TempDirPath:      tempDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transformedResSpecs, err := spec.BuildTransformedResourceSpecs(ctx, releaseNamespace, renderChartResult.ResourceSpecs, []spec.ResourceTransformer{
+		spec.NewResourceListsTransformer(),
+		spec.NewDropInvalidAnnotationsAndLabelsTransformer(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build transformed resource specs: %w", err)
+	}
+
+	releasableResSpecs, err := spec.BuildReleasableResourceSpecs(ctx, releaseNamespace, transformedResSpecs, []spec.ResourcePatcher{
+		spec.NewSecretStringDataPatcher(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build releasable resource specs: %w", err)
+	}
+
+	docs := lo.Filter(releasableResSpecs, func(res *spec.ResourceSpec, _ int) bool {
+		return !(res.StoreAs == common.StoreAsNone && spec.IsCRD(res.GroupVersionKind.GroupKind()))
+	})
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return spec.ResourceSpecSortHandler(docs[i], docs[j])
+	})
+
+	return docs, nil
+}
@@ -0,0 +1,164 @@
+package action_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/action"
+)
+
+func TestChartTestRender(t *testing.T) {
+	t.Run("passes and stores snapshots", func(t *testing.T) {
+		chartDir := newRenderTestChart(t, `tests:
+  - name: default values
+    asserts:
+      - documentCount: 1
+      - equal:
+          path: $.spec.replicas
+          value: 1
+        select:
+          kind: Deployment
+          template: templates/deployment.yaml
+      - exists:
+          path: $.metadata.labels.app
+      - matchSnapshot: {}
+  - name: custom replicas
+    values:
+      replicas: 3
+    asserts:
+      - equal:
+          path: $.spec.replicas
+          value: 3
+      - equal:
+          path: $.metadata.labels.app
+          value: myapp
+`)
+
+		require.NoError(t, action.ChartTestRender(context.Background(), action.ChartTestRenderOptions{
+			ChartDirPath:    chartDir,
+			TempDirPath:     t.TempDir(),
+			UpdateSnapshots: true,
+		}))
+
+		snapshot, err := os.ReadFile(filepath.Join(chartDir, "tests", "__snapshot__", "deployment_test.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(snapshot), "default values 1")
+		assert.Contains(t, string(snapshot), "name: myapp")
+	})
+
+	t.Run("fails on mismatch", func(t *testing.T) {
+		chartDir := newRenderTestChart(t, `tests:
+  - name: wrong replicas
+    asserts:
+      - equal:
+          path: $.spec.replicas
+          value: 2
+`)
+
+		err := action.ChartTestRender(context.Background(), action.ChartTestRenderOptions{
+			ChartDirPath: chartDir,
+			TempDirPath:  t.TempDir(),
+		})
+		assert.ErrorContains(t, err, "1 of 1 render tests failed")
+	})
+
+	t.Run("fails on missing or changed snapshot unless updating", func(t *testing.T) {
+		chartDir := newRenderTestChart(t, `tests:
+  - name: snapshot
+    asserts:
+      - matchSnapshot: {}
+`)
+
+		opts := action.ChartTestRenderOptions{
+			ChartDirPath: chartDir,
+			TempDirPath:  t.TempDir(),
+		}
+
+		assert.ErrorContains(t, action.ChartTestRender(context.Background(), opts), "1 of 1 render tests failed")
+		assert.NoDirExists(t, filepath.Join(chartDir, "tests", "__snapshot__"))
+
+		opts.TempDirPath = t.TempDir()
+		opts.UpdateSnapshots = true
+		require.NoError(t, action.ChartTestRender(context.Background(), opts))
+
+		opts.TempDirPath = t.TempDir()
+		opts.UpdateSnapshots = false
+		require.NoError(t, action.ChartTestRender(context.Background(), opts))
+
+		opts.TempDirPath = t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("replicas: 5\n"), 0o644))
+		assert.Error(t, action.ChartTestRender(context.Background(), opts))
+
+		opts.TempDirPath = t.TempDir()
+		opts.UpdateSnapshots = true
+		require.NoError(t, action.ChartTestRender(context.Background(), opts))
+
+		opts.TempDirPath = t.TempDir()
+		opts.UpdateSnapshots = false
+		require.NoError(t, action.ChartTestRender(context.Background(), opts))
+	})
+
+	t.Run("keeps snapshots of tests failed to render", func(t *testing.T) {
+		chartDir := newRenderTestChart(t, `tests:
+  - name: snapshot
+    asserts:
+      - matchSnapshot: {}
+`)
+
+		opts := action.ChartTestRenderOptions{
+			ChartDirPath:    chartDir,
+			TempDirPath:     t.TempDir(),
+			UpdateSnapshots: true,
+		}
+
+		require.NoError(t, action.ChartTestRender(context.Background(), opts))
+
+		suitePath := filepath.Join(chartDir, "tests", "deployment_test.yaml")
+		require.NoError(t, os.WriteFile(suitePath, []byte(`tests:
+  - name: snapshot
+    valuesFiles:
+      - missing.yaml
+    asserts:
+      - matchSnapshot: {}
+`), 0o644))
+
+		opts.TempDirPath = t.TempDir()
+		assert.ErrorContains(t, action.ChartTestRender(context.Background(), opts), "1 of 1 render tests failed")
+
+		snapshot, err := os.ReadFile(filepath.Join(chartDir, "tests", "__snapshot__", "deployment_test.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(snapshot), "snapshot 1")
+	})
+}
+
+func newRenderTestChart(t *testing.T, suite string) string {
+	chartDir := filepath.Join(t.TempDir(), "myapp")
+
+	files := map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: myapp\nversion: 0.1.0\n",
+		"values.yaml": "replicas: 1\n",
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Chart.Name }}
+  labels:
+    app: {{ .Chart.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+`,
+		"tests/deployment_test.yaml": "release:\n  name: myapp\n  namespace: prod\n" + suite,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(chartDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return chartDir
+}
@@ -15,15 +15,20 @@ import (
 	"github.com/werf/nelm/pkg/featgate"
 	helmchart "github.com/werf/nelm/pkg/helm/pkg/chart"
 	"github.com/werf/nelm/pkg/helm/pkg/chart/loader"
+	"github.com/werf/nelm/pkg/helm/pkg/chartutil"
 	"github.com/werf/nelm/pkg/helm/pkg/werf/helmopts"
 	"github.com/werf/nelm/pkg/log"
 	"github.com/werf/nelm/pkg/ts"
+	"github.com/werf/nelm/pkg/util"
 )
 
 type ChartTSBuildOptions struct {
 	ChartDirPath   string
 	DenoBinaryPath string
 	TempDirPath    string
+	// Watch, when true, rebuilds the chart on changes to TypeScript sources and values files,
+	// until the context is canceled.
+	Watch bool
 }
 
 func ChartTSBuild(ctx context.Context, opts ChartTSBuildOptions) error {
@@ -43,6 +48,67 @@ func ChartTSBuild(ctx context.Context, opts ChartTSBuildOptions) error {
 		return fmt.Errorf("TypeScript charts feature is not enabled. Set NELM_FEAT_TYPESCRIPT=true to use this feature")
 	}
 
+	if !opts.Watch {
+		return buildTSChart(ctx, absPath, opts)
+	}
+
+	watcher := util.NewFileWatcher([]string{
+		filepath.Join(absPath, common.ChartTSSourceDir),
+		filepath.Join(absPath, "charts"),
+		filepath.Join(absPath, chartutil.ValuesfileName),
+		filepath.Join(absPath, chartutil.SchemafileName),
+	}, util.FileWatcherOptions{
+		Ignore: func(path string, isDir bool) bool {
+			// Generated values types must not trigger rebuilds.
+			if path == filepath.Join(absPath, common.ChartTSSourceDir, common.ChartTSValuesTypesFile) {
+				return true
+			}
+
+			return isDir && lo.Contains([]string{"dist", "node_modules", "vendor"}, filepath.Base(path))
+		},
+	})
+
+	if _, err := watcher.Changes(); err != nil {
+		return fmt.Errorf("watch chart files: %w", err)
+	}
+
+	if err := buildTSChart(ctx, absPath, opts); err != nil {
+		log.Default.Error(ctx, "Error: %s", err)
+	}
+
+	log.Default.Info(ctx, "Watching for changes in %s", absPath)
+
+	if err := watcher.Watch(ctx, func(ctx context.Context, changedPaths []string) error {
+		log.Default.Info(ctx, "Changed: %s", strings.Join(changedPaths, ", "))
+
+		if err := buildTSChart(ctx, absPath, opts); err != nil {
+			log.Default.Error(ctx, "Error: %s", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("watch chart files: %w", err)
+	}
+
+	return nil
+}
+
+func buildTSChart(ctx context.Context, absPath string, opts ChartTSBuildOptions) error {
+	if _, err := os.Stat(filepath.Join(absPath, common.ChartTSSourceDir)); err == nil {
+		typesPath, err := ts.GenerateValuesTypes(ctx, absPath)
+		if err != nil {
+			return fmt.Errorf("generate values types: %w", err)
+		}
+
+		log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render("Generated: ")+"%s", typesPath)
+
+		if err := ts.CheckTypes(ctx, absPath, opts.DenoBinaryPath); err != nil {
+			return fmt.Errorf("check types: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat %s: %w", common.ChartTSSourceDir, err)
+	}
+
 	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render("Run bundle for ")+"%s", absPath)
 
 	helmOpts := helmopts.HelmOptions{
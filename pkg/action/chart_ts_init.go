@@ -68,6 +68,10 @@ func ChartTSInit(ctx context.Context, opts ChartTSInitOptions) error {
 		return fmt.Errorf("init TypeScript boilerplate: %w", err)
 	}
 
+	if _, err := ts.GenerateValuesTypes(ctx, absPath); err != nil {
+		return fmt.Errorf("generate values types: %w", err)
+	}
+
 	if err := ts.EnsureGitignore(absPath); err != nil {
 		return fmt.Errorf("ensure .gitignore: %w", err)
 	}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gookit/color"
+
+	"github.com/werf/nelm/pkg/featgate"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/ts"
+)
+
+type ChartTSTypesOptions struct {
+	ChartDirPath string
+}
+
+func ChartTSTypes(ctx context.Context, opts ChartTSTypesOptions) error {
+	chartPath := opts.ChartDirPath
+	if chartPath == "" {
+		chartPath = "."
+	}
+
+	absPath, err := filepath.Abs(chartPath)
+	if err != nil {
+		return fmt.Errorf("get absolute path: %w", err)
+	}
+
+	if !featgate.FeatGateTypescript.Enabled() {
+		log.Default.Warn(ctx, "TypeScript charts require NELM_FEAT_TYPESCRIPT=true environment variable")
+
+		return fmt.Errorf("TypeScript charts feature is not enabled. Set NELM_FEAT_TYPESCRIPT=true to use this feature")
+	}
+
+	typesPath, err := ts.GenerateValuesTypes(ctx, absPath)
+	if err != nil {
+		return fmt.Errorf("generate values types: %w", err)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render("Generated: ")+"%s", typesPath)
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package action
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const (
+	DefaultClusterSnapshotLogLevel   = log.InfoLevel
+	DefaultClusterSnapshotOutputPath = "cluster-snapshot.yaml"
+)
+
+// Resources which are never managed by charts and change all the time.
+var clusterSnapshotSkippedGroupResources = map[schema.GroupResource]bool{
+	{Group: "", Resource: "componentstatuses"}:                             true,
+	{Group: "", Resource: "endpoints"}:                                     true,
+	{Group: "", Resource: "events"}:                                        true,
+	{Group: "", Resource: "nodes"}:                                         true,
+	{Group: "", Resource: "pods"}:                                          true,
+	{Group: "apps", Resource: "controllerrevisions"}:                       true,
+	{Group: "apps", Resource: "replicasets"}:                               true,
+	{Group: "certificates.k8s.io", Resource: "certificatesigningrequests"}: true,
+	{Group: "coordination.k8s.io", Resource: "leases"}:                     true,
+	{Group: "discovery.k8s.io", Resource: "endpointslices"}:                true,
+	{Group: "events.k8s.io", Resource: "events"}:                           true,
+	{Group: "metrics.k8s.io", Resource: "nodes"}:                           true,
+	{Group: "metrics.k8s.io", Resource: "pods"}:                            true,
+	{Group: "storage.k8s.io", Resource: "csinodes"}:                        true,
+	{Group: "storage.k8s.io", Resource: "volumeattachments"}:               true,
+}
+
+type ClusterSnapshotOptions struct {
+	common.KubeConnectionOptions
+
+	// IncludeSecretData, when true, keeps data of Secrets in the snapshot. Otherwise, data of Secrets
+	// is redacted. Releases in release Secrets and ConfigMaps, needed for the release history, are
+	// kept, but Secrets in their manifests and their values are redacted.
+	IncludeSecretData bool
+	// Namespaces to take namespaced resources from. Cluster-scoped resources are always taken.
+	// Resources from all namespaces are taken if not specified.
+	Namespaces []string
+	// NetworkParallelism limits the number of concurrent requests to the Kubernetes API.
+	// Defaults to DefaultNetworkParallelism if not set or <= 0.
+	NetworkParallelism int
+	// OutputPath is the path to save the snapshot to.
+	// Defaults to DefaultClusterSnapshotOutputPath if not specified.
+	OutputPath string
+}
+
+// Saves resources of the cluster into a YAML file with a List, which can be used to plan a
+// release installation against an in-memory cluster, without connecting to the cluster.
+func ClusterSnapshot(ctx context.Context, opts ClusterSnapshotOptions) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyClusterSnapshotOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return fmt.Errorf("build cluster snapshot options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := newKubeConfig(ctx, opts.KubeConfigPaths, "", opts.KubeConnectionOptions)
+	if err != nil {
+		return fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	resourceLists, err := clientFactory.Discovery().ServerPreferredResources()
+	if err != nil {
+		if discovery.IsGroupDiscoveryFailedError(err) {
+			log.Default.Warn(ctx, "Discovery failed: %s", err.Error())
+		} else {
+			return fmt.Errorf("discover resources: %w", err)
+		}
+	}
+
+	listPool := pool.NewWithResults[[]*unstructured.Unstructured]().WithContext(ctx).WithMaxGoroutines(opts.NetworkParallelism).WithCancelOnError().WithFirstError()
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return fmt.Errorf("parse group version %q: %w", resourceList.GroupVersion, err)
+		}
+
+		for _, apiResource := range resourceList.APIResources {
+			gvr := gv.WithResource(apiResource.Name)
+
+			if strings.Contains(apiResource.Name, "/") ||
+				!lo.Contains(apiResource.Verbs, "list") ||
+				clusterSnapshotSkippedGroupResources[gvr.GroupResource()] {
+				continue
+			}
+
+			var namespaces []string
+			if apiResource.Namespaced && len(opts.Namespaces) > 0 {
+				namespaces = opts.Namespaces
+			} else {
+				namespaces = []string{metav1.NamespaceAll}
+			}
+
+			for _, namespace := range namespaces {
+				listPool.Go(func(ctx context.Context) ([]*unstructured.Unstructured, error) {
+					resources, err := listClusterSnapshotResources(ctx, gvr, namespace, clientFactory)
+					if err != nil {
+						return nil, fmt.Errorf("list %q: %w", gvr.String(), err)
+					}
+
+					return resources, nil
+				})
+			}
+		}
+	}
+
+	results, err := listPool.Wait()
+	if err != nil {
+		return fmt.Errorf("wait for list pool: %w", err)
+	}
+
+	resources := lo.Filter(lo.Flatten(results), func(res *unstructured.Unstructured, _ int) bool {
+		return len(opts.Namespaces) == 0 ||
+			res.GroupVersionKind().GroupKind() != (schema.GroupKind{Kind: "Namespace"}) ||
+			lo.Contains(opts.Namespaces, res.GetName())
+	})
+
+	if !opts.IncludeSecretData {
+		for _, res := range resources {
+			switch res.GroupVersionKind().GroupKind() {
+			case schema.GroupKind{Kind: "Secret"}:
+				redactClusterSnapshotSecret(res)
+			case schema.GroupKind{Kind: "ConfigMap"}:
+				kube.RedactReleaseRecord(res.Object, false)
+			}
+		}
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		iGVK, jGVK := resources[i].GroupVersionKind(), resources[j].GroupVersionKind()
+
+		switch {
+		case iGVK.Group != jGVK.Group:
+			return iGVK.Group < jGVK.Group
+		case iGVK.Kind != jGVK.Kind:
+			return iGVK.Kind < jGVK.Kind
+		case resources[i].GetNamespace() != resources[j].GetNamespace():
+			return resources[i].GetNamespace() < resources[j].GetNamespace()
+		default:
+			return resources[i].GetName() < resources[j].GetName()
+		}
+	})
+
+	list := &unstructured.UnstructuredList{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+		},
+	}
+
+	for _, res := range resources {
+		list.Items = append(list.Items, *res)
+	}
+
+	listJSON, err := list.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal snapshot to json: %w", err)
+	}
+
+	listYAML, err := yaml.JSONToYAML(listJSON)
+	if err != nil {
+		return fmt.Errorf("convert snapshot to yaml: %w", err)
+	}
+
+	if err := os.WriteFile(opts.OutputPath, listYAML, 0o600); err != nil {
+		return fmt.Errorf("write snapshot to %q: %w", opts.OutputPath, err)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Saved snapshot of %d resources to %q", len(resources), opts.OutputPath)))
+
+	return nil
+}
+
+func listClusterSnapshotResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, clientFactory kube.ClientFactorier) ([]*unstructured.Unstructured, error) {
+	var resources []*unstructured.Unstructured
+
+	listOpts := metav1.ListOptions{Limit: 500}
+	for {
+		list, err := clientFactory.Dynamic().Resource(gvr).Namespace(namespace).List(ctx, listOpts)
+		if err != nil {
+			if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+				log.Default.Warn(ctx, "Skipping %q in snapshot: %s", gvr.String(), err)
+
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		for i := range list.Items {
+			resources = append(resources, &list.Items[i])
+		}
+
+		if list.GetContinue() == "" {
+			return resources, nil
+		}
+
+		listOpts.Continue = list.GetContinue()
+	}
+}
+
+// Releases in release Secrets are kept, since planning needs the release history, but redacted too.
+func redactClusterSnapshotSecret(secret *unstructured.Unstructured) {
+	if kube.RedactReleaseRecord(secret.Object, true) {
+		return
+	}
+
+	if data, found, _ := unstructured.NestedMap(secret.Object, "data"); found {
+		for key := range data {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(kube.KubeRecordRedacted))
+		}
+
+		lo.Must0(unstructured.SetNestedMap(secret.Object, data, "data"))
+	}
+
+	annotations := secret.GetAnnotations()
+	if _, found := annotations["kubectl.kubernetes.io/last-applied-configuration"]; found {
+		delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		secret.SetAnnotations(annotations)
+	}
+}
+
+func applyClusterSnapshotOptionsDefaults(opts ClusterSnapshotOptions, homeDir string) (ClusterSnapshotOptions, error) {
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	if opts.OutputPath == "" {
+		opts.OutputPath = DefaultClusterSnapshotOutputPath
+	}
+
+	return opts, nil
+}
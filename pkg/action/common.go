@@ -3,6 +3,7 @@ package action
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,16 +17,19 @@ import (
 	"github.com/samber/lo"
 	"github.com/xo/terminfo"
 
-	"github.com/werf/kubedog/pkg/informer"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/logstore"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/statestore"
 	kdutil "github.com/werf/kubedog/pkg/trackers/dyntracker/util"
+	"github.com/werf/nelm/pkg/chart"
 	"github.com/werf/nelm/pkg/common"
 	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
 	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/kube/fake"
 	"github.com/werf/nelm/pkg/log"
 	"github.com/werf/nelm/pkg/plan"
 	"github.com/werf/nelm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
 	"github.com/werf/nelm/pkg/util"
 )
 
@@ -98,6 +102,55 @@ type runFailurePlanResult struct {
 	FailedResourceOps    []*plan.Operation
 }
 
+// Constructs KubeConfig for the cluster, or for the recorded responses of the cluster if replaying.
+func newKubeConfig(ctx context.Context, kubeConfigPaths []string, releaseNamespace string, opts common.KubeConnectionOptions) (*kube.KubeConfig, error) {
+	if opts.KubeReplayDir != "" {
+		return fake.NewReplayKubeConfig(ctx, opts.KubeReplayDir, releaseNamespace)
+	}
+
+	return kube.NewKubeConfig(ctx, kubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts,
+		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
+	})
+}
+
+// Constructs clients for an in-memory cluster seeded with resources from the files.
+func newDryClusterClientFactory(ctx context.Context, releaseNamespace string, resourcesPaths []string, kubeVersion string) (*fake.ClientFactory, error) {
+	resources, err := chart.ParseLocalResources(resourcesPaths)
+	if err != nil {
+		return nil, fmt.Errorf("parse dry cluster resources: %w", err)
+	}
+
+	log.Default.Debug(ctx, "Seeding dry cluster with %d resources", len(resources))
+
+	clientFactory, err := fake.NewDryClusterClientFactory(ctx, resources, fake.DryClusterOptions{
+		DefaultNamespace: releaseNamespace,
+		KubeVersion:      kubeVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct dry cluster clients: %w", err)
+	}
+
+	return clientFactory, nil
+}
+
+// Returns nil if the cache is disabled or can't be used, in which case all dry-run applies are
+// done.
+func newDryApplyCache(ctx context.Context, noDryApplyCache bool) *plan.DryApplyCache {
+	if noDryApplyCache {
+		return nil
+	}
+
+	cache, err := plan.NewDryApplyCache(ctx, common.DryApplyCacheDir, common.DefaultDryApplyCacheLifetime)
+	if err != nil {
+		log.Default.Warn(ctx, "Not caching server-side dry-run apply results: %s", err)
+
+		return nil
+	}
+
+	return cache
+}
+
 func handleBuildPlanErr(ctx context.Context, installPlan *plan.Plan, planErr error, installGraphPath, tempDirPath, fallbackGraphFilename string) {
 	var graphPath string
 	if installGraphPath != "" {
@@ -163,7 +216,7 @@ func printReport(ctx context.Context, report *releaseReportV3) {
 	}
 }
 
-func runFailurePlan(ctx context.Context, releaseNamespace string, failedPlan *plan.Plan, installableInfos []*plan.InstallableResourceInfo, releaseInfos []*plan.ReleaseInfo, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], history *release.History, clientFactory *kube.ClientFactory, opts runFailureInstallPlanOptions) (result *runFailurePlanResult, nonCritErrs, critErrs *util.MultiError) {
+func runFailurePlan(ctx context.Context, releaseNamespace string, failedPlan *plan.Plan, installableInfos []*plan.InstallableResourceInfo, releaseInfos []*plan.ReleaseInfo, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, history *release.History, clientFactory *kube.ClientFactory, opts runFailureInstallPlanOptions) (result *runFailurePlanResult, nonCritErrs, critErrs *util.MultiError) {
 	critErrs = &util.MultiError{}
 	nonCritErrs = &util.MultiError{}
 
@@ -189,7 +242,7 @@ func runFailurePlan(ctx context.Context, releaseNamespace string, failedPlan *pl
 
 	log.Default.Debug(ctx, "Execute failure plan")
 
-	if err := plan.ExecutePlan(ctx, releaseNamespace, failurePlan, taskStore, logStore, informerFactory, history, clientFactory, plan.ExecutePlanOptions{
+	if err := plan.ExecutePlan(ctx, releaseNamespace, failurePlan, taskStore, logStore, getInformerFactory, history, clientFactory, plan.ExecutePlanOptions{
 		LegacyProgressReporter:   opts.LegacyProgressReporter,
 		TrackingOptions:          opts.TrackingOptions,
 		NetworkParallelism:       opts.NetworkParallelism,
@@ -227,7 +280,7 @@ func savePlanAsDot(plan *plan.Plan, path string) error {
 		return fmt.Errorf("convert plan to DOT file: %w", err)
 	}
 
-	if err := os.WriteFile(path, dotByte, 0o600); err != nil {
+	if err := os.WriteFile(path, []byte(log.MaskSecrets(string(dotByte))), 0o600); err != nil {
 		return fmt.Errorf("write DOT graph file at %q: %w", path, err)
 	}
 
@@ -240,7 +293,7 @@ func saveReport(reportPath string, report *releaseReportV3) error {
 		return fmt.Errorf("marshal report: %w", err)
 	}
 
-	if err := os.WriteFile(reportPath, reportByte, 0o600); err != nil {
+	if err := os.WriteFile(reportPath, []byte(log.MaskSecrets(string(reportByte))), 0o600); err != nil {
 		return fmt.Errorf("write report: %w", err)
 	}
 
@@ -274,3 +327,61 @@ func writeWithSyntaxHighlight(outStream io.Writer, text, lang string, colorLevel
 
 	return nil
 }
+
+// Uses validationKubeVersion if it is explicitly set, otherwise the server version if connected to
+// the cluster, otherwise localKubeVersion.
+func apiDeprecationsKubeVersion(validationKubeVersion, serverVersion, localKubeVersion string) string {
+	if validationKubeVersion != "" && validationKubeVersion != common.DefaultResourceValidationKubeVersion {
+		return validationKubeVersion
+	}
+
+	return lo.CoalesceOrEmpty(serverVersion, localKubeVersion, validationKubeVersion)
+}
+
+// Warns about deprecated and removed APIs used by the new release, and about removed APIs used by
+// stored manifests of the previous release, which break its upgrade and uninstall.
+func checkAPIDeprecations(ctx context.Context, kubeVersion, releaseNamespace string, newRelResSpecs []*spec.ResourceSpec, prevRelease *helmrelease.Release, failOnRemoved bool) error {
+	newFindings, err := resource.FindAPIDeprecations(lo.Map(newRelResSpecs, func(resSpec *spec.ResourceSpec, _ int) *spec.ResourceMeta {
+		return resSpec.ResourceMeta
+	}), kubeVersion)
+	if err != nil {
+		return fmt.Errorf("find deprecated APIs of new release: %w", err)
+	}
+
+	removedErrs := &util.MultiError{}
+	for _, finding := range newFindings {
+		if finding.Removed && failOnRemoved {
+			removedErrs.Add(errors.New(finding.String()))
+		} else {
+			log.Default.Warn(ctx, "Deprecated API used for Kubernetes %s: %s", kubeVersion, finding)
+		}
+	}
+
+	if prevRelease != nil {
+		prevRelResSpecs, err := release.ReleaseToResourceSpecs(prevRelease, releaseNamespace, false)
+		if err != nil {
+			return fmt.Errorf("convert previous release to resource specs: %w", err)
+		}
+
+		prevFindings, err := resource.FindAPIDeprecations(lo.Map(prevRelResSpecs, func(resSpec *spec.ResourceSpec, _ int) *spec.ResourceMeta {
+			return resSpec.ResourceMeta
+		}), kubeVersion)
+		if err != nil {
+			return fmt.Errorf("find deprecated APIs of previous release: %w", err)
+		}
+
+		for _, finding := range prevFindings {
+			if !finding.Removed {
+				continue
+			}
+
+			log.Default.Warn(ctx, "Stored manifests of release %q (namespace: %q, revision: %d) use API removed in Kubernetes %s, its upgrade or uninstall might fail: %s", prevRelease.Name, prevRelease.Namespace, prevRelease.Version, kubeVersion, finding)
+		}
+	}
+
+	if err := removedErrs.OrNilIfNoErrs(); err != nil {
+		return fmt.Errorf("removed APIs used for Kubernetes %s: %w", kubeVersion, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,406 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const (
+	DefaultReleaseApplySetLogLevel    = log.InfoLevel
+	DefaultReleaseApplySetParallelism = 1
+)
+
+type ReleaseApplySetOptions struct {
+	common.ChartRepoConnectionOptions
+	common.KubeConnectionOptions
+	common.ReleaseInstallRuntimeOptions
+	common.ResourceDiffOptions
+	common.SecretValuesOptions
+	common.TrackingOptions
+	// Values applied to all releases of the set. Values of a release from the release set file
+	// take precedence.
+	common.ValuesOptions
+
+	// AutoRollback, when true, automatically rolls back a release to its previous deployed revision
+	// on installation failure.
+	AutoRollback bool
+	// DefaultNamespace is the namespace of releases which don't have one in the release set file.
+	DefaultNamespace string
+	// NetworkParallelism limits the number of concurrent network-related operations (API calls, resource fetches).
+	// Defaults to DefaultNetworkParallelism if not set or <= 0.
+	NetworkParallelism int
+	// NoPlan, when true, skips planning releases before installing them. Useful when releases can't
+	// be planned before their dependencies are installed, e.g. when they use CRDs from dependencies.
+	NoPlan bool
+	// NoShowNotes, when true, suppresses printing of NOTES.txt after successful installation.
+	NoShowNotes bool
+	// Parallelism limits the number of releases installed in parallel. Releases are never installed
+	// before their dependencies. Defaults to DefaultReleaseApplySetParallelism if not set or <= 0.
+	Parallelism int
+	// PlanOnly, when true, only plans releases and doesn't install them.
+	PlanOnly bool
+	// RegistryCredentialsPath is the path to Docker config.json file with registry credentials.
+	// Defaults to DefaultRegistryCredentialsPath (~/.docker/config.json) if not set.
+	RegistryCredentialsPath string
+	// ReportPath, if specified, saves a combined JSON report of all releases installations to this file path.
+	ReportPath string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+	// Timeout is the maximum duration for the entire operation.
+	// If 0, no timeout is applied and the operation runs until completion or error.
+	Timeout time.Duration
+}
+
+type releaseSetReportV1 struct {
+	Version  int                        `json:"version,omitempty"`
+	Releases []*releaseSetReleaseReport `json:"releases,omitempty"`
+}
+
+type releaseSetReleaseReport struct {
+	*releaseReportV3
+
+	Error string `json:"error,omitempty"`
+}
+
+// Plans and installs releases from the release set file in the order of their dependencies.
+func ReleaseApplySet(ctx context.Context, releaseSetPath string, opts ReleaseApplySetOptions) error {
+	if opts.Timeout != 0 {
+		var ctxCancelFn context.CancelFunc
+
+		ctx, ctxCancelFn = context.WithTimeoutCause(ctx, opts.Timeout, fmt.Errorf("context timed out: action timed out after %s", opts.Timeout.String()))
+		defer ctxCancelFn()
+	}
+
+	opts, err := applyReleaseApplySetOptionsDefaults(opts)
+	if err != nil {
+		return fmt.Errorf("build release apply set options: %w", err)
+	}
+
+	releaseSet, err := LoadReleaseSet(releaseSetPath, opts.DefaultNamespace)
+	if err != nil {
+		return fmt.Errorf("load release set: %w", err)
+	}
+
+	stages, err := releaseSet.Stages()
+	if err != nil {
+		return fmt.Errorf("build release set stages: %w", err)
+	}
+
+	orderedReleases := lo.Flatten(stages)
+
+	if !opts.NoPlan {
+		if err := planReleaseSet(ctx, orderedReleases, opts); err != nil {
+			return fmt.Errorf("plan release set: %w", err)
+		}
+	}
+
+	if opts.PlanOnly {
+		return nil
+	}
+
+	report, installErr := installReleaseSet(ctx, releaseSet, orderedReleases, opts)
+
+	if opts.ReportPath != "" {
+		if err := saveReleaseSetReport(opts.ReportPath, report); err != nil {
+			return errors.Join(installErr, fmt.Errorf("save release set report: %w", err))
+		}
+	}
+
+	if installErr != nil {
+		return fmt.Errorf("install release set: %w", installErr)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Succeeded release set %q", releaseSetPath)))
+
+	return nil
+}
+
+func planReleaseSet(ctx context.Context, releases []*ReleaseSetRelease, opts ReleaseApplySetOptions) error {
+	var changedReleases, upToDateReleases []string
+	for _, rel := range releases {
+		log.Default.Info(ctx, color.Style{color.Bold}.Render(fmt.Sprintf("Planning release %q (namespace: %q)", rel.Name, rel.Namespace)))
+
+		if err := os.MkdirAll(releaseSetReleaseTempDir(rel, opts), 0o700); err != nil {
+			return fmt.Errorf("create temp dir of release %q: %w", rel.ID(), err)
+		}
+
+		err := ReleasePlanInstall(ctx, rel.Name, rel.Namespace, newReleaseSetPlanInstallOptions(rel, opts))
+		switch {
+		case err == nil:
+			upToDateReleases = append(upToDateReleases, rel.ID())
+		case errors.Is(err, ErrChangesPlanned), errors.Is(err, ErrResourceChangesPlanned), errors.Is(err, ErrReleaseInstallPlanned):
+			changedReleases = append(changedReleases, rel.ID())
+		default:
+			return fmt.Errorf("plan release %q: %w", rel.ID(), err)
+		}
+	}
+
+	log.Default.InfoBlock(ctx, log.BlockOptions{
+		BlockTitle: color.Style{color.Bold, color.Blue}.Render("Release set plan"),
+	}, func() {
+		for _, id := range changedReleases {
+			log.Default.Info(ctx, color.Style{color.Yellow}.Render(fmt.Sprintf("Release %q will be installed", id)))
+		}
+
+		for _, id := range upToDateReleases {
+			log.Default.Info(ctx, fmt.Sprintf("Release %q is up to date", id))
+		}
+	})
+
+	return nil
+}
+
+// Releases are submitted in the order of their dependencies, so every release waits only for
+// releases which are already running or finished, which can't deadlock the pool. The report has
+// every release of the set, even if the release set failed before the release could be installed.
+func installReleaseSet(ctx context.Context, releaseSet *ReleaseSet, releases []*ReleaseSetRelease, opts ReleaseApplySetOptions) (*releaseSetReportV1, error) {
+	var (
+		reportsMutex sync.Mutex
+		reports      = map[string]*releaseSetReleaseReport{}
+	)
+
+	buildSetReport := func(notInstalledErr error) *releaseSetReportV1 {
+		reportsMutex.Lock()
+		defer reportsMutex.Unlock()
+
+		return &releaseSetReportV1{
+			Version: 1,
+			Releases: lo.Map(releases, func(rel *ReleaseSetRelease, _ int) *releaseSetReleaseReport {
+				if report, found := reports[rel.ID()]; found {
+					return report
+				}
+
+				return &releaseSetReleaseReport{
+					releaseReportV3: &releaseReportV3{
+						Version:   3,
+						Release:   rel.Name,
+						Namespace: rel.Namespace,
+					},
+					Error: notInstalledErr.Error(),
+				}
+			}),
+		}
+	}
+
+	depsByID := map[string][]string{}
+	for _, rel := range releases {
+		deps, err := releaseSet.Dependencies(rel)
+		if err != nil {
+			err = fmt.Errorf("get dependencies of release %q: %w", rel.ID(), err)
+			return buildSetReport(err), err
+		}
+
+		depsByID[rel.ID()] = deps
+	}
+
+	doneChs := map[string]chan struct{}{}
+	for _, rel := range releases {
+		doneChs[rel.ID()] = make(chan struct{})
+	}
+
+	failed := func(id string) bool {
+		reportsMutex.Lock()
+		defer reportsMutex.Unlock()
+
+		return reports[id].Error != ""
+	}
+
+	installPool := pool.New().WithContext(ctx).WithMaxGoroutines(opts.Parallelism)
+	for _, rel := range releases {
+		installPool.Go(func(ctx context.Context) error {
+			defer close(doneChs[rel.ID()])
+
+			report := &releaseSetReleaseReport{
+				releaseReportV3: &releaseReportV3{
+					Version:   3,
+					Release:   rel.Name,
+					Namespace: rel.Namespace,
+				},
+			}
+
+			defer func() {
+				reportsMutex.Lock()
+				defer reportsMutex.Unlock()
+
+				reports[rel.ID()] = report
+			}()
+
+			for _, dep := range depsByID[rel.ID()] {
+				select {
+				case <-doneChs[dep]:
+				case <-ctx.Done():
+					report.Error = context.Cause(ctx).Error()
+					return nil
+				}
+
+				if failed(dep) {
+					report.Error = fmt.Sprintf("dependency %q failed", dep)
+					return nil
+				}
+			}
+
+			if err := os.MkdirAll(releaseSetReleaseTempDir(rel, opts), 0o700); err != nil {
+				report.Error = fmt.Sprintf("create release temp dir: %s", err)
+				return nil
+			}
+
+			installOpts := newReleaseSetInstallOptions(rel, opts)
+			installOpts.InstallReportPath = filepath.Join(installOpts.TempDirPath, "report.json")
+
+			log.Default.Info(ctx, color.Style{color.Bold}.Render(fmt.Sprintf("Installing release %q (namespace: %q)", rel.Name, rel.Namespace)))
+
+			installErr := ReleaseInstall(ctx, rel.Name, rel.Namespace, installOpts)
+
+			if releaseReport, err := loadReport(installOpts.InstallReportPath); err == nil {
+				report.releaseReportV3 = releaseReport
+			}
+
+			if installErr != nil {
+				report.Error = installErr.Error()
+			}
+
+			return nil
+		})
+	}
+
+	if err := installPool.Wait(); err != nil {
+		err = fmt.Errorf("wait for releases installation: %w", err)
+		return buildSetReport(err), err
+	}
+
+	setReport := buildSetReport(errors.New("not installed"))
+
+	failedReleases := lo.FilterMap(setReport.Releases, func(report *releaseSetReleaseReport, _ int) (string, bool) {
+		return fmt.Sprintf("%s/%s: %s", report.Namespace, report.Release, report.Error), report.Error != ""
+	})
+	if len(failedReleases) > 0 {
+		return setReport, fmt.Errorf("failed releases:\n%s", strings.Join(failedReleases, "\n"))
+	}
+
+	return setReport, nil
+}
+
+func newReleaseSetPlanInstallOptions(rel *ReleaseSetRelease, opts ReleaseApplySetOptions) ReleasePlanInstallOptions {
+	return ReleasePlanInstallOptions{
+		ChartRepoConnectionOptions:   opts.ChartRepoConnectionOptions,
+		KubeConnectionOptions:        opts.KubeConnectionOptions,
+		ReleaseInstallRuntimeOptions: opts.ReleaseInstallRuntimeOptions,
+		ResourceDiffOptions:          opts.ResourceDiffOptions,
+		SecretValuesOptions:          newReleaseSetSecretValuesOptions(rel, opts),
+		ValuesOptions:                newReleaseSetValuesOptions(rel, opts),
+		Chart:                        rel.Chart,
+		ChartVersion:                 rel.ChartVersion,
+		ErrorIfChangesPlanned:        true,
+		LegacyHelmCompatibleTracking: opts.LegacyHelmCompatibleTracking,
+		NetworkParallelism:           opts.NetworkParallelism,
+		NoFinalTracking:              opts.NoFinalTracking,
+		RegistryCredentialsPath:      opts.RegistryCredentialsPath,
+		TempDirPath:                  releaseSetReleaseTempDir(rel, opts),
+	}
+}
+
+func newReleaseSetInstallOptions(rel *ReleaseSetRelease, opts ReleaseApplySetOptions) ReleaseInstallOptions {
+	return ReleaseInstallOptions{
+		ChartRepoConnectionOptions:   opts.ChartRepoConnectionOptions,
+		KubeConnectionOptions:        opts.KubeConnectionOptions,
+		ReleaseInstallRuntimeOptions: opts.ReleaseInstallRuntimeOptions,
+		SecretValuesOptions:          newReleaseSetSecretValuesOptions(rel, opts),
+		TrackingOptions:              opts.TrackingOptions,
+		ValuesOptions:                newReleaseSetValuesOptions(rel, opts),
+		AutoRollback:                 opts.AutoRollback,
+		Chart:                        rel.Chart,
+		ChartVersion:                 rel.ChartVersion,
+		NetworkParallelism:           opts.NetworkParallelism,
+		NoShowNotes:                  opts.NoShowNotes,
+		RegistryCredentialsPath:      opts.RegistryCredentialsPath,
+		TempDirPath:                  releaseSetReleaseTempDir(rel, opts),
+	}
+}
+
+// Every release gets a temp dir of its own, since parallel installs of the same chart would race on
+// files rendered into the temp dir.
+func releaseSetReleaseTempDir(rel *ReleaseSetRelease, opts ReleaseApplySetOptions) string {
+	return filepath.Join(opts.TempDirPath, "releases", rel.Namespace, rel.Name)
+}
+
+func newReleaseSetValuesOptions(rel *ReleaseSetRelease, opts ReleaseApplySetOptions) common.ValuesOptions {
+	valuesOpts := opts.ValuesOptions
+	valuesOpts.ValuesFiles = append(append([]string{}, opts.ValuesFiles...), rel.Values...)
+	valuesOpts.ValuesSet = append(append([]string{}, opts.ValuesSet...), rel.Set...)
+	valuesOpts.ValuesSetString = append(append([]string{}, opts.ValuesSetString...), rel.SetString...)
+
+	return valuesOpts
+}
+
+func newReleaseSetSecretValuesOptions(rel *ReleaseSetRelease, opts ReleaseApplySetOptions) common.SecretValuesOptions {
+	secretValuesOpts := opts.SecretValuesOptions
+	secretValuesOpts.SecretValuesFiles = append(append([]string{}, opts.SecretValuesFiles...), rel.SecretValues...)
+
+	return secretValuesOpts
+}
+
+func loadReport(reportPath string) (*releaseReportV3, error) {
+	reportBytes, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+
+	report := &releaseReportV3{}
+	if err := json.Unmarshal(reportBytes, report); err != nil {
+		return nil, fmt.Errorf("unmarshal report: %w", err)
+	}
+
+	return report, nil
+}
+
+func saveReleaseSetReport(reportPath string, report *releaseSetReportV1) error {
+	reportByte, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(reportPath, []byte(log.MaskSecrets(string(reportByte))), 0o600); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	return nil
+}
+
+func applyReleaseApplySetOptionsDefaults(opts ReleaseApplySetOptions) (ReleaseApplySetOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseApplySetOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultReleaseApplySetParallelism
+	}
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	if opts.RegistryCredentialsPath == "" {
+		opts.RegistryCredentialsPath = common.DefaultRegistryCredentialsPath
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,318 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/color"
+	prtable "github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+const (
+	DefaultReleaseCheckAPIsLogLevel     = log.ErrorLevel
+	DefaultReleaseCheckAPIsOutputFormat = common.OutputFormatTable
+)
+
+type ReleaseCheckAPIsOptions struct {
+	common.KubeConnectionOptions
+
+	// FailOnRemovedAPIs, when true, returns an error if stored manifests of any release use APIs
+	// removed in the target Kubernetes version.
+	FailOnRemovedAPIs bool
+	// KubeVersion is the target Kubernetes version to check APIs against, e.g. "1.32.0", or "master"
+	// to report everything deprecated or removed in any Kubernetes version.
+	// Defaults to the version of the cluster.
+	KubeVersion string
+	// OutputFormat specifies the output format for the found APIs.
+	// Valid values: "table" (default), "json", "yaml".
+	OutputFormat string
+	// OutputNoPrint, when true, suppresses printing the output and only returns the result data structure.
+	// Useful when calling this programmatically.
+	OutputNoPrint bool
+	// ReleaseNamespace specifies the namespace to search releases in.
+	// If empty, all namespaces are searched.
+	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
+	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
+	// Defaults to "secret" if not specified or set to "default".
+	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
+	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
+	// Only used when ReleaseStorageDriver is "sql".
+	ReleaseStorageSQLConnection string
+}
+
+type ReleaseCheckAPIsResultV1 struct {
+	APIVersion  string                           `json:"apiVersion"`
+	KubeVersion string                           `json:"kubeVersion"`
+	Releases    []*ReleaseCheckAPIsResultRelease `json:"releases"`
+}
+
+type ReleaseCheckAPIsResultRelease struct {
+	Name      string                       `json:"name"`
+	Namespace string                       `json:"namespace"`
+	Revision  int                          `json:"revision"`
+	APIs      []*ReleaseCheckAPIsResultAPI `json:"apis"`
+}
+
+type ReleaseCheckAPIsResultAPI struct {
+	Resource              string `json:"resource"`
+	APIVersion            string `json:"apiVersion"`
+	Kind                  string `json:"kind"`
+	DeprecatedIn          string `json:"deprecatedIn"`
+	RemovedIn             string `json:"removedIn,omitempty"`
+	Removed               bool   `json:"removed"`
+	ReplacementAPIVersion string `json:"replacementApiVersion,omitempty"`
+	ReplacementKind       string `json:"replacementKind,omitempty"`
+}
+
+// Checks stored manifests of the latest deployed revision of each release against the built-in
+// table of deprecations and removals of Kubernetes APIs. Releases using removed APIs can't be
+// upgraded or uninstalled after the cluster upgrade, so this is meant to be run before it.
+func ReleaseCheckAPIs(ctx context.Context, opts ReleaseCheckAPIsOptions) (*ReleaseCheckAPIsResultV1, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseCheckAPIsOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("build release check apis options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  opts.ReleaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	kubeVersion := opts.KubeVersion
+	if kubeVersion == "" {
+		serverVersion, err := clientFactory.Discovery().ServerVersion()
+		if err != nil {
+			return nil, fmt.Errorf("get kubernetes server version: %w", err)
+		}
+
+		kubeVersion = serverVersion.GitVersion
+	}
+
+	releaseStorage, err := release.NewReleaseStorage(ctx, opts.ReleaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
+		SQLConnection: opts.ReleaseStorageSQLConnection,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct release storage: %w", err)
+	}
+
+	log.Default.Info(ctx, "Check APIs of stored releases for Kubernetes %s", kubeVersion)
+
+	histories, err := release.BuildHistories(releaseStorage, release.HistoryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("build release histories: %w", err)
+	}
+
+	result := &ReleaseCheckAPIsResultV1{
+		APIVersion:  "v1",
+		KubeVersion: kubeVersion,
+	}
+
+	for _, history := range histories {
+		deployedRelease := lo.LastOrEmpty(history.FindAllDeployed())
+		if deployedRelease == nil {
+			continue
+		}
+
+		resSpecs, err := release.ReleaseToResourceSpecs(deployedRelease, deployedRelease.Namespace, false)
+		if err != nil {
+			return nil, fmt.Errorf("convert release %q (namespace: %q, revision: %d) to resource specs: %w", deployedRelease.Name, deployedRelease.Namespace, deployedRelease.Version, err)
+		}
+
+		findings, err := resource.FindAPIDeprecations(lo.Map(resSpecs, func(resSpec *spec.ResourceSpec, _ int) *spec.ResourceMeta {
+			return resSpec.ResourceMeta
+		}), kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("find deprecated APIs of release %q (namespace: %q, revision: %d): %w", deployedRelease.Name, deployedRelease.Namespace, deployedRelease.Version, err)
+		}
+
+		if len(findings) == 0 {
+			continue
+		}
+
+		result.Releases = append(result.Releases, &ReleaseCheckAPIsResultRelease{
+			Name:      deployedRelease.Name,
+			Namespace: deployedRelease.Namespace,
+			Revision:  deployedRelease.Version,
+			APIs:      lo.Map(findings, buildReleaseCheckAPIsResultAPI),
+		})
+	}
+
+	sort.SliceStable(result.Releases, func(i, j int) bool {
+		if result.Releases[i].Namespace != result.Releases[j].Namespace {
+			return result.Releases[i].Namespace < result.Releases[j].Namespace
+		}
+
+		return result.Releases[i].Name < result.Releases[j].Name
+	})
+
+	var resultErr error
+	if opts.FailOnRemovedAPIs {
+		removedCount := lo.SumBy(result.Releases, func(rel *ReleaseCheckAPIsResultRelease) int {
+			return lo.CountBy(rel.APIs, func(api *ReleaseCheckAPIsResultAPI) bool {
+				return api.Removed
+			})
+		})
+
+		if removedCount > 0 {
+			resultErr = fmt.Errorf("stored releases use %d resources with APIs removed in Kubernetes %s", removedCount, kubeVersion)
+		}
+	}
+
+	if opts.OutputNoPrint {
+		return result, resultErr
+	}
+
+	var resultMessage string
+
+	switch opts.OutputFormat {
+	case common.OutputFormatTable:
+		table := buildReleaseCheckAPIsOutputTable(ctx, result)
+		resultMessage = table.Render() + "\n"
+	case common.OutputFormatJSON:
+		b, err := json.MarshalIndent(result, "", strings.Repeat(" ", 2))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to json: %w", err)
+		}
+
+		resultMessage = string(b) + "\n"
+	case common.OutputFormatYAML:
+		b, err := yaml.MarshalContext(ctx, result, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to yaml: %w", err)
+		}
+
+		resultMessage = string(b)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+
+	var colorLevel color.Level
+	if color.Enable {
+		colorLevel = color.TermColorLevel()
+	}
+
+	if err := writeWithSyntaxHighlight(os.Stdout, resultMessage, opts.OutputFormat, colorLevel); err != nil {
+		return nil, fmt.Errorf("write result to output: %w", err)
+	}
+
+	return result, resultErr
+}
+
+func buildReleaseCheckAPIsResultAPI(finding *resource.APIDeprecationFinding, _ int) *ReleaseCheckAPIsResultAPI {
+	api := &ReleaseCheckAPIsResultAPI{
+		Resource:     finding.ResourceMeta.IDHuman(),
+		APIVersion:   finding.GroupVersionKind.GroupVersion().String(),
+		Kind:         finding.GroupVersionKind.Kind,
+		DeprecatedIn: finding.DeprecatedIn.Original(),
+		Removed:      finding.Removed,
+	}
+
+	if finding.RemovedIn != nil {
+		api.RemovedIn = finding.RemovedIn.Original()
+	}
+
+	if finding.ReplacementGroupVersionKind != nil {
+		api.ReplacementAPIVersion = finding.ReplacementGroupVersionKind.GroupVersion().String()
+		api.ReplacementKind = finding.ReplacementGroupVersionKind.Kind
+	}
+
+	return api
+}
+
+func buildReleaseCheckAPIsOutputTable(ctx context.Context, result *ReleaseCheckAPIsResultV1) prtable.Writer {
+	table := prtable.NewWriter()
+	setReleaseListOutputTableStyle(ctx, table)
+
+	table.AppendHeader(prtable.Row{
+		color.New(color.Bold).Sprintf("RELEASE"),
+		color.New(color.Bold).Sprintf("REVISION"),
+		color.New(color.Bold).Sprintf("RESOURCE"),
+		color.New(color.Bold).Sprintf("API"),
+		color.New(color.Bold).Sprintf("STATUS"),
+		color.New(color.Bold).Sprintf("REPLACEMENT"),
+	})
+
+	for _, rel := range result.Releases {
+		for _, api := range rel.APIs {
+			var status string
+			if api.Removed {
+				status = color.New(color.Red).Sprintf("removed in %s", api.RemovedIn)
+			} else {
+				status = color.New(color.LightYellow).Sprintf("deprecated in %s", api.DeprecatedIn)
+			}
+
+			table.AppendRow(prtable.Row{
+				color.New(color.Cyan).Sprintf("%s/%s", rel.Namespace, rel.Name),
+				rel.Revision,
+				api.Resource,
+				fmt.Sprintf("%s %s", api.APIVersion, api.Kind),
+				status,
+				strings.TrimSpace(fmt.Sprintf("%s %s", api.ReplacementAPIVersion, api.ReplacementKind)),
+			})
+		}
+	}
+
+	return table
+}
+
+func applyReleaseCheckAPIsOptionsDefaults(opts ReleaseCheckAPIsOptions, homeDir string) (ReleaseCheckAPIsOptions, error) {
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	switch opts.ReleaseStorageDriver {
+	case common.ReleaseStorageDriverDefault:
+		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	case common.ReleaseStorageDriverMemory:
+		return ReleaseCheckAPIsOptions{}, fmt.Errorf("memory release storage driver is not supported")
+	}
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = DefaultReleaseCheckAPIsOutputFormat
+	}
+
+	return opts, nil
+}
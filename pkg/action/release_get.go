@@ -46,10 +46,16 @@ type ReleaseGetOptions struct {
 	// PrintValues, when true, includes the computed values used to render the release in the output.
 	// These are the merged values from all sources (values.yaml, --set flags, etc.).
 	PrintValues bool
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
-	// Valid values: "secret" (default), "configmap", "sql".
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
 	// Defaults to "secret" if not specified or set to "default".
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -128,6 +134,8 @@ func ReleaseGet(ctx context.Context, releaseName, releaseNamespace string, opts
 	}
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
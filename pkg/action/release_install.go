@@ -15,7 +15,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
-	"github.com/werf/kubedog/pkg/informer"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/logstore"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/statestore"
 	kdutil "github.com/werf/kubedog/pkg/trackers/dyntracker/util"
@@ -107,6 +106,10 @@ type ReleaseInstallOptions struct {
 	NetworkParallelism int
 	// NoCreateNamespace, when true, skips creating the release namespace entirely.
 	NoCreateNamespace bool
+	// NoDryApplyCache, when true, neither reuses results of server-side dry-run applies saved by
+	// previous runs, e.g. by release plan install, nor saves new ones. Results are reused only while
+	// neither the resource manifest nor the resource in the cluster have changed.
+	NoDryApplyCache bool
 	// NoShowNotes, when true, suppresses printing of NOTES.txt after successful installation.
 	// NOTES.txt typically contains usage instructions and next steps.
 	NoShowNotes bool
@@ -229,10 +232,7 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 		opts.KubeConfigPaths = lo.Compact(splitPaths)
 	}
 
-	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
-		KubeConnectionOptions: opts.KubeConnectionOptions,
-		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
-	})
+	kubeConfig, err := newKubeConfig(ctx, opts.KubeConfigPaths, releaseNamespace, opts.KubeConnectionOptions)
 	if err != nil {
 		return fmt.Errorf("construct kube config: %w", err)
 	}
@@ -264,7 +264,9 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 	}
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
 		HistoryLimit:  opts.ReleaseHistoryLimit,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
@@ -273,7 +275,9 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 
 	var lockManager *lock.LockManager
 	if !opts.LegacyNoReleaseLock {
-		if m, err := lock.NewLockManager(ctx, releaseNamespace, false, clientFactory); err != nil {
+		if m, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+			Locker: opts.ReleaseLocker,
+		}); err != nil {
 			return fmt.Errorf("construct lock manager: %w", err)
 		} else {
 			lockManager = m
@@ -409,7 +413,7 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 		}
 
 		newRelease, err = release.NewRelease(releaseName, releaseNamespace, newRevision, deployType, releasableResSpecs, renderChartResult.Chart, renderChartResult.ReleaseConfig, release.ReleaseOptions{
-			InfoAnnotations: opts.ReleaseInfoAnnotations,
+			InfoAnnotations: lo.Assign(release.ProtectionInfoAnnotations(prevRelease), release.TTLInfoAnnotations(opts.ReleaseTTL), opts.ReleaseInfoAnnotations),
 			Labels:          opts.ReleaseLabels,
 			Notes:           renderChartResult.Notes,
 		})
@@ -436,6 +440,11 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 
 		log.Default.Debug(ctx, "Build resources")
 
+		releaseDeps, err := resource.ReleaseDependencies(renderChartResult.Chart.Metadata.Annotations, releaseNamespace)
+		if err != nil {
+			return fmt.Errorf("get release dependencies from chart annotations: %w", err)
+		}
+
 		instResources, delResources, err := resource.BuildResources(ctx, deployType, releaseNamespace, prevRelResSpecs, newRelResSpecs, []spec.ResourcePatcher{
 			spec.NewReleaseMetadataPatcher(releaseName, releaseNamespace),
 			spec.NewExtraMetadataPatcher(opts.ExtraRuntimeAnnotations, opts.ExtraRuntimeLabels),
@@ -443,15 +452,22 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 			Remote:                   true,
 			DefaultDeletePropagation: metav1.DeletionPropagation(opts.DefaultDeletePropagation),
 			NoPodLogs:                opts.NoPodLogs,
+			ReleaseDependencies:      releaseDeps,
 		})
 		if err != nil {
 			return fmt.Errorf("build resources: %w", err)
 		}
 
-		log.Default.Debug(ctx, "Locally validate resources")
+		log.Default.Debug(ctx, "Check resource protection")
+
+		if err := checkResourceProtection(ctx, instResources, delResources, opts.OverrideProtection); err != nil {
+			return err
+		}
+
+		log.Default.Debug(ctx, "Validate resources")
 
-		if err := resource.ValidateLocal(ctx, releaseNamespace, instResources, opts.ResourceValidationOptions); err != nil {
-			return fmt.Errorf("locally validate resources: %w", err)
+		if err := resource.ValidateWithCluster(ctx, releaseNamespace, instResources, clientFactory.Discovery(), opts.ResourceValidationOptions); err != nil {
+			return fmt.Errorf("validate resources: %w", err)
 		}
 
 		log.Default.Debug(ctx, "Build resource infos")
@@ -469,6 +485,8 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 		}
 
 		instResInfos, delResInfos, err = plan.BuildResourceInfos(ctx, deployType, releaseName, releaseNamespace, instResources, delResources, prevReleaseFailed, clientFactory, plan.BuildResourceInfosOptions{
+			DryApplyCache:                      newDryApplyCache(ctx, opts.NoDryApplyCache),
+			FieldManager:                       opts.FieldManager,
 			NetworkParallelism:                 opts.NetworkParallelism,
 			NoRemoveManualChanges:              opts.NoRemoveManualChanges,
 			LastDeployedOrLastRelResourceSpecs: lastDeployedOrLastRelResSpecs,
@@ -479,7 +497,10 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 
 		log.Default.Debug(ctx, "Remotely validate resources")
 
-		if err := plan.ValidateRemote(releaseName, releaseNamespace, instResInfos, opts.ForceAdoption); err != nil {
+		if err := plan.ValidateRemote(ctx, releaseName, releaseNamespace, instResInfos, plan.ValidateRemoteOptions{
+			AdoptResources: opts.AdoptResources,
+			ForceAdoption:  opts.ForceAdoption,
+		}); err != nil {
 			return fmt.Errorf("remotely validate resources: %w", err)
 		}
 
@@ -493,6 +514,7 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 		log.Default.Debug(ctx, "Build install plan")
 
 		installPlan, err = plan.BuildPlan(instResInfos, delResInfos, relInfos, plan.BuildPlanOptions{
+			FieldManager:    opts.FieldManager,
 			NoFinalTracking: opts.NoFinalTracking,
 		})
 		if err != nil {
@@ -557,7 +579,8 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 	taskStore := kdutil.NewConcurrent(statestore.NewTaskStore())
 	logStore := kdutil.NewConcurrent(logstore.NewLogStore())
 	watchErrCh := make(chan error, 1)
-	informerFactory := informer.NewConcurrentInformerFactory(ctx.Done(), watchErrCh, clientFactory.Dynamic(), informer.ConcurrentInformerFactoryOptions{})
+	getInformerFactory, releaseInformers := kube.DefaultInformerPool.InformerFactoryGetter(clientFactory, watchErrCh)
+	defer releaseInformers()
 
 	log.Default.Debug(ctx, "Start tracking")
 
@@ -593,7 +616,12 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 
 	log.Default.Debug(ctx, "Execute release install plan")
 
-	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, installPlan, taskStore, logStore, informerFactory, history, clientFactory, plan.ExecutePlanOptions{
+	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, installPlan, taskStore, logStore, getInformerFactory, history, clientFactory, plan.ExecutePlanOptions{
+		GetReleaseStorage: newReleaseStorageGetter(ctx, clientFactory, opts.ReleaseStorageDriver, release.ReleaseStorageOptions{
+			FilesystemDir: opts.ReleaseStorageDir,
+			S3URL:         opts.ReleaseStorageS3URL,
+			SQLConnection: opts.ReleaseStorageSQLConnection,
+		}),
 		LegacyProgressReporter:   reporter,
 		TrackingOptions:          opts.TrackingOptions,
 		NetworkParallelism:       opts.NetworkParallelism,
@@ -620,7 +648,7 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 	})
 
 	if executePlanErr != nil {
-		runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, installPlan, instResInfos, relInfos, taskStore, logStore, informerFactory, history, clientFactory, runFailureInstallPlanOptions{
+		runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, installPlan, instResInfos, relInfos, taskStore, logStore, getInformerFactory, history, clientFactory, runFailureInstallPlanOptions{
 			LegacyProgressReporter: reporter,
 			TrackingOptions:        opts.TrackingOptions,
 			NetworkParallelism:     opts.NetworkParallelism,
@@ -636,7 +664,7 @@ func releaseInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc, re
 		}
 
 		if opts.AutoRollback && prevDeployedRelease != nil {
-			runRollbackPlanResult, nonCritErrs, critErrs := runRollbackPlan(ctx, releaseName, releaseNamespace, newRelease, prevDeployedRelease, taskStore, logStore, informerFactory, history, clientFactory, runRollbackPlanOptions{
+			runRollbackPlanResult, nonCritErrs, critErrs := runRollbackPlan(ctx, releaseName, releaseNamespace, newRelease, prevDeployedRelease, taskStore, logStore, getInformerFactory, history, clientFactory, runRollbackPlanOptions{
 				ReleaseInstallRuntimeOptions: opts.ReleaseInstallRuntimeOptions,
 				TrackingOptions:              opts.TrackingOptions,
 				LegacyProgressReporter:       reporter,
@@ -769,6 +797,10 @@ func applyReleaseInstallOptionsDefaults(opts ReleaseInstallOptions, currentDir,
 		opts.DefaultDeletePropagation = string(common.DefaultDeletePropagation)
 	}
 
+	if opts.FieldManager == "" {
+		opts.FieldManager = common.DefaultFieldManager
+	}
+
 	return opts, nil
 }
 
@@ -831,7 +863,7 @@ func createReleaseNamespace(ctx context.Context, clientFactory kube.ClientFactor
 	return nil
 }
 
-func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string, failedRelease, prevDeployedRelease *helmrelease.Release, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], history *release.History, clientFactory *kube.ClientFactory, opts runRollbackPlanOptions) (result *runRollbackPlanResult, nonCritErrs, critErrs *util.MultiError) {
+func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string, failedRelease, prevDeployedRelease *helmrelease.Release, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, history *release.History, clientFactory *kube.ClientFactory, opts runRollbackPlanOptions) (result *runRollbackPlanResult, nonCritErrs, critErrs *util.MultiError) {
 	critErrs = &util.MultiError{}
 	nonCritErrs = &util.MultiError{}
 
@@ -869,7 +901,7 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 	}
 
 	newRelease, err := release.NewRelease(releaseName, releaseNamespace, failedRelease.Version+1, common.DeployTypeRollback, releasableResSpecs, prevDeployedRelease.Chart, prevDeployedRelease.Config, release.ReleaseOptions{
-		InfoAnnotations: opts.ReleaseInfoAnnotations,
+		InfoAnnotations: lo.Assign(release.TTLInfoAnnotations(opts.ReleaseTTL), opts.ReleaseInfoAnnotations),
 		Labels:          opts.ReleaseLabels,
 		Notes:           prevDeployedRelease.Info.Notes,
 	})
@@ -905,10 +937,10 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 		return nil, nonCritErrs, critErrs.Add(fmt.Errorf("build resources: %w", err))
 	}
 
-	log.Default.Debug(ctx, "Locally validate resources")
+	log.Default.Debug(ctx, "Validate resources")
 
-	if err := resource.ValidateLocal(ctx, releaseNamespace, instResources, opts.ResourceValidationOptions); err != nil {
-		return nil, nonCritErrs, critErrs.Add(fmt.Errorf("locally validate resources: %w", err))
+	if err := resource.ValidateWithCluster(ctx, releaseNamespace, instResources, clientFactory.Discovery(), opts.ResourceValidationOptions); err != nil {
+		return nil, nonCritErrs, critErrs.Add(fmt.Errorf("validate resources: %w", err))
 	}
 
 	log.Default.Debug(ctx, "Build resource infos")
@@ -919,6 +951,7 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 	}
 
 	instResInfos, delResInfos, err := plan.BuildResourceInfos(ctx, common.DeployTypeRollback, releaseName, releaseNamespace, instResources, delResources, true, clientFactory, plan.BuildResourceInfosOptions{
+		FieldManager:                       opts.FieldManager,
 		NetworkParallelism:                 opts.NetworkParallelism,
 		NoRemoveManualChanges:              opts.NoRemoveManualChanges,
 		LastDeployedOrLastRelResourceSpecs: lastDeployedOrLastRelResSpecs,
@@ -929,7 +962,10 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 
 	log.Default.Debug(ctx, "Remotely validate resources")
 
-	if err := plan.ValidateRemote(releaseName, releaseNamespace, instResInfos, opts.ForceAdoption); err != nil {
+	if err := plan.ValidateRemote(ctx, releaseName, releaseNamespace, instResInfos, plan.ValidateRemoteOptions{
+		AdoptResources: opts.AdoptResources,
+		ForceAdoption:  opts.ForceAdoption,
+	}); err != nil {
 		return nil, nonCritErrs, critErrs.Add(fmt.Errorf("remotely validate resources: %w", err))
 	}
 
@@ -945,6 +981,7 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 	log.Default.Debug(ctx, "Build rollback plan")
 
 	rollbackPlan, err := plan.BuildPlan(instResInfos, delResInfos, relInfos, plan.BuildPlanOptions{
+		FieldManager:    opts.FieldManager,
 		NoFinalTracking: opts.NoFinalTracking,
 	})
 	if err != nil {
@@ -985,7 +1022,12 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 
 	log.Default.Debug(ctx, "Execute rollback plan")
 
-	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, rollbackPlan, taskStore, logStore, informerFactory, history, clientFactory, plan.ExecutePlanOptions{
+	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, rollbackPlan, taskStore, logStore, getInformerFactory, history, clientFactory, plan.ExecutePlanOptions{
+		GetReleaseStorage: newReleaseStorageGetter(ctx, clientFactory, opts.ReleaseStorageDriver, release.ReleaseStorageOptions{
+			FilesystemDir: opts.ReleaseStorageDir,
+			S3URL:         opts.ReleaseStorageS3URL,
+			SQLConnection: opts.ReleaseStorageSQLConnection,
+		}),
 		LegacyProgressReporter:   opts.LegacyProgressReporter,
 		TrackingOptions:          opts.TrackingOptions,
 		NetworkParallelism:       opts.NetworkParallelism,
@@ -1012,7 +1054,7 @@ func runRollbackPlan(ctx context.Context, releaseName, releaseNamespace string,
 	})
 
 	if executePlanErr != nil {
-		runFailurePlanResult, nonCrErrs, crErrs := runFailurePlan(ctx, releaseNamespace, rollbackPlan, instResInfos, relInfos, taskStore, logStore, informerFactory, history, clientFactory, runFailureInstallPlanOptions{
+		runFailurePlanResult, nonCrErrs, crErrs := runFailurePlan(ctx, releaseNamespace, rollbackPlan, instResInfos, relInfos, taskStore, logStore, getInformerFactory, history, clientFactory, runFailureInstallPlanOptions{
 			LegacyProgressReporter: opts.LegacyProgressReporter,
 			TrackingOptions:        opts.TrackingOptions,
 			NetworkParallelism:     opts.NetworkParallelism,
@@ -0,0 +1,335 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const DefaultReleaseInstallTargetsParallelism = 3
+
+type ReleaseInstallTargetsOptions struct {
+	// Options shared by all targets. Kube context, namespace and values of a target take precedence.
+	ReleaseInstallOptions
+
+	// Parallelism limits the number of targets installed in parallel. Defaults to
+	// DefaultReleaseInstallTargetsParallelism if not set or <= 0.
+	Parallelism int
+}
+
+type releaseTargetsReportV1 struct {
+	Version int                    `json:"version,omitempty"`
+	Targets []*releaseTargetReport `json:"targets,omitempty"`
+}
+
+type releaseTargetReport struct {
+	*releaseReportV3
+
+	Target      string `json:"target,omitempty"`
+	KubeContext string `json:"kubeContext,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Plans and installs the release into every target. Targets are rendered and planned one by one,
+// then installed in parallel from their plans. Every target has its own kube client, release lock
+// and release storage, so a failed target doesn't affect others.
+func ReleaseInstallTargets(ctx context.Context, releaseName string, targets *ReleaseTargets, opts ReleaseInstallTargetsOptions) error {
+	if opts.Timeout != 0 {
+		var ctxCancelFn context.CancelFunc
+
+		ctx, ctxCancelFn = context.WithTimeoutCause(ctx, opts.Timeout, fmt.Errorf("context timed out: action timed out after %s", opts.Timeout.String()))
+		defer ctxCancelFn()
+	}
+
+	if opts.PlanArtifactPath != "" {
+		return fmt.Errorf("plan artifacts are not supported when installing into multiple targets")
+	}
+
+	opts, err := applyReleaseInstallTargetsOptionsDefaults(opts)
+	if err != nil {
+		return fmt.Errorf("build release install targets options: %w", err)
+	}
+
+	planErrs := planReleaseTargets(ctx, releaseName, targets, opts)
+
+	report, installErr := installReleaseTargets(ctx, releaseName, targets, planErrs, opts)
+
+	if opts.InstallReportPath != "" {
+		if err := saveReleaseTargetsReport(opts.InstallReportPath, report); err != nil {
+			return errors.Join(installErr, fmt.Errorf("save release targets report: %w", err))
+		}
+	}
+
+	if installErr != nil {
+		return fmt.Errorf("install release targets: %w", installErr)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Succeeded release %q in %d targets", releaseName, len(targets.Targets))))
+
+	return nil
+}
+
+// Targets are planned sequentially to get a readable combined diff. Plan artifacts are saved to
+// target temp dirs, so targets are installed exactly as planned, without rendering charts again.
+func planReleaseTargets(ctx context.Context, releaseName string, targets *ReleaseTargets, opts ReleaseInstallTargetsOptions) map[string]error {
+	planErrs := map[string]error{}
+
+	var changedTargets, upToDateTargets []string
+	for _, target := range targets.Targets {
+		log.Default.InfoBlock(ctx, log.BlockOptions{
+			BlockTitle: color.Style{color.Bold}.Render(fmt.Sprintf("Planning target %q (context: %q, namespace: %q)", target.Name, target.KubeContext, target.Namespace)),
+		}, func() {
+			if err := os.MkdirAll(releaseTargetTempDir(target, opts), 0o700); err != nil {
+				planErrs[target.Name] = fmt.Errorf("create target temp dir: %w", err)
+				return
+			}
+
+			err := ReleasePlanInstall(ctx, releaseName, target.Namespace, newReleaseTargetPlanInstallOptions(target, opts))
+			switch {
+			case err == nil:
+				upToDateTargets = append(upToDateTargets, target.Name)
+			case errors.Is(err, ErrChangesPlanned), errors.Is(err, ErrResourceChangesPlanned), errors.Is(err, ErrReleaseInstallPlanned):
+				changedTargets = append(changedTargets, target.Name)
+			default:
+				log.Default.Error(ctx, "Error: plan target %q: %s", target.Name, err)
+				planErrs[target.Name] = fmt.Errorf("plan: %w", err)
+			}
+		})
+	}
+
+	log.Default.InfoBlock(ctx, log.BlockOptions{
+		BlockTitle: color.Style{color.Bold, color.Blue}.Render("Targets plan"),
+	}, func() {
+		for _, name := range changedTargets {
+			log.Default.Info(ctx, color.Style{color.Yellow}.Render(fmt.Sprintf("Release %q will be installed in target %q", releaseName, name)))
+		}
+
+		for _, name := range upToDateTargets {
+			log.Default.Info(ctx, fmt.Sprintf("Release %q is up to date in target %q", releaseName, name))
+		}
+
+		for _, target := range targets.Targets {
+			if err, failed := planErrs[target.Name]; failed {
+				log.Default.Info(ctx, color.Style{color.Red}.Render(fmt.Sprintf("Target %q will be skipped: %s", target.Name, err)))
+			}
+		}
+	})
+
+	return planErrs
+}
+
+func installReleaseTargets(ctx context.Context, releaseName string, targets *ReleaseTargets, planErrs map[string]error, opts ReleaseInstallTargetsOptions) (*releaseTargetsReportV1, error) {
+	var (
+		reportsMutex sync.Mutex
+		reports      = map[string]*releaseTargetReport{}
+	)
+
+	installPool := pool.New().WithContext(ctx).WithMaxGoroutines(opts.Parallelism)
+	for _, target := range targets.Targets {
+		installPool.Go(func(ctx context.Context) error {
+			report := &releaseTargetReport{
+				releaseReportV3: &releaseReportV3{
+					Version:   3,
+					Release:   releaseName,
+					Namespace: target.Namespace,
+				},
+				Target:      target.Name,
+				KubeContext: target.KubeContext,
+			}
+
+			defer func() {
+				reportsMutex.Lock()
+				defer reportsMutex.Unlock()
+
+				reports[target.Name] = report
+			}()
+
+			if err := planErrs[target.Name]; err != nil {
+				report.Error = err.Error()
+				return nil
+			}
+
+			ctx = log.NewPrefixedContext(ctx, fmt.Sprintf("[%s] ", target.Name))
+
+			installOpts := newReleaseTargetInstallOptions(target, opts)
+
+			installErr := ReleaseInstall(ctx, releaseName, target.Namespace, installOpts)
+
+			if releaseReport, err := loadReport(installOpts.InstallReportPath); err == nil {
+				report.releaseReportV3 = releaseReport
+			}
+
+			if installErr != nil {
+				report.Error = installErr.Error()
+			}
+
+			return nil
+		})
+	}
+
+	if err := installPool.Wait(); err != nil {
+		return nil, fmt.Errorf("wait for targets installation: %w", err)
+	}
+
+	targetsReport := &releaseTargetsReportV1{
+		Version: 1,
+		Targets: lo.Map(targets.Targets, func(target *ReleaseTarget, _ int) *releaseTargetReport {
+			return reports[target.Name]
+		}),
+	}
+
+	failedTargets := lo.FilterMap(targetsReport.Targets, func(report *releaseTargetReport, _ int) (string, bool) {
+		return fmt.Sprintf("%s: %s", report.Target, report.Error), report.Error != ""
+	})
+	if len(failedTargets) > 0 {
+		return targetsReport, fmt.Errorf("failed targets:\n%s", strings.Join(failedTargets, "\n"))
+	}
+
+	return targetsReport, nil
+}
+
+func newReleaseTargetPlanInstallOptions(target *ReleaseTarget, opts ReleaseInstallTargetsOptions) ReleasePlanInstallOptions {
+	installOpts := newReleaseTargetInstallOptions(target, opts)
+	installOpts.KubeRecordDir = releaseTargetKubeRecordDir(opts.KubeRecordDir, target, "plan")
+	installOpts.KubeReplayDir = releaseTargetKubeRecordDir(opts.KubeReplayDir, target, "plan")
+
+	return ReleasePlanInstallOptions{
+		ChartRepoConnectionOptions:   installOpts.ChartRepoConnectionOptions,
+		KubeConnectionOptions:        installOpts.KubeConnectionOptions,
+		ReleaseInstallRuntimeOptions: installOpts.ReleaseInstallRuntimeOptions,
+		SecretValuesOptions:          installOpts.SecretValuesOptions,
+		ValuesOptions:                installOpts.ValuesOptions,
+		Chart:                        installOpts.Chart,
+		ChartAppVersion:              installOpts.ChartAppVersion,
+		ChartDirPath:                 installOpts.ChartDirPath,
+		ChartProvenanceKeyring:       installOpts.ChartProvenanceKeyring,
+		ChartProvenanceStrategy:      installOpts.ChartProvenanceStrategy,
+		ChartRepoSkipUpdate:          installOpts.ChartRepoSkipUpdate,
+		ChartVersion:                 installOpts.ChartVersion,
+		DefaultChartAPIVersion:       installOpts.DefaultChartAPIVersion,
+		DefaultChartName:             installOpts.DefaultChartName,
+		DefaultChartVersion:          installOpts.DefaultChartVersion,
+		DenoBinaryPath:               installOpts.DenoBinaryPath,
+		ErrorIfChangesPlanned:        true,
+		IgnoreBundleJS:               installOpts.IgnoreBundleJS,
+		LegacyChartType:              installOpts.LegacyChartType,
+		LegacyExtraValues:            installOpts.LegacyExtraValues,
+		LegacyHelmCompatibleTracking: installOpts.LegacyHelmCompatibleTracking,
+		LegacyLogRegistryStreamOut:   installOpts.LegacyLogRegistryStreamOut,
+		NetworkParallelism:           installOpts.NetworkParallelism,
+		NoDryApplyCache:              installOpts.NoDryApplyCache,
+		NoFinalTracking:              installOpts.NoFinalTracking,
+		PlanArtifactPath:             installOpts.PlanArtifactPath,
+		RegistryCredentialsPath:      installOpts.RegistryCredentialsPath,
+		TempDirPath:                  installOpts.TempDirPath,
+		TemplatesAllowDNS:            installOpts.TemplatesAllowDNS,
+	}
+}
+
+func newReleaseTargetInstallOptions(target *ReleaseTarget, opts ReleaseInstallTargetsOptions) ReleaseInstallOptions {
+	targetDir := releaseTargetTempDir(target, opts)
+
+	installOpts := opts.ReleaseInstallOptions
+	installOpts.InstallGraphPath = releaseTargetFilePath(opts.InstallGraphPath, target)
+	installOpts.InstallReportPath = filepath.Join(targetDir, "report.json")
+	installOpts.LegacyProgressReportCh = nil
+	installOpts.PlanArtifactPath = filepath.Join(targetDir, "plan.json.gz")
+	installOpts.RollbackGraphPath = releaseTargetFilePath(opts.RollbackGraphPath, target)
+	installOpts.TempDirPath = targetDir
+	installOpts.Timeout = 0
+
+	if target.KubeContext != "" {
+		installOpts.KubeContextCurrent = target.KubeContext
+	}
+
+	if target.KubeConfig != "" {
+		installOpts.KubeConfigBase64 = ""
+		installOpts.KubeConfigPaths = []string{target.KubeConfig}
+	}
+
+	installOpts.KubeRecordDir = releaseTargetKubeRecordDir(opts.KubeRecordDir, target, "install")
+	installOpts.KubeReplayDir = releaseTargetKubeRecordDir(opts.KubeReplayDir, target, "install")
+
+	installOpts.ValuesFiles = append(append([]string{}, opts.ValuesFiles...), target.Values...)
+	installOpts.ValuesSet = append(append([]string{}, opts.ValuesSet...), target.Set...)
+	installOpts.ValuesSetString = append(append([]string{}, opts.ValuesSetString...), target.SetString...)
+	installOpts.SecretValuesFiles = append(append([]string{}, opts.SecretValuesFiles...), target.SecretValues...)
+
+	return installOpts
+}
+
+func releaseTargetTempDir(target *ReleaseTarget, opts ReleaseInstallTargetsOptions) string {
+	return filepath.Join(opts.TempDirPath, "targets", releaseTargetFileName(target))
+}
+
+// Requests of every target and action are recorded separately, so that they are replayed
+// separately too.
+func releaseTargetKubeRecordDir(dir string, target *ReleaseTarget, action string) string {
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, releaseTargetFileName(target), action)
+}
+
+// Inserts the target name before the file extension, e.g. "graph.dot" -> "graph.eu-west.dot".
+func releaseTargetFilePath(path string, target *ReleaseTarget) string {
+	if path == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "." + releaseTargetFileName(target) + ext
+}
+
+// Target names default to kube context names, which might be e.g. EKS cluster ARNs with slashes
+// and colons.
+func releaseTargetFileName(target *ReleaseTarget) string {
+	return strings.NewReplacer("/", "_", ":", "_", "\\", "_").Replace(target.Name)
+}
+
+func saveReleaseTargetsReport(reportPath string, report *releaseTargetsReportV1) error {
+	reportByte, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(reportPath, []byte(log.MaskSecrets(string(reportByte))), 0o600); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	return nil
+}
+
+func applyReleaseInstallTargetsOptionsDefaults(opts ReleaseInstallTargetsOptions) (ReleaseInstallTargetsOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseInstallTargetsOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultReleaseInstallTargetsParallelism
+	}
+
+	if opts.PlanArtifactLifetime <= 0 {
+		opts.PlanArtifactLifetime = common.DefaultPlanArtifactLifetime
+	}
+
+	return opts, nil
+}
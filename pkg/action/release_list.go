@@ -45,10 +45,16 @@ type ReleaseListOptions struct {
 	// ReleaseNamespace specifies the namespace to list releases from.
 	// If empty, uses the namespace from kubeconfig context.
 	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
-	// Valid values: "secret" (default), "configmap", "sql".
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
 	// Defaults to "secret" if not specified or set to "default".
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -118,6 +124,8 @@ func ReleaseList(ctx context.Context, opts ReleaseListOptions) (*ReleaseListResu
 	}
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, opts.ReleaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
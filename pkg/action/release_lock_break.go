@@ -0,0 +1,115 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/lock"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const DefaultReleaseLockBreakLogLevel = log.InfoLevel
+
+type ReleaseLockBreakOptions struct {
+	common.KubeConnectionOptions
+
+	// Force, when true, breaks the lock even if it is not expired, i.e. its holder might still be
+	// running. Otherwise, only expired locks are broken.
+	Force bool
+	// ReleaseLocker specifies how the release is locked. Valid values: "configmap" (default), "lease".
+	ReleaseLocker string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+}
+
+// Forcefully releases the lock of the release, e.g. a stale lock left by a killed process.
+func ReleaseLockBreak(ctx context.Context, releaseName, releaseNamespace string, opts ReleaseLockBreakOptions) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseLockBreakOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return fmt.Errorf("build release lock break options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	lockManager, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+		Locker: opts.ReleaseLocker,
+	})
+	if err != nil {
+		return fmt.Errorf("construct lock manager: %w", err)
+	}
+
+	releaseLock, err := lockManager.GetReleaseLock(ctx, releaseName)
+	if err != nil {
+		return fmt.Errorf("get release lock: %w", err)
+	}
+
+	if releaseLock == nil {
+		log.Default.Info(ctx, "Release %q (namespace: %q) is not locked", releaseName, releaseNamespace)
+		return nil
+	}
+
+	if !releaseLock.Expired && !opts.Force {
+		return fmt.Errorf("lock of release %q (namespace: %q) held by %q is not expired, its holder might still be running, use force to break it anyway", releaseName, releaseNamespace, releaseLock.HolderID)
+	}
+
+	broken, err := lockManager.BreakReleaseLock(ctx, releaseName)
+	if err != nil {
+		return fmt.Errorf("break release lock: %w", err)
+	}
+
+	if !broken {
+		log.Default.Info(ctx, "Release %q (namespace: %q) is not locked", releaseName, releaseNamespace)
+		return nil
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Broke lock of release %q (namespace: %q) held by %q", releaseName, releaseNamespace, releaseLock.HolderID)))
+
+	return nil
+}
+
+func applyReleaseLockBreakOptionsDefaults(opts ReleaseLockBreakOptions, homeDir string) (ReleaseLockBreakOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseLockBreakOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	return opts, nil
+}
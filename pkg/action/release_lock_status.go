@@ -0,0 +1,197 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/lock"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const (
+	DefaultReleaseLockStatusLogLevel     = log.ErrorLevel
+	DefaultReleaseLockStatusOutputFormat = common.OutputFormatYAML
+)
+
+type ReleaseLockStatusOptions struct {
+	common.KubeConnectionOptions
+
+	// OutputFormat specifies the output format for the lock status.
+	// Valid values: "yaml" (default), "json".
+	OutputFormat string
+	// OutputNoPrint, when true, suppresses printing the output and only returns the result data structure.
+	// Useful when calling this programmatically.
+	OutputNoPrint bool
+	// ReleaseLocker specifies how the release is locked. Valid values: "configmap" (default), "lease".
+	ReleaseLocker string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+}
+
+type ReleaseLockStatusResultV1 struct {
+	APIVersion string                       `json:"apiVersion"`
+	Locked     bool                         `json:"locked"`
+	Lock       *ReleaseLockStatusResultLock `json:"lock,omitempty"`
+}
+
+type ReleaseLockStatusResultLock struct {
+	Locker     string                         `json:"locker"`
+	HolderID   string                         `json:"holderID"`
+	Holder     *ReleaseLockStatusResultHolder `json:"holder,omitempty"`
+	AcquiredAt *time.Time                     `json:"acquiredAt,omitempty"`
+	ExpiresAt  time.Time                      `json:"expiresAt"`
+	Expired    bool                           `json:"expired"`
+}
+
+type ReleaseLockStatusResultHolder struct {
+	User     string `json:"user,omitempty"`
+	Host     string `json:"host,omitempty"`
+	CIJobURL string `json:"ciJobURL,omitempty"`
+}
+
+// Shows who holds the lock of the release and whether the lock is expired.
+func ReleaseLockStatus(ctx context.Context, releaseName, releaseNamespace string, opts ReleaseLockStatusOptions) (*ReleaseLockStatusResultV1, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseLockStatusOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("build release lock status options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	lockManager, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+		Locker: opts.ReleaseLocker,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct lock manager: %w", err)
+	}
+
+	releaseLock, err := lockManager.GetReleaseLock(ctx, releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("get release lock: %w", err)
+	}
+
+	result := buildReleaseLockStatusResult(releaseLock)
+
+	if opts.OutputNoPrint {
+		return result, nil
+	}
+
+	var resultMessage string
+
+	switch opts.OutputFormat {
+	case common.OutputFormatJSON:
+		b, err := json.MarshalIndent(result, "", strings.Repeat(" ", 2))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to json: %w", err)
+		}
+
+		resultMessage = string(b)
+	case common.OutputFormatYAML:
+		b, err := yaml.MarshalContext(ctx, result, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to yaml: %w", err)
+		}
+
+		resultMessage = string(b)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+
+	var colorLevel color.Level
+	if color.Enable {
+		colorLevel = color.TermColorLevel()
+	}
+
+	if err := writeWithSyntaxHighlight(os.Stdout, resultMessage, opts.OutputFormat, colorLevel); err != nil {
+		return nil, fmt.Errorf("write result to output: %w", err)
+	}
+
+	return result, nil
+}
+
+func applyReleaseLockStatusOptionsDefaults(opts ReleaseLockStatusOptions, homeDir string) (ReleaseLockStatusOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseLockStatusOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = DefaultReleaseLockStatusOutputFormat
+	}
+
+	return opts, nil
+}
+
+func buildReleaseLockStatusResult(releaseLock *lock.ReleaseLock) *ReleaseLockStatusResultV1 {
+	result := &ReleaseLockStatusResultV1{
+		APIVersion: "v1",
+	}
+
+	if releaseLock == nil {
+		return result
+	}
+
+	result.Locked = true
+	result.Lock = &ReleaseLockStatusResultLock{
+		Expired:   releaseLock.Expired,
+		ExpiresAt: releaseLock.ExpiresAt,
+		HolderID:  releaseLock.HolderID,
+		Locker:    releaseLock.Locker,
+	}
+
+	if !releaseLock.AcquiredAt.IsZero() {
+		result.Lock.AcquiredAt = &releaseLock.AcquiredAt
+	}
+
+	if releaseLock.Holder != (lock.LockHolder{}) {
+		result.Lock.Holder = &ReleaseLockStatusResultHolder{
+			CIJobURL: releaseLock.Holder.CIJobURL,
+			Host:     releaseLock.Holder.Host,
+			User:     releaseLock.Holder.User,
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,293 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/color"
+	prtable "github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/release"
+)
+
+const (
+	DefaultReleaseOrphansLogLevel     = log.ErrorLevel
+	DefaultReleaseOrphansOutputFormat = common.OutputFormatTable
+)
+
+type ReleaseOrphansOptions struct {
+	common.KubeConnectionOptions
+
+	// Delete, when true, deletes the found orphaned resources, except for the protected ones and the
+	// ones with the "keep" resource policy.
+	Delete bool
+	// NetworkParallelism limits the number of concurrent network-related operations (API calls, resource fetches).
+	// Defaults to DefaultNetworkParallelism if not set or <= 0.
+	NetworkParallelism int
+	// OutputFormat specifies the output format for the orphaned resources.
+	// Valid values: "table" (default), "yaml", "json".
+	OutputFormat string
+	// OutputNoPrint, when true, suppresses printing the output and only returns the result data structure.
+	// Useful when calling this programmatically.
+	OutputNoPrint bool
+	// ReleaseName, if specified, only resources of this release are considered.
+	ReleaseName string
+	// ReleaseNamespace specifies the namespace to search orphaned resources in.
+	// If empty, all namespaces are searched.
+	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
+	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
+	// Defaults to "secret" if not specified or set to "default".
+	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
+	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
+	// Only used when ReleaseStorageDriver is "sql".
+	ReleaseStorageSQLConnection string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+}
+
+type ReleaseOrphansResultV1 struct {
+	APIVersion string                          `json:"apiVersion"`
+	Resources  []*ReleaseOrphansResultResource `json:"resources"`
+}
+
+type ReleaseOrphansResultResource struct {
+	Group            string `json:"group"`
+	Version          string `json:"version"`
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	ReleaseName      string `json:"releaseName"`
+	ReleaseNamespace string `json:"releaseNamespace"`
+	Reason           string `json:"reason"`
+	Deleted          bool   `json:"deleted"`
+}
+
+// Finds resources in the cluster whose release annotations point at a non-existent, uninstalled or
+// superseded release, and optionally deletes them.
+func ReleaseOrphans(ctx context.Context, opts ReleaseOrphansOptions) (*ReleaseOrphansResultV1, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseOrphansOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("build release orphans options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  opts.ReleaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	getStorage := newReleaseStorageGetter(ctx, clientFactory, opts.ReleaseStorageDriver, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
+		SQLConnection: opts.ReleaseStorageSQLConnection,
+	})
+
+	log.Default.Info(ctx, "Find orphaned resources")
+
+	orphans, err := release.FindOrphanedResources(ctx, opts.ReleaseNamespace, getStorage, clientFactory, release.FindOrphanedResourcesOptions{
+		NetworkParallelism: opts.NetworkParallelism,
+		ReleaseName:        opts.ReleaseName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find orphaned resources: %w", err)
+	}
+
+	var deleted map[*release.OrphanedResource]bool
+	if opts.Delete {
+		deleted, err = deleteOrphanedResources(ctx, orphans, clientFactory, opts.NetworkParallelism)
+		if err != nil {
+			return nil, fmt.Errorf("delete orphaned resources: %w", err)
+		}
+	}
+
+	result := &ReleaseOrphansResultV1{
+		APIVersion: "v1",
+		Resources: lo.Map(orphans, func(orphan *release.OrphanedResource, _ int) *ReleaseOrphansResultResource {
+			return &ReleaseOrphansResultResource{
+				Deleted:          deleted[orphan],
+				Group:            orphan.GroupVersionKind.Group,
+				Kind:             orphan.GroupVersionKind.Kind,
+				Name:             orphan.Name,
+				Namespace:        lo.Ternary(orphan.Namespace == "" && orphan.Namespaced, orphan.ReleaseNamespace, orphan.Namespace),
+				Reason:           string(orphan.Reason),
+				ReleaseName:      orphan.ReleaseName,
+				ReleaseNamespace: orphan.ReleaseNamespace,
+				Version:          orphan.GroupVersionKind.Version,
+			}
+		}),
+	}
+
+	if opts.OutputNoPrint {
+		return result, nil
+	}
+
+	var resultMessage string
+
+	switch opts.OutputFormat {
+	case common.OutputFormatTable:
+		table := buildReleaseOrphansOutputTable(ctx, result, opts.Delete)
+		resultMessage = table.Render() + "\n"
+	case common.OutputFormatJSON:
+		b, err := json.MarshalIndent(result, "", strings.Repeat(" ", 2))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to json: %w", err)
+		}
+
+		resultMessage = string(b) + "\n"
+	case common.OutputFormatYAML:
+		b, err := yaml.MarshalContext(ctx, result, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to yaml: %w", err)
+		}
+
+		resultMessage = string(b)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+
+	var colorLevel color.Level
+	if color.Enable {
+		colorLevel = color.TermColorLevel()
+	}
+
+	if err := writeWithSyntaxHighlight(os.Stdout, resultMessage, opts.OutputFormat, colorLevel); err != nil {
+		return nil, fmt.Errorf("write result to output: %w", err)
+	}
+
+	return result, nil
+}
+
+func deleteOrphanedResources(ctx context.Context, orphans []*release.OrphanedResource, clientFactory kube.ClientFactorier, parallelism int) (map[*release.OrphanedResource]bool, error) {
+	deletePool := pool.NewWithResults[*release.OrphanedResource]().WithContext(ctx).WithMaxGoroutines(parallelism).WithCancelOnError().WithFirstError()
+	for _, orphan := range orphans {
+		if orphan.Protected {
+			log.Default.Warn(ctx, "Not deleting protected orphaned resource %q", orphan.IDHuman())
+			continue
+		}
+
+		if lo.Contains(orphan.ResourcePolicies, common.ResourcePolicySkipDelete) {
+			log.Default.Info(ctx, "Not deleting orphaned resource %q due to its resource policy", orphan.IDHuman())
+			continue
+		}
+
+		deletePool.Go(func(ctx context.Context) (*release.OrphanedResource, error) {
+			if err := clientFactory.KubeClient().Delete(ctx, orphan.ResourceMeta, kube.KubeClientDeleteOptions{
+				DefaultNamespace:  orphan.ReleaseNamespace,
+				PropagationPolicy: orphan.DeletePropagation,
+			}); err != nil {
+				return nil, fmt.Errorf("delete resource %q: %w", orphan.IDHuman(), err)
+			}
+
+			log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Deleted orphaned resource %q of release %q (namespace: %q)", orphan.IDHuman(), orphan.ReleaseName, orphan.ReleaseNamespace)))
+
+			return orphan, nil
+		})
+	}
+
+	deletedOrphans, err := deletePool.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.SliceToMap(deletedOrphans, func(orphan *release.OrphanedResource) (*release.OrphanedResource, bool) {
+		return orphan, true
+	}), nil
+}
+
+func buildReleaseOrphansOutputTable(ctx context.Context, result *ReleaseOrphansResultV1, withDeleted bool) prtable.Writer {
+	table := prtable.NewWriter()
+	setReleaseListOutputTableStyle(ctx, table)
+
+	headerRow := prtable.Row{
+		color.New(color.Bold).Sprintf("RELEASE"),
+		color.New(color.Bold).Sprintf("RESOURCE"),
+		color.New(color.Bold).Sprintf("REASON"),
+	}
+	if withDeleted {
+		headerRow = append(headerRow, color.New(color.Bold).Sprintf("DELETED"))
+	}
+
+	table.AppendHeader(headerRow)
+
+	for _, res := range result.Resources {
+		resID := lo.Ternary(res.Namespace != "", res.Namespace+"/", "") + lo.Ternary(res.Group != "", res.Kind+"."+res.Group, res.Kind) + "/" + res.Name
+
+		row := prtable.Row{
+			color.New(color.Cyan).Sprintf("%s/%s", res.ReleaseNamespace, res.ReleaseName),
+			resID,
+			color.New(color.LightYellow).Sprintf("%s", res.Reason),
+		}
+		if withDeleted {
+			row = append(row, res.Deleted)
+		}
+
+		table.AppendRow(row)
+	}
+
+	return table
+}
+
+func applyReleaseOrphansOptionsDefaults(opts ReleaseOrphansOptions, homeDir string) (ReleaseOrphansOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseOrphansOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	if opts.ReleaseStorageDriver == common.ReleaseStorageDriverDefault {
+		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	}
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = DefaultReleaseOrphansOutputFormat
+	}
+
+	return opts, nil
+}
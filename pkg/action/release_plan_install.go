@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -74,6 +75,16 @@ type ReleasePlanInstallOptions struct {
 	DefaultChartVersion string
 	// DenoBinaryPath, if specified, uses this path as the Deno binary instead of auto-downloading.
 	DenoBinaryPath string
+	// DryCluster, when true, plans against an in-memory cluster seeded with DryClusterResourcesPaths
+	// instead of connecting to a cluster. Server-side apply is emulated. Implied by
+	// DryClusterResourcesPaths.
+	DryCluster bool
+	// DryClusterKubeVersion is the Kubernetes version of the in-memory cluster.
+	// Defaults to DefaultLocalKubeVersion if not set.
+	DryClusterKubeVersion string
+	// DryClusterResourcesPaths are YAML files with resources to seed the in-memory cluster with, e.g.
+	// a snapshot made with ClusterSnapshot. Also used for lookups in templates.
+	DryClusterResourcesPaths []string
 	// ErrorIfChangesPlanned, when true, returns ErrChangesPlanned if any changes are detected.
 	// Used with --exit-code flag to return exit code 2 if changes are planned, 0 if no changes, 1 on error.
 	ErrorIfChangesPlanned bool
@@ -96,6 +107,10 @@ type ReleasePlanInstallOptions struct {
 	// NetworkParallelism limits the number of concurrent network-related operations (API calls, resource fetches).
 	// Defaults to DefaultNetworkParallelism if not set or <= 0.
 	NetworkParallelism int
+	// NoDryApplyCache, when true, neither reuses results of server-side dry-run applies saved by
+	// previous runs, e.g. by release plan install, nor saves new ones. Results are reused only while
+	// neither the resource manifest nor the resource in the cluster have changed.
+	NoDryApplyCache bool
 	// NoFinalTracking, when true, disables final tracking operations in the plan that have no
 	// create/update/delete resource operations after them. This speeds up plan generation.
 	NoFinalTracking bool
@@ -171,17 +186,22 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 		opts.KubeConfigPaths = lo.Compact(splitPaths)
 	}
 
-	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
-		KubeConnectionOptions: opts.KubeConnectionOptions,
-		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
-	})
-	if err != nil {
-		return fmt.Errorf("construct kube config: %w", err)
-	}
+	var clientFactory kube.ClientFactorier
+	if opts.DryCluster {
+		clientFactory, err = newDryClusterClientFactory(ctx, releaseNamespace, opts.DryClusterResourcesPaths, opts.DryClusterKubeVersion)
+		if err != nil {
+			return fmt.Errorf("construct dry cluster client factory: %w", err)
+		}
+	} else {
+		kubeConfig, err := newKubeConfig(ctx, opts.KubeConfigPaths, releaseNamespace, opts.KubeConnectionOptions)
+		if err != nil {
+			return fmt.Errorf("construct kube config: %w", err)
+		}
 
-	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
-	if err != nil {
-		return fmt.Errorf("construct kube client factory: %w", err)
+		clientFactory, err = kube.NewClientFactory(ctx, kubeConfig)
+		if err != nil {
+			return fmt.Errorf("construct kube client factory: %w", err)
+		}
 	}
 
 	helmRegistryClientOpts := []registry.ClientOption{
@@ -203,6 +223,8 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 	}
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
@@ -270,6 +292,7 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 		ChartRepoNoUpdate:          opts.ChartRepoSkipUpdate,
 		ChartVersion:               opts.ChartVersion,
 		HelmOptions:                helmOptions,
+		LocalLookupResourcesPaths:  opts.DryClusterResourcesPaths,
 		NoStandaloneCRDs:           opts.NoInstallStandaloneCRDs,
 		Remote:                     true,
 		TemplatesAllowDNS:          opts.TemplatesAllowDNS,
@@ -308,7 +331,7 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 	}
 
 	newRelease, err := release.NewRelease(releaseName, releaseNamespace, newRevision, deployType, releasableResSpecs, renderChartResult.Chart, renderChartResult.ReleaseConfig, release.ReleaseOptions{
-		InfoAnnotations: opts.ReleaseInfoAnnotations,
+		InfoAnnotations: lo.Assign(release.ProtectionInfoAnnotations(prevRelease), release.TTLInfoAnnotations(opts.ReleaseTTL), opts.ReleaseInfoAnnotations),
 		Labels:          opts.ReleaseLabels,
 		Notes:           renderChartResult.Notes,
 	})
@@ -335,21 +358,44 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 
 	log.Default.Debug(ctx, "Build resources")
 
+	releaseDeps, err := resource.ReleaseDependencies(renderChartResult.Chart.Metadata.Annotations, releaseNamespace)
+	if err != nil {
+		return fmt.Errorf("get release dependencies from chart annotations: %w", err)
+	}
+
 	instResources, delResources, err := resource.BuildResources(ctx, deployType, releaseNamespace, prevRelResSpecs, newRelResSpecs, []spec.ResourcePatcher{
 		spec.NewReleaseMetadataPatcher(releaseName, releaseNamespace),
 		spec.NewExtraMetadataPatcher(opts.ExtraRuntimeAnnotations, opts.ExtraRuntimeLabels),
 	}, clientFactory, resource.BuildResourcesOptions{
 		Remote:                   true,
 		DefaultDeletePropagation: metav1.DeletionPropagation(opts.DefaultDeletePropagation),
+		ReleaseDependencies:      releaseDeps,
 	})
 	if err != nil {
 		return fmt.Errorf("build resources: %w", err)
 	}
 
-	log.Default.Debug(ctx, "Locally validate resources")
+	log.Default.Debug(ctx, "Check resource protection")
+
+	if err := checkResourceProtection(ctx, instResources, delResources, opts.OverrideProtection); err != nil {
+		return err
+	}
+
+	log.Default.Debug(ctx, "Validate resources")
+
+	if err := resource.ValidateWithCluster(ctx, releaseNamespace, instResources, clientFactory.Discovery(), opts.ResourceValidationOptions); err != nil {
+		return fmt.Errorf("validate resources: %w", err)
+	}
+
+	log.Default.Debug(ctx, "Check deprecated APIs")
 
-	if err := resource.ValidateLocal(ctx, releaseNamespace, instResources, opts.ResourceValidationOptions); err != nil {
-		return fmt.Errorf("locally validate resources: %w", err)
+	serverVersion, err := clientFactory.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("get kubernetes server version: %w", err)
+	}
+
+	if err := checkAPIDeprecations(ctx, apiDeprecationsKubeVersion(opts.ValidationKubeVersion, serverVersion.GitVersion, ""), releaseNamespace, newRelResSpecs, prevRelease, false); err != nil {
+		return fmt.Errorf("check deprecated APIs: %w", err)
 	}
 
 	log.Default.Debug(ctx, "Build resource infos")
@@ -365,6 +411,8 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 	}
 
 	instResInfos, delResInfos, err := plan.BuildResourceInfos(ctx, deployType, releaseName, releaseNamespace, instResources, delResources, prevReleaseFailed, clientFactory, plan.BuildResourceInfosOptions{
+		DryApplyCache:                      newDryApplyCache(ctx, opts.NoDryApplyCache),
+		FieldManager:                       opts.FieldManager,
 		NetworkParallelism:                 opts.NetworkParallelism,
 		NoRemoveManualChanges:              opts.NoRemoveManualChanges,
 		LastDeployedOrLastRelResourceSpecs: lastDeployedOrLastRelResSpecs,
@@ -373,9 +421,26 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 		return fmt.Errorf("build resource infos: %w", err)
 	}
 
+	log.Default.Debug(ctx, "Build adoptions")
+
+	adoptions, err := plan.BuildAdoptions(ctx, releaseName, releaseNamespace, instResInfos, plan.BuildAdoptionsOptions{
+		AdoptResources: opts.AdoptResources,
+		ForceAdoption:  opts.ForceAdoption,
+	})
+	if err != nil {
+		return fmt.Errorf("build adoptions: %w", err)
+	}
+
+	if err := logPlannedAdoptions(ctx, releaseName, releaseNamespace, adoptions, opts.ResourceDiffOptions); err != nil {
+		return fmt.Errorf("log planned adoptions: %w", err)
+	}
+
 	log.Default.Debug(ctx, "Remotely validate resources")
 
-	if err := plan.ValidateRemote(releaseName, releaseNamespace, instResInfos, opts.ForceAdoption); err != nil {
+	if err := plan.ValidateRemote(ctx, releaseName, releaseNamespace, instResInfos, plan.ValidateRemoteOptions{
+		AdoptResources: opts.AdoptResources,
+		ForceAdoption:  opts.ForceAdoption,
+	}); err != nil {
 		return fmt.Errorf("remotely validate resources: %w", err)
 	}
 
@@ -389,6 +454,7 @@ func releasePlanInstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc
 	log.Default.Debug(ctx, "Build install plan")
 
 	installPlan, err := plan.BuildPlan(instResInfos, delResInfos, relInfos, plan.BuildPlanOptions{
+		FieldManager:    opts.FieldManager,
 		NoFinalTracking: opts.NoFinalTracking,
 	})
 	if err != nil {
@@ -567,9 +633,88 @@ func applyReleasePlanInstallOptionsDefaults(opts ReleasePlanInstallOptions, curr
 		opts.DefaultDeletePropagation = string(common.DefaultDeletePropagation)
 	}
 
+	if opts.FieldManager == "" {
+		opts.FieldManager = common.DefaultFieldManager
+	}
+
+	if len(opts.DryClusterResourcesPaths) > 0 {
+		opts.DryCluster = true
+	}
+
+	if opts.DryCluster {
+		if opts.KubeReplayDir != "" {
+			return ReleasePlanInstallOptions{}, fmt.Errorf("dry cluster can't be used with replaying Kubernetes API responses")
+		}
+
+		if opts.ValidationClusterSchemas {
+			return ReleasePlanInstallOptions{}, fmt.Errorf("validation against cluster schemas is not supported with dry cluster")
+		}
+
+		if opts.DryClusterKubeVersion == "" {
+			opts.DryClusterKubeVersion = common.DefaultLocalKubeVersion
+		}
+
+		// Resources of the in-memory cluster are never the same as in real clusters.
+		opts.NoDryApplyCache = true
+	}
+
 	return opts, nil
 }
 
+func logPlannedAdoptions(ctx context.Context, releaseName, releaseNamespace string, adoptions []*plan.Adoption, opts common.ResourceDiffOptions) error {
+	if len(adoptions) == 0 {
+		return nil
+	}
+
+	log.Default.Info(ctx, "")
+
+	for _, adoption := range adoptions {
+		if err := log.Default.InfoBlockErr(ctx, log.BlockOptions{
+			BlockTitle: buildDiffHeader(adoption.Change),
+		}, func() error {
+			if adoption.FromReleaseName != "" {
+				log.Default.Info(ctx, "<from release %q (namespace: %q)>", adoption.FromReleaseName, adoption.FromReleaseNamespace)
+			} else {
+				log.Default.Info(ctx, "<from no release>")
+			}
+
+			if managersBefore, managersAfter := plan.FieldManagers(adoption.ManagedFieldsBefore), plan.FieldManagers(adoption.ManagedFieldsAfter); !slices.Equal(managersBefore, managersAfter) {
+				log.Default.Info(ctx, "<field managers: %s -> %s>", strings.Join(managersBefore, ", "), strings.Join(managersAfter, ", "))
+			}
+
+			uDiff, err := adoption.Change.UDiff(opts)
+			if err != nil {
+				return fmt.Errorf("calculate diff for resource %s: %w", adoption.IDHuman(), err)
+			}
+
+			log.Default.Info(ctx, "%s", uDiff)
+
+			if !adoption.Allowed {
+				log.Default.Info(ctx, "<not allowed: %s>", adoption.NonAdoptableReason)
+			}
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("log adoptions: %w", err)
+		}
+	}
+
+	allowed := lo.CountBy(adoptions, func(adoption *plan.Adoption) bool {
+		return adoption.Allowed
+	})
+
+	log.Default.Info(ctx, color.Bold.Render("Planned adoptions summary")+" for release %q (namespace: %q):", releaseName, releaseNamespace)
+	log.Default.Info(ctx, "- %s: %d resources", color.Style{color.Bold, color.Magenta}.Render("allowed"), allowed)
+
+	if notAllowed := len(adoptions) - allowed; notAllowed > 0 {
+		log.Default.Info(ctx, "- %s: %d resources, allow with --adopt-resource or --force-adoption", color.Style{color.Bold, color.Red}.Render("not allowed"), notAllowed)
+	}
+
+	log.Default.Info(ctx, "")
+
+	return nil
+}
+
 func buildDiffHeader(change *plan.ResourceChange) string {
 	header := change.TypeStyle.Render(util.Capitalize(change.Type))
 	header += " " + color.Style{color.Bold}.Render(change.ResourceMeta.IDHuman())
@@ -0,0 +1,190 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+const DefaultReleaseProtectLogLevel = log.InfoLevel
+
+type ReleaseProtectOptions struct {
+	common.KubeConnectionOptions
+
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
+	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
+	// Defaults to "secret" if not specified or set to "default".
+	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
+	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
+	// Only used when ReleaseStorageDriver is "sql".
+	ReleaseStorageSQLConnection string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+	// Unprotect, when true, removes the protection from the release instead of adding it.
+	Unprotect bool
+}
+
+// Marks the latest revision of the Helm release protected (or unprotected). While protected, the
+// release can't be uninstalled or rolled back unless the protection is explicitly overridden.
+func ReleaseProtect(ctx context.Context, releaseName, releaseNamespace string, opts ReleaseProtectOptions) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseProtectOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return fmt.Errorf("build release protect options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  releaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
+		SQLConnection: opts.ReleaseStorageSQLConnection,
+	})
+	if err != nil {
+		return fmt.Errorf("construct release storage: %w", err)
+	}
+
+	rel, err := releaseStorage.GetRelease(releaseName, 0)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return fmt.Errorf("release %q (namespace: %q) not found", releaseName, releaseNamespace)
+		}
+
+		return fmt.Errorf("get release %q (namespace: %q): %w", releaseName, releaseNamespace, err)
+	}
+
+	if release.IsReleaseProtected(rel) != opts.Unprotect {
+		log.Default.Info(ctx, "Release %q (namespace: %q) is already %s", releaseName, releaseNamespace, lo.Ternary(opts.Unprotect, "unprotected", "protected"))
+		return nil
+	}
+
+	if opts.Unprotect {
+		delete(rel.Info.Annotations, common.AnnotationKeyHumanProtect)
+		delete(rel.Labels, common.AnnotationKeyHumanProtect)
+	} else {
+		rel.Info.Annotations = lo.Assign(rel.Info.Annotations, map[string]string{common.AnnotationKeyHumanProtect: "true"})
+	}
+
+	if err := releaseStorage.Update(rel); err != nil {
+		return fmt.Errorf("update release %q (namespace: %q, revision: %d): %w", releaseName, releaseNamespace, rel.Version, err)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("%s release %q (namespace: %q)", lo.Ternary(opts.Unprotect, "Unprotected", "Protected"), releaseName, releaseNamespace)))
+
+	return nil
+}
+
+func applyReleaseProtectOptionsDefaults(opts ReleaseProtectOptions, homeDir string) (ReleaseProtectOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseProtectOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	if opts.ReleaseStorageDriver == common.ReleaseStorageDriverDefault {
+		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	}
+
+	return opts, nil
+}
+
+// Refuses to proceed with the operation if the latest revision of the release is protected.
+func checkReleaseProtection(ctx context.Context, operation, releaseName, releaseNamespace string, releaseStorage release.ReleaseStorager, overrideProtection bool) error {
+	rel, err := releaseStorage.GetRelease(releaseName, 0)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("get release %q (namespace: %q): %w", releaseName, releaseNamespace, err)
+	}
+
+	if !release.IsReleaseProtected(rel) {
+		return nil
+	}
+
+	if overrideProtection {
+		log.Default.Warn(ctx, "Release %q (namespace: %q) is protected, proceeding with %s anyway since protection is overridden", releaseName, releaseNamespace, operation)
+		return nil
+	}
+
+	return fmt.Errorf("release %q (namespace: %q) is protected, refusing to %s it: unprotect the release or override protection", releaseName, releaseNamespace, operation)
+}
+
+// Refuses to proceed with the plan if it deletes resources marked with "werf.io/protect".
+func checkResourceProtection(ctx context.Context, instResources []*resource.InstallableResource, delResources []*resource.DeletableResource, overrideProtection bool) error {
+	protectedResources := lo.Filter(delResources, func(delRes *resource.DeletableResource, _ int) bool {
+		if !delRes.Protected {
+			return false
+		}
+
+		_, kept := lo.Find(instResources, func(instRes *resource.InstallableResource) bool {
+			return instRes.ID() == delRes.ID()
+		})
+
+		return !kept
+	})
+
+	if len(protectedResources) == 0 {
+		return nil
+	}
+
+	protectedResourceIDs := lo.Map(protectedResources, func(res *resource.DeletableResource, _ int) string {
+		return res.IDHuman()
+	})
+
+	if overrideProtection {
+		log.Default.Warn(ctx, "Deleting protected resources since protection is overridden: %s", strings.Join(protectedResourceIDs, ", "))
+		return nil
+	}
+
+	return fmt.Errorf("refusing to delete resources protected with %q annotation: %s", common.AnnotationKeyHumanProtect, strings.Join(protectedResourceIDs, ", "))
+}
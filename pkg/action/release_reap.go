@@ -0,0 +1,342 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gookit/color"
+	prtable "github.com/jedib0t/go-pretty/v6/table"
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/release"
+)
+
+const (
+	DefaultReleaseReapLogLevel     = log.ErrorLevel
+	DefaultReleaseReapOutputFormat = common.OutputFormatJSON
+)
+
+type ReleaseReapOptions struct {
+	common.KubeConnectionOptions
+	common.TrackingOptions
+
+	// DefaultDeletePropagation sets the deletion propagation policy for resource deletions.
+	DefaultDeletePropagation string
+	// DeleteReleaseNamespace, when true, deletes the release namespace after uninstalling an expired
+	// release. WARNING: This will delete the entire namespace including resources not managed by the
+	// release.
+	DeleteReleaseNamespace bool
+	// DryRun, when true, only finds expired releases without uninstalling them.
+	DryRun bool
+	// NetworkParallelism limits the number of concurrent network-related operations (API calls, resource fetches).
+	// Defaults to DefaultNetworkParallelism if not set or <= 0.
+	NetworkParallelism int
+	// OutputFormat specifies the output format for the reaped releases.
+	// Valid values: "json" (default), "yaml", "table".
+	OutputFormat string
+	// OutputNoPrint, when true, suppresses printing the output and only returns the result data structure.
+	// Useful when calling this programmatically.
+	OutputNoPrint bool
+	// ReleaseLocker specifies how the release is locked during the operation.
+	// Valid values: "configmap" (default) uses the werf-synchronization ConfigMap, "lease" uses a Lease per release.
+	ReleaseLocker string
+	// ReleaseNamespace specifies the namespace to search expired releases in.
+	// If empty, all namespaces are searched.
+	ReleaseNamespace string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
+	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
+	// Defaults to "secret" if not specified or set to "default".
+	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
+	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
+	// Only used when ReleaseStorageDriver is "sql".
+	ReleaseStorageSQLConnection string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+	// Timeout is the maximum duration for uninstalling each expired release.
+	// If 0, no timeout is applied.
+	Timeout time.Duration
+}
+
+type ReleaseReapResultV1 struct {
+	APIVersion string                      `json:"apiVersion"`
+	Releases   []*ReleaseReapResultRelease `json:"releases"`
+}
+
+type ReleaseReapResultRelease struct {
+	Name       string             `json:"name"`
+	Namespace  string             `json:"namespace"`
+	Revision   int                `json:"revision"`
+	Status     helmrelease.Status `json:"status"`
+	ExpiresAt  string             `json:"expiresAt"`
+	Reaped     bool               `json:"reaped"`
+	SkipReason string             `json:"skipReason,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// Uninstalls releases whose TTL, recorded in the "werf.io/expires-at" release info annotation, has
+// passed. Protected releases are skipped. Failing to reap a release doesn't stop reaping the others,
+// but an error is returned in the end.
+func ReleaseReap(ctx context.Context, opts ReleaseReapOptions) (*ReleaseReapResultV1, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseReapOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("build release reap options: %w", err)
+	}
+
+	if len(opts.KubeConfigPaths) > 0 {
+		var splitPaths []string
+		for _, path := range opts.KubeConfigPaths {
+			splitPaths = append(splitPaths, filepath.SplitList(path)...)
+		}
+
+		opts.KubeConfigPaths = lo.Compact(splitPaths)
+	}
+
+	kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+		KubeConnectionOptions: opts.KubeConnectionOptions,
+		KubeContextNamespace:  opts.ReleaseNamespace, // TODO: unset it everywhere
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct kube config: %w", err)
+	}
+
+	clientFactory, err := kube.NewClientFactory(ctx, kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("construct kube client factory: %w", err)
+	}
+
+	releaseStorage, err := release.NewReleaseStorage(ctx, opts.ReleaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
+		SQLConnection: opts.ReleaseStorageSQLConnection,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("construct release storage: %w", err)
+	}
+
+	log.Default.Info(ctx, "Find expired releases")
+
+	histories, err := release.BuildHistories(releaseStorage, release.HistoryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("build release histories: %w", err)
+	}
+
+	result := &ReleaseReapResultV1{
+		APIVersion: "v1",
+	}
+
+	now := time.Now()
+	for _, history := range histories {
+		lastRelease := lo.LastOrEmpty(history.Releases())
+		if lastRelease == nil || lastRelease.Info.Status == helmrelease.StatusUninstalled {
+			continue
+		}
+
+		resultRelease := &ReleaseReapResultRelease{
+			Name:      lastRelease.Name,
+			Namespace: lastRelease.Namespace,
+			Revision:  lastRelease.Version,
+			Status:    lastRelease.Info.Status,
+		}
+
+		expiresAt, expires, err := release.ReleaseExpiresAt(lastRelease)
+		if err != nil {
+			resultRelease.Error = err.Error()
+			result.Releases = append(result.Releases, resultRelease)
+
+			continue
+		} else if !expires || expiresAt.After(now) {
+			continue
+		}
+
+		resultRelease.ExpiresAt = expiresAt.Format(time.RFC3339)
+
+		if release.IsReleaseProtected(lastRelease) {
+			resultRelease.SkipReason = "release is protected"
+		} else if opts.DryRun {
+			resultRelease.SkipReason = "dry run"
+		}
+
+		result.Releases = append(result.Releases, resultRelease)
+	}
+
+	sort.SliceStable(result.Releases, func(i, j int) bool {
+		if result.Releases[i].Namespace != result.Releases[j].Namespace {
+			return result.Releases[i].Namespace < result.Releases[j].Namespace
+		}
+
+		return result.Releases[i].Name < result.Releases[j].Name
+	})
+
+	for _, rel := range result.Releases {
+		if rel.Error != "" || rel.SkipReason != "" {
+			continue
+		}
+
+		log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Reap release %q (namespace: %q) expired at %s", rel.Name, rel.Namespace, rel.ExpiresAt)))
+
+		if err := reapRelease(ctx, rel.Name, rel.Namespace, opts); err != nil {
+			log.Default.Error(ctx, "Failed to reap release %q (namespace: %q): %s", rel.Name, rel.Namespace, err)
+			rel.Error = err.Error()
+
+			continue
+		}
+
+		rel.Reaped = true
+	}
+
+	failedReleases := lo.Filter(result.Releases, func(rel *ReleaseReapResultRelease, _ int) bool {
+		return rel.Error != ""
+	})
+
+	var resultErr error
+	if len(failedReleases) > 0 {
+		resultErr = fmt.Errorf("failed to reap %d of %d expired releases", len(failedReleases), len(result.Releases))
+	}
+
+	if opts.OutputNoPrint {
+		return result, resultErr
+	}
+
+	var resultMessage string
+
+	switch opts.OutputFormat {
+	case common.OutputFormatTable:
+		table := buildReleaseReapOutputTable(ctx, result)
+		resultMessage = table.Render() + "\n"
+	case common.OutputFormatJSON:
+		b, err := json.MarshalIndent(result, "", strings.Repeat(" ", 2))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to json: %w", err)
+		}
+
+		resultMessage = string(b) + "\n"
+	case common.OutputFormatYAML:
+		b, err := yaml.MarshalContext(ctx, result, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			return nil, fmt.Errorf("marshal result to yaml: %w", err)
+		}
+
+		resultMessage = string(b)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+
+	var colorLevel color.Level
+	if color.Enable {
+		colorLevel = color.TermColorLevel()
+	}
+
+	if err := writeWithSyntaxHighlight(os.Stdout, resultMessage, opts.OutputFormat, colorLevel); err != nil {
+		return nil, fmt.Errorf("write result to output: %w", err)
+	}
+
+	return result, resultErr
+}
+
+func reapRelease(ctx context.Context, releaseName, releaseNamespace string, opts ReleaseReapOptions) error {
+	trackingOpts := opts.TrackingOptions
+	trackingOpts.NoProgressTablePrint = true
+
+	return ReleaseUninstall(ctx, releaseName, releaseNamespace, ReleaseUninstallOptions{
+		KubeConnectionOptions:       opts.KubeConnectionOptions,
+		TrackingOptions:             trackingOpts,
+		DefaultDeletePropagation:    opts.DefaultDeletePropagation,
+		DeleteReleaseNamespace:      opts.DeleteReleaseNamespace,
+		NetworkParallelism:          opts.NetworkParallelism,
+		ReleaseLocker:               opts.ReleaseLocker,
+		ReleaseStorageDir:           opts.ReleaseStorageDir,
+		ReleaseStorageDriver:        opts.ReleaseStorageDriver,
+		ReleaseStorageS3URL:         opts.ReleaseStorageS3URL,
+		ReleaseStorageSQLConnection: opts.ReleaseStorageSQLConnection,
+		TempDirPath:                 opts.TempDirPath,
+		Timeout:                     opts.Timeout,
+	})
+}
+
+func buildReleaseReapOutputTable(ctx context.Context, result *ReleaseReapResultV1) prtable.Writer {
+	table := prtable.NewWriter()
+	setReleaseListOutputTableStyle(ctx, table)
+
+	table.AppendHeader(prtable.Row{
+		color.New(color.Bold).Sprintf("RELEASE"),
+		color.New(color.Bold).Sprintf("EXPIRES AT"),
+		color.New(color.Bold).Sprintf("RESULT"),
+	})
+
+	for _, rel := range result.Releases {
+		var res string
+		switch {
+		case rel.Error != "":
+			res = color.New(color.Red).Sprintf("failed: %s", rel.Error)
+		case rel.SkipReason != "":
+			res = color.New(color.LightYellow).Sprintf("skipped: %s", rel.SkipReason)
+		default:
+			res = color.New(color.Green).Sprintf("reaped")
+		}
+
+		table.AppendRow(prtable.Row{
+			color.New(color.Cyan).Sprintf("%s/%s", rel.Namespace, rel.Name),
+			rel.ExpiresAt,
+			res,
+		})
+	}
+
+	return table
+}
+
+func applyReleaseReapOptionsDefaults(opts ReleaseReapOptions, homeDir string) (ReleaseReapOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseReapOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+	opts.TrackingOptions.ApplyDefaults()
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	switch opts.ReleaseStorageDriver {
+	case common.ReleaseStorageDriverDefault:
+		opts.ReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	case common.ReleaseStorageDriverMemory:
+		return ReleaseReapOptions{}, fmt.Errorf("memory release storage driver is not supported")
+	}
+
+	if opts.DefaultDeletePropagation == "" {
+		opts.DefaultDeletePropagation = string(common.DefaultDeletePropagation)
+	}
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = DefaultReleaseReapOutputFormat
+	}
+
+	return opts, nil
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/werf/kubedog/pkg/informer"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/logstore"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/statestore"
 	kdutil "github.com/werf/kubedog/pkg/trackers/dyntracker/util"
@@ -44,6 +43,9 @@ type ReleaseRollbackOptions struct {
 	// ExtraRuntimeLabels are additional labels to add to resources at runtime during rollback.
 	// These are added during resource creation/update but not stored in the release.
 	ExtraRuntimeLabels map[string]string
+	// FieldManager is the field manager name used for server-side apply of resources.
+	// Defaults to DefaultFieldManager if not specified.
+	FieldManager string
 	// ForceAdoption, when true, allows adopting resources that belong to a different Helm release.
 	// WARNING: This can lead to conflicts if resources are managed by multiple releases.
 	ForceAdoption bool
@@ -58,6 +60,9 @@ type ReleaseRollbackOptions struct {
 	// NoShowNotes, when true, suppresses printing of NOTES.txt after successful rollback.
 	// NOTES.txt typically contains usage instructions and next steps.
 	NoShowNotes bool
+	// OverrideProtection, when true, allows the operation on a protected release and deleting
+	// resources marked with the "werf.io/protect" annotation.
+	OverrideProtection bool
 	// ReleaseHistoryLimit sets the maximum number of release revisions to keep in storage.
 	// When exceeded, the oldest revisions are deleted. Defaults to DefaultReleaseHistoryLimit if not set or <= 0.
 	// Note: Only release metadata is deleted; actual Kubernetes resources are not affected.
@@ -68,10 +73,19 @@ type ReleaseRollbackOptions struct {
 	// ReleaseLabels are labels to add to the new rollback release storage object (Secret/ConfigMap).
 	// Used for filtering and organizing releases in storage.
 	ReleaseLabels map[string]string
+	// ReleaseLocker specifies how the release is locked during the operation.
+	// Valid values: "configmap" (default) uses the werf-synchronization ConfigMap, "lease" uses a Lease per release.
+	ReleaseLocker string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
-	// Valid values: "secret" (default), "configmap", "sql".
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
 	// Defaults to "secret" if not specified or set to "default".
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -153,15 +167,23 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
 		HistoryLimit:  opts.ReleaseHistoryLimit,
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
 		return fmt.Errorf("construct release storage: %w", err)
 	}
 
+	if err := checkReleaseProtection(ctx, "roll back", releaseName, releaseNamespace, releaseStorage, opts.OverrideProtection); err != nil {
+		return err
+	}
+
 	var lockManager *lock.LockManager
 	if !opts.LegacyNoReleaseLock {
-		if m, err := lock.NewLockManager(ctx, releaseNamespace, false, clientFactory); err != nil {
+		if m, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+			Locker: opts.ReleaseLocker,
+		}); err != nil {
 			return fmt.Errorf("construct lock manager: %w", err)
 		} else {
 			lockManager = m
@@ -244,8 +266,8 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 	}
 
 	newRelease, err := release.NewRelease(releaseName, releaseNamespace, newRevision, deployType, rollbackReleaseResSpecs, rollbackRelease.Chart, rollbackRelease.Config, release.ReleaseOptions{
-		InfoAnnotations: lo.Assign(rollbackRelease.Info.Annotations, opts.ReleaseInfoAnnotations),
-		Labels:          lo.Assign(rollbackRelease.Labels, opts.ReleaseLabels),
+		InfoAnnotations: lo.Assign(lo.OmitByKeys(rollbackRelease.Info.Annotations, []string{common.AnnotationKeyHumanProtect, common.AnnotationKeyHumanExpiresAt, common.AnnotationKeyHumanTTL}), release.ProtectionInfoAnnotations(prevRelease), release.ExpiryInfoAnnotations(prevRelease), opts.ReleaseInfoAnnotations),
+		Labels:          lo.Assign(lo.OmitByKeys(rollbackRelease.Labels, []string{common.AnnotationKeyHumanProtect}), opts.ReleaseLabels),
 		Notes:           rollbackRelease.Info.Notes,
 	})
 	if err != nil {
@@ -277,19 +299,31 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 		patchers = append(patchers, spec.NewLegacyOnlyTrackJobsPatcher())
 	}
 
+	releaseDeps, err := resource.ReleaseDependencies(rollbackRelease.Chart.Metadata.Annotations, releaseNamespace)
+	if err != nil {
+		return fmt.Errorf("get release dependencies from chart annotations: %w", err)
+	}
+
 	instResources, delResources, err := resource.BuildResources(ctx, deployType, releaseNamespace, prevRelResSpecs, newRelResSpecs, patchers, clientFactory, resource.BuildResourcesOptions{
 		Remote:                   true,
 		DefaultDeletePropagation: metav1.DeletionPropagation(opts.DefaultDeletePropagation),
 		NoPodLogs:                opts.NoPodLogs,
+		ReleaseDependencies:      releaseDeps,
 	})
 	if err != nil {
 		return fmt.Errorf("build resources: %w", err)
 	}
 
-	log.Default.Debug(ctx, "Locally validate resources")
+	log.Default.Debug(ctx, "Check resource protection")
 
-	if err := resource.ValidateLocal(ctx, releaseNamespace, instResources, opts.ResourceValidationOptions); err != nil {
-		return fmt.Errorf("locally validate resources: %w", err)
+	if err := checkResourceProtection(ctx, instResources, delResources, opts.OverrideProtection); err != nil {
+		return err
+	}
+
+	log.Default.Debug(ctx, "Validate resources")
+
+	if err := resource.ValidateWithCluster(ctx, releaseNamespace, instResources, clientFactory.Discovery(), opts.ResourceValidationOptions); err != nil {
+		return fmt.Errorf("validate resources: %w", err)
 	}
 
 	log.Default.Debug(ctx, "Build resource infos")
@@ -305,6 +339,7 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 	}
 
 	instResInfos, delResInfos, err := plan.BuildResourceInfos(ctx, deployType, releaseName, releaseNamespace, instResources, delResources, prevReleaseFailed, clientFactory, plan.BuildResourceInfosOptions{
+		FieldManager:                       opts.FieldManager,
 		NetworkParallelism:                 opts.NetworkParallelism,
 		NoRemoveManualChanges:              opts.NoRemoveManualChanges,
 		LastDeployedOrLastRelResourceSpecs: lastDeployedOrLastRelResSpecs,
@@ -315,7 +350,9 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 
 	log.Default.Debug(ctx, "Remotely validate resources")
 
-	if err := plan.ValidateRemote(releaseName, releaseNamespace, instResInfos, opts.ForceAdoption); err != nil {
+	if err := plan.ValidateRemote(ctx, releaseName, releaseNamespace, instResInfos, plan.ValidateRemoteOptions{
+		ForceAdoption: opts.ForceAdoption,
+	}); err != nil {
 		return fmt.Errorf("remotely validate resources: %w", err)
 	}
 
@@ -329,6 +366,7 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 	log.Default.Debug(ctx, "Build install plan")
 
 	installPlan, err := plan.BuildPlan(instResInfos, delResInfos, relInfos, plan.BuildPlanOptions{
+		FieldManager:    opts.FieldManager,
 		NoFinalTracking: opts.NoFinalTracking,
 	})
 	if err != nil {
@@ -384,7 +422,8 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 	taskStore := kdutil.NewConcurrent(statestore.NewTaskStore())
 	logStore := kdutil.NewConcurrent(logstore.NewLogStore())
 	watchErrCh := make(chan error, 1)
-	informerFactory := informer.NewConcurrentInformerFactory(ctx.Done(), watchErrCh, clientFactory.Dynamic(), informer.ConcurrentInformerFactoryOptions{})
+	getInformerFactory, releaseInformers := kube.DefaultInformerPool.InformerFactoryGetter(clientFactory, watchErrCh)
+	defer releaseInformers()
 
 	log.Default.Debug(ctx, "Start tracking")
 
@@ -414,7 +453,12 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 
 	log.Default.Debug(ctx, "Execute release install plan")
 
-	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, installPlan, taskStore, logStore, informerFactory, history, clientFactory, plan.ExecutePlanOptions{
+	executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, installPlan, taskStore, logStore, getInformerFactory, history, clientFactory, plan.ExecutePlanOptions{
+		GetReleaseStorage: newReleaseStorageGetter(ctx, clientFactory, opts.ReleaseStorageDriver, release.ReleaseStorageOptions{
+			FilesystemDir: opts.ReleaseStorageDir,
+			S3URL:         opts.ReleaseStorageS3URL,
+			SQLConnection: opts.ReleaseStorageSQLConnection,
+		}),
 		TrackingOptions:          opts.TrackingOptions,
 		NetworkParallelism:       opts.NetworkParallelism,
 		InstallableResourceInfos: instResInfos,
@@ -440,7 +484,7 @@ func releaseRollback(ctx context.Context, ctxCancelFn context.CancelCauseFunc, r
 	})
 
 	if executePlanErr != nil {
-		runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, installPlan, instResInfos, relInfos, taskStore, logStore, informerFactory, history, clientFactory, runFailureInstallPlanOptions{
+		runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, installPlan, instResInfos, relInfos, taskStore, logStore, getInformerFactory, history, clientFactory, runFailureInstallPlanOptions{
 			TrackingOptions:    opts.TrackingOptions,
 			NetworkParallelism: opts.NetworkParallelism,
 		})
@@ -548,5 +592,9 @@ func applyReleaseRollbackOptionsDefaults(opts ReleaseRollbackOptions, homeDir st
 		opts.DefaultDeletePropagation = string(common.DefaultDeletePropagation)
 	}
 
+	if opts.FieldManager == "" {
+		opts.FieldManager = common.DefaultFieldManager
+	}
+
 	return opts, nil
 }
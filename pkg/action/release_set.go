@@ -0,0 +1,206 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/samber/lo"
+)
+
+// A declarative list of releases to be deployed together, e.g. all releases of an environment.
+type ReleaseSet struct {
+	Releases []*ReleaseSetRelease `json:"releases"`
+}
+
+type ReleaseSetRelease struct {
+	// Name of the release.
+	Name string `json:"name"`
+	// Namespace of the release. Defaults to the default namespace of the release set.
+	Namespace string `json:"namespace"`
+	// Chart to deploy. Local paths starting with "./" or "../" are relative to the release set file.
+	Chart string `json:"chart"`
+	// ChartVersion is the version of the chart to deploy.
+	ChartVersion string `json:"chartVersion"`
+	// DependsOn are releases from the set which must be deployed before this one, as "name" or
+	// "namespace/name".
+	DependsOn []string `json:"dependsOn"`
+	// Values are paths to values files, relative to the release set file.
+	Values []string `json:"values"`
+	// Set are values in "key=value" format.
+	Set []string `json:"set"`
+	// SetString are values in "key=value" format, always set as strings.
+	SetString []string `json:"setString"`
+	// SecretValues are paths to encrypted values files, relative to the release set file.
+	SecretValues []string `json:"secretValues"`
+}
+
+// Returns the release ID in the "namespace/name" format.
+func (r *ReleaseSetRelease) ID() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// Reads the release set from the file. Relative paths in the release set are resolved relative to
+// the file directory.
+func LoadReleaseSet(path, defaultNamespace string) (*ReleaseSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read release set file %q: %w", path, err)
+	}
+
+	releaseSet := &ReleaseSet{}
+	if err := yaml.UnmarshalWithOptions(data, releaseSet, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("unmarshal release set file %q: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for _, rel := range releaseSet.Releases {
+		if rel.Namespace == "" {
+			rel.Namespace = defaultNamespace
+		}
+
+		if strings.HasPrefix(rel.Chart, "./") || strings.HasPrefix(rel.Chart, "../") {
+			rel.Chart = filepath.Join(baseDir, rel.Chart)
+		}
+
+		rel.Values = resolveReleaseSetPaths(rel.Values, baseDir)
+		rel.SecretValues = resolveReleaseSetPaths(rel.SecretValues, baseDir)
+	}
+
+	if err := validateReleaseSet(releaseSet); err != nil {
+		return nil, fmt.Errorf("validate release set file %q: %w", path, err)
+	}
+
+	return releaseSet, nil
+}
+
+// Groups releases into stages: each release depends only on releases from previous stages.
+func (s *ReleaseSet) Stages() ([][]*ReleaseSetRelease, error) {
+	deps := map[string][]string{}
+	for _, rel := range s.Releases {
+		relDeps, err := s.Dependencies(rel)
+		if err != nil {
+			return nil, err
+		}
+
+		deps[rel.ID()] = relDeps
+	}
+
+	var stages [][]*ReleaseSetRelease
+
+	done := map[string]bool{}
+	for len(done) < len(s.Releases) {
+		stage := lo.Filter(s.Releases, func(rel *ReleaseSetRelease, _ int) bool {
+			return !done[rel.ID()] && lo.EveryBy(deps[rel.ID()], func(dep string) bool {
+				return done[dep]
+			})
+		})
+		if len(stage) == 0 {
+			cycled := lo.FilterMap(s.Releases, func(rel *ReleaseSetRelease, _ int) (string, bool) {
+				return rel.ID(), !done[rel.ID()]
+			})
+
+			return nil, fmt.Errorf("dependency cycle between releases: %s", strings.Join(cycled, ", "))
+		}
+
+		for _, rel := range stage {
+			done[rel.ID()] = true
+		}
+
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
+
+// Returns IDs of releases from the set which the release depends on.
+func (s *ReleaseSet) Dependencies(rel *ReleaseSetRelease) ([]string, error) {
+	var deps []string
+	for _, dep := range rel.DependsOn {
+		depRel, err := s.findRelease(dep, rel.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("resolve dependency %q of release %q: %w", dep, rel.ID(), err)
+		}
+
+		deps = append(deps, depRel.ID())
+	}
+
+	return deps, nil
+}
+
+func (s *ReleaseSet) findRelease(ref, namespace string) (*ReleaseSetRelease, error) {
+	if strings.Contains(ref, "/") {
+		if rel, found := lo.Find(s.Releases, func(rel *ReleaseSetRelease) bool {
+			return rel.ID() == ref
+		}); found {
+			return rel, nil
+		}
+
+		return nil, fmt.Errorf("release not found in the release set")
+	}
+
+	candidates := lo.Filter(s.Releases, func(rel *ReleaseSetRelease, _ int) bool {
+		return rel.Name == ref
+	})
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("release not found in the release set")
+	case 1:
+		return candidates[0], nil
+	}
+
+	if rel, found := lo.Find(candidates, func(rel *ReleaseSetRelease) bool {
+		return rel.Namespace == namespace
+	}); found {
+		return rel, nil
+	}
+
+	return nil, fmt.Errorf("ambiguous release name, use the \"namespace/name\" format")
+}
+
+func validateReleaseSet(releaseSet *ReleaseSet) error {
+	if len(releaseSet.Releases) == 0 {
+		return fmt.Errorf("no releases specified")
+	}
+
+	for i, rel := range releaseSet.Releases {
+		if rel.Name == "" {
+			return fmt.Errorf("release #%d: name not specified", i+1)
+		}
+
+		if rel.Namespace == "" {
+			return fmt.Errorf("release %q: namespace not specified", rel.Name)
+		}
+
+		if rel.Chart == "" {
+			return fmt.Errorf("release %q: chart not specified", rel.ID())
+		}
+	}
+
+	if duplicates := lo.FindDuplicatesBy(releaseSet.Releases, func(rel *ReleaseSetRelease) string {
+		return rel.ID()
+	}); len(duplicates) > 0 {
+		return fmt.Errorf("duplicated releases: %s", strings.Join(lo.Map(duplicates, func(rel *ReleaseSetRelease, _ int) string {
+			return rel.ID()
+		}), ", "))
+	}
+
+	if _, err := releaseSet.Stages(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func resolveReleaseSetPaths(paths []string, baseDir string) []string {
+	return lo.Map(paths, func(path string, _ int) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+
+		return filepath.Join(baseDir, path)
+	})
+}
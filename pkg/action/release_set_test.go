@@ -0,0 +1,94 @@
+package action_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/action"
+)
+
+func TestLoadReleaseSet(t *testing.T) {
+	t.Run("orders releases by dependencies", func(t *testing.T) {
+		path := writeTestFile(t, "releases.yaml", `releases:
+  - name: app
+    chart: ./charts/app
+    values: [values/app.yaml]
+    dependsOn: [db, infra/ingress]
+  - name: db
+    chart: ./charts/db
+  - name: ingress
+    namespace: infra
+    chart: repo/ingress-nginx
+    chartVersion: 4.0.0
+`)
+
+		releaseSet, err := action.LoadReleaseSet(path, "prod")
+		require.NoError(t, err)
+
+		app := releaseSet.Releases[0]
+		assert.Equal(t, "prod/app", app.ID())
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "charts/app"), app.Chart)
+		assert.Equal(t, []string{filepath.Join(filepath.Dir(path), "values/app.yaml")}, app.Values)
+		assert.Equal(t, "repo/ingress-nginx", releaseSet.Releases[2].Chart)
+
+		stages, err := releaseSet.Stages()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"prod/db", "infra/ingress"}, {"prod/app"}}, lo.Map(stages, func(stage []*action.ReleaseSetRelease, _ int) []string {
+			return lo.Map(stage, func(rel *action.ReleaseSetRelease, _ int) string {
+				return rel.ID()
+			})
+		}))
+	})
+
+	t.Run("fails on dependency cycle", func(t *testing.T) {
+		path := writeTestFile(t, "releases.yaml", `releases:
+  - name: a
+    chart: ./a
+    dependsOn: [b]
+  - name: b
+    chart: ./b
+    dependsOn: [a]
+`)
+
+		_, err := action.LoadReleaseSet(path, "prod")
+		require.ErrorContains(t, err, "dependency cycle")
+	})
+
+	t.Run("fails on unknown dependency", func(t *testing.T) {
+		path := writeTestFile(t, "releases.yaml", `releases:
+  - name: a
+    chart: ./a
+    dependsOn: [missing]
+`)
+
+		_, err := action.LoadReleaseSet(path, "prod")
+		require.ErrorContains(t, err, "release not found")
+	})
+
+	t.Run("fails on duplicated releases", func(t *testing.T) {
+		path := writeTestFile(t, "releases.yaml", `releases:
+  - name: a
+    chart: ./a
+  - name: a
+    namespace: prod
+    chart: ./b
+`)
+
+		_, err := action.LoadReleaseSet(path, "prod")
+		require.ErrorContains(t, err, "duplicated releases: prod/a")
+	})
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
@@ -0,0 +1,276 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/samber/lo"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/release"
+)
+
+const DefaultReleaseStorageMigrateLogLevel = log.InfoLevel
+
+type ReleaseStorageMigrateOptions struct {
+	common.KubeConnectionOptions
+
+	// DeleteSource, when true, deletes the migrated releases from the source storage after all of
+	// their revisions are copied and verified.
+	DeleteSource bool
+	// DryRun, when true, only reports what would be migrated without changing anything.
+	DryRun bool
+	// FromReleaseStorageDir is the directory with releases for the "filesystem" source driver.
+	FromReleaseStorageDir string
+	// FromReleaseStorageDriver specifies the release storage driver to migrate releases from.
+	// Defaults to "secret" if not specified.
+	FromReleaseStorageDriver string
+	// FromReleaseStorageS3URL is the bucket URL for the "s3" source driver.
+	FromReleaseStorageS3URL string
+	// FromReleaseStorageSQLConnection is the SQL connection string for the "sql" source driver.
+	FromReleaseStorageSQLConnection string
+	// ReleaseName, if specified, migrates only this release. Requires ReleaseNamespace.
+	ReleaseName string
+	// ReleaseNamespace specifies the namespace to migrate releases from. If empty, releases from
+	// all namespaces are migrated.
+	ReleaseNamespace string
+	// TargetReleaseNamespace, if specified, moves the releases to this namespace in the target
+	// storage. Otherwise, releases keep their namespaces.
+	TargetReleaseNamespace string
+	// TempDirPath is the directory for temporary files during the operation.
+	// A temporary directory is created automatically if not specified.
+	TempDirPath string
+	// ToReleaseStorageDir is the directory for releases for the "filesystem" target driver.
+	ToReleaseStorageDir string
+	// ToReleaseStorageDriver specifies the release storage driver to migrate releases to. Required.
+	ToReleaseStorageDriver string
+	// ToReleaseStorageS3URL is the bucket URL for the "s3" target driver.
+	ToReleaseStorageS3URL string
+	// ToReleaseStorageSQLConnection is the SQL connection string for the "sql" target driver.
+	ToReleaseStorageSQLConnection string
+}
+
+type ReleaseStorageMigrateResultV1 struct {
+	APIVersion string                           `json:"apiVersion"`
+	Revisions  []*ReleaseStorageMigrateRevision `json:"revisions"`
+}
+
+type ReleaseStorageMigrateRevision struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	Revision        int    `json:"revision"`
+	TargetNamespace string `json:"targetNamespace"`
+	AlreadyExists   bool   `json:"alreadyExists"`
+}
+
+// Copies all revisions of Helm releases from one release storage to another, optionally moving
+// them to a different namespace and deleting them from the source storage.
+func ReleaseStorageMigrate(ctx context.Context, opts ReleaseStorageMigrateOptions) (*ReleaseStorageMigrateResultV1, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home directory: %w", err)
+	}
+
+	opts, err = applyReleaseStorageMigrateOptionsDefaults(opts, homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("build release storage migrate options: %w", err)
+	}
+
+	if err := validateReleaseStorageMigrateOptions(opts); err != nil {
+		return nil, err
+	}
+
+	// Only Kubernetes-based drivers need the cluster access.
+	var clientFactory *kube.ClientFactory
+	if isKubeReleaseStorageDriver(opts.FromReleaseStorageDriver) || isKubeReleaseStorageDriver(opts.ToReleaseStorageDriver) {
+		if len(opts.KubeConfigPaths) > 0 {
+			var splitPaths []string
+			for _, path := range opts.KubeConfigPaths {
+				splitPaths = append(splitPaths, filepath.SplitList(path)...)
+			}
+
+			opts.KubeConfigPaths = lo.Compact(splitPaths)
+		}
+
+		kubeConfig, err := kube.NewKubeConfig(ctx, opts.KubeConfigPaths, kube.KubeConfigOptions{
+			KubeConnectionOptions: opts.KubeConnectionOptions,
+			KubeContextNamespace:  opts.ReleaseNamespace, // TODO: unset it everywhere
+		})
+		if err != nil {
+			return nil, fmt.Errorf("construct kube config: %w", err)
+		}
+
+		clientFactory, err = kube.NewClientFactory(ctx, kubeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("construct kube client factory: %w", err)
+		}
+	}
+
+	getSourceStorage := newReleaseStorageGetter(ctx, clientFactory, opts.FromReleaseStorageDriver, release.ReleaseStorageOptions{
+		FilesystemDir: opts.FromReleaseStorageDir,
+		S3URL:         opts.FromReleaseStorageS3URL,
+		SQLConnection: opts.FromReleaseStorageSQLConnection,
+	})
+
+	getTargetStorage := newReleaseStorageGetter(ctx, clientFactory, opts.ToReleaseStorageDriver, release.ReleaseStorageOptions{
+		FilesystemDir: opts.ToReleaseStorageDir,
+		S3URL:         opts.ToReleaseStorageS3URL,
+		SQLConnection: opts.ToReleaseStorageSQLConnection,
+	})
+
+	if opts.DryRun {
+		log.Default.Info(ctx, "Dry run, nothing will be changed")
+	}
+
+	migratedRevisions, err := release.MigrateReleases(ctx, opts.ReleaseNamespace, getSourceStorage, getTargetStorage, release.MigrateReleasesOptions{
+		DeleteSource:    opts.DeleteSource,
+		DryRun:          opts.DryRun,
+		ReleaseName:     opts.ReleaseName,
+		SameStorage:     isSameReleaseStorage(opts),
+		TargetNamespace: opts.TargetReleaseNamespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrate releases: %w", err)
+	}
+
+	result := &ReleaseStorageMigrateResultV1{
+		APIVersion: "v1",
+	}
+
+	for _, rev := range migratedRevisions {
+		result.Revisions = append(result.Revisions, &ReleaseStorageMigrateRevision{
+			AlreadyExists:   rev.AlreadyExists,
+			Name:            rev.Name,
+			Namespace:       rev.Namespace,
+			Revision:        rev.Revision,
+			TargetNamespace: rev.TargetNamespace,
+		})
+	}
+
+	skipped := lo.CountBy(migratedRevisions, func(rev *release.MigratedRevision) bool {
+		return rev.AlreadyExists
+	})
+
+	if opts.DryRun {
+		log.Default.Info(ctx, "Would migrate %d revisions, %d revisions already migrated", len(migratedRevisions)-skipped, skipped)
+	} else {
+		log.Default.Info(ctx, "Migrated %d revisions, %d revisions already migrated", len(migratedRevisions)-skipped, skipped)
+	}
+
+	return result, nil
+}
+
+func applyReleaseStorageMigrateOptionsDefaults(opts ReleaseStorageMigrateOptions, homeDir string) (ReleaseStorageMigrateOptions, error) {
+	var err error
+	if opts.TempDirPath == "" {
+		opts.TempDirPath, err = os.MkdirTemp("", "")
+		if err != nil {
+			return ReleaseStorageMigrateOptions{}, fmt.Errorf("create temp dir: %w", err)
+		}
+	}
+
+	opts.KubeConnectionOptions.ApplyDefaults(homeDir)
+
+	if opts.FromReleaseStorageDriver == common.ReleaseStorageDriverDefault {
+		opts.FromReleaseStorageDriver = common.ReleaseStorageDriverSecrets
+	}
+
+	return opts, nil
+}
+
+// Caches release storages by namespace, since Kubernetes-based drivers operate in a single namespace.
+func newReleaseStorageGetter(ctx context.Context, clientFactory *kube.ClientFactory, storageDriver string, opts release.ReleaseStorageOptions) release.ReleaseStorageGetter {
+	var storagesMutex sync.Mutex
+
+	storages := map[string]release.ReleaseStorager{}
+
+	return func(namespace string) (release.ReleaseStorager, error) {
+		storagesMutex.Lock()
+		defer storagesMutex.Unlock()
+
+		if storage, found := storages[namespace]; found {
+			return storage, nil
+		}
+
+		storage, err := release.NewReleaseStorage(ctx, namespace, storageDriver, clientFactory, opts)
+		if err != nil {
+			return nil, fmt.Errorf("construct release storage: %w", err)
+		}
+
+		storages[namespace] = storage
+
+		return storage, nil
+	}
+}
+
+func normalizeReleaseStorageDriver(storageDriver string) string {
+	switch storageDriver {
+	case common.ReleaseStorageDriverDefault, common.ReleaseStorageDriverSecret:
+		return common.ReleaseStorageDriverSecrets
+	case common.ReleaseStorageDriverConfigMap:
+		return common.ReleaseStorageDriverConfigMaps
+	default:
+		return storageDriver
+	}
+}
+
+func isKubeReleaseStorageDriver(storageDriver string) bool {
+	switch normalizeReleaseStorageDriver(storageDriver) {
+	case common.ReleaseStorageDriverSecrets, common.ReleaseStorageDriverConfigMaps:
+		return true
+	default:
+		return false
+	}
+}
+
+func validateReleaseStorageMigrateOptions(opts ReleaseStorageMigrateOptions) error {
+	if opts.ToReleaseStorageDriver == "" {
+		return fmt.Errorf("target release storage driver must be specified")
+	}
+
+	supportedDrivers := []string{
+		common.ReleaseStorageDriverSecrets,
+		common.ReleaseStorageDriverConfigMaps,
+		common.ReleaseStorageDriverSQL,
+		common.ReleaseStorageDriverFilesystem,
+		common.ReleaseStorageDriverS3,
+	}
+
+	if !lo.Contains(supportedDrivers, normalizeReleaseStorageDriver(opts.FromReleaseStorageDriver)) {
+		return fmt.Errorf("unsupported source release storage driver %q, use one of: secret, configmap, sql, filesystem, s3", opts.FromReleaseStorageDriver)
+	}
+
+	if !lo.Contains(supportedDrivers, normalizeReleaseStorageDriver(opts.ToReleaseStorageDriver)) {
+		return fmt.Errorf("unsupported target release storage driver %q, use one of: secret, configmap, sql, filesystem, s3", opts.ToReleaseStorageDriver)
+	}
+
+	if opts.ReleaseName != "" && opts.ReleaseNamespace == "" {
+		return fmt.Errorf("release namespace must be specified when migrating a single release")
+	}
+
+	if !isSameReleaseStorage(opts) {
+		return nil
+	}
+
+	if opts.TargetReleaseNamespace == "" || opts.TargetReleaseNamespace == opts.ReleaseNamespace {
+		return fmt.Errorf("source and target release storages are the same, specify a different target driver or target namespace")
+	}
+
+	if opts.ReleaseNamespace == "" {
+		return fmt.Errorf("source and target release storages are the same, specify the release namespace to move releases from")
+	}
+
+	return nil
+}
+
+func isSameReleaseStorage(opts ReleaseStorageMigrateOptions) bool {
+	return normalizeReleaseStorageDriver(opts.FromReleaseStorageDriver) == normalizeReleaseStorageDriver(opts.ToReleaseStorageDriver) &&
+		opts.FromReleaseStorageDir == opts.ToReleaseStorageDir &&
+		opts.FromReleaseStorageS3URL == opts.ToReleaseStorageS3URL &&
+		opts.FromReleaseStorageSQLConnection == opts.ToReleaseStorageSQLConnection
+}
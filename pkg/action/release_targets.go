@@ -0,0 +1,126 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/samber/lo"
+)
+
+// A declarative list of clusters to deploy the same release to, e.g. all regions of an environment.
+type ReleaseTargets struct {
+	Targets []*ReleaseTarget `json:"targets"`
+}
+
+type ReleaseTarget struct {
+	// Name of the target, used in logs and reports. Defaults to the kube context.
+	Name string `json:"name"`
+	// KubeContext is the kubeconfig context of the target cluster.
+	KubeContext string `json:"kubeContext"`
+	// KubeConfig is the path to the kubeconfig file of the target cluster, relative to the targets
+	// file. Defaults to kubeconfig files of the invocation.
+	KubeConfig string `json:"kubeConfig"`
+	// Namespace of the release in the target cluster. Defaults to the release namespace of the
+	// invocation.
+	Namespace string `json:"namespace"`
+	// Values are paths to values files, relative to the targets file.
+	Values []string `json:"values"`
+	// Set are values in "key=value" format.
+	Set []string `json:"set"`
+	// SetString are values in "key=value" format, always set as strings.
+	SetString []string `json:"setString"`
+	// SecretValues are paths to encrypted values files, relative to the targets file.
+	SecretValues []string `json:"secretValues"`
+}
+
+// Reads the targets from the file. Relative paths in the targets file are resolved relative to the
+// file directory.
+func LoadReleaseTargets(path, defaultNamespace string) (*ReleaseTargets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read targets file %q: %w", path, err)
+	}
+
+	targets := &ReleaseTargets{}
+	if err := yaml.UnmarshalWithOptions(data, targets, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("unmarshal targets file %q: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for _, target := range targets.Targets {
+		if target.KubeConfig != "" && !filepath.IsAbs(target.KubeConfig) {
+			target.KubeConfig = filepath.Join(baseDir, target.KubeConfig)
+		}
+
+		target.Values = resolveReleaseSetPaths(target.Values, baseDir)
+		target.SecretValues = resolveReleaseSetPaths(target.SecretValues, baseDir)
+	}
+
+	applyReleaseTargetsDefaults(targets, defaultNamespace)
+
+	if err := validateReleaseTargets(targets); err != nil {
+		return nil, fmt.Errorf("validate targets file %q: %w", path, err)
+	}
+
+	return targets, nil
+}
+
+// Builds targets for the kube contexts, all with the same namespace and values.
+func NewReleaseTargetsFromKubeContexts(kubeContexts []string, namespace string) (*ReleaseTargets, error) {
+	targets := &ReleaseTargets{
+		Targets: lo.Map(kubeContexts, func(kubeContext string, _ int) *ReleaseTarget {
+			return &ReleaseTarget{
+				KubeContext: kubeContext,
+			}
+		}),
+	}
+
+	applyReleaseTargetsDefaults(targets, namespace)
+
+	if err := validateReleaseTargets(targets); err != nil {
+		return nil, fmt.Errorf("validate targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+func applyReleaseTargetsDefaults(targets *ReleaseTargets, defaultNamespace string) {
+	for _, target := range targets.Targets {
+		if target.Name == "" {
+			target.Name = target.KubeContext
+		}
+
+		if target.Namespace == "" {
+			target.Namespace = defaultNamespace
+		}
+	}
+}
+
+func validateReleaseTargets(targets *ReleaseTargets) error {
+	if len(targets.Targets) == 0 {
+		return fmt.Errorf("no targets specified")
+	}
+
+	for i, target := range targets.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("target #%d: neither name nor kube context specified", i+1)
+		}
+
+		if target.Namespace == "" {
+			return fmt.Errorf("target %q: namespace not specified", target.Name)
+		}
+	}
+
+	if duplicates := lo.FindDuplicatesBy(targets.Targets, func(target *ReleaseTarget) string {
+		return target.Name
+	}); len(duplicates) > 0 {
+		return fmt.Errorf("duplicated targets: %s", strings.Join(lo.Map(duplicates, func(target *ReleaseTarget, _ int) string {
+			return target.Name
+		}), ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package action_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/action"
+)
+
+func TestLoadReleaseTargets(t *testing.T) {
+	t.Run("applies defaults and resolves paths", func(t *testing.T) {
+		path := writeTestFile(t, "targets.yaml", `targets:
+  - kubeContext: prod-eu-west
+    values: [values/eu-west.yaml]
+  - name: us-east
+    kubeContext: prod-us-east
+    kubeConfig: kubeconfigs/us-east
+    namespace: app-us
+    set: [replicas=5]
+`)
+
+		targets, err := action.LoadReleaseTargets(path, "app")
+		require.NoError(t, err)
+
+		euWest := targets.Targets[0]
+		assert.Equal(t, "prod-eu-west", euWest.Name)
+		assert.Equal(t, "app", euWest.Namespace)
+		assert.Equal(t, []string{filepath.Join(filepath.Dir(path), "values/eu-west.yaml")}, euWest.Values)
+
+		usEast := targets.Targets[1]
+		assert.Equal(t, "us-east", usEast.Name)
+		assert.Equal(t, "app-us", usEast.Namespace)
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "kubeconfigs/us-east"), usEast.KubeConfig)
+		assert.Equal(t, []string{"replicas=5"}, usEast.Set)
+	})
+
+	t.Run("fails on duplicated targets", func(t *testing.T) {
+		path := writeTestFile(t, "targets.yaml", `targets:
+  - kubeContext: prod
+  - name: prod
+    kubeContext: prod-2
+`)
+
+		_, err := action.LoadReleaseTargets(path, "app")
+		require.ErrorContains(t, err, "duplicated targets: prod")
+	})
+
+	t.Run("fails without namespace", func(t *testing.T) {
+		path := writeTestFile(t, "targets.yaml", `targets:
+  - kubeContext: prod
+`)
+
+		_, err := action.LoadReleaseTargets(path, "")
+		require.ErrorContains(t, err, `target "prod": namespace not specified`)
+	})
+}
+
+func TestNewReleaseTargetsFromKubeContexts(t *testing.T) {
+	targets, err := action.NewReleaseTargetsFromKubeContexts([]string{"eu-west", "us-east"}, "app")
+	require.NoError(t, err)
+
+	require.Len(t, targets.Targets, 2)
+	assert.Equal(t, "eu-west", targets.Targets[0].Name)
+	assert.Equal(t, "eu-west", targets.Targets[0].KubeContext)
+	assert.Equal(t, "app", targets.Targets[1].Namespace)
+}
@@ -13,7 +13,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
-	"github.com/werf/kubedog/pkg/informer"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/logstore"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/statestore"
@@ -56,14 +55,26 @@ type ReleaseUninstallOptions struct {
 	// NoRemoveManualChanges, when true, preserves fields manually added to resources in the cluster
 	// that are not present in the chart manifests. By default, such fields are removed during deletion.
 	NoRemoveManualChanges bool
+	// OverrideProtection, when true, allows the operation on a protected release and deleting
+	// resources marked with the "werf.io/protect" annotation.
+	OverrideProtection bool
 	// ReleaseHistoryLimit sets the maximum number of release revisions to keep in storage.
 	// Defaults to DefaultReleaseHistoryLimit if not set or <= 0.
 	// After uninstall, only the uninstall record itself is kept.
 	ReleaseHistoryLimit int
+	// ReleaseLocker specifies how the release is locked during the operation.
+	// Valid values: "configmap" (default) uses the werf-synchronization ConfigMap, "lease" uses a Lease per release.
+	ReleaseLocker string
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string
 	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
-	// Valid values: "secret" (default), "configmap", "sql".
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
 	// Defaults to "secret" if not specified or set to "default".
 	ReleaseStorageDriver string
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string
@@ -148,15 +159,23 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 
 	releaseStorage, err := release.NewReleaseStorage(ctx, releaseNamespace, opts.ReleaseStorageDriver, clientFactory, release.ReleaseStorageOptions{
 		HistoryLimit:  opts.ReleaseHistoryLimit,
+		FilesystemDir: opts.ReleaseStorageDir,
+		S3URL:         opts.ReleaseStorageS3URL,
 		SQLConnection: opts.ReleaseStorageSQLConnection,
 	})
 	if err != nil {
 		return fmt.Errorf("construct release storage: %w", err)
 	}
 
+	if err := checkReleaseProtection(ctx, "uninstall", releaseName, releaseNamespace, releaseStorage, opts.OverrideProtection); err != nil {
+		return err
+	}
+
 	var lockManager *lock.LockManager
 	if !opts.LegacyNoReleaseLock {
-		if m, err := lock.NewLockManager(ctx, releaseNamespace, false, clientFactory); err != nil {
+		if m, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+			Locker: opts.ReleaseLocker,
+		}); err != nil {
 			return fmt.Errorf("construct lock manager: %w", err)
 		} else {
 			lockManager = m
@@ -174,7 +193,8 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 	}
 
 	watchErrCh := make(chan error, 1)
-	informerFactory := informer.NewConcurrentInformerFactory(ctx.Done(), watchErrCh, clientFactory.Dynamic(), informer.ConcurrentInformerFactoryOptions{})
+	getInformerFactory, releaseInformers := kube.DefaultInformerPool.InformerFactoryGetter(clientFactory, watchErrCh)
+	defer releaseInformers()
 
 	go func() {
 		for {
@@ -246,6 +266,12 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 			return fmt.Errorf("build resources: %w", err)
 		}
 
+		log.Default.Debug(ctx, "Check resource protection")
+
+		if err := checkResourceProtection(ctx, instResources, delResources, opts.OverrideProtection); err != nil {
+			return err
+		}
+
 		log.Default.Debug(ctx, "Build resource infos")
 
 		instResInfos, delResInfos, err := plan.BuildResourceInfos(ctx, deployType, releaseName, releaseNamespace, instResources, delResources, prevReleaseFailed, clientFactory, plan.BuildResourceInfosOptions{
@@ -307,7 +333,7 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 
 		log.Default.Debug(ctx, "Execute release delete plan")
 
-		executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, deletePlan, taskStore, logStore, informerFactory, history, clientFactory, plan.ExecutePlanOptions{
+		executePlanErr := plan.ExecutePlan(ctx, releaseNamespace, deletePlan, taskStore, logStore, getInformerFactory, history, clientFactory, plan.ExecutePlanOptions{
 			LegacyProgressReporter: reporter,
 			TrackingOptions:        opts.TrackingOptions,
 			NetworkParallelism:     opts.NetworkParallelism,
@@ -333,7 +359,7 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 		})
 
 		if executePlanErr != nil {
-			runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, deletePlan, instResInfos, relInfos, taskStore, logStore, informerFactory, history, clientFactory, runFailureInstallPlanOptions{
+			runFailurePlanResult, nonCritErrs, critErrs := runFailurePlan(ctx, releaseNamespace, deletePlan, instResInfos, relInfos, taskStore, logStore, getInformerFactory, history, clientFactory, runFailureInstallPlanOptions{
 				LegacyProgressReporter: reporter,
 				TrackingOptions:        opts.TrackingOptions,
 				NetworkParallelism:     opts.NetworkParallelism,
@@ -422,6 +448,13 @@ func releaseUninstall(ctx context.Context, ctxCancelFn context.CancelCauseFunc,
 			statestore.NewAbsenceTaskState(nsMeta.Name, "", nsMeta.GroupVersionKind, statestore.AbsenceTaskStateOptions{}),
 		)
 
+		informerFactory, releaseInformerFactory, err := getInformerFactory(nsMeta.GroupVersionKind, "")
+		if err != nil {
+			return fmt.Errorf("get informer factory: %w", err)
+		}
+
+		defer releaseInformerFactory()
+
 		tracker := dyntracker.NewDynamicAbsenceTracker(taskState, informerFactory, clientFactory.Dynamic(), clientFactory.Mapper(), dyntracker.DynamicAbsenceTrackerOptions{
 			Timeout: opts.TrackDeletionTimeout,
 		})
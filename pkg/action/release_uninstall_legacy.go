@@ -46,7 +46,9 @@ type LegacyReleaseUninstallOptions struct {
 	LegacyNoReleaseLock    bool
 	NetworkParallelism     int
 	NoDeleteHooks          bool
+	OverrideProtection     bool
 	ReleaseHistoryLimit    int
+	ReleaseLocker          string
 	ReleaseStorageDriver   string
 	TempDirPath            string
 	Timeout                time.Duration
@@ -213,11 +215,17 @@ func legacyReleaseUninstall(ctx context.Context, releaseName, releaseNamespace s
 			return nil
 		}
 
+		if err := checkReleaseProtection(ctx, "uninstall", releaseName, releaseNamespace, helmReleaseStorage, opts.OverrideProtection); err != nil {
+			return err
+		}
+
 		log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render("Deleting release")+" %q (namespace: %q)", releaseName, releaseNamespace)
 
 		var lockManager *lock.LockManager
 		if !opts.LegacyNoReleaseLock {
-			if m, err := lock.NewLockManager(ctx, releaseNamespace, false, clientFactory); err != nil {
+			if m, err := lock.NewLockManagerWithOptions(ctx, releaseNamespace, false, clientFactory, lock.LockManagerOptions{
+				Locker: opts.ReleaseLocker,
+			}); err != nil {
 				return fmt.Errorf("construct lock manager: %w", err)
 			} else {
 				lockManager = m
@@ -0,0 +1,108 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gookit/color"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+const (
+	DefaultSchemaPullLogLevel   = log.InfoLevel
+	DefaultSchemaPullOutputPath = "schemas.tar.gz"
+)
+
+type SchemaPullOptions struct {
+	// ExtraKinds are kinds, usually of custom resources, to pull schemas for in addition to built-in
+	// kinds, in the "group/version/Kind" format, or "version/Kind" for the core group.
+	ExtraKinds []string
+	// KubeVersion is the Kubernetes version to pull schemas of built-in kinds for.
+	// Defaults to DefaultResourceValidationKubeVersion if not specified.
+	KubeVersion string
+	// NetworkParallelism limits the number of concurrent schema downloads.
+	// Defaults to DefaultNetworkParallelism if not set or <= 0.
+	NetworkParallelism int
+	// OutputPath is the path to save the schema bundle to.
+	// Defaults to DefaultSchemaPullOutputPath if not specified.
+	OutputPath string
+	// Schemas are json schema sources to pull schemas from.
+	// Defaults to DefaultResourceValidationSchema if not specified.
+	Schemas []string
+}
+
+// Pulls json schemas of built-in Kubernetes kinds and of the extra kinds into a tar.gz bundle,
+// which can be used for resource validation without network access.
+func SchemaPull(ctx context.Context, opts SchemaPullOptions) error {
+	opts = applySchemaPullOptionsDefaults(opts)
+
+	extraKinds, err := parseSchemaPullKinds(opts.ExtraKinds)
+	if err != nil {
+		return fmt.Errorf("parse extra kinds: %w", err)
+	}
+
+	log.Default.Info(ctx, "Pull schemas for kube version %s", opts.KubeVersion)
+
+	manifest, err := resource.PullSchemaBundle(ctx, opts.KubeVersion, opts.OutputPath, resource.PullSchemaBundleOptions{
+		ExtraKinds:         extraKinds,
+		NetworkParallelism: opts.NetworkParallelism,
+		Sources:            opts.Schemas,
+	})
+	if err != nil {
+		return fmt.Errorf("pull schema bundle: %w", err)
+	}
+
+	log.Default.Info(ctx, color.Style{color.Bold, color.Green}.Render(fmt.Sprintf("Saved %d schemas for kube version %s to %q", len(manifest.Schemas), manifest.KubeVersion, opts.OutputPath)))
+
+	return nil
+}
+
+func parseSchemaPullKinds(kinds []string) ([]schema.GroupVersionKind, error) {
+	var gvks []schema.GroupVersionKind
+	for _, kind := range kinds {
+		parts := strings.Split(kind, "/")
+
+		var gvk schema.GroupVersionKind
+		switch len(parts) {
+		case 2:
+			gvk = schema.GroupVersionKind{Version: parts[0], Kind: parts[1]}
+		case 3:
+			gvk = schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}
+		default:
+			return nil, fmt.Errorf("invalid kind %q: expected \"group/version/Kind\" or \"version/Kind\"", kind)
+		}
+
+		if gvk.Version == "" || gvk.Kind == "" {
+			return nil, fmt.Errorf("invalid kind %q: version and kind must not be empty", kind)
+		}
+
+		gvks = append(gvks, gvk)
+	}
+
+	return gvks, nil
+}
+
+func applySchemaPullOptionsDefaults(opts SchemaPullOptions) SchemaPullOptions {
+	if opts.KubeVersion == "" {
+		opts.KubeVersion = common.DefaultResourceValidationKubeVersion
+	}
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	if opts.OutputPath == "" {
+		opts.OutputPath = DefaultSchemaPullOutputPath
+	}
+
+	if len(opts.Schemas) == 0 {
+		opts.Schemas = common.DefaultResourceValidationSchema
+	}
+
+	return opts
+}
@@ -13,7 +13,6 @@ import (
 	"unicode"
 
 	"github.com/goccy/go-yaml"
-	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
@@ -60,6 +59,7 @@ type RenderChartOptions struct {
 	NoStandaloneCRDs          bool
 	Remote                    bool
 	SubchartNotes             bool
+	TSBundleCache             *ts.BundleCache
 	TempDirPath               string
 	TemplatesAllowDNS         bool
 }
@@ -133,6 +133,8 @@ func RenderChart(ctx context.Context, chartPath, releaseName, releaseNamespace s
 		return nil, fmt.Errorf("validate chart at %q: %w", chartPath, err)
 	}
 
+	log.AddSecretValuesToMask(secretValuesToMask(chart)...)
+
 	log.Default.TraceStruct(ctx, chart, "Chart:")
 
 	if err := chartutil.ProcessDependenciesWithMerge(chart, &overrideValues); err != nil {
@@ -197,19 +199,23 @@ func RenderChart(ctx context.Context, chartPath, releaseName, releaseNamespace s
 
 	log.Default.TraceStruct(ctx, renderedValues.AsMap(), "Rendered values:")
 
-	var engine *helmengine.Engine
-	if opts.Remote && clientFactory.KubeClient() != nil {
-		engine = lo.ToPtr(helmengine.New(clientFactory.KubeConfig().RestConfig))
-	} else {
-		engine = &helmengine.Engine{}
-		if len(opts.LocalLookupResourcesPaths) > 0 {
-			localLookupResources, err := parseLocalLookupResources(opts.LocalLookupResourcesPaths)
-			if err != nil {
-				return nil, fmt.Errorf("parse local lookup resources: %w", err)
-			}
-
-			engine.SetClientProvider(newLocalClientProvider(localLookupResources))
+	// Shared by the Go templates and TypeScript charts. Nil makes lookups return empty results.
+	// In-memory clusters have no kube config, so their lookups use local lookup resources.
+	var lookupClientProvider helmengine.ClientProvider
+	if opts.Remote && clientFactory.KubeClient() != nil && clientFactory.KubeConfig() != nil {
+		lookupClientProvider = helmengine.NewClientProvider(clientFactory.KubeConfig().RestConfig)
+	} else if len(opts.LocalLookupResourcesPaths) > 0 {
+		localLookupResources, err := parseLocalLookupResources(opts.LocalLookupResourcesPaths)
+		if err != nil {
+			return nil, fmt.Errorf("parse local lookup resources: %w", err)
 		}
+
+		lookupClientProvider = newLocalClientProvider(localLookupResources)
+	}
+
+	engine := &helmengine.Engine{}
+	if lookupClientProvider != nil {
+		engine.SetClientProvider(lookupClientProvider)
 	}
 
 	engine.EnableDNS = opts.TemplatesAllowDNS
@@ -242,7 +248,17 @@ func RenderChart(ctx context.Context, chartPath, releaseName, releaseNamespace s
 	if featgate.FeatGateTypescript.Enabled() {
 		log.Default.Debug(ctx, "Rendering TypeScript resources for chart %q and its dependencies", chart.Name())
 
-		jsRenderedTemplates, err := ts.RenderChart(ctx, chart, renderedValues, opts.IgnoreBundleJS, chartPath, opts.TempDirPath, opts.DenoBinaryPath)
+		tsRenderOpts := ts.RenderChartOptions{
+			BundleCache:   opts.TSBundleCache,
+			RebuildBundle: opts.IgnoreBundleJS,
+		}
+
+		// Like in Go templates, lookups return empty results in lint mode.
+		if !opts.LintMode {
+			tsRenderOpts.ClientProvider = lookupClientProvider
+		}
+
+		jsRenderedTemplates, err := ts.RenderChart(ctx, chart, renderedValues, chartPath, opts.TempDirPath, opts.DenoBinaryPath, tsRenderOpts)
 		if err != nil {
 			return nil, fmt.Errorf("render TypeScript templates for chart %q: %w", chart.Name(), err)
 		}
@@ -287,6 +303,11 @@ func RenderChart(ctx context.Context, chartPath, releaseName, releaseNamespace s
 	}, nil
 }
 
+// Parses resources from YAML files for local lookups. Used to seed in-memory clusters too.
+func ParseLocalResources(paths []string) ([]*unstructured.Unstructured, error) {
+	return parseLocalLookupResources(paths)
+}
+
 func parseLocalLookupResources(paths []string) ([]*unstructured.Unstructured, error) {
 	var resources []*unstructured.Unstructured
 
@@ -497,6 +518,19 @@ func renderedTemplatesToResourceSpecs(renderedTemplates map[string]string, relea
 	return resources, nil
 }
 
+func secretValuesToMask(chart *helmchart.Chart) []string {
+	var values []string
+	if chart.SecretsRuntimeData != nil {
+		values = append(values, chart.SecretsRuntimeData.GetSecretValuesToMask()...)
+	}
+
+	for _, dep := range chart.Dependencies() {
+		values = append(values, secretValuesToMask(dep)...)
+	}
+
+	return values
+}
+
 func validateChart(ctx context.Context, chart *helmchart.Chart) error {
 	if chart == nil {
 		return fmt.Errorf("load chart: %w", action.ErrMissingChart())
@@ -0,0 +1,494 @@
+package chart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ohler55/ojg/jp"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/nelm/pkg/resource/spec"
+	"github.com/werf/nelm/pkg/util"
+)
+
+const (
+	// RenderTestSnapshotsDir is the directory, relative to RenderTestsDir, with stored snapshots.
+	RenderTestSnapshotsDir = "__snapshot__"
+	// RenderTestsDir is the directory in the chart with render test suites.
+	RenderTestsDir = "tests"
+)
+
+// RenderTestSuite is a single file with render test cases, e.g. tests/deployment_test.yaml.
+type RenderTestSuite struct {
+	// Path to the suite file.
+	Path string `json:"-"`
+
+	// Name of the suite. Defaults to the suite file name.
+	Name    string            `json:"suite,omitempty"`
+	Release RenderTestRelease `json:"release,omitempty"`
+	// Values applied to all test cases of the suite.
+	Values map[string]interface{} `json:"values,omitempty"`
+	// ValuesFiles applied to all test cases of the suite. Relative to the chart directory.
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+	// ValuesSet applied to all test cases of the suite, in "key=value" format.
+	ValuesSet []string          `json:"set,omitempty"`
+	Tests     []*RenderTestCase `json:"tests"`
+}
+
+type RenderTestRelease struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type RenderTestCase struct {
+	Name string `json:"name"`
+	// Values merged on top of the suite values.
+	Values map[string]interface{} `json:"values,omitempty"`
+	// ValuesFiles applied after the suite values files. Relative to the chart directory.
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+	// ValuesSet applied after the suite values set, in "key=value" format.
+	ValuesSet []string               `json:"set,omitempty"`
+	Asserts   []*RenderTestAssertion `json:"asserts"`
+}
+
+// RenderTestAssertion must have exactly one of DocumentCount, Equal, Exists or MatchSnapshot set.
+type RenderTestAssertion struct {
+	// Select limits the documents checked by the assertion. All documents by default.
+	Select *RenderTestDocumentSelector `json:"select,omitempty"`
+
+	DocumentCount *int                         `json:"documentCount,omitempty"`
+	Equal         *RenderTestEqualAssertion    `json:"equal,omitempty"`
+	Exists        *RenderTestExistsAssertion   `json:"exists,omitempty"`
+	MatchSnapshot *RenderTestSnapshotAssertion `json:"matchSnapshot,omitempty"`
+}
+
+type RenderTestDocumentSelector struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Template is the path to the template relative to the chart directory, e.g.
+	// "templates/deployment.yaml".
+	Template string `json:"template,omitempty"`
+}
+
+type RenderTestEqualAssertion struct {
+	// JSONPath, e.g. "$.spec.replicas".
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type RenderTestExistsAssertion struct {
+	// JSONPath, e.g. "$.metadata.labels.app".
+	Path string `json:"path"`
+}
+
+type RenderTestSnapshotAssertion struct{}
+
+// Discover render test suites (*_test.yaml files) in the tests/ directory of the chart. If
+// suitePaths specified, only these suites are loaded.
+func LoadRenderTestSuites(chartDir string, suitePaths []string) ([]*RenderTestSuite, error) {
+	if len(suitePaths) == 0 {
+		testsDir := filepath.Join(chartDir, RenderTestsDir)
+
+		entries, err := os.ReadDir(testsDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("read dir %q: %w", testsDir, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			if !strings.HasSuffix(entry.Name(), "_test.yaml") && !strings.HasSuffix(entry.Name(), "_test.yml") {
+				continue
+			}
+
+			suitePaths = append(suitePaths, filepath.Join(testsDir, entry.Name()))
+		}
+	}
+
+	var suites []*RenderTestSuite
+	for _, suitePath := range suitePaths {
+		suite, err := loadRenderTestSuite(suitePath)
+		if err != nil {
+			return nil, fmt.Errorf("load render test suite %q: %w", suitePath, err)
+		}
+
+		suites = append(suites, suite)
+	}
+
+	return suites, nil
+}
+
+// Check the assertions of the test case against the rendered documents. Returns the failure
+// messages, which are empty if all assertions passed.
+func (c *RenderTestCase) Check(docs []*spec.ResourceSpec, snapshots *RenderTestSnapshots, updateSnapshots bool) ([]string, error) {
+	var (
+		failures      []string
+		snapshotIndex int
+	)
+
+	for i, assertion := range c.Asserts {
+		selectedDocs := lo.Filter(docs, func(doc *spec.ResourceSpec, _ int) bool {
+			return assertion.Select.Match(doc)
+		})
+
+		var (
+			failure string
+			err     error
+		)
+
+		switch {
+		case assertion.DocumentCount != nil:
+			if len(selectedDocs) != *assertion.DocumentCount {
+				failure = fmt.Sprintf("expected %d documents, got %d", *assertion.DocumentCount, len(selectedDocs))
+			}
+		case assertion.Equal != nil:
+			failure, err = checkEqual(selectedDocs, assertion.Equal)
+		case assertion.Exists != nil:
+			failure, err = checkExists(selectedDocs, assertion.Exists)
+		case assertion.MatchSnapshot != nil:
+			snapshotIndex++
+			failure, err = checkSnapshot(selectedDocs, snapshots, renderTestSnapshotKey(c.Name, snapshotIndex), updateSnapshots)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("check assertion %d: %w", i+1, err)
+		}
+
+		if failure != "" {
+			failures = append(failures, fmt.Sprintf("assertion %d: %s", i+1, failure))
+		}
+	}
+
+	return failures, nil
+}
+
+// Keep the snapshots of the test case from being pruned when its assertions couldn't be checked,
+// e.g. because the chart failed to render.
+func (c *RenderTestCase) KeepSnapshots(snapshots *RenderTestSnapshots) {
+	var snapshotIndex int
+	for _, assertion := range c.Asserts {
+		if assertion.MatchSnapshot == nil {
+			continue
+		}
+
+		snapshotIndex++
+		snapshots.used[renderTestSnapshotKey(c.Name, snapshotIndex)] = true
+	}
+}
+
+func (s *RenderTestDocumentSelector) Match(doc *spec.ResourceSpec) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.APIVersion != "" && s.APIVersion != doc.Unstruct.GetAPIVersion() {
+		return false
+	}
+
+	if s.Kind != "" && s.Kind != doc.Unstruct.GetKind() {
+		return false
+	}
+
+	if s.Name != "" && s.Name != doc.Unstruct.GetName() {
+		return false
+	}
+
+	if s.Namespace != "" && s.Namespace != doc.Unstruct.GetNamespace() {
+		return false
+	}
+
+	// FilePath is prefixed with the chart name, e.g. "mychart/templates/deployment.yaml".
+	if s.Template != "" && doc.FilePath != s.Template && !strings.HasSuffix(doc.FilePath, "/"+s.Template) {
+		return false
+	}
+
+	return true
+}
+
+// RenderTestSnapshots are stored snapshots of a render test suite, e.g.
+// tests/__snapshot__/deployment_test.yaml.
+type RenderTestSnapshots struct {
+	path      string
+	snapshots map[string]string
+	used      map[string]bool
+	changed   bool
+}
+
+func LoadRenderTestSnapshots(suite *RenderTestSuite) (*RenderTestSnapshots, error) {
+	snapshotsPath := filepath.Join(filepath.Dir(suite.Path), RenderTestSnapshotsDir, filepath.Base(suite.Path))
+
+	snapshots := &RenderTestSnapshots{
+		path:      snapshotsPath,
+		snapshots: map[string]string{},
+		used:      map[string]bool{},
+	}
+
+	data, err := os.ReadFile(snapshotsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshots, nil
+		}
+
+		return nil, fmt.Errorf("read snapshots file %q: %w", snapshotsPath, err)
+	}
+
+	if err := yaml.Unmarshal(data, &snapshots.snapshots); err != nil {
+		return nil, fmt.Errorf("unmarshal snapshots file %q: %w", snapshotsPath, err)
+	}
+
+	return snapshots, nil
+}
+
+// Save the snapshots if new snapshots were added or existing were updated. When pruneUnused, also
+// removes the snapshots which were not checked by any assertion.
+func (s *RenderTestSnapshots) Save(pruneUnused bool) error {
+	if pruneUnused {
+		for key := range s.snapshots {
+			if !s.used[key] {
+				delete(s.snapshots, key)
+				s.changed = true
+			}
+		}
+	}
+
+	if !s.changed {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create dir %q: %w", filepath.Dir(s.path), err)
+	}
+
+	data, err := yaml.Marshal(s.snapshots)
+	if err != nil {
+		return fmt.Errorf("marshal snapshots: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write snapshots file %q: %w", s.path, err)
+	}
+
+	s.changed = false
+
+	return nil
+}
+
+func loadRenderTestSuite(suitePath string) (*RenderTestSuite, error) {
+	data, err := os.ReadFile(suitePath)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	suite := &RenderTestSuite{}
+	if err := yaml.UnmarshalStrict(data, suite); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	suite.Path = suitePath
+
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(suitePath), filepath.Ext(suitePath))
+	}
+
+	if len(suite.Tests) == 0 {
+		return nil, errors.New("no tests defined")
+	}
+
+	testNames := map[string]bool{}
+	for i, test := range suite.Tests {
+		if test.Name == "" {
+			return nil, fmt.Errorf("test %d: name must not be empty", i+1)
+		}
+
+		if testNames[test.Name] {
+			return nil, fmt.Errorf("test %q: duplicate test name", test.Name)
+		}
+
+		testNames[test.Name] = true
+
+		for j, assertion := range test.Asserts {
+			if err := validateRenderTestAssertion(assertion); err != nil {
+				return nil, fmt.Errorf("test %q: assertion %d: %w", test.Name, j+1, err)
+			}
+		}
+	}
+
+	return suite, nil
+}
+
+func validateRenderTestAssertion(assertion *RenderTestAssertion) error {
+	setCount := lo.Count([]bool{
+		assertion.DocumentCount != nil,
+		assertion.Equal != nil,
+		assertion.Exists != nil,
+		assertion.MatchSnapshot != nil,
+	}, true)
+
+	if setCount != 1 {
+		return errors.New("exactly one of documentCount, equal, exists or matchSnapshot must be specified")
+	}
+
+	var path string
+	switch {
+	case assertion.Equal != nil:
+		path = assertion.Equal.Path
+	case assertion.Exists != nil:
+		path = assertion.Exists.Path
+	default:
+		return nil
+	}
+
+	if path == "" {
+		return errors.New("path must not be empty")
+	}
+
+	if _, err := jp.ParseString(path); err != nil {
+		return fmt.Errorf("parse JSONPath %q: %w", path, err)
+	}
+
+	return nil
+}
+
+func checkEqual(docs []*spec.ResourceSpec, assertion *RenderTestEqualAssertion) (string, error) {
+	if len(docs) == 0 {
+		return "no documents selected", nil
+	}
+
+	expected, err := normalizeRenderTestValue(assertion.Value)
+	if err != nil {
+		return "", fmt.Errorf("normalize expected value: %w", err)
+	}
+
+	path := jp.MustParseString(assertion.Path)
+
+	for _, doc := range docs {
+		results := path.Get(doc.Unstruct.Object)
+		if len(results) == 0 {
+			return fmt.Sprintf("%s: path %q not found", doc.IDHuman(), assertion.Path), nil
+		}
+
+		var actual interface{}
+		if len(results) == 1 {
+			actual = results[0]
+		} else {
+			actual = results
+		}
+
+		actual, err = normalizeRenderTestValue(actual)
+		if err != nil {
+			return "", fmt.Errorf("normalize actual value: %w", err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Sprintf("%s: path %q: expected %s, got %s", doc.IDHuman(), assertion.Path, renderTestValueString(expected), renderTestValueString(actual)), nil
+		}
+	}
+
+	return "", nil
+}
+
+func checkExists(docs []*spec.ResourceSpec, assertion *RenderTestExistsAssertion) (string, error) {
+	if len(docs) == 0 {
+		return "no documents selected", nil
+	}
+
+	path := jp.MustParseString(assertion.Path)
+
+	for _, doc := range docs {
+		if len(path.Get(doc.Unstruct.Object)) == 0 {
+			return fmt.Sprintf("%s: path %q not found", doc.IDHuman(), assertion.Path), nil
+		}
+	}
+
+	return "", nil
+}
+
+func renderTestSnapshotKey(testName string, snapshotIndex int) string {
+	return fmt.Sprintf("%s %d", testName, snapshotIndex)
+}
+
+func checkSnapshot(docs []*spec.ResourceSpec, snapshots *RenderTestSnapshots, key string, update bool) (string, error) {
+	var manifests []string
+	for _, doc := range docs {
+		manifest, err := renderTestManifest(doc.Unstruct, doc.FilePath)
+		if err != nil {
+			return "", fmt.Errorf("render manifest of %s: %w", doc.IDHuman(), err)
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	actual := strings.Join(manifests, "")
+	snapshots.used[key] = true
+
+	expected, found := snapshots.snapshots[key]
+	if update && (!found || expected != actual) {
+		snapshots.snapshots[key] = actual
+		snapshots.changed = true
+
+		return "", nil
+	}
+
+	if !found {
+		return fmt.Sprintf("snapshot %q not found, run with --update-snapshots to create it", key), nil
+	}
+
+	if expected != actual {
+		return fmt.Sprintf("snapshot %q does not match:\n%s", key, util.ColoredUnifiedDiff(expected, actual, 3)), nil
+	}
+
+	return "", nil
+}
+
+func normalizeRenderTestValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	return normalized, nil
+}
+
+func renderTestManifest(unstruct *unstructured.Unstructured, path string) (string, error) {
+	resourceJSONBytes, err := runtime.Encode(unstructured.UnstructuredJSONScheme, unstruct)
+	if err != nil {
+		return "", fmt.Errorf("encode to JSON: %w", err)
+	}
+
+	resourceYAMLBytes, err := yaml.JSONToYAML(resourceJSONBytes)
+	if err != nil {
+		return "", fmt.Errorf("marshal JSON to YAML: %w", err)
+	}
+
+	return fmt.Sprintf("---\n# Source: %s\n%s", path, resourceYAMLBytes), nil
+}
+
+func renderTestValueString(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(data)
+}
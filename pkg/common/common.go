@@ -17,6 +17,11 @@ import (
 )
 
 const (
+	// Take ownership of fields managed by other field managers on server-side apply conflicts.
+	ApplyConflictsForce ApplyConflicts = "force"
+	// Fail on server-side apply conflicts with other field managers.
+	ApplyConflictsFail ApplyConflicts = "fail"
+
 	// Delete the resource after it is successfully deployed.
 	DeletePolicySucceeded DeletePolicy = "succeeded"
 	// Delete the resource after it fails to be deployed.
@@ -96,11 +101,17 @@ const (
 	ChartTSEntryPointTS = "src/index.ts"
 	// ChartTSInputFile is the name of the input file with context for the Deno app.
 	ChartTSInputFile = "input.yaml"
+	// ChartTSLookupRequestPrefix prefixes the lines written to stdout by the Deno app to request
+	// objects from the cluster. The line continues with the JSON-encoded request. The wire format
+	// is documented in the README, and the lookup() function generated by "chart ts init" uses it.
+	ChartTSLookupRequestPrefix = "::nelm-lookup::"
 	// ChartTSOutputFile is the name of the output file with rendered manifests from the Deno app.
 	ChartTSOutputFile = "output.yaml"
 	// ChartTSSourceDir is the directory containing TypeScript sources in a Helm chart.
-	ChartTSSourceDir  = "ts/"
-	DefaultBurstLimit = 100
+	ChartTSSourceDir = "ts/"
+	// ChartTSValuesTypesFile is the path to the generated TypeScript types of chart values.
+	ChartTSValuesTypesFile = "src/values.ts"
+	DefaultBurstLimit      = 100
 	// TODO(major): switch to if-possible
 	DefaultChartProvenanceStrategy = "never"
 	// TODO(major): reconsider?
@@ -121,15 +132,21 @@ const (
 	DefaultWebhookRetryTimeout           = 4 * time.Minute
 	KubectlEditFieldManager              = "kubectl-edit"
 	LockConfigMapName                    = "werf-synchronization"
+	LockLeaseNamePrefix                  = "nelm-lock-"
 	OldDeckhouseControllerManager        = "deckhouse-controller"
 	OldFieldManagerPrefix                = "werf"
 	OutputFormatJSON                     = "json"
 	OutputFormatTable                    = "table"
 	OutputFormatYAML                     = "yaml"
+	ReleaseLockerConfigMap               = "configmap"
+	ReleaseLockerDefault                 = ""
+	ReleaseLockerLease                   = "lease"
 	ReleaseStorageDriverConfigMap        = "configmap"
 	ReleaseStorageDriverConfigMaps       = "configmaps"
 	ReleaseStorageDriverDefault          = ""
+	ReleaseStorageDriverFilesystem       = "filesystem"
 	ReleaseStorageDriverMemory           = "memory"
+	ReleaseStorageDriverS3               = "s3"
 	ReleaseStorageDriverSQL              = "sql"
 	ReleaseStorageDriverSecret           = "secret"
 	ReleaseStorageDriverSecrets          = "secrets"
@@ -241,6 +258,16 @@ var (
 	AnnotationKeyPatternOwnership                         = regexp.MustCompile(`^werf.io/ownership$`)
 	AnnotationKeyHumanDeletePropagation                   = "werf.io/delete-propagation"
 	AnnotationKeyPatternDeletePropagation                 = regexp.MustCompile(`^werf.io/delete-propagation$`)
+	AnnotationKeyHumanProtect                             = "werf.io/protect"
+	AnnotationKeyPatternProtect                           = regexp.MustCompile(`^werf.io/protect$`)
+	AnnotationKeyHumanReleaseDependency                   = "werf.io/release-dependency-<name>"
+	AnnotationKeyPatternReleaseDependency                 = regexp.MustCompile(`^werf.io/release-dependency-(?P<id>.+)$`)
+	AnnotationKeyHumanExpiresAt                           = "werf.io/expires-at"
+	AnnotationKeyPatternExpiresAt                         = regexp.MustCompile(`^werf.io/expires-at$`)
+	AnnotationKeyHumanTTL                                 = "werf.io/ttl"
+	AnnotationKeyPatternTTL                               = regexp.MustCompile(`^werf.io/ttl$`)
+	AnnotationKeyHumanApplyConflicts                      = "werf.io/apply-conflicts"
+	AnnotationKeyPatternApplyConflicts                    = regexp.MustCompile(`^werf.io/apply-conflicts$`)
 	SprigFuncs                                            = sprig.TxtFuncMap()
 	DefaultPlanArtifactLifetime                           = 2 * time.Hour
 	DefaultResourceValidationSchema                       = []string{
@@ -249,8 +276,13 @@ var (
 	}
 	DefaultResourceValidationCacheLifetime   = 48 * time.Hour
 	APIResourceValidationJSONSchemasCacheDir = helmpath.CachePath("nelm", "api-resource-json-schemas")
+	DefaultDryApplyCacheLifetime             = 15 * time.Minute
+	DryApplyCacheDir                         = helmpath.CachePath("nelm", "dry-apply-results")
 )
 
+// How to resolve server-side apply conflicts with other field managers.
+type ApplyConflicts string
+
 // Type of the current operation.
 type DeployType string
 
@@ -50,6 +50,12 @@ type KubeConnectionOptions struct {
 	// KubeQPSLimit is the Queries Per Second limit for requests to Kubernetes API.
 	// Controls the rate of API requests. Defaults to DefaultQPSLimit (30) if not set or <= 0.
 	KubeQPSLimit int
+	// KubeRecordDir, if specified, saves every request to the Kubernetes API and the response to it
+	// into this directory, with Secrets redacted. Used to reproduce issues offline with KubeReplayDir.
+	KubeRecordDir string
+	// KubeReplayDir, if specified, serves requests to the Kubernetes API from responses recorded
+	// into this directory with KubeRecordDir, instead of connecting to a cluster.
+	KubeReplayDir string
 	// KubeRequestTimeout is the timeout duration for all requests to the Kubernetes API.
 	// If 0, no timeout is applied.
 	KubeRequestTimeout time.Duration
@@ -230,6 +236,12 @@ type ResourceValidationOptions struct {
 	ValidationSchemas []string `json:"validationSchemas"`
 	// ValidationExtraSchemas extra schema sources to validate Kubernetes resources (preferred).
 	ValidationExtraSchemas []string `json:"validationExtraSchemas"`
+	// ValidationSchemaBundle path to the schema bundle created with "schema pull", used instead of
+	// ValidationSchemas without network access.
+	ValidationSchemaBundle string `json:"validationSchemaBundle"`
+	// ValidationClusterSchemas validate resources against OpenAPI v3 schemas served by the cluster
+	// (preferred over schema sources). Only used when cluster access is allowed.
+	ValidationClusterSchemas bool `json:"validationClusterSchemas"`
 }
 
 func (opts *ResourceValidationOptions) ApplyDefaults() {}
@@ -237,6 +249,10 @@ func (opts *ResourceValidationOptions) ApplyDefaults() {}
 type ReleaseInstallRuntimeOptions struct {
 	ResourceValidationOptions
 
+	// AdoptResources are filters of resources which are allowed to be adopted from other Helm
+	// releases or from no release, in the "key1=value1,key2=value2" format. Supported keys: group,
+	// version, kind, name, namespace. A more granular alternative to ForceAdoption.
+	AdoptResources []string `json:"adoptResources"`
 	// DefaultDeletePropagation sets the deletion propagation policy for resource deletions.
 	DefaultDeletePropagation string `json:"defaultDeletePropagation"`
 	// ExtraAnnotations are additional Kubernetes annotations to add to all chart resources.
@@ -251,6 +267,10 @@ type ReleaseInstallRuntimeOptions struct {
 	// ExtraRuntimeLabels are additional labels to add to resources at runtime.
 	// These are added during resource creation/update but not stored in the release.
 	ExtraRuntimeLabels map[string]string `json:"extraRuntimeLabels"`
+	// FieldManager is the field manager name used for server-side apply of resources. Fields owned
+	// by the default "helm" field manager are migrated to it.
+	// Defaults to DefaultFieldManager if not specified.
+	FieldManager string `json:"fieldManager"`
 	// ForceAdoption, when true, allows adopting resources that belong to a different Helm release.
 	// WARNING: This can lead to conflicts if resources are managed by multiple releases.
 	ForceAdoption bool `json:"forceAdoption"`
@@ -260,6 +280,9 @@ type ReleaseInstallRuntimeOptions struct {
 	// NoRemoveManualChanges, when true, preserves fields manually added to resources in the cluster
 	// that are not present in the chart manifests. By default, such fields are removed during updates.
 	NoRemoveManualChanges bool `json:"noRemoveManualChanges"`
+	// OverrideProtection, when true, allows deleting resources marked with the "werf.io/protect"
+	// annotation. Otherwise, the operation is refused if it would delete such resources.
+	OverrideProtection bool `json:"overrideProtection"`
 	// ReleaseHistoryLimit sets the maximum number of release revisions to keep in storage.
 	// When exceeded, the oldest revisions are deleted. Defaults to DefaultReleaseHistoryLimit if not set or <= 0.
 	// Note: Only release metadata is deleted; actual Kubernetes resources are not affected.
@@ -270,13 +293,26 @@ type ReleaseInstallRuntimeOptions struct {
 	// ReleaseLabels are labels to add to the release storage object (Secret/ConfigMap).
 	// Used for filtering and organizing releases in storage.
 	ReleaseLabels map[string]string `json:"releaseLabels"`
+	// ReleaseLocker specifies how the release is locked during the operation.
+	// Valid values: "configmap" (default) uses the werf-synchronization ConfigMap, "lease" uses a Lease per release.
+	ReleaseLocker string `json:"releaseLocker"`
+	// ReleaseStorageDir is the directory to store releases in.
+	// Only used when ReleaseStorageDriver is "filesystem".
+	ReleaseStorageDir string `json:"releaseStorageDir"`
 	// ReleaseStorageDriver specifies how release metadata is stored in Kubernetes.
-	// Valid values: "secret" (default), "configmap", "sql".
+	// Valid values: "secret" (default), "configmap", "sql", "filesystem", "s3".
 	// Defaults to "secret" if not specified or set to "default".
 	ReleaseStorageDriver string `json:"releaseStorageDriver"`
+	// ReleaseStorageS3URL is the URL of the S3 bucket to store releases in, e.g. "s3://bucket/prefix"
+	// or "https://minio.example.com/bucket/prefix". Only used when ReleaseStorageDriver is "s3".
+	ReleaseStorageS3URL string `json:"releaseStorageS3URL"`
 	// ReleaseStorageSQLConnection is the SQL connection string when using SQL storage driver.
 	// Only used when ReleaseStorageDriver is "sql".
 	ReleaseStorageSQLConnection string `json:"releaseStorageSQLConnection"`
+	// ReleaseTTL, if specified, records in the release metadata that the release expires after this
+	// duration. Expired releases are uninstalled by "release reap". Every install resets the expiry:
+	// an install without ReleaseTTL makes the release never expire.
+	ReleaseTTL time.Duration `json:"releaseTTL"`
 }
 
 type ResourceDiffOptions struct {
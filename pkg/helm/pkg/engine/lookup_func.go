@@ -49,6 +49,17 @@ type ClientProvider interface {
 	GetClientFor(apiVersion, kind string) (dynamic.NamespaceableResourceInterface, bool, error)
 }
 
+// NewClientProvider returns a ClientProvider that creates clients for the cluster from the rest config.
+func NewClientProvider(config *rest.Config) ClientProvider {
+	return clientProviderFromConfig{config: config}
+}
+
+// NewClientProviderLookupFunction returns a function for looking up objects with the provided
+// ClientProvider. It behaves exactly like the lookup template function.
+func NewClientProviderLookupFunction(clientProvider ClientProvider) lookupFunc {
+	return newLookupFunction(clientProvider)
+}
+
 type clientProviderFromConfig struct {
 	config *rest.Config
 }
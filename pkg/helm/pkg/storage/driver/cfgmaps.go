@@ -53,6 +53,11 @@ type ConfigMaps struct {
 	// namespace it lists in and is required whenever MetadataClient is set.
 	MetadataClient metadata.Interface
 	Namespace      string
+
+	// ChunkSize is the maximum size of the encoded release kept in a single
+	// ConfigMap. The rest of a larger release is split across additional
+	// chunk ConfigMaps. DefaultReleaseChunkSize is used if not set.
+	ChunkSize int
 }
 
 // NewConfigMaps initializes a new ConfigMaps wrapping an implementation of
@@ -119,7 +124,7 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 		return nil, err
 	}
 	// found the configmap, decode the base64 data string
-	r, err := decodeRelease(obj.Data["release"])
+	r, err := cfgmaps.decodeConfigMapsObject(obj)
 	if err != nil {
 		cfgmaps.Log("get: failed to decode data %q: %s", key, err)
 		return nil, err
@@ -147,7 +152,7 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 	// iterate over the configmaps object list
 	// and decode each release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(item.Data["release"])
+		rls, err := cfgmaps.decodeConfigMapsObject(&item)
 		if err != nil {
 			cfgmaps.Log("list: failed to decode release: %v: %s", item, err)
 			continue
@@ -187,7 +192,11 @@ func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, err
 
 	var results []*rspb.Release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(item.Data["release"])
+		if item.Labels["owner"] == releaseChunkOwner {
+			continue
+		}
+
+		rls, err := cfgmaps.decodeConfigMapsObject(&item)
 		if err != nil {
 			cfgmaps.Log("query: failed to decode release: %s", err)
 			continue
@@ -214,6 +223,22 @@ func (cfgmaps *ConfigMaps) Create(key string, rls *rspb.Release) error {
 		cfgmaps.Log("create: failed to encode release %q: %s", rls.Name, err)
 		return err
 	}
+
+	if chunks := cfgmaps.splitConfigMapsObject(obj); len(chunks) > 0 {
+		// check existence first to not leave the chunks of a release that can't be created
+		if _, err := cfgmaps.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+			return ErrReleaseExists
+		} else if !apierrors.IsNotFound(err) {
+			cfgmaps.Log("create: failed to get %q: %s", key, err)
+			return err
+		}
+
+		if err := cfgmaps.createChunks(chunks); err != nil {
+			cfgmaps.Log("create: failed to create chunks: %s", err)
+			return err
+		}
+	}
+
 	// push the configmap object out into the kubiverse
 	if _, err := cfgmaps.impl.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
 		if apierrors.IsAlreadyExists(err) {
@@ -242,12 +267,23 @@ func (cfgmaps *ConfigMaps) Update(key string, rls *rspb.Release) error {
 		cfgmaps.Log("update: failed to encode release %q: %s", rls.Name, err)
 		return err
 	}
+
+	if err := cfgmaps.createChunks(cfgmaps.splitConfigMapsObject(obj)); err != nil {
+		cfgmaps.Log("update: failed to create chunks: %s", err)
+		return err
+	}
+
 	// push the configmap object out into the kubiverse
 	_, err = cfgmaps.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
 	if err != nil {
 		cfgmaps.Log("update: failed to update: %s", err)
 		return err
 	}
+	// remove the chunks of the previous release data
+	if err := cfgmaps.deleteChunks(rls.Name, rls.Version, obj.Labels[releaseChunksIDLabel]); err != nil {
+		cfgmaps.Log("update: failed to delete stale chunks: %s", err)
+		return err
+	}
 	return nil
 }
 
@@ -261,9 +297,92 @@ func (cfgmaps *ConfigMaps) Delete(key string) (rls *rspb.Release, err error) {
 	if err = cfgmaps.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
 		return rls, err
 	}
+	// delete the release chunks, if any
+	if err = cfgmaps.deleteChunks(rls.Name, rls.Version, ""); err != nil {
+		return rls, err
+	}
 	return rls, nil
 }
 
+// createChunks creates the chunk ConfigMaps. Chunks are named after the
+// checksum of the whole release data, so existing chunks are the same.
+func (cfgmaps *ConfigMaps) createChunks(chunks []*v1.ConfigMap) error {
+	for _, chunk := range chunks {
+		if _, err := cfgmaps.impl.Create(context.Background(), chunk, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create chunk %q", chunk.Name)
+		}
+	}
+
+	return nil
+}
+
+// decodeConfigMapsObject reassembles the release data of the ConfigMap and
+// its chunk ConfigMaps, if any, and decodes the release.
+func (cfgmaps *ConfigMaps) decodeConfigMapsObject(obj *v1.ConfigMap) (*rspb.Release, error) {
+	chunks, id, err := releaseChunksCount(obj.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var data strings.Builder
+	data.WriteString(obj.Data["release"])
+
+	for i := 1; i <= chunks; i++ {
+		chunk, err := cfgmaps.impl.Get(context.Background(), releaseChunkName(obj.Name, id, i), metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "get chunk %d of %q", i, obj.Name)
+		}
+
+		data.WriteString(chunk.Data["release"])
+	}
+
+	return decodeRelease(data.String())
+}
+
+// deleteChunks deletes the chunk ConfigMaps of the release revision, except
+// the chunks with keepID.
+func (cfgmaps *ConfigMaps) deleteChunks(name string, version int, keepID string) error {
+	list, err := cfgmaps.impl.List(context.Background(), metav1.ListOptions{LabelSelector: releaseChunksSelector(name, strconv.Itoa(version))})
+	if err != nil {
+		return errors.Wrap(err, "list chunks")
+	}
+
+	for _, item := range list.Items {
+		if keepID != "" && item.Labels[releaseChunksIDLabel] == keepID {
+			continue
+		}
+
+		if err := cfgmaps.impl.Delete(context.Background(), item.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete chunk %q", item.Name)
+		}
+	}
+
+	return nil
+}
+
+// splitConfigMapsObject moves the part of the release data exceeding the
+// chunk size out of the ConfigMap into the returned chunk ConfigMaps.
+func (cfgmaps *ConfigMaps) splitConfigMapsObject(obj *v1.ConfigMap) []*v1.ConfigMap {
+	data := obj.Data["release"]
+	head, chunks := splitReleaseData(data, cfgmaps.ChunkSize)
+
+	setReleaseChunksLabels(obj.Labels, data, chunks)
+	obj.Data["release"] = head
+
+	var result []*v1.ConfigMap
+	for i, chunk := range chunks {
+		result = append(result, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   releaseChunkName(obj.Name, obj.Labels[releaseChunksIDLabel], i+1),
+				Labels: releaseChunkLabels(obj.Labels),
+			},
+			Data: map[string]string{"release": chunk},
+		})
+	}
+
+	return result
+}
+
 // newConfigMapsObject constructs a kubernetes ConfigMap object
 // to store a release. Each configmap data entry is the base64
 // encoded gzipped string of a release.
@@ -276,6 +395,8 @@ func (cfgmaps *ConfigMaps) Delete(key string) (rls *rspb.Release, err error) {
 //	"status"         - status of the release (see pkg/release/status.go for variants)
 //	"owner"          - owner of the configmap, currently "helm".
 //	"name"           - name of the release.
+//	"chunks"         - number of chunks of a release split across several configmaps.
+//	"chunksID"       - checksum of the release data of a release split across several configmaps.
 func newConfigMapsObject(key string, rls *rspb.Release, lbs labels) (*v1.ConfigMap, error) {
 	const owner = "helm"
 
@@ -0,0 +1,103 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	kblabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultReleaseChunkSize is the default maximum size of the encoded release kept in a single
+// Secret or ConfigMap. It leaves room below the 1 MiB object data limit of Kubernetes.
+const DefaultReleaseChunkSize = 1000 * 1024
+
+const (
+	// releaseChunkOwner is the "owner" label value of the objects holding release chunks, so that
+	// they never match the "owner=helm" selector of release objects.
+	releaseChunkOwner = "helm-chunk"
+	// releaseChunksLabel is set on a chunked release object to the total number of chunks,
+	// including the chunk kept in the release object itself.
+	releaseChunksLabel = "chunks"
+	// releaseChunksIDLabel is set on a chunked release object and on its chunk objects to the
+	// checksum of the whole encoded release. It links the chunks of a single write together, so
+	// a rewrite never clobbers the chunks of the previous one.
+	releaseChunksIDLabel = "chunksID"
+)
+
+// splitReleaseData splits the encoded release into the part kept in the release object itself and
+// the parts kept in additional chunk objects.
+func splitReleaseData(data string, chunkSize int) (string, []string) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultReleaseChunkSize
+	}
+
+	if len(data) <= chunkSize {
+		return data, nil
+	}
+
+	var chunks []string
+	for i := chunkSize; i < len(data); i += chunkSize {
+		chunks = append(chunks, data[i:min(i+chunkSize, len(data))])
+	}
+
+	return data[:chunkSize], chunks
+}
+
+// releaseChunksID returns the ID of the chunks of the encoded release.
+func releaseChunksID(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:8])
+}
+
+// releaseChunkName returns the object name of the chunk with the index, starting from 1, of the
+// release object named by key.
+func releaseChunkName(key, id string, index int) string {
+	return fmt.Sprintf("%s.chunk.%s.%d", key, id, index)
+}
+
+// releaseChunkLabels returns the labels of the chunk objects of the release object.
+func releaseChunkLabels(releaseLabels map[string]string) map[string]string {
+	return map[string]string{
+		"owner":              releaseChunkOwner,
+		"name":               releaseLabels["name"],
+		"version":            releaseLabels["version"],
+		releaseChunksIDLabel: releaseLabels[releaseChunksIDLabel],
+	}
+}
+
+// releaseChunksSelector selects the chunk objects of all writes of the release revision.
+func releaseChunksSelector(name, version string) string {
+	return kblabels.Set{"owner": releaseChunkOwner, "name": name, "version": version}.AsSelector().String()
+}
+
+// releaseChunksCount returns the number of additional chunk objects of the release object and the
+// ID of the chunks.
+func releaseChunksCount(releaseLabels map[string]string) (int, string, error) {
+	value, found := releaseLabels[releaseChunksLabel]
+	if !found {
+		return 0, "", nil
+	}
+
+	chunks, err := strconv.Atoi(value)
+	if err != nil || chunks < 1 {
+		return 0, "", fmt.Errorf("invalid %q label value %q", releaseChunksLabel, value)
+	}
+
+	return chunks - 1, releaseLabels[releaseChunksIDLabel], nil
+}
+
+// setReleaseChunksLabels sets or, if there are no additional chunks, removes the chunk labels of
+// the release object.
+func setReleaseChunksLabels(lbs labels, data string, chunks []string) {
+	delete(lbs, releaseChunksLabel)
+	delete(lbs, releaseChunksIDLabel)
+
+	if len(chunks) == 0 {
+		return
+	}
+
+	lbs.set(releaseChunksLabel, strconv.Itoa(len(chunks)+1))
+	lbs.set(releaseChunksIDLabel, releaseChunksID(data))
+}
@@ -0,0 +1,388 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	rspb "github.com/werf/nelm/pkg/helm/pkg/release"
+)
+
+var _ Driver = (*ObjectStorage)(nil)
+
+const (
+	// FilesystemDriverName is the string name of the driver storing releases in a local directory.
+	FilesystemDriverName = "Filesystem"
+	// S3DriverName is the string name of the driver storing releases in an S3-compatible bucket.
+	S3DriverName = "S3"
+
+	objectStorageReleasePrefix = "sh.helm.release.v1."
+)
+
+var (
+	// ErrObjectNotFound indicates that an object is not found in the object store.
+	ErrObjectNotFound = errors.New("object: not found")
+	// ErrObjectExists indicates that an object already exists in the object store.
+	ErrObjectExists = errors.New("object: already exists")
+	// ErrObjectStorageNoNamespace indicates that a single release is accessed with a driver
+	// operating on releases of all namespaces.
+	ErrObjectStorageNoNamespace = errors.New("object storage: namespace is not set")
+)
+
+// ObjectStore is a minimal key-value blob storage, like a directory or an S3 bucket. Keys are
+// slash-separated paths.
+type ObjectStore interface {
+	// Get returns the object data or ErrObjectNotFound.
+	Get(key string) ([]byte, error)
+	// Put stores the object. If overwrite is false and the object exists, ErrObjectExists is
+	// returned and the object is not modified.
+	Put(key string, data []byte, overwrite bool) error
+	// Delete deletes the object or returns ErrObjectNotFound.
+	Delete(key string) error
+	// List returns sorted keys of all objects under the directory-like prefix.
+	List(prefix string) ([]string, error)
+}
+
+// ObjectStorage is the storage driver implementation keeping each release revision as a separate
+// object in an ObjectStore. Objects are laid out as "<namespace>/<release name>/<revision>.json",
+// where the revision is zero-padded so that keys sort in the revision order.
+type ObjectStorage struct {
+	Log func(string, ...interface{})
+
+	name      string
+	namespace string
+	store     ObjectStore
+}
+
+type objectStorageRecord struct {
+	Labels  map[string]string `json:"labels"`
+	Release string            `json:"release"`
+}
+
+// NewObjectStorage initializes a new ObjectStorage driver. Releases are always stored in and read
+// from the namespace of the driver. An empty namespace makes List and Query operate on releases of
+// all namespaces, while Get, LastVersion, Create, Update and Delete fail with
+// ErrObjectStorageNoNamespace.
+func NewObjectStorage(name string, store ObjectStore, namespace string) *ObjectStorage {
+	return &ObjectStorage{
+		Log:       func(_ string, _ ...interface{}) {},
+		name:      name,
+		namespace: namespace,
+		store:     store,
+	}
+}
+
+// Name returns the name of the driver.
+func (o *ObjectStorage) Name() string {
+	return o.name
+}
+
+// Get returns the release named by key or returns ErrReleaseNotFound.
+func (o *ObjectStorage) Get(key string) (*rspb.Release, error) {
+	name, version, err := parseObjectStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	objectKey, err := o.objectKey(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	rls, lbs, err := o.getRecord(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rls.Labels = filterSystemLabels(lbs)
+
+	return rls, nil
+}
+
+// List returns the list of all releases such that filter(release) == true.
+func (o *ObjectStorage) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	keys, err := o.store.List(o.namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+
+	var results []*rspb.Release
+	for _, key := range keys {
+		if _, _, _, ok := parseObjectStorageObjectKey(key); !ok {
+			continue
+		}
+
+		rls, lbs, err := o.getRecord(key)
+		if err != nil {
+			o.Log("list: failed to get release %q: %s", key, err)
+			continue
+		}
+
+		rls.Labels = lbs
+
+		if filter(rls) {
+			results = append(results, rls)
+		}
+	}
+
+	return results, nil
+}
+
+// Query returns the set of releases that match the provided set of labels. Only the objects of the
+// named release (or the single revision) are read if the "name" (and the "version") label is
+// queried.
+func (o *ObjectStorage) Query(keyvals map[string]string) ([]*rspb.Release, error) {
+	var query labels
+
+	query.init()
+	query.fromMap(keyvals)
+
+	var keys []string
+	if name := query.get("name"); name != "" && o.namespace != "" {
+		if version, err := strconv.Atoi(query.get("version")); err == nil {
+			objectKey, err := o.objectKey(name, version)
+			if err != nil {
+				return nil, err
+			}
+
+			keys = []string{objectKey}
+		} else {
+			keys, err = o.store.List(path.Join(o.namespace, name))
+			if err != nil {
+				return nil, errors.Wrap(err, "query: failed to list")
+			}
+		}
+	} else {
+		var err error
+		keys, err = o.store.List(o.namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "query: failed to list")
+		}
+	}
+
+	var results []*rspb.Release
+	for _, key := range keys {
+		if _, _, _, ok := parseObjectStorageObjectKey(key); !ok {
+			continue
+		}
+
+		rls, lbs, err := o.getRecord(key)
+		if err != nil {
+			if !errors.Is(err, ErrReleaseNotFound) {
+				o.Log("query: failed to get release %q: %s", key, err)
+			}
+
+			continue
+		}
+
+		if !labels(lbs).match(query) {
+			continue
+		}
+
+		rls.Labels = lbs
+		results = append(results, rls)
+	}
+
+	if len(results) == 0 {
+		return nil, ErrReleaseNotFound
+	}
+
+	return results, nil
+}
+
+// LastVersion returns the highest revision of the named release, or ErrReleaseNotFound if the
+// release does not exist. Only the object keys are listed, no release is read.
+func (o *ObjectStorage) LastVersion(name string) (int, error) {
+	if o.namespace == "" {
+		return 0, ErrObjectStorageNoNamespace
+	}
+
+	keys, err := o.store.List(path.Join(o.namespace, name))
+	if err != nil {
+		return 0, errors.Wrap(err, "last version: failed to list")
+	}
+
+	latest := 0
+	for _, key := range keys {
+		if _, _, version, ok := parseObjectStorageObjectKey(key); ok && version > latest {
+			latest = version
+		}
+	}
+
+	if latest == 0 {
+		return 0, ErrReleaseNotFound
+	}
+
+	return latest, nil
+}
+
+// Create creates a new release or returns ErrReleaseExists.
+func (o *ObjectStorage) Create(_ string, rls *rspb.Release) error {
+	key, err := o.objectKey(rls.Name, rls.Version)
+	if err != nil {
+		return err
+	}
+
+	var lbs labels
+
+	lbs.init()
+	lbs.set("createdAt", strconv.Itoa(int(time.Now().Unix())))
+
+	data, err := newObjectStorageRecord(rls, lbs)
+	if err != nil {
+		return errors.Wrapf(err, "create: failed to encode release %q", rls.Name)
+	}
+
+	if err := o.store.Put(key, data, false); err != nil {
+		if errors.Is(err, ErrObjectExists) {
+			return ErrReleaseExists
+		}
+
+		return errors.Wrap(err, "create: failed to create")
+	}
+
+	return nil
+}
+
+// Update updates a release or returns ErrReleaseNotFound.
+func (o *ObjectStorage) Update(_ string, rls *rspb.Release) error {
+	key, err := o.objectKey(rls.Name, rls.Version)
+	if err != nil {
+		return err
+	}
+
+	if _, err := o.store.Get(key); err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return ErrReleaseNotFound
+		}
+
+		return errors.Wrap(err, "update: failed to get")
+	}
+
+	var lbs labels
+
+	lbs.init()
+	lbs.set("modifiedAt", strconv.Itoa(int(time.Now().Unix())))
+
+	data, err := newObjectStorageRecord(rls, lbs)
+	if err != nil {
+		return errors.Wrapf(err, "update: failed to encode release %q", rls.Name)
+	}
+
+	return errors.Wrap(o.store.Put(key, data, true), "update: failed to update")
+}
+
+// Delete deletes a release or returns ErrReleaseNotFound.
+func (o *ObjectStorage) Delete(key string) (*rspb.Release, error) {
+	rls, err := o.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = o.objectKey(rls.Name, rls.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.store.Delete(key); err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrReleaseNotFound
+		}
+
+		return nil, errors.Wrap(err, "delete: failed to delete")
+	}
+
+	return rls, nil
+}
+
+func (o *ObjectStorage) getRecord(key string) (*rspb.Release, map[string]string, error) {
+	data, err := o.store.Get(key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, nil, ErrReleaseNotFound
+		}
+
+		return nil, nil, errors.Wrapf(err, "get: failed to get %q", key)
+	}
+
+	var record objectStorageRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, nil, errors.Wrapf(err, "get: failed to unmarshal %q", key)
+	}
+
+	rls, err := decodeRelease(record.Release)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "get: failed to decode data %q", key)
+	}
+
+	return rls, record.Labels, nil
+}
+
+// The key of the object of a single release revision, shared by all operations so that releases are
+// written, read and deleted at the same place.
+func (o *ObjectStorage) objectKey(name string, version int) (string, error) {
+	if o.namespace == "" {
+		return "", ErrObjectStorageNoNamespace
+	}
+
+	return objectStorageObjectKey(o.namespace, name, version), nil
+}
+
+// newObjectStorageRecord encodes the release along with the labels. The following labels are set
+// in addition to the custom labels of the release: "name", "owner", "status", "version".
+func newObjectStorageRecord(rls *rspb.Release, lbs labels) ([]byte, error) {
+	encoded, err := encodeRelease(rls)
+	if err != nil {
+		return nil, err
+	}
+
+	lbs.fromMap(rls.Labels)
+	lbs.set("name", rls.Name)
+	lbs.set("owner", "helm")
+	lbs.set("status", rls.Info.Status.String())
+	lbs.set("version", strconv.Itoa(rls.Version))
+
+	return json.Marshal(objectStorageRecord{
+		Labels:  lbs.toMap(),
+		Release: encoded,
+	})
+}
+
+func objectStorageObjectKey(namespace, name string, version int) string {
+	return path.Join(namespace, name, fmt.Sprintf("%010d.json", version))
+}
+
+func parseObjectStorageKey(key string) (string, int, error) {
+	keyWithoutPrefix := strings.TrimPrefix(key, objectStorageReleasePrefix)
+
+	i := strings.LastIndex(keyWithoutPrefix, ".v")
+	if i <= 0 {
+		return "", 0, ErrInvalidKey
+	}
+
+	version, err := strconv.Atoi(keyWithoutPrefix[i+2:])
+	if err != nil {
+		return "", 0, ErrInvalidKey
+	}
+
+	return keyWithoutPrefix[:i], version, nil
+}
+
+func parseObjectStorageObjectKey(key string) (string, string, int, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return "", "", 0, false
+	}
+
+	version, err := strconv.Atoi(strings.TrimSuffix(parts[2], ".json"))
+	if err != nil || !strings.HasSuffix(parts[2], ".json") {
+		return "", "", 0, false
+	}
+
+	return parts[0], parts[1], version, true
+}
@@ -0,0 +1,139 @@
+package driver
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var _ ObjectStore = (*FilesystemObjectStore)(nil)
+
+const filesystemObjectStoreTempPrefix = ".tmp-"
+
+// FilesystemObjectStore is an ObjectStore keeping objects as files in a local directory.
+type FilesystemObjectStore struct {
+	dir string
+}
+
+// NewFilesystem initializes a new ObjectStorage driver keeping releases in the local directory.
+func NewFilesystem(dir, namespace string) *ObjectStorage {
+	return NewObjectStorage(FilesystemDriverName, NewFilesystemObjectStore(dir), namespace)
+}
+
+// NewFilesystemObjectStore initializes a new FilesystemObjectStore. The directory is created on the
+// first write.
+func NewFilesystemObjectStore(dir string) *FilesystemObjectStore {
+	return &FilesystemObjectStore{dir: dir}
+}
+
+// Get returns the object data or ErrObjectNotFound.
+func (s *FilesystemObjectStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Put writes the object atomically: the data is written to a temporary file first, which then
+// replaces the object file, or is hard-linked to it if overwriting is not allowed.
+func (s *FilesystemObjectStore) Put(key string, data []byte, overwrite bool) error {
+	path := s.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrapf(err, "create directory for %q", key)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filesystemObjectStoreTempPrefix)
+	if err != nil {
+		return errors.Wrapf(err, "create temporary file for %q", key)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return errors.Wrapf(err, "write temporary file for %q", key)
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return errors.Wrapf(err, "sync temporary file for %q", key)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "close temporary file for %q", key)
+	}
+
+	if overwrite {
+		return errors.Wrapf(os.Rename(tmpFile.Name(), path), "rename temporary file to %q", key)
+	}
+
+	if err := os.Link(tmpFile.Name(), path); err != nil {
+		if os.IsExist(err) {
+			return ErrObjectExists
+		}
+
+		return errors.Wrapf(err, "link temporary file to %q", key)
+	}
+
+	return nil
+}
+
+// Delete deletes the object or returns ErrObjectNotFound.
+func (s *FilesystemObjectStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrObjectNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// List returns sorted keys of all objects under the directory-like prefix.
+func (s *FilesystemObjectStore) List(prefix string) ([]string, error) {
+	var keys []string
+	if err := filepath.WalkDir(s.path(prefix), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if d.IsDir() || strings.HasPrefix(d.Name(), filesystemObjectStoreTempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, filepath.ToSlash(rel))
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (s *FilesystemObjectStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,374 @@
+package driver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ ObjectStore = (*S3ObjectStore)(nil)
+
+const defaultS3Region = "us-east-1"
+
+// S3ObjectStore is an ObjectStore keeping objects in an S3-compatible bucket. Path-style requests
+// signed with AWS Signature Version 4 are used, so any S3-compatible storage, like MinIO, works.
+type S3ObjectStore struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	bucket   string
+	client   *http.Client
+	endpoint *url.URL
+	prefix   string
+	region   string
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// NewS3 initializes a new ObjectStorage driver keeping releases in the S3 bucket. See
+// NewS3ObjectStore for the format of the URL.
+func NewS3(rawURL, namespace string) (*ObjectStorage, error) {
+	store, err := NewS3ObjectStore(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewObjectStorage(S3DriverName, store, namespace), nil
+}
+
+// NewS3ObjectStore initializes a new S3ObjectStore. The URL is either
+// "s3://<bucket>[/<prefix>]" for AWS S3 or "http[s]://<host>[:<port>]/<bucket>[/<prefix>]" for
+// other S3-compatible storages. The region can be set with the "region" query parameter, otherwise
+// it is taken from $AWS_REGION or $AWS_DEFAULT_REGION, defaulting to us-east-1. Credentials are
+// taken from $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN. Requests are not
+// signed if there are no credentials.
+func NewS3ObjectStore(rawURL string) (*S3ObjectStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse S3 URL %q", rawURL)
+	}
+
+	region := u.Query().Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	var bucket, prefix string
+	endpoint := &url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	switch u.Scheme {
+	case "s3":
+		bucket = u.Host
+		prefix = u.Path
+		endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("s3.%s.amazonaws.com", region)}
+	case "http", "https":
+		bucket, prefix, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	default:
+		return nil, errors.Errorf("unsupported scheme %q of S3 URL %q, expected s3, http or https", u.Scheme, rawURL)
+	}
+
+	if bucket == "" {
+		return nil, errors.Errorf("no bucket in S3 URL %q", rawURL)
+	}
+
+	return &S3ObjectStore{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		bucket:          bucket,
+		client:          &http.Client{Timeout: time.Minute},
+		endpoint:        endpoint,
+		prefix:          strings.Trim(prefix, "/"),
+		region:          region,
+	}, nil
+}
+
+// Get returns the object data or ErrObjectNotFound.
+func (s *S3ObjectStore) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, s.objectKey(key), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrObjectNotFound
+	default:
+		return nil, s3ResponseError(resp)
+	}
+}
+
+// Put stores the object. Conditional writes ("If-None-Match: *") are used to not overwrite
+// existing objects.
+func (s *S3ObjectStore) Put(key string, data []byte, overwrite bool) error {
+	header := http.Header{}
+	if !overwrite {
+		header.Set("If-None-Match", "*")
+	}
+
+	resp, err := s.do(http.MethodPut, s.objectKey(key), nil, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		if !overwrite {
+			return ErrObjectExists
+		}
+	}
+
+	return s3ResponseError(resp)
+}
+
+// Delete deletes the object or returns ErrObjectNotFound. S3 doesn't report missing objects on
+// delete, so the object is checked first.
+func (s *S3ObjectStore) Delete(key string) error {
+	if _, err := s.Get(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, s.objectKey(key), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp)
+	}
+
+	return nil
+}
+
+// List returns sorted keys of all objects under the directory-like prefix.
+func (s *S3ObjectStore) List(prefix string) ([]string, error) {
+	listPrefix := s.objectKey(prefix)
+	if listPrefix != "" {
+		listPrefix += "/"
+	}
+
+	var keys []string
+	var continuationToken string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", listPrefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		result, err := s.listObjects(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			key := strings.TrimPrefix(content.Key, s.prefix)
+			keys = append(keys, strings.TrimPrefix(key, "/"))
+		}
+
+		if !result.IsTruncated {
+			break
+		}
+
+		continuationToken = result.NextContinuationToken
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (s *S3ObjectStore) listObjects(query url.Values) (*s3ListBucketResult, error) {
+	resp, err := s.do(http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3ResponseError(resp)
+	}
+
+	var result s3ListBucketResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "decode list objects response")
+	}
+
+	return &result, nil
+}
+
+func (s *S3ObjectStore) objectKey(key string) string {
+	return strings.Trim(s.prefix+"/"+key, "/")
+}
+
+func (s *S3ObjectStore) do(method, objectKey string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	canonicalURI := "/" + s3URIEncode(s.bucket, false)
+	if objectKey != "" {
+		canonicalURI += "/" + s3URIEncode(objectKey, true)
+	}
+
+	canonicalQuery := s3CanonicalQuery(query)
+
+	rawURL := s.endpoint.String() + canonicalURI
+	if canonicalQuery != "" {
+		rawURL += "?" + canonicalQuery
+	}
+
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "construct %s request", method)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if s.AccessKeyID != "" {
+		s.sign(req, canonicalURI, canonicalQuery, body, time.Now().UTC())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, canonicalURI)
+	}
+
+	return resp, nil
+}
+
+// Sign the request with AWS Signature Version 4.
+func (s *S3ObjectStore) sign(req *http.Request, canonicalURI, canonicalQuery string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signedHeaderValues := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if s.SessionToken != "" {
+		signedHeaderValues["x-amz-security-token"] = s.SessionToken
+	}
+
+	var signedHeaders []string
+	for name := range signedHeaderValues {
+		signedHeaders = append(signedHeaders, name)
+	}
+
+	sort.Strings(signedHeaders)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(signedHeaderValues[name]) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, strings.Join(signedHeaders, ";"), hex.EncodeToString(hmacSHA256(signingKey, stringToSign)),
+	))
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	var keys []string
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, s3URIEncode(k, false)+"="+s3URIEncode(v, false))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func s3ResponseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return errors.Errorf("unexpected response %q from %s %s: %s", resp.Status, resp.Request.Method, resp.Request.URL.Path, strings.TrimSpace(string(body)))
+}
+
+// URI-encode every byte except the unreserved characters, as required by AWS Signature Version 4.
+func s3URIEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' || keepSlash && c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
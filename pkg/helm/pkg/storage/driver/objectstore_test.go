@@ -0,0 +1,283 @@
+package driver
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	rspb "github.com/werf/nelm/pkg/helm/pkg/release"
+)
+
+func TestObjectStorageFilesystem(t *testing.T) {
+	testObjectStorage(t, func(namespace string) *ObjectStorage {
+		return NewFilesystem(t.TempDir(), namespace)
+	})
+}
+
+func TestObjectStorageS3(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+
+	testObjectStorage(t, func(namespace string) *ObjectStorage {
+		server := newFakeS3Server(t, "releases")
+
+		driver, err := NewS3(server.URL+"/releases/prefix", namespace)
+		if err != nil {
+			t.Fatalf("failed to construct s3 driver: %s", err)
+		}
+
+		return driver
+	})
+}
+
+func TestObjectStorageAllNamespaces(t *testing.T) {
+	dir := t.TempDir()
+
+	namespaced := NewFilesystem(dir, "ns")
+	allNamespaces := NewFilesystem(dir, "")
+
+	rls := releaseStub("rls-a", 1, "ns", rspb.StatusDeployed)
+	if err := namespaced.Create(testKey(rls.Name, rls.Version), rls); err != nil {
+		t.Fatalf("failed to create release: %s", err)
+	}
+
+	if rels, err := allNamespaces.List(func(_ *rspb.Release) bool { return true }); err != nil || len(rels) != 1 {
+		t.Errorf("expected 1 listed release, got %v (%v)", rels, err)
+	}
+
+	if rels, err := allNamespaces.Query(map[string]string{"name": "rls-a", "owner": "helm", "version": "1"}); err != nil || len(rels) != 1 {
+		t.Errorf("expected 1 queried release, got %v (%v)", rels, err)
+	}
+
+	if _, err := allNamespaces.Get(testKey("rls-a", 1)); !errors.Is(err, ErrObjectStorageNoNamespace) {
+		t.Errorf("expected ErrObjectStorageNoNamespace on get, got %v", err)
+	}
+
+	if _, err := allNamespaces.LastVersion("rls-a"); !errors.Is(err, ErrObjectStorageNoNamespace) {
+		t.Errorf("expected ErrObjectStorageNoNamespace on last version, got %v", err)
+	}
+
+	if err := allNamespaces.Create(testKey("rls-a", 2), releaseStub("rls-a", 2, "ns", rspb.StatusDeployed)); !errors.Is(err, ErrObjectStorageNoNamespace) {
+		t.Errorf("expected ErrObjectStorageNoNamespace on create, got %v", err)
+	}
+
+	if err := allNamespaces.Update(testKey("rls-a", 1), rls); !errors.Is(err, ErrObjectStorageNoNamespace) {
+		t.Errorf("expected ErrObjectStorageNoNamespace on update, got %v", err)
+	}
+
+	if _, err := allNamespaces.Delete(testKey("rls-a", 1)); !errors.Is(err, ErrObjectStorageNoNamespace) {
+		t.Errorf("expected ErrObjectStorageNoNamespace on delete, got %v", err)
+	}
+
+	if _, err := namespaced.Get(testKey("rls-a", 1)); err != nil {
+		t.Errorf("expected release to be kept, got %v", err)
+	}
+}
+
+func TestNewS3ObjectStore(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	store, err := NewS3ObjectStore("s3://bucket/some/prefix/")
+	if err != nil {
+		t.Fatalf("failed to construct s3 object store: %s", err)
+	}
+
+	if store.bucket != "bucket" || store.prefix != "some/prefix" || store.region != "eu-west-1" || store.endpoint.String() != "https://s3.eu-west-1.amazonaws.com" {
+		t.Errorf("unexpected s3 object store: %+v", store)
+	}
+
+	store, err = NewS3ObjectStore("http://localhost:9000/bucket?region=local")
+	if err != nil {
+		t.Fatalf("failed to construct s3 object store: %s", err)
+	}
+
+	if store.bucket != "bucket" || store.prefix != "" || store.region != "local" || store.endpoint.String() != "http://localhost:9000" {
+		t.Errorf("unexpected s3 object store: %+v", store)
+	}
+
+	if _, err := NewS3ObjectStore("http://localhost:9000/"); err == nil {
+		t.Error("expected error for URL without bucket")
+	}
+}
+
+func testObjectStorage(t *testing.T, newDriver func(namespace string) *ObjectStorage) {
+	driver := newDriver("default")
+
+	for _, rls := range []*rspb.Release{
+		releaseStub("rls-a", 1, "default", rspb.StatusSuperseded),
+		releaseStub("rls-a", 2, "default", rspb.StatusSuperseded),
+		releaseStub("rls-a", 10, "default", rspb.StatusDeployed),
+		releaseStub("rls-b", 1, "default", rspb.StatusDeployed),
+	} {
+		if err := driver.Create(testKey(rls.Name, rls.Version), rls); err != nil {
+			t.Fatalf("failed to create release %s.v%d: %s", rls.Name, rls.Version, err)
+		}
+	}
+
+	if err := driver.Create(testKey("rls-b", 1), releaseStub("rls-b", 1, "default", rspb.StatusDeployed)); !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("expected ErrReleaseExists, got %v", err)
+	}
+
+	rls, err := driver.Get("sh.helm.release.v1.rls-a.v2")
+	if err != nil {
+		t.Fatalf("failed to get release: %s", err)
+	}
+
+	if rls.Name != "rls-a" || rls.Version != 2 || !reflect.DeepEqual(rls.Labels, map[string]string{"key1": "val1", "key2": "val2"}) {
+		t.Errorf("unexpected release: %+v", rls)
+	}
+
+	if _, err := driver.Get(testKey("rls-c", 1)); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("expected ErrReleaseNotFound, got %v", err)
+	}
+
+	version, err := driver.LastVersion("rls-a")
+	if err != nil || version != 10 {
+		t.Errorf("expected last version 10, got %d (%v)", version, err)
+	}
+
+	if _, err := driver.LastVersion("rls-c"); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("expected ErrReleaseNotFound, got %v", err)
+	}
+
+	rels, err := driver.Query(map[string]string{"name": "rls-a", "owner": "helm", "status": "superseded"})
+	if err != nil {
+		t.Fatalf("failed to query releases: %s", err)
+	}
+
+	if versions := releaseVersions(rels); !reflect.DeepEqual(versions, []int{1, 2}) {
+		t.Errorf("expected superseded versions [1 2], got %v", versions)
+	}
+
+	rels, err = driver.Query(map[string]string{"name": "rls-a", "owner": "helm", "version": "10"})
+	if err != nil || len(rels) != 1 || rels[0].Version != 10 {
+		t.Errorf("expected a single release of version 10, got %v (%v)", rels, err)
+	}
+
+	rels, err = driver.Query(map[string]string{"key1": "val1", "status": "deployed"})
+	if err != nil || len(rels) != 2 {
+		t.Errorf("expected 2 deployed releases, got %v (%v)", rels, err)
+	}
+
+	if _, err := driver.Query(map[string]string{"name": "rls-c", "owner": "helm"}); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("expected ErrReleaseNotFound, got %v", err)
+	}
+
+	rels, err = driver.List(func(rls *rspb.Release) bool { return rls.Name == "rls-b" })
+	if err != nil || len(rels) != 1 {
+		t.Errorf("expected 1 listed release, got %v (%v)", rels, err)
+	}
+
+	updated := releaseStub("rls-a", 10, "default", rspb.StatusSuperseded)
+	if err := driver.Update(testKey("rls-a", 10), updated); err != nil {
+		t.Fatalf("failed to update release: %s", err)
+	}
+
+	if rls, err := driver.Get(testKey("rls-a", 10)); err != nil || rls.Info.Status != rspb.StatusSuperseded {
+		t.Errorf("expected updated release to be superseded, got %v (%v)", rls, err)
+	}
+
+	if err := driver.Update(testKey("rls-c", 1), releaseStub("rls-c", 1, "default", rspb.StatusDeployed)); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("expected ErrReleaseNotFound, got %v", err)
+	}
+
+	if _, err := driver.Delete(testKey("rls-a", 10)); err != nil {
+		t.Fatalf("failed to delete release: %s", err)
+	}
+
+	if _, err := driver.Delete(testKey("rls-a", 10)); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("expected ErrReleaseNotFound, got %v", err)
+	}
+
+	version, err = driver.LastVersion("rls-a")
+	if err != nil || version != 2 {
+		t.Errorf("expected last version 2 after delete, got %d (%v)", version, err)
+	}
+}
+
+func releaseVersions(rels []*rspb.Release) []int {
+	var versions []int
+	for _, rls := range rels {
+		versions = append(versions, rls.Version)
+	}
+
+	sort.Ints(versions)
+
+	return versions
+}
+
+// Minimal in-memory S3 server with path-style requests, like MinIO.
+func newFakeS3Server(t *testing.T, bucket string) *httptest.Server {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if path == bucket && r.Method == http.MethodGet {
+			var result struct {
+				XMLName  xml.Name `xml:"ListBucketResult"`
+				Contents []struct {
+					Key string `xml:"Key"`
+				} `xml:"Contents"`
+			}
+
+			for key := range objects {
+				if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+					result.Contents = append(result.Contents, struct {
+						Key string `xml:"Key"`
+					}{Key: key})
+				}
+			}
+
+			_ = xml.NewEncoder(w).Encode(result)
+
+			return
+		}
+
+		key, found := strings.CutPrefix(path, bucket+"/")
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			data, found := objects[key]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			if _, found := objects[key]; found && r.Header.Get("If-None-Match") == "*" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			data, _ := io.ReadAll(r.Body)
+			objects[key] = data
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
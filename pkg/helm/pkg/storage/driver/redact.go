@@ -0,0 +1,25 @@
+package driver
+
+import (
+	"github.com/pkg/errors"
+
+	rspb "github.com/werf/nelm/pkg/helm/pkg/release"
+)
+
+// RedactEncodedRelease decodes the release data, as stored by the Secrets and
+// ConfigMaps drivers, passes the release to redact and encodes it back.
+func RedactEncodedRelease(data string, redact func(rls *rspb.Release)) (string, error) {
+	rls, err := decodeRelease(data)
+	if err != nil {
+		return "", errors.Wrap(err, "decode release")
+	}
+
+	redact(rls)
+
+	encoded, err := encodeRelease(rls)
+	if err != nil {
+		return "", errors.Wrap(err, "encode release")
+	}
+
+	return encoded, nil
+}
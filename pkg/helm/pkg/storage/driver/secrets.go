@@ -53,6 +53,11 @@ type Secrets struct {
 	// namespace it lists in and is required whenever MetadataClient is set.
 	MetadataClient metadata.Interface
 	Namespace      string
+
+	// ChunkSize is the maximum size of the encoded release kept in a single
+	// Secret. The rest of a larger release is split across additional chunk
+	// Secrets. DefaultReleaseChunkSize is used if not set.
+	ChunkSize int
 }
 
 // NewSecrets initializes a new Secrets wrapping an implementation of
@@ -117,9 +122,12 @@ func (secrets *Secrets) Get(key string) (*rspb.Release, error) {
 		return nil, errors.Wrapf(err, "get: failed to get %q", key)
 	}
 	// found the secret, decode the base64 data string
-	r, err := decodeRelease(string(obj.Data["release"]))
+	r, err := secrets.decodeSecretsObject(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get: failed to decode data %q", key)
+	}
 	r.Labels = filterSystemLabels(obj.ObjectMeta.Labels)
-	return r, errors.Wrapf(err, "get: failed to decode data %q", key)
+	return r, nil
 }
 
 // List fetches all releases and returns the list releases such
@@ -139,7 +147,7 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 	// iterate over the secrets object list
 	// and decode each release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+		rls, err := secrets.decodeSecretsObject(&item)
 		if err != nil {
 			secrets.Log("list: failed to decode release: %v: %s", item, err)
 			continue
@@ -178,7 +186,11 @@ func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error)
 
 	var results []*rspb.Release
 	for _, item := range list.Items {
-		rls, err := decodeRelease(string(item.Data["release"]))
+		if item.Labels["owner"] == releaseChunkOwner {
+			continue
+		}
+
+		rls, err := secrets.decodeSecretsObject(&item)
 		if err != nil {
 			secrets.Log("query: failed to decode release: %s", err)
 			continue
@@ -204,6 +216,20 @@ func (secrets *Secrets) Create(key string, rls *rspb.Release) error {
 	if err != nil {
 		return errors.Wrapf(err, "create: failed to encode release %q", rls.Name)
 	}
+
+	if chunks := secrets.splitSecretsObject(obj); len(chunks) > 0 {
+		// check existence first to not leave the chunks of a release that can't be created
+		if _, err := secrets.impl.Get(context.Background(), key, metav1.GetOptions{}); err == nil {
+			return ErrReleaseExists
+		} else if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "create: failed to get %q", key)
+		}
+
+		if err := secrets.createChunks(chunks); err != nil {
+			return errors.Wrap(err, "create: failed to create chunks")
+		}
+	}
+
 	// push the secret object out into the kubiverse
 	if _, err := secrets.impl.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
 		if apierrors.IsAlreadyExists(err) {
@@ -230,9 +256,18 @@ func (secrets *Secrets) Update(key string, rls *rspb.Release) error {
 	if err != nil {
 		return errors.Wrapf(err, "update: failed to encode release %q", rls.Name)
 	}
+
+	if err := secrets.createChunks(secrets.splitSecretsObject(obj)); err != nil {
+		return errors.Wrap(err, "update: failed to create chunks")
+	}
+
 	// push the secret object out into the kubiverse
-	_, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{})
-	return errors.Wrap(err, "update: failed to update")
+	if _, err = secrets.impl.Update(context.Background(), obj, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrap(err, "update: failed to update")
+	}
+
+	// remove the chunks of the previous release data
+	return errors.Wrap(secrets.deleteChunks(rls.Name, rls.Version, obj.Labels[releaseChunksIDLabel]), "update: failed to delete stale chunks")
 }
 
 // Delete deletes the Secret holding the release named by key.
@@ -242,8 +277,91 @@ func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 		return nil, err
 	}
 	// delete the release
-	err = secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{})
-	return rls, err
+	if err = secrets.impl.Delete(context.Background(), key, metav1.DeleteOptions{}); err != nil {
+		return rls, err
+	}
+	// delete the release chunks, if any
+	return rls, errors.Wrap(secrets.deleteChunks(rls.Name, rls.Version, ""), "delete: failed to delete chunks")
+}
+
+// createChunks creates the chunk Secrets. Chunks are named after the
+// checksum of the whole release data, so existing chunks are the same.
+func (secrets *Secrets) createChunks(chunks []*v1.Secret) error {
+	for _, chunk := range chunks {
+		if _, err := secrets.impl.Create(context.Background(), chunk, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create chunk %q", chunk.Name)
+		}
+	}
+
+	return nil
+}
+
+// decodeSecretsObject reassembles the release data of the Secret and its
+// chunk Secrets, if any, and decodes the release.
+func (secrets *Secrets) decodeSecretsObject(obj *v1.Secret) (*rspb.Release, error) {
+	chunks, id, err := releaseChunksCount(obj.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var data strings.Builder
+	data.Write(obj.Data["release"])
+
+	for i := 1; i <= chunks; i++ {
+		chunk, err := secrets.impl.Get(context.Background(), releaseChunkName(obj.Name, id, i), metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "get chunk %d of %q", i, obj.Name)
+		}
+
+		data.Write(chunk.Data["release"])
+	}
+
+	return decodeRelease(data.String())
+}
+
+// deleteChunks deletes the chunk Secrets of the release revision, except
+// the chunks with keepID.
+func (secrets *Secrets) deleteChunks(name string, version int, keepID string) error {
+	list, err := secrets.impl.List(context.Background(), metav1.ListOptions{LabelSelector: releaseChunksSelector(name, strconv.Itoa(version))})
+	if err != nil {
+		return errors.Wrap(err, "list chunks")
+	}
+
+	for _, item := range list.Items {
+		if keepID != "" && item.Labels[releaseChunksIDLabel] == keepID {
+			continue
+		}
+
+		if err := secrets.impl.Delete(context.Background(), item.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete chunk %q", item.Name)
+		}
+	}
+
+	return nil
+}
+
+// splitSecretsObject moves the part of the release data exceeding the chunk
+// size out of the Secret into the returned chunk Secrets.
+func (secrets *Secrets) splitSecretsObject(obj *v1.Secret) []*v1.Secret {
+	data := string(obj.Data["release"])
+	head, chunks := splitReleaseData(data, secrets.ChunkSize)
+
+	setReleaseChunksLabels(obj.Labels, data, chunks)
+	obj.Data["release"] = []byte(head)
+
+	var result []*v1.Secret
+	for i, chunk := range chunks {
+		result = append(result, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   releaseChunkName(obj.Name, obj.Labels[releaseChunksIDLabel], i+1),
+				Labels: releaseChunkLabels(obj.Labels),
+			},
+			Type: "helm.sh/release-chunk.v1",
+			Data: map[string][]byte{"release": []byte(chunk)},
+		})
+	}
+
+	return result
 }
 
 // newSecretsObject constructs a kubernetes Secret object
@@ -258,6 +376,8 @@ func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 //	"status"         - status of the release (see pkg/release/status.go for variants)
 //	"owner"          - owner of the secret, currently "helm".
 //	"name"           - name of the release.
+//	"chunks"         - number of chunks of a release split across several secrets.
+//	"chunksID"       - checksum of the release data of a release split across several secrets.
 func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, error) {
 	const owner = "helm"
 
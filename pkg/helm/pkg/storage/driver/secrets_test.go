@@ -249,3 +249,108 @@ func TestSecretDelete(t *testing.T) {
 		t.Errorf("Expected {%v}, got {%v}", ErrReleaseNotFound, err)
 	}
 }
+
+func TestSecretChunked(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+
+	var mock MockSecretsInterface
+	mock.Init(t)
+
+	secrets := NewSecrets(&mock)
+	secrets.ChunkSize = 64
+
+	// store the release split across several secrets
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+	if len(mock.objects) < 2 {
+		t.Fatalf("Expected release to be split across several secrets, got %d secrets", len(mock.objects))
+	}
+	if err := secrets.Create(key, rel); err != ErrReleaseExists {
+		t.Errorf("Expected {%v}, got {%v}", ErrReleaseExists, err)
+	}
+
+	// get the release back
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release with key %q: %s", key, err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+
+	// chunks are not releases themselves
+	rls, err := secrets.Query(map[string]string{"name": name})
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	if len(rls) != 1 || rls[0].Info.Status != rspb.StatusDeployed {
+		t.Errorf("Expected a single deployed release, got %v", rls)
+	}
+
+	// old chunks are replaced on update
+	rel.Info.Status = rspb.StatusSuperseded
+	rel.Info.Description = "some longer description to change the release data"
+	if err := secrets.Update(key, rel); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+
+	got, err = secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release with key %q: %s", key, err)
+	}
+	if got.Info.Status != rspb.StatusSuperseded {
+		t.Errorf("Expected status %s, got status %s", rspb.StatusSuperseded, got.Info.Status)
+	}
+	for _, obj := range mock.objects {
+		if obj.Labels["owner"] == releaseChunkOwner && obj.Labels[releaseChunksIDLabel] != mock.objects[key].Labels[releaseChunksIDLabel] {
+			t.Errorf("Expected stale chunk %q to be deleted", obj.Name)
+		}
+	}
+
+	// chunks are deleted along with the release
+	if _, err := secrets.Delete(key); err != nil {
+		t.Fatalf("Failed to delete release with key %q: %s", key, err)
+	}
+	if len(mock.objects) != 0 {
+		t.Errorf("Expected all secrets to be deleted, got %d secrets", len(mock.objects))
+	}
+}
+
+func TestSecretChunkedMissingChunk(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+
+	var mock MockSecretsInterface
+	mock.Init(t)
+
+	secrets := NewSecrets(&mock)
+	secrets.ChunkSize = 64
+
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release with key %q: %s", key, err)
+	}
+
+	// lose one of the chunks
+	for objName, obj := range mock.objects {
+		if obj.Labels["owner"] == releaseChunkOwner {
+			delete(mock.objects, objName)
+			break
+		}
+	}
+
+	got, err := secrets.Get(key)
+	if err == nil {
+		t.Fatalf("Expected error getting release with a missing chunk, got release %v", got)
+	}
+	if got != nil {
+		t.Errorf("Expected no release, got %v", got)
+	}
+}
@@ -37,7 +37,7 @@ var b64 = base64.StdEncoding
 
 var magicGzip = []byte{0x1f, 0x8b, 0x08}
 
-var systemLabels = []string{"name", "owner", "status", "version", "createdAt", "modifiedAt"}
+var systemLabels = []string{"name", "owner", "status", "version", "createdAt", "modifiedAt", releaseChunksLabel, releaseChunksIDLabel}
 
 // lastVersionFromMetadata resolves the highest release revision matching selector
 // using a metadata-only list. It transfers only object metadata (labels), never
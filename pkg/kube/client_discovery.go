@@ -8,10 +8,21 @@ import (
 	"strings"
 	"time"
 
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
 )
 
-func NewDiscoveryKubeClientFromKubeConfig(kubeConfig *KubeConfig) (*disk.CachedDiscoveryClient, error) {
+func NewDiscoveryKubeClientFromKubeConfig(kubeConfig *KubeConfig) (discovery.CachedDiscoveryInterface, error) {
+	if kubeConfig.NoDiscoveryCache {
+		client, err := discovery.NewDiscoveryClientForConfig(kubeConfig.RestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("new discovery client for config: %w", err)
+		}
+
+		return memory.NewMemCacheClient(client), nil
+	}
+
 	var cacheDir string
 	if dir := os.Getenv(KubectlCacheDirEnv); dir != "" {
 		cacheDir = dir
@@ -109,8 +109,8 @@ func (c *KubeClient) Apply(ctx context.Context, resSpec *spec.ResourceSpec, opts
 
 		resultObj, applyErr = clientResource.Apply(ctx, resSpec.Name, resSpec.Unstruct, metav1.ApplyOptions{
 			DryRun:       dryRun,
-			Force:        true,
-			FieldManager: common.DefaultFieldManager,
+			Force:        !opts.NoForceConflicts,
+			FieldManager: lo.CoalesceOrEmpty(opts.FieldManager, common.DefaultFieldManager),
 		})
 		if applyErr != nil {
 			return fmt.Errorf("server-side apply: %w", applyErr)
@@ -185,8 +185,8 @@ func (c *KubeClient) Create(ctx context.Context, resSpec *spec.ResourceSpec, opt
 		var createErr error
 
 		resultObj, createErr = clientResource.Apply(ctx, resSpec.Name, resSpec.Unstruct, metav1.ApplyOptions{
-			Force:        true,
-			FieldManager: common.DefaultFieldManager,
+			Force:        !opts.NoForceConflicts,
+			FieldManager: lo.CoalesceOrEmpty(opts.FieldManager, common.DefaultFieldManager),
 		})
 		if createErr != nil {
 			return fmt.Errorf("server-side apply: %w", createErr)
@@ -360,7 +360,7 @@ func (c *KubeClient) MergePatch(ctx context.Context, resMeta *spec.ResourceMeta,
 	log.Default.Debug(ctx, "Merge patching resource %q", resMeta.IDHuman())
 
 	resultObj, err := clientResource.Patch(ctx, resMeta.Name, types.MergePatchType, patch, metav1.PatchOptions{
-		FieldManager: common.DefaultFieldManager,
+		FieldManager: lo.CoalesceOrEmpty(opts.FieldManager, common.DefaultFieldManager),
 	})
 	if err != nil {
 		if !IsNotFoundErr(err) {
@@ -518,19 +518,29 @@ type KubeClientGetOptions struct {
 }
 
 type KubeClientCreateOptions struct {
-	DefaultNamespace    string
-	ForceReplicas       *int
+	DefaultNamespace string
+	// Defaults to common.DefaultFieldManager if not set.
+	FieldManager  string
+	ForceReplicas *int
+	// Fail on conflicts with other field managers instead of taking ownership of conflicting fields.
+	NoForceConflicts    bool
 	RetryOnWebhookError bool
 }
 
 type KubeClientApplyOptions struct {
-	DefaultNamespace    string
-	DryRun              bool
+	DefaultNamespace string
+	DryRun           bool
+	// Defaults to common.DefaultFieldManager if not set.
+	FieldManager string
+	// Fail on conflicts with other field managers instead of taking ownership of conflicting fields.
+	NoForceConflicts    bool
 	RetryOnWebhookError bool
 }
 
 type KubeClientMergePatchOptions struct {
 	DefaultNamespace string
+	// Defaults to common.DefaultFieldManager if not set.
+	FieldManager string
 }
 
 type KubeClientDeleteOptions struct {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
+	"os"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -17,8 +19,16 @@ import (
 type KubeConfig struct {
 	LegacyClientConfig clientcmd.ClientConfig
 	Namespace          string
-	RawConfig          *api.Config
-	RestConfig         *rest.Config
+	// NoDiscoveryCache, when true, makes discovery clients cache API resources only in memory
+	// instead of the kubectl cache dir, so that every discovery request reaches the transport.
+	NoDiscoveryCache bool
+	// NoSharedInformers, when true, makes actions start informers of their own instead of using
+	// the ones shared across the process, so that every watch request reaches the transport.
+	NoSharedInformers bool
+	RawConfig         *api.Config
+	RestConfig        *rest.Config
+	// Throttling counts requests throttled by the API server.
+	Throttling *ThrottleCounter
 }
 
 func NewKubeConfig(ctx context.Context, kubeConfigPaths []string, opts KubeConfigOptions) (*KubeConfig, error) {
@@ -99,11 +109,30 @@ func NewKubeConfig(ctx context.Context, kubeConfigPaths []string, opts KubeConfi
 	restConfig.QPS = float32(opts.KubeQPSLimit)
 	restConfig.Burst = opts.KubeBurstLimit
 
+	throttling := &ThrottleCounter{}
+	restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return NewThrottleDetectingTransport(throttling, rt)
+	})
+
+	if opts.KubeRecordDir != "" {
+		if err := os.MkdirAll(opts.KubeRecordDir, 0o700); err != nil {
+			return nil, fmt.Errorf("create kube record dir: %w", err)
+		}
+
+		recorder := NewKubeRecorder(opts.KubeRecordDir)
+		restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return NewRecordingTransport(recorder, rt)
+		})
+	}
+
 	kubeConfig := &KubeConfig{
 		LegacyClientConfig: clientConfig,
 		Namespace:          namespace,
+		NoDiscoveryCache:   opts.KubeRecordDir != "",
+		NoSharedInformers:  opts.KubeRecordDir != "",
 		RawConfig:          &rawConfig,
 		RestConfig:         restConfig,
+		Throttling:         throttling,
 	}
 
 	log.Default.TraceStruct(ctx, kubeConfig, "Constructed KubeConfig:")
@@ -8,6 +8,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/validation"
 )
 
+func IsConflictErr(err error) bool {
+	return err != nil && errors.IsConflict(err)
+}
+
 func IsImmutableErr(err error) bool {
 	return err != nil && errors.IsInvalid(err) && strings.Contains(err.Error(), validation.FieldImmutableErrorMsg)
 }
@@ -24,6 +28,10 @@ func IsNotFoundErr(err error) bool {
 	return err != nil && errors.IsNotFound(err)
 }
 
+func IsTooManyRequestsErr(err error) bool {
+	return err != nil && errors.IsTooManyRequests(err)
+}
+
 func IsTypedObjectErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "failed to create typed patch object")
 }
@@ -0,0 +1,341 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/samber/lo"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	staticfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+var (
+	_ dynamic.Interface                      = (*dryClusterDynamicClient)(nil)
+	_ dynamic.NamespaceableResourceInterface = (*dryClusterNamespaceableResourceClient)(nil)
+	_ dynamic.ResourceInterface              = (*dryClusterResourceClient)(nil)
+)
+
+// Built-in kinds which are not namespaced. Fake discovery marks every kind as namespaced otherwise.
+var clusterScopedGroupKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "ComponentStatus"}:                                              true,
+	{Group: "", Kind: "Namespace"}:                                                    true,
+	{Group: "", Kind: "Node"}:                                                         true,
+	{Group: "", Kind: "PersistentVolume"}:                                             true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:     true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:                 true,
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                             true,
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:                 true,
+	{Group: "certificates.k8s.io", Kind: "ClusterTrustBundle"}:                        true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                       true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:       true,
+	{Group: "internal.apiserver.k8s.io", Kind: "StorageVersion"}:                      true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                                true,
+	{Group: "networking.k8s.io", Kind: "IPAddress"}:                                   true,
+	{Group: "networking.k8s.io", Kind: "ServiceCIDR"}:                                 true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                      true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                         true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                  true,
+	{Group: "resource.k8s.io", Kind: "ResourceClass"}:                                 true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                               true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                      true,
+	{Group: "storage.k8s.io", Kind: "CSINode"}:                                        true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                   true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                               true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttributesClass"}:                          true,
+}
+
+type DryClusterOptions struct {
+	// DefaultNamespace is the namespace for namespaced resources which have no namespace specified.
+	DefaultNamespace string
+	// KubeVersion is the Kubernetes version reported by the cluster.
+	// Defaults to DefaultLocalKubeVersion if not specified.
+	KubeVersion string
+}
+
+// Constructs clients for an in-memory "dry" cluster, seeded with the resources, e.g. taken with
+// `nelm cluster snapshot`. Custom resources are served if their CustomResourceDefinitions are
+// among the resources. Server-side apply is emulated, and dry-run requests never change stored
+// resources. Only the dynamic client emulates server-side apply, while the static client is
+// expected to be used for reading, e.g. by the release storage.
+func NewDryClusterClientFactory(ctx context.Context, resources []*unstructured.Unstructured, opts DryClusterOptions) (*ClientFactory, error) {
+	kube.AddToScheme.Do(func() {
+		lo.Must0(apiextv1.AddToScheme(scheme.Scheme))
+		lo.Must0(apiextv1beta1.AddToScheme(scheme.Scheme))
+	})
+
+	if opts.KubeVersion == "" {
+		opts.KubeVersion = common.DefaultLocalKubeVersion
+	}
+
+	kubeVersion, err := utilversion.ParseGeneric(opts.KubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("parse kube version %q: %w", opts.KubeVersion, err)
+	}
+
+	discoveryClient, err := NewCachedDiscoveryClient()
+	if err != nil {
+		return nil, fmt.Errorf("construct fake cached discovery client: %w", err)
+	}
+
+	discoveryClient.FakedServerVersion = &version.Info{
+		Major:      fmt.Sprint(kubeVersion.Major()),
+		Minor:      fmt.Sprint(kubeVersion.Minor()),
+		GitVersion: "v" + kubeVersion.String(),
+	}
+
+	for _, resourceList := range discoveryClient.Resources {
+		for i, apiResource := range resourceList.APIResources {
+			if clusterScopedGroupKinds[schema.GroupKind{Group: apiResource.Group, Kind: apiResource.Kind}] {
+				resourceList.APIResources[i].Namespaced = false
+			}
+		}
+	}
+
+	customListKinds := map[schema.GroupVersionResource]string{}
+	for _, res := range resources {
+		if res.GroupVersionKind() != apiextv1.SchemeGroupVersion.WithKind("CustomResourceDefinition") {
+			continue
+		}
+
+		crd := &apiextv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(res.UnstructuredContent(), crd); err != nil {
+			return nil, fmt.Errorf("convert CustomResourceDefinition %q: %w", res.GetName(), err)
+		}
+
+		for _, crdVersion := range crd.Spec.Versions {
+			if !crdVersion.Served {
+				continue
+			}
+
+			apiResource := metav1.APIResource{
+				Name:         crd.Spec.Names.Plural,
+				SingularName: crd.Spec.Names.Singular,
+				Namespaced:   crd.Spec.Scope == apiextv1.NamespaceScoped,
+				Group:        crd.Spec.Group,
+				Version:      crdVersion.Name,
+				Kind:         crd.Spec.Names.Kind,
+				ShortNames:   crd.Spec.Names.ShortNames,
+				Verbs:        []string{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"},
+			}
+
+			addDiscoveryResource(discoveryClient, apiResource)
+
+			customListKinds[schema.GroupVersionResource{
+				Group:    crd.Spec.Group,
+				Version:  crdVersion.Name,
+				Resource: crd.Spec.Names.Plural,
+			}] = lo.CoalesceOrEmpty(crd.Spec.Names.ListKind, crd.Spec.Names.Kind+"List")
+		}
+	}
+
+	mapper := reflect.ValueOf(kube.NewKubeMapper(ctx, discoveryClient)).Interface().(meta.ResettableRESTMapper)
+	staticClient := NewStaticClient(mapper)
+	dynamicClient := newDryClusterDynamicClient(mapper, customListKinds)
+
+	if err := seedDryCluster(ctx, resources, staticClient, dynamicClient.FakeDynamicClient, mapper, opts.DefaultNamespace); err != nil {
+		return nil, fmt.Errorf("seed dry cluster: %w", err)
+	}
+
+	kubeClient := kube.NewKubeClient(staticClient, dynamicClient, discoveryClient, mapper)
+
+	clientFactory := &ClientFactory{
+		discoveryClient: discoveryClient,
+		dynamicClient:   dynamicClient,
+		kubeClient:      kubeClient,
+		mapper:          mapper,
+		staticClient:    staticClient,
+	}
+
+	return clientFactory, nil
+}
+
+func addDiscoveryResource(discoveryClient *CachedDiscoveryClient, apiResource metav1.APIResource) {
+	groupVersion := schema.GroupVersion{Group: apiResource.Group, Version: apiResource.Version}.String()
+
+	resourceList, found := lo.Find(discoveryClient.Resources, func(list *metav1.APIResourceList) bool {
+		return list.GroupVersion == groupVersion
+	})
+	if !found {
+		resourceList = &metav1.APIResourceList{
+			GroupVersion: groupVersion,
+		}
+
+		discoveryClient.Resources = append(discoveryClient.Resources, resourceList)
+	}
+
+	resourceList.APIResources = append(resourceList.APIResources, apiResource)
+}
+
+// Resources are added to both trackers, since static and dynamic fake clients don't share them.
+// Only kinds known to the scheme can be added to the static tracker.
+func seedDryCluster(ctx context.Context, resources []*unstructured.Unstructured, staticClient *staticfake.Clientset, dynamicClient *dynamicfake.FakeDynamicClient, mapper meta.ResettableRESTMapper, defaultNamespace string) error {
+	for _, res := range resources {
+		gvk := res.GroupVersionKind()
+
+		gvr, namespaced, err := spec.GVKtoGVR(gvk, mapper)
+		if kube.IsNoSuchKindErr(err) {
+			// E.g. aggregated APIs or versions unknown to the scheme, which snapshots might have.
+			log.Default.Warn(ctx, "Skipping resource %q of unknown kind in dry cluster", spec.IDHuman(res.GetName(), res.GetNamespace(), gvk.Group, gvk.Kind))
+
+			continue
+		} else if err != nil {
+			return fmt.Errorf("map gvk to gvr for resource %q: %w", spec.IDHuman(res.GetName(), res.GetNamespace(), gvk.Group, gvk.Kind), err)
+		}
+
+		obj := res.DeepCopy()
+
+		var namespace string
+		if namespaced {
+			namespace = lo.CoalesceOrEmpty(obj.GetNamespace(), defaultNamespace)
+		}
+
+		obj.SetNamespace(namespace)
+
+		if err := dynamicClient.Tracker().Create(gvr, obj, namespace); err != nil {
+			return fmt.Errorf("add resource %q: %w", spec.IDHuman(obj.GetName(), namespace, gvk.Group, gvk.Kind), err)
+		}
+
+		if !scheme.Scheme.Recognizes(gvk) {
+			continue
+		}
+
+		typedObj, err := scheme.Scheme.New(gvk)
+		if err != nil {
+			return fmt.Errorf("construct typed object for resource %q: %w", spec.IDHuman(obj.GetName(), namespace, gvk.Group, gvk.Kind), err)
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), typedObj); err != nil {
+			return fmt.Errorf("convert resource %q to typed object: %w", spec.IDHuman(obj.GetName(), namespace, gvk.Group, gvk.Kind), err)
+		}
+
+		if err := staticClient.Tracker().Create(gvr, typedObj, namespace); err != nil {
+			return fmt.Errorf("add typed resource %q: %w", spec.IDHuman(obj.GetName(), namespace, gvk.Group, gvk.Kind), err)
+		}
+	}
+
+	return nil
+}
+
+type dryClusterDynamicClient struct {
+	*dynamicfake.FakeDynamicClient
+
+	applier *serverSideApplier
+}
+
+func newDryClusterDynamicClient(mapper meta.ResettableRESTMapper, customListKinds map[schema.GroupVersionResource]string) *dryClusterDynamicClient {
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme.Scheme, customListKinds)
+	dynClient.PrependReactor("*", "*", prepareReaction(dynClient.Tracker(), mapper))
+
+	return &dryClusterDynamicClient{
+		FakeDynamicClient: dynClient,
+		applier:           newServerSideApplier(dynClient.Tracker()),
+	}
+}
+
+func (c *dryClusterDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resource := c.FakeDynamicClient.Resource(gvr)
+
+	return &dryClusterNamespaceableResourceClient{
+		dryClusterResourceClient: &dryClusterResourceClient{
+			ResourceInterface: resource,
+			applier:           c.applier,
+			gvr:               gvr,
+		},
+		resource: resource,
+	}
+}
+
+type dryClusterNamespaceableResourceClient struct {
+	*dryClusterResourceClient
+
+	resource dynamic.NamespaceableResourceInterface
+}
+
+func (c *dryClusterNamespaceableResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &dryClusterResourceClient{
+		ResourceInterface: c.resource.Namespace(namespace),
+		applier:           c.applier,
+		gvr:               c.gvr,
+		namespace:         namespace,
+	}
+}
+
+// Emulates server-side apply and makes sure dry-run requests don't change stored resources, which
+// the fake dynamic client doesn't care about.
+type dryClusterResourceClient struct {
+	dynamic.ResourceInterface
+
+	applier   *serverSideApplier
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func (c *dryClusterResourceClient) Apply(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, apierrors.NewMethodNotSupported(c.gvr.GroupResource(), "apply to subresources")
+	}
+
+	return c.applier.Apply(c.gvr, c.namespace, name, obj, options)
+}
+
+func (c *dryClusterResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(options.DryRun) == 0 {
+		return c.ResourceInterface.Create(ctx, obj, options, subresources...)
+	}
+
+	if _, err := c.ResourceInterface.Get(ctx, obj.GetName(), metav1.GetOptions{}); err == nil {
+		return nil, apierrors.NewAlreadyExists(c.gvr.GroupResource(), obj.GetName())
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	return obj.DeepCopy(), nil
+}
+
+func (c *dryClusterResourceClient) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	if len(options.DryRun) == 0 {
+		return c.ResourceInterface.Delete(ctx, name, options, subresources...)
+	}
+
+	_, err := c.ResourceInterface.Get(ctx, name, metav1.GetOptions{})
+
+	return err
+}
+
+func (c *dryClusterResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(options.DryRun) > 0 {
+		return nil, apierrors.NewMethodNotSupported(c.gvr.GroupResource(), "dry-run update")
+	}
+
+	return c.ResourceInterface.Update(ctx, obj, options, subresources...)
+}
+
+func (c *dryClusterResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(options.DryRun) > 0 {
+		return nil, apierrors.NewMethodNotSupported(c.gvr.GroupResource(), "dry-run patch")
+	}
+
+	return c.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+}
@@ -0,0 +1,127 @@
+package fake_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/nelm/pkg/kube/fake"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+func TestDryClusterClientFactory(t *testing.T) {
+	ctx := context.Background()
+
+	clientFactory, err := fake.NewDryClusterClientFactory(ctx, []*unstructured.Unstructured{
+		newUnstructured(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  a: "1"
+  b: "2"
+`),
+		newUnstructured(t, `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Widget
+    plural: widgets
+    singular: widget
+  versions:
+    - name: v1
+      served: true
+      storage: true
+`),
+		newUnstructured(t, `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: app
+spec:
+  size: 1
+`),
+	}, fake.DryClusterOptions{
+		DefaultNamespace: "app",
+		KubeVersion:      "1.30.2",
+	})
+	require.NoError(t, err)
+
+	serverVersion, err := clientFactory.Discovery().ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.30.2", serverVersion.GitVersion)
+
+	nsGVR, namespaced, err := spec.GVKtoGVR(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, clientFactory.Mapper())
+	require.NoError(t, err)
+	assert.Equal(t, "namespaces", nsGVR.Resource)
+	assert.False(t, namespaced)
+
+	configMaps := clientFactory.Dynamic().Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("app")
+
+	dryApplied, err := configMaps.Apply(ctx, "config", newUnstructured(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  a: "5"
+`), metav1.ApplyOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "nelm", Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "5", "b": "2"}, dryApplied.Object["data"])
+	assert.NotEmpty(t, dryApplied.GetManagedFields())
+
+	stored, err := configMaps.Get(ctx, "config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, stored.Object["data"])
+
+	_, err = configMaps.Apply(ctx, "config", newUnstructured(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  a: "5"
+`), metav1.ApplyOptions{FieldManager: "nelm", Force: true})
+	require.NoError(t, err)
+
+	_, err = configMaps.Apply(ctx, "config", newUnstructured(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  a: "6"
+`), metav1.ApplyOptions{FieldManager: "other"})
+	require.ErrorContains(t, err, "conflict")
+
+	// Seeded resources are available to the static client too, e.g. for the release storage.
+	configMap, err := clientFactory.Static().CoreV1().ConfigMaps("app").Get(ctx, "config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1", configMap.Data["a"])
+
+	widget, err := clientFactory.Dynamic().Resource(schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}).Namespace("app").Get(ctx, "widget", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Widget", widget.GetKind())
+}
+
+func newUnstructured(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &obj.Object))
+
+	return obj
+}
@@ -66,8 +66,9 @@ func (f *ClientFactory) KubeClient() kube.KubeClienter {
 	return f.kubeClient
 }
 
+// Fake clients don't connect to any cluster, so there is no kube config.
 func (f *ClientFactory) KubeConfig() *kube.KubeConfig {
-	panic("not implemented yet")
+	return nil
 }
 
 func (f *ClientFactory) LegacyClientGetter() *kube.LegacyClientGetter {
@@ -0,0 +1,162 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/werf/kubedog/pkg/trackers/dyntracker/util"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const replayServerAddress = "https://kube-replay.invalid"
+
+var _ http.RoundTripper = (*ReplayTransport)(nil)
+
+// Serves requests to the Kubernetes API from exchanges recorded by kube.RecordingTransport.
+// Requests are matched by method, path and query. Exchanges matching the same request are served
+// in the recorded order, and the last one is served again for any further requests, e.g. when
+// polling takes longer than during recording.
+type ReplayTransport struct {
+	exchanges *util.Concurrent[map[string][]*kube.KubeRecordExchange]
+}
+
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+kube.KubeRecordFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("glob recorded exchanges: %w", err)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no recorded exchanges found in %q", dir)
+	}
+
+	// File names are sorted in the order of recording.
+	var exchanges []*kube.KubeRecordExchange
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read recorded exchange %q: %w", path, err)
+		}
+
+		exchange := &kube.KubeRecordExchange{}
+		if err := json.Unmarshal(data, exchange); err != nil {
+			return nil, fmt.Errorf("unmarshal recorded exchange %q: %w", path, err)
+		}
+
+		exchanges = append(exchanges, exchange)
+	}
+
+	exchangesByKey := map[string][]*kube.KubeRecordExchange{}
+	for _, exchange := range exchanges {
+		key := replayKey(exchange.Method, exchange.Path, exchange.Query)
+		exchangesByKey[key] = append(exchangesByKey[key], exchange)
+	}
+
+	return &ReplayTransport{
+		exchanges: util.NewConcurrent(exchangesByKey),
+	}, nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	key := replayKey(req.Method, req.URL.Path, req.URL.RawQuery)
+
+	var exchange *kube.KubeRecordExchange
+	t.exchanges.RWTransaction(func(exchanges map[string][]*kube.KubeRecordExchange) {
+		queue := exchanges[key]
+		if len(queue) == 0 {
+			return
+		}
+
+		exchange = queue[0]
+		if len(queue) > 1 {
+			exchanges[key] = queue[1:]
+		}
+	})
+
+	if exchange == nil {
+		return nil, fmt.Errorf("no recorded response for request %q", key)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{exchange.ContentType}},
+		Body:          io.NopCloser(strings.NewReader(exchange.ResponseBody)),
+		ContentLength: int64(len(exchange.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Constructs KubeConfig which makes Kubernetes clients to be served from the exchanges recorded
+// into the directory, without any cluster connection.
+func NewReplayKubeConfig(ctx context.Context, dir, namespace string) (*kube.KubeConfig, error) {
+	transport, err := NewReplayTransport(dir)
+	if err != nil {
+		return nil, fmt.Errorf("construct replay transport: %w", err)
+	}
+
+	rawConfig := api.NewConfig()
+	rawConfig.Clusters["replay"] = &api.Cluster{Server: replayServerAddress}
+	rawConfig.AuthInfos["replay"] = &api.AuthInfo{}
+	rawConfig.Contexts["replay"] = &api.Context{
+		Cluster:   "replay",
+		AuthInfo:  "replay",
+		Namespace: namespace,
+	}
+	rawConfig.CurrentContext = "replay"
+
+	kubeConfig := &kube.KubeConfig{
+		LegacyClientConfig: clientcmd.NewDefaultClientConfig(*rawConfig, &clientcmd.ConfigOverrides{}),
+		Namespace:          namespace,
+		NoDiscoveryCache:   true,
+		NoSharedInformers:  true,
+		RawConfig:          rawConfig,
+		RestConfig: &rest.Config{
+			Host:      replayServerAddress,
+			Transport: transport,
+			// Recorded responses are served instantly, no need to throttle.
+			QPS:   1000,
+			Burst: 1000,
+		},
+	}
+
+	log.Default.Debug(ctx, "Replaying Kubernetes API responses from %q", dir)
+
+	return kubeConfig, nil
+}
+
+// Watch timeouts are randomized by clients, so they are ignored when matching requests.
+func replayKey(method, path, rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return method + " " + path + "?" + rawQuery
+	}
+
+	query.Del("timeout")
+	query.Del("timeoutSeconds")
+
+	if len(query) == 0 {
+		return method + " " + path
+	}
+
+	return method + " " + path + "?" + query.Encode()
+}
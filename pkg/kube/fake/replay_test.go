@@ -0,0 +1,193 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	helmdriver "github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/kube/fake"
+)
+
+func TestReplayTransport(t *testing.T) {
+	responses := []string{
+		`{"kind":"Secret","apiVersion":"v1","metadata":{"name":"creds"},"data":{"password":"c2VjcmV0"}}`,
+		`{"kind":"SecretList","apiVersion":"v1","items":[{"metadata":{"name":"release"},"type":"helm.sh/release.v1","data":{"release":"cmVsZWFzZQ=="}}]}`,
+	}
+
+	var requestNum int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, responses[requestNum])
+		requestNum++
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recordingClient := &http.Client{Transport: kube.NewRecordingTransport(kube.NewKubeRecorder(dir), http.DefaultTransport)}
+
+	getBody(t, recordingClient, server.URL+"/api/v1/namespaces/app/secrets/creds")
+	getBody(t, recordingClient, server.URL+"/api/v1/namespaces/app/secrets?labelSelector=owner%3Dhelm&timeoutSeconds=300")
+
+	replayTransport, err := fake.NewReplayTransport(dir)
+	require.NoError(t, err)
+
+	replayClient := &http.Client{Transport: replayTransport}
+
+	secret := getBody(t, replayClient, "https://kube-replay.invalid/api/v1/namespaces/app/secrets/creds")
+	assert.NotContains(t, secret, "c2VjcmV0")
+	assert.Contains(t, secret, `"password":"PHJlZGFjdGVkPg=="`)
+
+	// Release data which can't be decoded is redacted entirely.
+	releases := getBody(t, replayClient, "https://kube-replay.invalid/api/v1/namespaces/app/secrets?labelSelector=owner%3Dhelm&timeoutSeconds=17")
+	assert.NotContains(t, releases, "cmVsZWFzZQ==")
+	assert.Contains(t, releases, `"release":"PHJlZGFjdGVkPg=="`)
+
+	// The last recorded response is served again.
+	assert.Equal(t, secret, getBody(t, replayClient, "https://kube-replay.invalid/api/v1/namespaces/app/secrets/creds"))
+
+	_, err = replayClient.Get("https://kube-replay.invalid/api/v1/namespaces/app/secrets/other")
+	require.ErrorContains(t, err, "no recorded response")
+}
+
+func TestReplayTransportMultipleClients(t *testing.T) {
+	var requestNum int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestNum++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":"config","resourceVersion":"%d"}}`, requestNum)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := kube.NewKubeRecorder(dir)
+	firstClient := &http.Client{Transport: kube.NewRecordingTransport(recorder, http.DefaultTransport)}
+	secondClient := &http.Client{Transport: kube.NewRecordingTransport(recorder, http.DefaultTransport)}
+
+	url := "/api/v1/namespaces/app/configmaps/config"
+
+	for _, client := range []*http.Client{firstClient, secondClient, firstClient} {
+		getBody(t, client, server.URL+url)
+	}
+
+	replayTransport, err := fake.NewReplayTransport(dir)
+	require.NoError(t, err)
+
+	replayClient := &http.Client{Transport: replayTransport}
+
+	// Responses recorded through different clients are served in the order they were recorded.
+	for i := 1; i <= 3; i++ {
+		assert.Contains(t, getBody(t, replayClient, "https://kube-replay.invalid"+url), fmt.Sprintf(`"resourceVersion":"%d"`, i))
+	}
+}
+
+func TestReplayTransportRedactsReleases(t *testing.T) {
+	ctx := context.Background()
+
+	rel := &helmrelease.Release{
+		Name:      "app",
+		Namespace: "app",
+		Version:   1,
+		Info:      &helmrelease.Info{Status: helmrelease.StatusDeployed},
+		Config:    map[string]interface{}{"password": "values-secret", "replicas": 3},
+		Manifest: `---
+# Source: app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: manifest-secret
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: value
+`,
+	}
+
+	clientset := k8sfake.NewSimpleClientset()
+	require.NoError(t, helmdriver.NewSecrets(clientset.CoreV1().Secrets("app")).Create("sh.helm.release.v1.app.v1", rel))
+	require.NoError(t, helmdriver.NewConfigMaps(clientset.CoreV1().ConfigMaps("app")).Create("sh.helm.release.v1.app.v1", rel))
+
+	releaseSecret, err := clientset.CoreV1().Secrets("app").Get(ctx, "sh.helm.release.v1.app.v1", metav1.GetOptions{})
+	require.NoError(t, err)
+	releaseSecret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+
+	releaseConfigMap, err := clientset.CoreV1().ConfigMaps("app").Get(ctx, "sh.helm.release.v1.app.v1", metav1.GetOptions{})
+	require.NoError(t, err)
+	releaseConfigMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+
+	responses := [][]byte{lo.Must(json.Marshal(releaseSecret)), lo.Must(json.Marshal(releaseConfigMap))}
+
+	var requestNum int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(responses[requestNum])
+		requestNum++
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recordingClient := &http.Client{Transport: kube.NewRecordingTransport(kube.NewKubeRecorder(dir), http.DefaultTransport)}
+
+	getBody(t, recordingClient, server.URL+"/api/v1/namespaces/app/secrets/sh.helm.release.v1.app.v1")
+	getBody(t, recordingClient, server.URL+"/api/v1/namespaces/app/configmaps/sh.helm.release.v1.app.v1")
+
+	replayTransport, err := fake.NewReplayTransport(dir)
+	require.NoError(t, err)
+
+	replayClient := &http.Client{Transport: replayTransport}
+
+	var replayedSecret corev1.Secret
+	require.NoError(t, json.Unmarshal([]byte(getBody(t, replayClient, "https://kube-replay.invalid/api/v1/namespaces/app/secrets/sh.helm.release.v1.app.v1")), &replayedSecret))
+
+	var replayedConfigMap corev1.ConfigMap
+	require.NoError(t, json.Unmarshal([]byte(getBody(t, replayClient, "https://kube-replay.invalid/api/v1/namespaces/app/configmaps/sh.helm.release.v1.app.v1")), &replayedConfigMap))
+
+	replayedClientset := k8sfake.NewSimpleClientset(&replayedSecret, &replayedConfigMap)
+
+	replayedSecretRelease, err := helmdriver.NewSecrets(replayedClientset.CoreV1().Secrets("app")).Get("sh.helm.release.v1.app.v1")
+	require.NoError(t, err)
+
+	replayedConfigMapRelease, err := helmdriver.NewConfigMaps(replayedClientset.CoreV1().ConfigMaps("app")).Get("sh.helm.release.v1.app.v1")
+	require.NoError(t, err)
+
+	for _, replayedRelease := range []*helmrelease.Release{replayedSecretRelease, replayedConfigMapRelease} {
+		assert.NotContains(t, replayedRelease.Manifest, "manifest-secret")
+		assert.Contains(t, replayedRelease.Manifest, "# Source: app/templates/secret.yaml")
+		assert.Contains(t, replayedRelease.Manifest, "password: <redacted>")
+		assert.Contains(t, replayedRelease.Manifest, "key: value")
+		assert.Equal(t, kube.KubeRecordRedacted, replayedRelease.Config["password"])
+		assert.EqualValues(t, 3, replayedRelease.Config["replicas"])
+	}
+}
+
+func getBody(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return string(body)
+}
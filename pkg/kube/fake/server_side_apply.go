@@ -0,0 +1,160 @@
+package fake
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/managedfields"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/testing"
+)
+
+var (
+	_ runtime.ObjectConvertor = unstructuredObjectConvertor{}
+	_ runtime.ObjectCreater   = unstructuredObjectCreater{}
+	_ runtime.ObjectDefaulter = unstructuredObjectDefaulter{}
+)
+
+// Emulates server-side apply of the API server on top of the object tracker: applied objects are
+// merged with live ones, managed fields are tracked and conflicts are detected. There are no
+// OpenAPI schemas, so the structure of objects is deduced, which makes all lists atomic: an applied
+// list replaces the live one as a whole instead of being merged by keys.
+type serverSideApplier struct {
+	tracker       testing.ObjectTracker
+	typeConverter managedfields.TypeConverter
+}
+
+func newServerSideApplier(tracker testing.ObjectTracker) *serverSideApplier {
+	return &serverSideApplier{
+		tracker:       tracker,
+		typeConverter: managedfields.NewDeducedTypeConverter(),
+	}
+}
+
+// Not safe to call concurrently for the same object. KubeClient already serializes requests for
+// the same resource.
+func (a *serverSideApplier) Apply(gvr schema.GroupVersionResource, namespace, name string, obj *unstructured.Unstructured, opts metav1.ApplyOptions) (*unstructured.Unstructured, error) {
+	if opts.FieldManager == "" {
+		return nil, apierrors.NewBadRequest("fieldManager is required for apply requests")
+	}
+
+	appliedObj := obj.DeepCopy()
+	appliedObj.SetName(name)
+	appliedObj.SetNamespace(namespace)
+
+	gvk := appliedObj.GroupVersionKind()
+
+	fieldManager, err := managedfields.NewDefaultCRDFieldManager(a.typeConverter, unstructuredObjectConvertor{}, unstructuredObjectDefaulter{}, unstructuredObjectCreater{}, gvk, gvk.GroupVersion(), "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("construct field manager: %w", err)
+	}
+
+	liveObj, err := a.get(gvr, namespace, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("get live object: %w", err)
+	}
+
+	create := liveObj == nil
+	if create {
+		liveObj = &unstructured.Unstructured{}
+		liveObj.SetGroupVersionKind(gvk)
+	}
+
+	mergedObj, err := fieldManager.Apply(liveObj, appliedObj, opts.FieldManager, opts.Force)
+	if err != nil {
+		return nil, fmt.Errorf("merge applied object: %w", err)
+	}
+
+	resultObj := mergedObj.(*unstructured.Unstructured)
+
+	if create {
+		resultObj.SetUID(uuid.NewUUID())
+		resultObj.SetCreationTimestamp(metav1.Now())
+		resultObj.SetGeneration(1)
+		resultObj.SetResourceVersion("1")
+	} else if !equality.Semantic.DeepEqual(liveObj.Object, resultObj.Object) {
+		if !equality.Semantic.DeepEqual(withoutMetadataAndStatus(liveObj), withoutMetadataAndStatus(resultObj)) {
+			resultObj.SetGeneration(liveObj.GetGeneration() + 1)
+		}
+
+		resourceVersion, _ := strconv.ParseInt(liveObj.GetResourceVersion(), 10, 64)
+		resultObj.SetResourceVersion(strconv.FormatInt(resourceVersion+1, 10))
+	}
+
+	if len(opts.DryRun) > 0 {
+		return resultObj, nil
+	}
+
+	if create {
+		err = a.tracker.Create(gvr, resultObj, namespace)
+	} else {
+		err = a.tracker.Update(gvr, resultObj, namespace)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("store applied object: %w", err)
+	}
+
+	return resultObj.DeepCopy(), nil
+}
+
+func (a *serverSideApplier) get(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	obj, err := a.tracker.Get(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if unstruct, ok := obj.(*unstructured.Unstructured); ok {
+		return unstruct, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("convert object to unstructured: %w", err)
+	}
+
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+func withoutMetadataAndStatus(obj *unstructured.Unstructured) map[string]interface{} {
+	content := obj.DeepCopy().Object
+	delete(content, "metadata")
+	delete(content, "status")
+
+	return content
+}
+
+// Objects are never converted, since only the applied version is served.
+type unstructuredObjectConvertor struct{}
+
+func (unstructuredObjectConvertor) Convert(in, out, context interface{}) error {
+	return fmt.Errorf("conversion is not supported")
+}
+
+func (unstructuredObjectConvertor) ConvertToVersion(in runtime.Object, _ runtime.GroupVersioner) (runtime.Object, error) {
+	return in, nil
+}
+
+func (unstructuredObjectConvertor) ConvertFieldLabel(_ schema.GroupVersionKind, _, _ string) (string, string, error) {
+	return "", "", fmt.Errorf("field label conversion is not supported")
+}
+
+type unstructuredObjectCreater struct{}
+
+func (unstructuredObjectCreater) New(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetGroupVersionKind(gvk)
+
+	return obj, nil
+}
+
+// There are no defaulting webhooks or built-in defaults, so objects are stored as applied.
+type unstructuredObjectDefaulter struct{}
+
+func (unstructuredObjectDefaulter) Default(_ runtime.Object) {}
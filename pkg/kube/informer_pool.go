@@ -0,0 +1,256 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/werf/kubedog/pkg/informer"
+	kdutil "github.com/werf/kubedog/pkg/trackers/dyntracker/util"
+)
+
+// Shared by all actions running in the process.
+var DefaultInformerPool = NewInformerPool()
+
+// Returns the informer factory to track resources of the kind in the namespace. The returned
+// function must be called once the tracking is done.
+type InformerFactoryGetter func(gvk schema.GroupVersionKind, namespace string) (factory *kdutil.Concurrent[*informer.InformerFactory], release func(), err error)
+
+// Shares informers between actions running concurrently in one process, e.g. when werf deploys
+// many releases in parallel. Trackers of resources of the same GVR in the same namespace, working
+// with the same cluster as the same user, get the same informer factory, so that these resources
+// are watched only once, no matter how many actions track them. Every informer factory is
+// reference-counted: its informers are stopped once the last tracker using it is done. Watch errors
+// of an informer factory are sent only to the actions tracking resources with it.
+type InformerPool struct {
+	entries *kdutil.Concurrent[map[string]*informerPoolEntry]
+}
+
+type informerPoolEntry struct {
+	factory    *kdutil.Concurrent[*informer.InformerFactory]
+	holders    map[int]chan<- error
+	lastHolder int
+	stopCh     chan struct{}
+	watchErrCh chan error
+}
+
+func NewInformerPool() *InformerPool {
+	return &InformerPool{
+		entries: kdutil.NewConcurrent(make(map[string]*informerPoolEntry)),
+	}
+}
+
+// Returns the InformerFactoryGetter for an action working with the cluster of the clientFactory.
+// Watch errors of the informers used by the action are sent to watchErrCh. The returned function
+// must be called once the action is done, it releases everything the action didn't release.
+// Clients without a kube config or with KubeConfig.NoSharedInformers get informers of their own.
+func (p *InformerPool) InformerFactoryGetter(clientFactory ClientFactorier, watchErrCh chan<- error) (getInformerFactory InformerFactoryGetter, releaseAll func()) {
+	pool := p
+
+	var (
+		clusterKey       string
+		newDynamicClient func() (dynamic.Interface, error)
+	)
+
+	if kubeConfig := clientFactory.KubeConfig(); kubeConfig != nil && !kubeConfig.NoSharedInformers {
+		clusterKey = informerPoolClusterKey(kubeConfig.RestConfig)
+		newDynamicClient = func() (dynamic.Interface, error) {
+			return newInformerPoolDynamicClient(kubeConfig.RestConfig)
+		}
+	} else {
+		pool = NewInformerPool()
+		newDynamicClient = func() (dynamic.Interface, error) {
+			return clientFactory.Dynamic(), nil
+		}
+	}
+
+	var (
+		releases      []func()
+		releasesMutex sync.Mutex
+	)
+
+	getInformerFactory = func(gvk schema.GroupVersionKind, namespace string) (*kdutil.Concurrent[*informer.InformerFactory], func(), error) {
+		key := strings.Join([]string{clusterKey, informerPoolResource(clientFactory.Mapper(), gvk), namespace}, "|")
+
+		factory, release, err := pool.acquire(key, newDynamicClient, watchErrCh)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		releasesMutex.Lock()
+		releases = append(releases, release)
+		releasesMutex.Unlock()
+
+		return factory, release, nil
+	}
+
+	releaseAll = func() {
+		releasesMutex.Lock()
+		defer releasesMutex.Unlock()
+
+		for _, release := range releases {
+			release()
+		}
+
+		releases = nil
+	}
+
+	return getInformerFactory, releaseAll
+}
+
+func (p *InformerPool) acquire(key string, newDynamicClient func() (dynamic.Interface, error), watchErrCh chan<- error) (*kdutil.Concurrent[*informer.InformerFactory], func(), error) {
+	var (
+		entry    *informerPoolEntry
+		holderID int
+		err      error
+	)
+
+	p.entries.RWTransaction(func(entries map[string]*informerPoolEntry) {
+		entry = entries[key]
+		if entry == nil {
+			var dynamicClient dynamic.Interface
+			if dynamicClient, err = newDynamicClient(); err != nil {
+				return
+			}
+
+			entry = p.newEntry(dynamicClient)
+			entries[key] = entry
+		}
+
+		entry.lastHolder++
+		holderID = entry.lastHolder
+		entry.holders[holderID] = watchErrCh
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("construct dynamic client for informers: %w", err)
+	}
+
+	return entry.factory, func() {
+		p.release(key, entry, holderID)
+	}, nil
+}
+
+func (p *InformerPool) release(key string, entry *informerPoolEntry, holderID int) {
+	p.entries.RWTransaction(func(entries map[string]*informerPoolEntry) {
+		if _, found := entry.holders[holderID]; !found {
+			return
+		}
+
+		delete(entry.holders, holderID)
+
+		if len(entry.holders) > 0 {
+			return
+		}
+
+		if entries[key] == entry {
+			delete(entries, key)
+		}
+
+		close(entry.stopCh)
+	})
+}
+
+func (p *InformerPool) newEntry(dynamicClient dynamic.Interface) *informerPoolEntry {
+	entry := &informerPoolEntry{
+		holders:    make(map[int]chan<- error),
+		stopCh:     make(chan struct{}),
+		watchErrCh: make(chan error, 1),
+	}
+
+	entry.factory = informer.NewConcurrentInformerFactory(entry.stopCh, entry.watchErrCh, dynamicClient, informer.ConcurrentInformerFactoryOptions{})
+
+	go p.broadcastWatchErrors(entry)
+
+	return entry
+}
+
+func (p *InformerPool) broadcastWatchErrors(entry *informerPoolEntry) {
+	for {
+		select {
+		case err := <-entry.watchErrCh:
+			var holderErrChs []chan<- error
+			p.entries.RTransaction(func(_ map[string]*informerPoolEntry) {
+				holderErrChs = lo.Uniq(lo.Values(entry.holders))
+			})
+
+			for _, holderErrCh := range holderErrChs {
+				select {
+				case holderErrCh <- err:
+				default:
+				}
+			}
+		case <-entry.stopCh:
+			return
+		}
+	}
+}
+
+// The pool owns the clients of its informers, since they outlive the action that started them.
+// Transport wrappers of the action, like its ThrottleCounter, are not inherited.
+func newInformerPoolDynamicClient(restConfig *rest.Config) (dynamic.Interface, error) {
+	config := rest.CopyConfig(restConfig)
+	config.WrapTransport = nil
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("new client for config: %w", err)
+	}
+
+	return client, nil
+}
+
+// Falls back to the GVK if it can't be mapped, e.g. when the CRD is not installed yet.
+func informerPoolResource(mapper meta.RESTMapper, gvk schema.GroupVersionKind) string {
+	if mapper != nil {
+		if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return mapping.Resource.String()
+		}
+	}
+
+	return gvk.String()
+}
+
+// Actions can share informers only if they talk to the same API server as the same user, since
+// what can be watched depends on RBAC.
+func informerPoolClusterKey(restConfig *rest.Config) string {
+	var execCommand string
+	if restConfig.ExecProvider != nil {
+		execCommand = strings.Join(append([]string{restConfig.ExecProvider.Command}, restConfig.ExecProvider.Args...), " ")
+	}
+
+	var authProvider string
+	if restConfig.AuthProvider != nil {
+		authProvider = restConfig.AuthProvider.Name
+	}
+
+	hash := sha256.New()
+	for _, field := range []string{
+		restConfig.Host,
+		restConfig.APIPath,
+		restConfig.Username,
+		restConfig.Password,
+		restConfig.BearerToken,
+		restConfig.BearerTokenFile,
+		restConfig.Impersonate.UserName,
+		restConfig.Impersonate.UID,
+		strings.Join(restConfig.Impersonate.Groups, ","),
+		restConfig.CertFile,
+		restConfig.KeyFile,
+		string(restConfig.CertData),
+		string(restConfig.KeyData),
+		authProvider,
+		execCommand,
+	} {
+		fmt.Fprintf(hash, "%d:%s", len(field), field)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,183 @@
+package kube
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/werf/kubedog/pkg/informer"
+	kdutil "github.com/werf/kubedog/pkg/trackers/dyntracker/util"
+)
+
+func TestInformerPool(t *testing.T) {
+	pool := NewInformerPool()
+
+	deployments := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMaps := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	var wrappedTransports int
+
+	restConfig := &rest.Config{
+		Host: "https://a.example.com",
+		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+			wrappedTransports++
+			return rt
+		},
+	}
+
+	watchErrChA := make(chan error, 1)
+	getInformerFactoryA, releaseAllA := pool.InformerFactoryGetter(newInformerPoolTestClientFactory(&KubeConfig{RestConfig: restConfig}), watchErrChA)
+
+	watchErrChB := make(chan error, 1)
+	getInformerFactoryB, releaseAllB := pool.InformerFactoryGetter(newInformerPoolTestClientFactory(&KubeConfig{RestConfig: rest.CopyConfig(restConfig)}), watchErrChB)
+
+	getInformerFactoryOtherUser, releaseAllOtherUser := pool.InformerFactoryGetter(newInformerPoolTestClientFactory(&KubeConfig{RestConfig: &rest.Config{Host: "https://a.example.com", BearerToken: "other"}}), make(chan error, 1))
+	defer releaseAllOtherUser()
+
+	factoryA, releaseA, err := getInformerFactoryA(deployments, "ns-a")
+	require.NoError(t, err)
+
+	factoryB, releaseB, err := getInformerFactoryB(deployments, "ns-a")
+	require.NoError(t, err)
+
+	factoryBOtherNamespace, releaseBOtherNamespace, err := getInformerFactoryB(deployments, "ns-b")
+	require.NoError(t, err)
+
+	factoryBOtherResource, _, err := getInformerFactoryB(configMaps, "ns-a")
+	require.NoError(t, err)
+
+	factoryOtherUser, _, err := getInformerFactoryOtherUser(deployments, "ns-a")
+	require.NoError(t, err)
+
+	assert.Same(t, factoryA, factoryB)
+	assert.NotSame(t, factoryA, factoryBOtherNamespace)
+	assert.NotSame(t, factoryA, factoryBOtherResource)
+	assert.NotSame(t, factoryA, factoryOtherUser)
+	assert.Equal(t, 5, pool.holders())
+	assert.Zero(t, wrappedTransports, "informers must use clients of the pool")
+
+	t.Run("watch errors are sent only to holders of the factory", func(t *testing.T) {
+		pool.entryWatchErrCh(factoryBOtherNamespace) <- errors.New("forbidden")
+
+		select {
+		case err := <-watchErrChB:
+			assert.EqualError(t, err, "forbidden")
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch error not received")
+		}
+
+		select {
+		case err := <-watchErrChA:
+			t.Fatalf("unexpected watch error: %s", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("factory is stopped after its last holder releases it", func(t *testing.T) {
+		releaseBOtherNamespace()
+		releaseBOtherNamespace()
+		assert.Equal(t, 4, pool.holders())
+
+		factory, release, err := getInformerFactoryA(deployments, "ns-b")
+		require.NoError(t, err)
+		assert.NotSame(t, factoryBOtherNamespace, factory)
+
+		release()
+
+		releaseA()
+		assert.Equal(t, 3, pool.holders())
+
+		factory, release, err = getInformerFactoryA(deployments, "ns-a")
+		require.NoError(t, err)
+		assert.Same(t, factoryB, factory, "still held by the other action")
+
+		release()
+		releaseB()
+	})
+
+	t.Run("releasing all releases what the action didn't release", func(t *testing.T) {
+		releaseAllA()
+		releaseAllB()
+		releaseAllB()
+		assert.Equal(t, 1, pool.holders())
+	})
+
+	t.Run("no shared informers", func(t *testing.T) {
+		getInformerFactory, releaseAll := pool.InformerFactoryGetter(newInformerPoolTestClientFactory(&KubeConfig{
+			NoSharedInformers: true,
+			RestConfig:        &rest.Config{Host: "https://a.example.com", BearerToken: "other"},
+		}), make(chan error, 1))
+		defer releaseAll()
+
+		factory, _, err := getInformerFactory(deployments, "ns-a")
+		require.NoError(t, err)
+		assert.NotSame(t, factoryOtherUser, factory)
+		assert.Equal(t, 1, pool.holders())
+	})
+}
+
+func (p *InformerPool) holders() int {
+	var holders int
+
+	p.entries.RTransaction(func(entries map[string]*informerPoolEntry) {
+		for _, entry := range entries {
+			holders += len(entry.holders)
+		}
+	})
+
+	return holders
+}
+
+func (p *InformerPool) entryWatchErrCh(factory *kdutil.Concurrent[*informer.InformerFactory]) chan error {
+	var watchErrCh chan error
+
+	p.entries.RTransaction(func(entries map[string]*informerPoolEntry) {
+		for _, entry := range entries {
+			if entry.factory == factory {
+				watchErrCh = entry.watchErrCh
+			}
+		}
+	})
+
+	return watchErrCh
+}
+
+var _ ClientFactorier = (*informerPoolTestClientFactory)(nil)
+
+type informerPoolTestClientFactory struct {
+	dynamicClient dynamic.Interface
+	kubeConfig    *KubeConfig
+}
+
+func newInformerPoolTestClientFactory(kubeConfig *KubeConfig) *informerPoolTestClientFactory {
+	return &informerPoolTestClientFactory{
+		dynamicClient: dynfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		kubeConfig:    kubeConfig,
+	}
+}
+
+func (f *informerPoolTestClientFactory) KubeClient() KubeClienter { return nil }
+
+func (f *informerPoolTestClientFactory) Static() kubernetes.Interface { return nil }
+
+func (f *informerPoolTestClientFactory) Dynamic() dynamic.Interface { return f.dynamicClient }
+
+func (f *informerPoolTestClientFactory) Discovery() discovery.CachedDiscoveryInterface { return nil }
+
+func (f *informerPoolTestClientFactory) Mapper() meta.ResettableRESTMapper { return nil }
+
+func (f *informerPoolTestClientFactory) LegacyClientGetter() *LegacyClientGetter { return nil }
+
+func (f *informerPoolTestClientFactory) KubeConfig() *KubeConfig { return f.kubeConfig }
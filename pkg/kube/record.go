@@ -0,0 +1,236 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/werf/nelm/pkg/log"
+)
+
+const (
+	KubeRecordFileExt  = ".json"
+	KubeRecordRedacted = "<redacted>"
+)
+
+var _ http.RoundTripper = (*RecordingTransport)(nil)
+
+// A request to the Kubernetes API and the response to it, as saved by the RecordingTransport.
+type KubeRecordExchange struct {
+	Seq          int64  `json:"seq"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Query        string `json:"query,omitempty"`
+	RequestBody  string `json:"requestBody,omitempty"`
+	StatusCode   int    `json:"statusCode"`
+	ContentType  string `json:"contentType,omitempty"`
+	ResponseBody string `json:"responseBody,omitempty"`
+}
+
+// Saves requests to the Kubernetes API and responses to them into the directory, one file per
+// request. Shared by all recording transports of a kube config, so that requests of all clients are
+// numbered in the order they were made.
+type KubeRecorder struct {
+	dir      string
+	seq      atomic.Int64
+	session  string
+	warnOnce sync.Once
+}
+
+func NewKubeRecorder(dir string) *KubeRecorder {
+	return &KubeRecorder{
+		dir: dir,
+		// Multiple nelm runs, e.g. plan and then install, might record into the same directory. File
+		// names start with the session, so that files of later runs are sorted after earlier ones.
+		session: fmt.Sprintf("%d", time.Now().UnixNano()),
+	}
+}
+
+func (r *KubeRecorder) save(exchange *KubeRecordExchange) {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err == nil {
+		path := filepath.Join(r.dir, fmt.Sprintf("%s-%08d%s", r.session, exchange.Seq, KubeRecordFileExt))
+		err = os.WriteFile(path, data, 0o600)
+	}
+
+	if err != nil {
+		r.warnOnce.Do(func() {
+			log.Default.Warn(context.Background(), "Unable to save recorded Kubernetes API request: %s", err)
+		})
+	}
+}
+
+// Saves every request to the Kubernetes API and the response to it with the recorder. Headers are
+// not saved, data of Secrets is redacted, and registered secret values are masked. Release records
+// are kept, since replaying needs the release history, but Secrets and values in them are redacted.
+type RecordingTransport struct {
+	next     http.RoundTripper
+	recorder *KubeRecorder
+}
+
+func NewRecordingTransport(recorder *KubeRecorder, next http.RoundTripper) *RecordingTransport {
+	return &RecordingTransport{
+		next:     next,
+		recorder: recorder,
+	}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &KubeRecordExchange{
+		Seq:    t.recorder.seq.Add(1),
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+	}
+
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ := io.ReadAll(body)
+			_ = body.Close()
+			exchange.RequestBody = redactKubeRecordBody(reqBody)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange.StatusCode = resp.StatusCode
+	exchange.ContentType = resp.Header.Get("Content-Type")
+
+	// Watch responses are streamed, so the exchange is saved when the caller is done with the body.
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		onClose: func(respBody []byte) {
+			exchange.ResponseBody = redactKubeRecordBody(respBody)
+			t.recorder.save(exchange)
+		},
+	}
+
+	return resp, nil
+}
+
+type recordingBody struct {
+	io.ReadCloser
+
+	buf       bytes.Buffer
+	closeOnce sync.Once
+	onClose   func(body []byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.closeOnce.Do(func() {
+		b.onClose(b.buf.Bytes())
+	})
+
+	return b.ReadCloser.Close()
+}
+
+// Redacts Secrets in a JSON object, list or stream of watch events. Non-JSON bodies are only
+// masked.
+func redactKubeRecordBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var (
+		redacted bytes.Buffer
+		decoder  = json.NewDecoder(bytes.NewReader(body))
+		encoder  = json.NewEncoder(&redacted)
+	)
+
+	decoder.UseNumber()
+
+	for decoder.More() {
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return log.MaskSecrets(string(body))
+		}
+
+		redactKubeRecordValue(value)
+
+		if err := encoder.Encode(value); err != nil {
+			return log.MaskSecrets(string(body))
+		}
+	}
+
+	return log.MaskSecrets(redacted.String())
+}
+
+func redactKubeRecordValue(value interface{}) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		switch val["kind"] {
+		case "Secret":
+			redactKubeRecordSecret(val)
+		case "SecretList":
+			// Items of lists have no kind.
+			if items, ok := val["items"].([]interface{}); ok {
+				for _, item := range items {
+					if secret, ok := item.(map[string]interface{}); ok {
+						redactKubeRecordSecret(secret)
+					}
+				}
+			}
+		case "ConfigMap":
+			RedactReleaseRecord(val, false)
+		case "ConfigMapList":
+			if items, ok := val["items"].([]interface{}); ok {
+				for _, item := range items {
+					if configMap, ok := item.(map[string]interface{}); ok {
+						RedactReleaseRecord(configMap, false)
+					}
+				}
+			}
+		}
+
+		for _, nested := range val {
+			redactKubeRecordValue(nested)
+		}
+	case []interface{}:
+		for _, nested := range val {
+			redactKubeRecordValue(nested)
+		}
+	}
+}
+
+func redactKubeRecordSecret(secret map[string]interface{}) {
+	if RedactReleaseRecord(secret, true) {
+		return
+	}
+
+	if data, ok := secret["data"].(map[string]interface{}); ok {
+		for key := range data {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(KubeRecordRedacted))
+		}
+	}
+
+	if stringData, ok := secret["stringData"].(map[string]interface{}); ok {
+		for key := range stringData {
+			stringData[key] = KubeRecordRedacted
+		}
+	}
+
+	if metadata, ok := secret["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		}
+	}
+}
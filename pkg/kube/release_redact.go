@@ -0,0 +1,156 @@
+package kube
+
+import (
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/helm/pkg/releaseutil"
+	"github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+)
+
+const (
+	releaseRecordOwner      = "helm"
+	releaseRecordChunkOwner = "helm-chunk"
+	releaseRecordSecretType = "helm.sh/release.v1"
+)
+
+var manifestCommentLineRegexp = regexp.MustCompile(`^\s*#`)
+
+// Redacts the release in a release Secret or ConfigMap, or in a part of it, as stored by the Secrets
+// and ConfigMaps release storage drivers. Returns false if the object is not a release record.
+func RedactReleaseRecord(obj map[string]interface{}, secret bool) bool {
+	var owner string
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+			owner, _ = labels["owner"].(string)
+		}
+	}
+
+	if obj["type"] != releaseRecordSecretType && owner != releaseRecordOwner && owner != releaseRecordChunkOwner {
+		return false
+	}
+
+	data, ok := obj["data"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+
+	for key, value := range data {
+		value, _ := value.(string)
+
+		if !secret {
+			data[key] = RedactReleaseData(value)
+			continue
+		}
+
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(RedactReleaseData(string(decoded))))
+		} else {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(KubeRecordRedacted))
+		}
+	}
+
+	return true
+}
+
+// Redacts the release as stored in the "release" key of release Secrets (after base64-decoding) and
+// ConfigMaps. Data that can't be decoded, e.g. a part of a release split across multiple objects, is
+// redacted entirely.
+func RedactReleaseData(data string) string {
+	redacted, err := driver.RedactEncodedRelease(data, RedactRelease)
+	if err != nil {
+		return KubeRecordRedacted
+	}
+
+	return redacted
+}
+
+// Redacts data and stringData of Secrets in the manifests of the release and all string values of
+// its config, which has decrypted secret values too.
+func RedactRelease(rel *helmrelease.Release) {
+	redactReleaseConfigValue(rel.Config)
+	rel.Manifest = redactReleaseManifest(rel.Manifest)
+
+	for _, hook := range rel.Hooks {
+		hook.Manifest = redactReleaseManifest(hook.Manifest)
+	}
+}
+
+func redactReleaseConfigValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range val {
+			val[key] = redactReleaseConfigValue(nested)
+		}
+	case []interface{}:
+		for i, nested := range val {
+			val[i] = redactReleaseConfigValue(nested)
+		}
+	case string:
+		return KubeRecordRedacted
+	}
+
+	return value
+}
+
+// Only Secrets are rewritten, other manifests are kept byte for byte.
+func redactReleaseManifest(manifest string) string {
+	if manifest == "" {
+		return manifest
+	}
+
+	docsByName := releaseutil.SplitManifests(manifest)
+
+	names := make([]string, 0, len(docsByName))
+	for name := range docsByName {
+		names = append(names, name)
+	}
+
+	sort.Sort(releaseutil.BySplitManifestsOrder(names))
+
+	var redactedSecrets bool
+
+	docs := make([]string, 0, len(names))
+	for _, name := range names {
+		doc := docsByName[name]
+
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj["kind"] != "Secret" {
+			docs = append(docs, doc)
+			continue
+		}
+
+		redactKubeRecordSecret(obj)
+
+		redactedDoc, err := yaml.Marshal(obj)
+		if err != nil {
+			docs = append(docs, KubeRecordRedacted+"\n")
+			redactedSecrets = true
+
+			continue
+		}
+
+		var comments []string
+		for _, line := range strings.Split(doc, "\n") {
+			if !manifestCommentLineRegexp.MatchString(line) {
+				break
+			}
+
+			comments = append(comments, line)
+		}
+
+		docs = append(docs, strings.Join(append(comments, string(redactedDoc)), "\n"))
+		redactedSecrets = true
+	}
+
+	if !redactedSecrets {
+		return manifest
+	}
+
+	return "---\n" + strings.Join(docs, "---\n")
+}
@@ -0,0 +1,56 @@
+package kube
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var _ http.RoundTripper = (*ThrottleDetectingTransport)(nil)
+
+// Counts requests throttled by the API server. Safe to use when nil: it counts nothing then.
+type ThrottleCounter struct {
+	count atomic.Int64
+}
+
+func (c *ThrottleCounter) Count() int64 {
+	if c == nil {
+		return 0
+	}
+
+	return c.count.Load()
+}
+
+func (c *ThrottleCounter) inc() {
+	if c != nil {
+		c.count.Add(1)
+	}
+}
+
+// Counts responses with which the API server throttles us: 429 Too Many Requests, which is also
+// what API Priority and Fairness responds with when it rejects a request. client-go transparently
+// retries such requests, so callers never see most of them, but they can look at the counter to
+// reduce their concurrency.
+type ThrottleDetectingTransport struct {
+	counter *ThrottleCounter
+	next    http.RoundTripper
+}
+
+func NewThrottleDetectingTransport(counter *ThrottleCounter, next http.RoundTripper) *ThrottleDetectingTransport {
+	return &ThrottleDetectingTransport{
+		counter: counter,
+		next:    next,
+	}
+}
+
+func (t *ThrottleDetectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.counter.inc()
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,394 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/werf/lockgate"
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+)
+
+var _ lockgate.Locker = (*LeaseLocker)(nil)
+
+const (
+	DefaultLeaseDuration = 15 * time.Second
+
+	annotationKeyLockHolderCIJobURL = "werf.io/lock-holder-ci-job-url"
+	annotationKeyLockHolderHost     = "werf.io/lock-holder-host"
+	annotationKeyLockHolderUser     = "werf.io/lock-holder-user"
+	leasePollPeriod                 = 2 * time.Second
+)
+
+// Identifies the process holding a lock.
+type LockHolder struct {
+	CIJobURL string
+	Host     string
+	User     string
+}
+
+// Locks with coordination.k8s.io/v1 Leases, one Lease per lock. The Lease records the identity of
+// the holder and is renewed in background while held. Expired Leases are taken over.
+type LeaseLocker struct {
+	Holder    LockHolder
+	Namespace string
+
+	clientFactory   kube.ClientFactorier
+	createNamespace bool
+	ctx             context.Context
+	leaseDuration   time.Duration
+	mu              sync.Mutex
+	renewers        map[string]chan struct{}
+}
+
+type LeaseLockerOptions struct {
+	// CreateNamespace, when true, creates the namespace of the Leases if it doesn't exist.
+	CreateNamespace bool
+	// Holder identifies the lock holder. Defaults to the current process, see CurrentLockHolder.
+	Holder *LockHolder
+	// LeaseDuration is the duration after which a Lease that is not renewed can be taken over.
+	LeaseDuration time.Duration
+}
+
+func NewLeaseLocker(ctx context.Context, namespace string, clientFactory kube.ClientFactorier, opts LeaseLockerOptions) *LeaseLocker {
+	holder := lo.FromPtrOr(opts.Holder, CurrentLockHolder())
+
+	leaseDuration := opts.LeaseDuration
+	if leaseDuration == 0 {
+		leaseDuration = DefaultLeaseDuration
+	}
+
+	return &LeaseLocker{
+		Holder:          holder,
+		Namespace:       namespace,
+		clientFactory:   clientFactory,
+		createNamespace: opts.CreateNamespace,
+		ctx:             ctx,
+		leaseDuration:   leaseDuration,
+		renewers:        map[string]chan struct{}{},
+	}
+}
+
+func (locker *LeaseLocker) Acquire(lockName string, opts lockgate.AcquireOptions) (bool, lockgate.LockHandle, error) {
+	if opts.Shared {
+		return false, lockgate.LockHandle{}, fmt.Errorf("shared locks are not supported by lease locker")
+	}
+
+	if locker.createNamespace {
+		if err := createNamespaceIfNotExists(locker.clientFactory, locker.Namespace, locker.Namespace); err != nil {
+			return false, lockgate.LockHandle{}, fmt.Errorf("create namespace if not exists: %w", err)
+		}
+	}
+
+	handle := lockgate.LockHandle{
+		UUID:     uuid.NewString(),
+		LockName: lockName,
+	}
+
+	acquired, err := locker.tryAcquire(handle)
+	if err != nil {
+		return false, lockgate.LockHandle{}, fmt.Errorf("try to acquire lease: %w", err)
+	}
+
+	if !acquired {
+		if opts.NonBlocking {
+			return false, lockgate.LockHandle{}, nil
+		}
+
+		doWait := func() error {
+			return locker.waitAcquire(handle, opts.Timeout)
+		}
+
+		if opts.OnWaitFunc != nil {
+			err = opts.OnWaitFunc(lockName, doWait)
+		} else {
+			err = doWait()
+		}
+
+		if err != nil {
+			return false, lockgate.LockHandle{}, err
+		}
+	}
+
+	locker.runRenewer(handle, opts.OnLostLeaseFunc)
+
+	return true, handle, nil
+}
+
+func (locker *LeaseLocker) Release(handle lockgate.LockHandle) error {
+	locker.stopRenewer(handle)
+
+	leases := locker.clientFactory.Static().CoordinationV1().Leases(locker.Namespace)
+
+	lease, err := leases.Get(locker.ctx, LeaseName(handle.LockName), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("lease for lock %q not found", handle.LockName)
+		}
+
+		return fmt.Errorf("get lease: %w", err)
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != locker.holderIdentity(handle) {
+		return fmt.Errorf("lease for lock %q is held by %q", handle.LockName, ptr.Deref(lease.Spec.HolderIdentity, ""))
+	}
+
+	if err := leases.Delete(locker.ctx, lease.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			ResourceVersion: &lease.ResourceVersion,
+			UID:             &lease.UID,
+		},
+	}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete lease: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the identity of the current process: the OS user, the host and the URL of the CI job, if
+// running in GitLab CI, GitHub Actions or Jenkins.
+func CurrentLockHolder() LockHolder {
+	holder := LockHolder{
+		User: os.Getenv("USER"),
+	}
+
+	if u, err := user.Current(); err == nil {
+		holder.User = u.Username
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		holder.Host = host
+	}
+
+	switch {
+	case os.Getenv("CI_JOB_URL") != "":
+		holder.CIJobURL = os.Getenv("CI_JOB_URL")
+	case os.Getenv("GITHUB_RUN_ID") != "":
+		holder.CIJobURL = fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	case os.Getenv("BUILD_URL") != "":
+		holder.CIJobURL = os.Getenv("BUILD_URL")
+	}
+
+	return holder
+}
+
+// Returns true if the Lease is not renewed within its duration.
+func IsLeaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	renewTime := lease.Spec.RenewTime
+	if renewTime == nil {
+		renewTime = lease.Spec.AcquireTime
+	}
+
+	if renewTime == nil {
+		return true
+	}
+
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+
+	return now.After(renewTime.Add(duration))
+}
+
+// Returns the name of the Lease for the lock.
+func LeaseName(lockName string) string {
+	return common.LockLeaseNamePrefix + strings.ReplaceAll(lockName, "/", "-")
+}
+
+func (locker *LeaseLocker) holderIdentity(handle lockgate.LockHandle) string {
+	return fmt.Sprintf("%s@%s/%s", locker.Holder.User, locker.Holder.Host, handle.UUID)
+}
+
+func (locker *LeaseLocker) renew(handle lockgate.LockHandle) (lost bool, err error) {
+	leases := locker.clientFactory.Static().CoordinationV1().Leases(locker.Namespace)
+
+	lease, err := leases.Get(locker.ctx, LeaseName(handle.LockName), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, fmt.Errorf("get lease: %w", err)
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != locker.holderIdentity(handle) {
+		return true, nil
+	}
+
+	lease.Spec.RenewTime = ptr.To(metav1.NewMicroTime(time.Now()))
+
+	if _, err := leases.Update(locker.ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("update lease: %w", err)
+	}
+
+	return false, nil
+}
+
+func (locker *LeaseLocker) runRenewer(handle lockgate.LockHandle, onLostLease func(lock lockgate.LockHandle) error) {
+	done := make(chan struct{})
+
+	locker.mu.Lock()
+	locker.renewers[handle.UUID] = done
+	locker.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(locker.leaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				lost, err := locker.renew(handle)
+				if err != nil {
+					log.Default.Warn(locker.ctx, "Unable to renew lease for lock %q: %s", handle.LockName, err)
+					continue
+				}
+
+				if !lost {
+					continue
+				}
+
+				log.Default.Error(locker.ctx, "Lost lease for lock %q", handle.LockName)
+
+				if onLostLease != nil {
+					if err := onLostLease(handle); err != nil {
+						log.Default.Error(locker.ctx, "Lost lease handler error: %s", err)
+					}
+				}
+
+				return
+			}
+		}
+	}()
+}
+
+func (locker *LeaseLocker) setHolder(lease *coordinationv1.Lease, handle lockgate.LockHandle, now metav1.MicroTime) {
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+
+	lease.Annotations[annotationKeyLockHolderUser] = locker.Holder.User
+	lease.Annotations[annotationKeyLockHolderHost] = locker.Holder.Host
+
+	if locker.Holder.CIJobURL != "" {
+		lease.Annotations[annotationKeyLockHolderCIJobURL] = locker.Holder.CIJobURL
+	} else {
+		delete(lease.Annotations, annotationKeyLockHolderCIJobURL)
+	}
+
+	lease.Spec.HolderIdentity = ptr.To(locker.holderIdentity(handle))
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(locker.leaseDuration.Seconds()))
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+}
+
+func (locker *LeaseLocker) stopRenewer(handle lockgate.LockHandle) {
+	locker.mu.Lock()
+	defer locker.mu.Unlock()
+
+	if done, found := locker.renewers[handle.UUID]; found {
+		close(done)
+		delete(locker.renewers, handle.UUID)
+	}
+}
+
+func (locker *LeaseLocker) tryAcquire(handle lockgate.LockHandle) (bool, error) {
+	leases := locker.clientFactory.Static().CoordinationV1().Leases(locker.Namespace)
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(locker.ctx, LeaseName(handle.LockName), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("get lease: %w", err)
+		}
+
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: LeaseName(handle.LockName),
+			},
+		}
+		locker.setHolder(lease, handle, now)
+
+		if _, err := leases.Create(locker.ctx, lease, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("create lease: %w", err)
+		}
+
+		return true, nil
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != "" && !IsLeaseExpired(lease, now.Time) {
+		return false, nil
+	}
+
+	locker.setHolder(lease, handle, now)
+	lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+
+	if _, err := leases.Update(locker.ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("take over expired lease: %w", err)
+	}
+
+	return true, nil
+}
+
+func (locker *LeaseLocker) waitAcquire(handle lockgate.LockHandle, timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	ticker := time.NewTicker(leasePollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-locker.ctx.Done():
+			return fmt.Errorf("wait for lock %q: %w", handle.LockName, context.Cause(locker.ctx))
+		case <-ticker.C:
+		}
+
+		acquired, err := locker.tryAcquire(handle)
+		if err != nil {
+			if !isRetryableLeaseErr(err) {
+				return fmt.Errorf("try to acquire lease: %w", err)
+			}
+
+			log.Default.Debug(locker.ctx, "Retry acquiring lease for lock %q: %s", handle.LockName, err)
+		} else if acquired {
+			return nil
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for lock %q after %s", handle.LockName, timeout)
+		}
+	}
+}
+
+// Transient errors of the API server, after which acquiring the lease is worth retrying.
+func isRetryableLeaseErr(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err)
+}
@@ -0,0 +1,166 @@
+package lock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/werf/lockgate"
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/kube/fake"
+	"github.com/werf/nelm/pkg/lock"
+)
+
+const leaseTestNamespace = "test-ns"
+
+func TestLeaseLockerAcquireRelease(t *testing.T) {
+	ctx := context.Background()
+
+	clientFactory, err := fake.NewClientFactory(ctx)
+	require.NoError(t, err)
+
+	holder := &lock.LockHolder{Host: "host-a", User: "user-a", CIJobURL: "https://ci.example.com/jobs/1"}
+	locker := lock.NewLeaseLocker(ctx, leaseTestNamespace, clientFactory, lock.LeaseLockerOptions{Holder: holder})
+	otherLocker := lock.NewLeaseLocker(ctx, leaseTestNamespace, clientFactory, lock.LeaseLockerOptions{Holder: &lock.LockHolder{Host: "host-b", User: "user-b"}})
+
+	acquired, handle, err := locker.Acquire("release/app", lockgate.AcquireOptions{})
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	lease, err := clientFactory.Static().CoordinationV1().Leases(leaseTestNamespace).Get(ctx, lock.LeaseName("release/app"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "host-a", lease.Annotations["werf.io/lock-holder-host"])
+	assert.Equal(t, "https://ci.example.com/jobs/1", lease.Annotations["werf.io/lock-holder-ci-job-url"])
+
+	acquired, _, err = otherLocker.Acquire("release/app", lockgate.AcquireOptions{NonBlocking: true})
+	require.NoError(t, err)
+	assert.False(t, acquired, "held lease should not be acquired")
+
+	require.NoError(t, locker.Release(handle))
+
+	acquired, otherHandle, err := otherLocker.Acquire("release/app", lockgate.AcquireOptions{NonBlocking: true})
+	require.NoError(t, err)
+	assert.True(t, acquired, "released lease should be acquired")
+	require.NoError(t, otherLocker.Release(otherHandle))
+}
+
+func TestLeaseLockerTakeOverExpired(t *testing.T) {
+	ctx := context.Background()
+
+	clientFactory, err := fake.NewClientFactory(ctx)
+	require.NoError(t, err)
+
+	createExpiredLease(t, ctx, clientFactory, lock.LeaseName("release/app"))
+
+	locker := lock.NewLeaseLocker(ctx, leaseTestNamespace, clientFactory, lock.LeaseLockerOptions{Holder: &lock.LockHolder{Host: "host-a", User: "user-a"}})
+
+	acquired, handle, err := locker.Acquire("release/app", lockgate.AcquireOptions{NonBlocking: true})
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	lease, err := clientFactory.Static().CoordinationV1().Leases(leaseTestNamespace).Get(ctx, lock.LeaseName("release/app"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), ptr.Deref(lease.Spec.LeaseTransitions, 0))
+	assert.Empty(t, lease.Annotations["werf.io/lock-holder-ci-job-url"])
+
+	require.NoError(t, locker.Release(handle))
+}
+
+func TestLeaseLockerWaitCanceled(t *testing.T) {
+	clientFactory, err := fake.NewClientFactory(context.Background())
+	require.NoError(t, err)
+
+	locker := lock.NewLeaseLocker(context.Background(), leaseTestNamespace, clientFactory, lock.LeaseLockerOptions{Holder: &lock.LockHolder{Host: "host-a", User: "user-a"}})
+
+	acquired, handle, err := locker.Acquire("release/app", lockgate.AcquireOptions{})
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	otherLocker := lock.NewLeaseLocker(ctx, leaseTestNamespace, clientFactory, lock.LeaseLockerOptions{Holder: &lock.LockHolder{Host: "host-b", User: "user-b"}})
+
+	start := time.Now()
+	_, _, err = otherLocker.Acquire("release/app", lockgate.AcquireOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	require.NoError(t, locker.Release(handle))
+}
+
+func TestLockManagerLeaseLock(t *testing.T) {
+	ctx := context.Background()
+
+	clientFactory, err := fake.NewClientFactory(ctx)
+	require.NoError(t, err)
+
+	lockManager, err := lock.NewLockManagerWithOptions(ctx, leaseTestNamespace, false, clientFactory, lock.LockManagerOptions{Locker: common.ReleaseLockerLease})
+	require.NoError(t, err)
+
+	releaseLock, err := lockManager.GetReleaseLock(ctx, "app")
+	require.NoError(t, err)
+	assert.Nil(t, releaseLock)
+
+	broken, err := lockManager.BreakReleaseLock(ctx, "app")
+	require.NoError(t, err)
+	assert.False(t, broken)
+
+	createExpiredLease(t, ctx, clientFactory, lock.LeaseName("release/app"))
+
+	releaseLock, err = lockManager.GetReleaseLock(ctx, "app")
+	require.NoError(t, err)
+	require.NotNil(t, releaseLock)
+	assert.True(t, releaseLock.Expired)
+	assert.Equal(t, "stale@host-z/1", releaseLock.HolderID)
+	assert.Equal(t, "host-z", releaseLock.Holder.Host)
+	assert.Equal(t, common.ReleaseLockerLease, releaseLock.Locker)
+
+	broken, err = lockManager.BreakReleaseLock(ctx, "app")
+	require.NoError(t, err)
+	assert.True(t, broken)
+
+	releaseLock, err = lockManager.GetReleaseLock(ctx, "app")
+	require.NoError(t, err)
+	assert.Nil(t, releaseLock)
+}
+
+func TestNewLockManagerUnknownLocker(t *testing.T) {
+	ctx := context.Background()
+
+	clientFactory, err := fake.NewClientFactory(ctx)
+	require.NoError(t, err)
+
+	_, err = lock.NewLockManagerWithOptions(ctx, leaseTestNamespace, false, clientFactory, lock.LockManagerOptions{Locker: "unknown"})
+	assert.Error(t, err)
+}
+
+func createExpiredLease(t *testing.T, ctx context.Context, clientFactory *fake.ClientFactory, name string) {
+	t.Helper()
+
+	acquireTime := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+
+	_, err := clientFactory.Static().CoordinationV1().Leases(leaseTestNamespace).Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				"werf.io/lock-holder-ci-job-url": "https://ci.example.com/jobs/0",
+				"werf.io/lock-holder-host":       "host-z",
+				"werf.io/lock-holder-user":       "stale",
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			AcquireTime:          &acquireTime,
+			HolderIdentity:       ptr.To("stale@host-z/1"),
+			LeaseDurationSeconds: ptr.To(int32(15)),
+			RenewTime:            &acquireTime,
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
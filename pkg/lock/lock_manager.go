@@ -2,14 +2,21 @@ package lock
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
 
 	"github.com/werf/common-go/pkg/locker_with_retry"
 	"github.com/werf/lockgate"
 	"github.com/werf/lockgate/pkg/distributed_locker"
+	lockgateutil "github.com/werf/lockgate/pkg/util"
 	"github.com/werf/nelm/pkg/common"
 	"github.com/werf/nelm/pkg/kube"
 	"github.com/werf/nelm/pkg/log"
@@ -18,20 +25,58 @@ import (
 
 // NOTE: LockManager for not is not multithreaded due to the lack of support of contexts in the lockgate library
 type LockManager struct {
+	Locker          string
 	LockerWithRetry *locker_with_retry.LockerWithRetry
 	Namespace       string
+
+	clientFactory kube.ClientFactorier
+}
+
+type LockManagerOptions struct {
+	// Locker specifies how releases are locked: "configmap" (default) uses annotations of the
+	// werf-synchronization ConfigMap, "lease" uses a coordination.k8s.io/v1 Lease per release.
+	Locker string
+}
+
+// Describes the current lock of a release.
+type ReleaseLock struct {
+	// AcquiredAt is unknown for the "configmap" locker.
+	AcquiredAt time.Time
+	Expired    bool
+	ExpiresAt  time.Time
+	// Holder is unknown for the "configmap" locker.
+	Holder      LockHolder
+	HolderID    string
+	Locker      string
+	ReleaseName string
 }
 
+// Returns the LockManager with the default "configmap" locker.
 func NewLockManager(ctx context.Context, namespace string, createNamespace bool, clientFactory kube.ClientFactorier) (*LockManager, error) {
-	locker := distributed_locker.NewKubernetesLocker(
-		clientFactory.Dynamic(), schema.GroupVersionResource{
-			Group:    "",
-			Version:  "v1",
-			Resource: "configmaps",
-		}, common.LockConfigMapName, namespace,
-	)
-	cmLocker := NewConfigMapLocker(common.LockConfigMapName, namespace, namespace, locker, clientFactory, ConfigMapLockerOptions{CreateNamespace: createNamespace})
-	lockerWithRetry := locker_with_retry.NewLockerWithRetry(ctx, cmLocker, locker_with_retry.LockerWithRetryOptions{
+	return NewLockManagerWithOptions(ctx, namespace, createNamespace, clientFactory, LockManagerOptions{})
+}
+
+func NewLockManagerWithOptions(ctx context.Context, namespace string, createNamespace bool, clientFactory kube.ClientFactorier, opts LockManagerOptions) (*LockManager, error) {
+	var locker lockgate.Locker
+	switch opts.Locker {
+	case common.ReleaseLockerDefault, common.ReleaseLockerConfigMap:
+		opts.Locker = common.ReleaseLockerConfigMap
+
+		kubeLocker := distributed_locker.NewKubernetesLocker(
+			clientFactory.Dynamic(), schema.GroupVersionResource{
+				Group:    "",
+				Version:  "v1",
+				Resource: "configmaps",
+			}, common.LockConfigMapName, namespace,
+		)
+		locker = NewConfigMapLocker(common.LockConfigMapName, namespace, namespace, kubeLocker, clientFactory, ConfigMapLockerOptions{CreateNamespace: createNamespace})
+	case common.ReleaseLockerLease:
+		locker = NewLeaseLocker(ctx, namespace, clientFactory, LeaseLockerOptions{CreateNamespace: createNamespace})
+	default:
+		return nil, fmt.Errorf("unknown release locker %q, expected %q or %q", opts.Locker, common.ReleaseLockerConfigMap, common.ReleaseLockerLease)
+	}
+
+	lockerWithRetry := locker_with_retry.NewLockerWithRetry(ctx, locker, locker_with_retry.LockerWithRetryOptions{
 		MaxAcquireAttempts: 10,
 		MaxReleaseAttempts: 10,
 		CustomLogWarnFunc: func(msg string) {
@@ -43,15 +88,66 @@ func NewLockManager(ctx context.Context, namespace string, createNamespace bool,
 	})
 
 	return &LockManager{
+		Locker:          opts.Locker,
 		LockerWithRetry: lockerWithRetry,
 		Namespace:       namespace,
+		clientFactory:   clientFactory,
 	}, nil
 }
 
+// Forcefully releases the lock of the release, regardless of its holder. Returns false if the
+// release is not locked.
+func (lockManager *LockManager) BreakReleaseLock(ctx context.Context, releaseName string) (bool, error) {
+	if lockManager.Locker == common.ReleaseLockerLease {
+		if err := lockManager.clientFactory.Static().CoordinationV1().Leases(lockManager.Namespace).Delete(ctx, LeaseName(releaseLockName(releaseName)), metav1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("delete lease: %w", err)
+		}
+
+		return true, nil
+	}
+
+	configMaps := lockManager.clientFactory.Static().CoreV1().ConfigMaps(lockManager.Namespace)
+
+	configMap, err := configMaps.Get(ctx, common.LockConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("get configmap %q: %w", common.LockConfigMapName, err)
+	}
+
+	annoKey := configMapLockAnnotationKey(releaseLockName(releaseName))
+	if _, found := configMap.Annotations[annoKey]; !found {
+		return false, nil
+	}
+
+	delete(configMap.Annotations, annoKey)
+
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("update configmap %q: %w", common.LockConfigMapName, err)
+	}
+
+	return true, nil
+}
+
+// Returns the current lock of the release or nil if the release is not locked.
+func (lockManager *LockManager) GetReleaseLock(ctx context.Context, releaseName string) (*ReleaseLock, error) {
+	if lockManager.Locker == common.ReleaseLockerLease {
+		return lockManager.getReleaseLease(ctx, releaseName)
+	}
+
+	return lockManager.getReleaseConfigMapLock(ctx, releaseName)
+}
+
 func (lockManager *LockManager) LockRelease(ctx context.Context, releaseName string) (lockgate.LockHandle, error) {
 	lockManager.LockerWithRetry.Ctx = ctx
 
-	_, handle, err := lockManager.LockerWithRetry.Acquire(fmt.Sprintf("release/%s", releaseName), setupLockerDefaultOptions(ctx, lockgate.AcquireOptions{}))
+	_, handle, err := lockManager.LockerWithRetry.Acquire(releaseLockName(releaseName), setupLockerDefaultOptions(ctx, lockgate.AcquireOptions{}))
 	if err != nil {
 		return lockgate.LockHandle{}, fmt.Errorf("acquire release lock: %w", err)
 	}
@@ -71,6 +167,70 @@ func (lockManager *LockManager) Unlock(handle lockgate.LockHandle) error {
 	return nil
 }
 
+func (lockManager *LockManager) getReleaseConfigMapLock(ctx context.Context, releaseName string) (*ReleaseLock, error) {
+	configMap, err := lockManager.clientFactory.Static().CoreV1().ConfigMaps(lockManager.Namespace).Get(ctx, common.LockConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get configmap %q: %w", common.LockConfigMapName, err)
+	}
+
+	data := configMap.Annotations[configMapLockAnnotationKey(releaseLockName(releaseName))]
+	if data == "" {
+		return nil, nil
+	}
+
+	var record distributed_locker.LockLeaseRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("unmarshal lock lease record: %w", err)
+	}
+
+	expiresAt := time.Unix(record.ExpireAtTimestamp, 0)
+
+	return &ReleaseLock{
+		Expired:     time.Now().After(expiresAt),
+		ExpiresAt:   expiresAt,
+		HolderID:    record.UUID,
+		Locker:      common.ReleaseLockerConfigMap,
+		ReleaseName: releaseName,
+	}, nil
+}
+
+func (lockManager *LockManager) getReleaseLease(ctx context.Context, releaseName string) (*ReleaseLock, error) {
+	lease, err := lockManager.clientFactory.Static().CoordinationV1().Leases(lockManager.Namespace).Get(ctx, LeaseName(releaseLockName(releaseName)), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get lease: %w", err)
+	}
+
+	lock := &ReleaseLock{
+		Expired: IsLeaseExpired(lease, time.Now()),
+		Holder: LockHolder{
+			CIJobURL: lease.Annotations[annotationKeyLockHolderCIJobURL],
+			Host:     lease.Annotations[annotationKeyLockHolderHost],
+			User:     lease.Annotations[annotationKeyLockHolderUser],
+		},
+		HolderID:    ptr.Deref(lease.Spec.HolderIdentity, ""),
+		Locker:      common.ReleaseLockerLease,
+		ReleaseName: releaseName,
+	}
+
+	if lease.Spec.AcquireTime != nil {
+		lock.AcquiredAt = lease.Spec.AcquireTime.Time
+	}
+
+	if renewTime := lo.CoalesceOrEmpty(lease.Spec.RenewTime, lease.Spec.AcquireTime); renewTime != nil {
+		lock.ExpiresAt = renewTime.Add(time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second)
+	}
+
+	return lock, nil
+}
+
 type ConfigMapLocker struct {
 	ConfigMapName, Namespace string
 	Locker                   lockgate.Locker
@@ -155,6 +315,14 @@ func getOrCreateConfigMapWithNamespaceIfNotExists(clientFactory kube.ClientFacto
 	return nil
 }
 
+func configMapLockAnnotationKey(lockName string) string {
+	return fmt.Sprintf("lockgate.io/%s", lockgateutil.Sha3_224Hash(lockName))
+}
+
+func releaseLockName(releaseName string) string {
+	return fmt.Sprintf("release/%s", releaseName)
+}
+
 func setupLockerDefaultOptions(ctx context.Context, opts lockgate.AcquireOptions) lockgate.AcquireOptions {
 	if opts.OnWaitFunc == nil {
 		opts.OnWaitFunc = defaultLockerOnWait(ctx)
@@ -41,6 +41,9 @@ func SetupLogging(ctx context.Context, logLevel Level, opts SetupLoggingOptions)
 	spew.Config.DisablePointerAddresses = true
 	spew.Config.DisableCapacities = true
 
+	outStream := NewSecretsMaskingWriter(logboek.Context(ctx).OutStream())
+	errStream := NewSecretsMaskingWriter(logboek.Context(ctx).ErrStream())
+
 	switch logLevel {
 	case SilentLevel, ErrorLevel, WarningLevel, InfoLevel:
 		stdlog.SetOutput(io.Discard)
@@ -69,44 +72,44 @@ func SetupLogging(ctx context.Context, logLevel Level, opts SetupLoggingOptions)
 
 		debug.SetDebug(false)
 	case DebugLevel:
-		stdlog.SetOutput(os.Stdout)
+		stdlog.SetOutput(NewSecretsMaskingWriter(os.Stdout))
 
-		klog.SetOutputBySeverity("FATAL", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("ERROR", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("WARNING", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("INFO", logboek.Context(ctx).OutStream())
+		klog.SetOutputBySeverity("FATAL", errStream)
+		klog.SetOutputBySeverity("ERROR", errStream)
+		klog.SetOutputBySeverity("WARNING", errStream)
+		klog.SetOutputBySeverity("INFO", outStream)
 
-		klogv2.SetOutputBySeverity("FATAL", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("ERROR", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("WARNING", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("INFO", logboek.Context(ctx).OutStream())
+		klogv2.SetOutputBySeverity("FATAL", errStream)
+		klogv2.SetOutputBySeverity("ERROR", errStream)
+		klogv2.SetOutputBySeverity("WARNING", errStream)
+		klogv2.SetOutputBySeverity("INFO", outStream)
 
-		logrus.SetOutput(logboek.Context(ctx).OutStream())
+		logrus.SetOutput(outStream)
 		logrus.SetLevel(logrus.DebugLevel)
 
-		cdlog.L.Logger.SetOutput(logboek.Context(ctx).OutStream())
+		cdlog.L.Logger.SetOutput(outStream)
 		cdlog.L.Logger.SetLevel(logrus.DebugLevel)
 
 		engine.Debug = true
 
 		debug.SetDebug(true)
 	case TraceLevel:
-		stdlog.SetOutput(os.Stdout)
+		stdlog.SetOutput(NewSecretsMaskingWriter(os.Stdout))
 
-		klog.SetOutputBySeverity("FATAL", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("ERROR", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("WARNING", logboek.Context(ctx).ErrStream())
-		klog.SetOutputBySeverity("INFO", logboek.Context(ctx).OutStream())
+		klog.SetOutputBySeverity("FATAL", errStream)
+		klog.SetOutputBySeverity("ERROR", errStream)
+		klog.SetOutputBySeverity("WARNING", errStream)
+		klog.SetOutputBySeverity("INFO", outStream)
 
-		klogv2.SetOutputBySeverity("FATAL", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("ERROR", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("WARNING", logboek.Context(ctx).ErrStream())
-		klogv2.SetOutputBySeverity("INFO", logboek.Context(ctx).OutStream())
+		klogv2.SetOutputBySeverity("FATAL", errStream)
+		klogv2.SetOutputBySeverity("ERROR", errStream)
+		klogv2.SetOutputBySeverity("WARNING", errStream)
+		klogv2.SetOutputBySeverity("INFO", outStream)
 
-		logrus.SetOutput(logboek.Context(ctx).OutStream())
+		logrus.SetOutput(outStream)
 		logrus.SetLevel(logrus.TraceLevel)
 
-		cdlog.L.Logger.SetOutput(logboek.Context(ctx).OutStream())
+		cdlog.L.Logger.SetOutput(outStream)
 		cdlog.L.Logger.SetLevel(logrus.TraceLevel)
 
 		engine.Debug = true
@@ -124,6 +127,17 @@ func SetupLogging(ctx context.Context, logLevel Level, opts SetupLoggingOptions)
 	return ctx
 }
 
+// Returns a context with a logger which prefixes every line with the prefix. Used to tell apart the
+// output of actions running in parallel.
+func NewPrefixedContext(ctx context.Context, prefix string) context.Context {
+	parentLogger := logboek.Context(ctx)
+
+	logger := parentLogger.NewSubLogger(parentLogger.OutStream(), parentLogger.ErrStream())
+	logger.Streams().SetPrefix(prefix)
+
+	return logboek.NewContext(ctx, logger)
+}
+
 func getColorLevel(mode string, logIsParseable bool) terminfo.ColorLevel {
 	switch mode {
 	case LogColorModeOff:
@@ -57,7 +57,7 @@ func (l *LogboekLogger) Debug(ctx context.Context, format string, a ...interface
 		return
 	}
 
-	logboek.Context(ctx).Debug().LogF(format+"\n", a...)
+	logboek.Context(ctx).Debug().LogF("%s\n", MaskSecrets(fmt.Sprintf(format, a...)))
 }
 
 func (l *LogboekLogger) DebugPop(ctx context.Context, group string) {
@@ -81,7 +81,7 @@ func (l *LogboekLogger) Error(ctx context.Context, format string, a ...interface
 		return
 	}
 
-	logboek.Context(ctx).Error().LogFWithCustomStyle(color.Style{color.FgRed, color.Bold}, format+"\n", a...)
+	logboek.Context(ctx).Error().LogFWithCustomStyle(color.Style{color.FgRed, color.Bold}, "%s\n", MaskSecrets(fmt.Sprintf(format, a...)))
 }
 
 func (l *LogboekLogger) ErrorPop(ctx context.Context, group string) {
@@ -105,15 +105,15 @@ func (l *LogboekLogger) Info(ctx context.Context, format string, a ...interface{
 		return
 	}
 
-	logboek.Context(ctx).Default().LogF(format+"\n", a...)
+	logboek.Context(ctx).Default().LogF("%s\n", MaskSecrets(fmt.Sprintf(format, a...)))
 }
 
 func (l *LogboekLogger) InfoBlock(ctx context.Context, opts BlockOptions, fn func()) {
-	logboek.Context(ctx).Default().LogBlock(opts.BlockTitle).Do(fn)
+	logboek.Context(ctx).Default().LogBlock("%s", MaskSecrets(opts.BlockTitle)).Do(fn)
 }
 
 func (l *LogboekLogger) InfoBlockErr(ctx context.Context, opts BlockOptions, fn func() error) error {
-	return logboek.Context(ctx).Default().LogBlock(opts.BlockTitle).DoError(fn)
+	return logboek.Context(ctx).Default().LogBlock("%s", MaskSecrets(opts.BlockTitle)).DoError(fn)
 }
 
 func (l *LogboekLogger) InfoPop(ctx context.Context, group string) {
@@ -167,7 +167,7 @@ func (l *LogboekLogger) Trace(ctx context.Context, format string, a ...interface
 		return
 	}
 
-	logboek.Context(ctx).Debug().LogF(format+"\n", a...)
+	logboek.Context(ctx).Debug().LogF("%s\n", MaskSecrets(fmt.Sprintf(format, a...)))
 }
 
 func (l *LogboekLogger) TracePop(ctx context.Context, group string) {
@@ -193,7 +193,7 @@ func (l *LogboekLogger) TraceStruct(ctx context.Context, obj interface{}, format
 
 	dump := spew.Sdump(obj)
 
-	logboek.Context(ctx).Debug().LogF(fmt.Sprintf(format+"\n", a...) + dump + "\n")
+	logboek.Context(ctx).Debug().LogF("%s\n", MaskSecrets(fmt.Sprintf(format+"\n", a...)+dump))
 }
 
 func (l *LogboekLogger) Warn(ctx context.Context, format string, a ...interface{}) {
@@ -201,7 +201,7 @@ func (l *LogboekLogger) Warn(ctx context.Context, format string, a ...interface{
 		return
 	}
 
-	logboek.Context(ctx).Warn().LogFWithCustomStyle(color.Style{color.FgRed}, format+"\n", a...)
+	logboek.Context(ctx).Warn().LogFWithCustomStyle(color.Style{color.FgRed}, "%s\n", MaskSecrets(fmt.Sprintf(format, a...)))
 }
 
 func (l *LogboekLogger) WarnPop(ctx context.Context, group string) {
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"encoding/base64"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/werf/kubedog/pkg/trackers/dyntracker/util"
+)
+
+// Replaces secret values in everything printed by Nelm.
+const SecretMask = "***"
+
+// Shorter values are never masked, otherwise unrelated output gets garbled.
+const minSecretValueToMaskLen = 4
+
+var secretsMasker = util.NewConcurrent(&secretsMaskerState{
+	values: make(map[string]bool),
+})
+
+type secretsMaskerState struct {
+	replacer *strings.Replacer
+	values   map[string]bool
+}
+
+// Register decrypted secret values to be masked in all the output of the logger, in the output
+// of third-party loggers set up by SetupLogging and in the report files. Every line of a
+// multiline value and the base64-encoded form of the value are masked as well.
+func AddSecretValuesToMask(values ...string) {
+	secretsMasker.RWTransaction(func(state *secretsMaskerState) {
+		var added bool
+		for _, value := range values {
+			for _, variant := range secretValueVariants(value) {
+				if len(variant) < minSecretValueToMaskLen || state.values[variant] {
+					continue
+				}
+
+				state.values[variant] = true
+				added = true
+			}
+		}
+
+		if !added {
+			return
+		}
+
+		state.replacer = buildSecretsReplacer(state.values)
+	})
+}
+
+// Replace all registered secret values in the text with SecretMask.
+func MaskSecrets(text string) string {
+	var replacer *strings.Replacer
+	secretsMasker.RTransaction(func(state *secretsMaskerState) {
+		replacer = state.replacer
+	})
+
+	if replacer == nil {
+		return text
+	}
+
+	return replacer.Replace(text)
+}
+
+// Wrap the writer so that all registered secret values are masked before being written. Expects
+// that secret values are not split between writes, which holds for line-oriented loggers.
+func NewSecretsMaskingWriter(out io.Writer) io.Writer {
+	return &secretsMaskingWriter{out: out}
+}
+
+type secretsMaskingWriter struct {
+	out io.Writer
+}
+
+func (w *secretsMaskingWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write([]byte(MaskSecrets(string(p)))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func secretValueVariants(value string) []string {
+	variants := []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+	}
+
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			variants = append(variants, strings.TrimSpace(line))
+		}
+	}
+
+	return variants
+}
+
+func buildSecretsReplacer(values map[string]bool) *strings.Replacer {
+	sortedValues := make([]string, 0, len(values))
+	for value := range values {
+		sortedValues = append(sortedValues, value)
+	}
+
+	// Replacer prefers the earliest matching argument, so the longest values must come first.
+	sort.Slice(sortedValues, func(i, j int) bool {
+		if len(sortedValues[i]) != len(sortedValues[j]) {
+			return len(sortedValues[i]) > len(sortedValues[j])
+		}
+
+		return sortedValues[i] < sortedValues[j]
+	})
+
+	oldNew := make([]string, 0, len(sortedValues)*2)
+	for _, value := range sortedValues {
+		oldNew = append(oldNew, value, SecretMask)
+	}
+
+	return strings.NewReplacer(oldNew...)
+}
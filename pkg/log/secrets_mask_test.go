@@ -0,0 +1,47 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/log"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	log.AddSecretValuesToMask("s3cr3t-password", "line-one-secret\n  line-two-secret\n", "abc", "s3cr3t")
+
+	t.Run("masks plain values", func(t *testing.T) {
+		assert.Equal(t, "password is ***", log.MaskSecrets("password is s3cr3t-password"))
+	})
+
+	t.Run("prefers the longest value", func(t *testing.T) {
+		assert.Equal(t, "*** and ***", log.MaskSecrets("s3cr3t-password and s3cr3t"))
+	})
+
+	t.Run("masks base64 encoded values", func(t *testing.T) {
+		encoded := base64.StdEncoding.EncodeToString([]byte("s3cr3t-password"))
+		assert.Equal(t, "data: ***", log.MaskSecrets("data: "+encoded))
+	})
+
+	t.Run("masks separate lines of multiline values", func(t *testing.T) {
+		assert.Equal(t, "got *** here", log.MaskSecrets("got line-two-secret here"))
+	})
+
+	t.Run("does not mask too short values", func(t *testing.T) {
+		assert.Equal(t, "abc", log.MaskSecrets("abc"))
+	})
+
+	t.Run("masking writer masks written data", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		n, err := log.NewSecretsMaskingWriter(&buf).Write([]byte("debug: s3cr3t-password\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, len("debug: s3cr3t-password\n"), n)
+		assert.Equal(t, "debug: ***\n", buf.String())
+	})
+}
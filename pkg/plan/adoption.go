@@ -0,0 +1,100 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gookit/color"
+	"github.com/samber/lo"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+// A resource which already exists in the cluster, but doesn't belong to the release yet, and which
+// will be taken over by the release, either from another release or from no release at all.
+type Adoption struct {
+	*spec.ResourceMeta
+
+	// Allowed is true if the resource can be adopted with the current options.
+	Allowed bool
+	// From which release the resource is adopted. Empty if the resource doesn't belong to any release.
+	FromReleaseName      string
+	FromReleaseNamespace string
+	// The live resource changes applied on adoption: from the cluster state to the dry-run apply result.
+	Change *ResourceChange
+	// Managed fields of the live resource before and after they are fixed on adoption.
+	ManagedFieldsBefore []v1.ManagedFieldsEntry
+	ManagedFieldsAfter  []v1.ManagedFieldsEntry
+	// Why the resource can't be adopted without explicit permission.
+	NonAdoptableReason string
+}
+
+type BuildAdoptionsOptions struct {
+	// AdoptResources are resource filters in the "key1=value1,key2=value2" format. Resources
+	// matching any of them can be adopted from other releases or from no release.
+	AdoptResources []string
+	// ForceAdoption, when true, allows adopting any resource.
+	ForceAdoption bool
+}
+
+// Finds resources in the cluster which will be adopted by the release. Should only be called if
+// cluster access is allowed.
+func BuildAdoptions(ctx context.Context, releaseName, releaseNamespace string, installableResourceInfos []*InstallableResourceInfo, opts BuildAdoptionsOptions) ([]*Adoption, error) {
+	var adoptions []*Adoption
+	for _, info := range lo.UniqBy(installableResourceInfos, func(info *InstallableResourceInfo) string {
+		return info.ID()
+	}) {
+		if info.GetResult == nil || info.LocalResource.Ownership == common.OwnershipAnyone {
+			continue
+		}
+
+		adoptable, nonAdoptableReason := adoptableBy(info.GetResult, releaseName, releaseNamespace)
+		if adoptable {
+			continue
+		}
+
+		allowed := opts.ForceAdoption
+		if !allowed {
+			var err error
+
+			allowed, err = resource.MatchResourceFilters(ctx, opts.AdoptResources, releaseNamespace, info.ResourceMeta)
+			if err != nil {
+				return nil, fmt.Errorf("match resource %q against adopt filters: %w", info.IDHuman(), err)
+			}
+		}
+
+		change, err := buildResourceChange(info.ResourceMeta, info.GetResult, lo.Ternary(info.DryApplyResult != nil, info.DryApplyResult, info.LocalResource.Unstruct), false, "adopt", color.Style{color.Bold, color.Magenta})
+		if err != nil {
+			return nil, fmt.Errorf("build resource change for adopt: %w", err)
+		}
+
+		annotations := info.GetResult.GetAnnotations()
+
+		adoptions = append(adoptions, &Adoption{
+			ResourceMeta:         info.ResourceMeta,
+			Allowed:              allowed,
+			Change:               change,
+			FromReleaseName:      annotations[common.AnnotationKeyHumanReleaseName],
+			FromReleaseNamespace: annotations[common.AnnotationKeyHumanReleaseNamespace],
+			ManagedFieldsAfter:   info.GetResult.GetManagedFields(),
+			ManagedFieldsBefore:  lo.Ternary(info.ManagedFieldsBeforeFix != nil, info.ManagedFieldsBeforeFix, info.GetResult.GetManagedFields()),
+			NonAdoptableReason:   nonAdoptableReason,
+		})
+	}
+
+	return adoptions, nil
+}
+
+// Returns human-readable field managers, e.g. "kubectl-edit (Update)" or "helm (Apply, status)".
+func FieldManagers(managedFields []v1.ManagedFieldsEntry) []string {
+	return lo.Map(managedFields, func(entry v1.ManagedFieldsEntry, _ int) string {
+		if entry.Subresource != "" {
+			return fmt.Sprintf("%s (%s, %s)", entry.Manager, entry.Operation, entry.Subresource)
+		}
+
+		return fmt.Sprintf("%s (%s)", entry.Manager, entry.Operation)
+	})
+}
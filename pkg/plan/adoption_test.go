@@ -0,0 +1,97 @@
+package plan_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/plan"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+func TestBuildAdoptions(t *testing.T) {
+	ctx := context.Background()
+
+	infos := []*plan.InstallableResourceInfo{
+		newAdoptionTestInfo("owned", map[string]string{
+			"meta.helm.sh/release-name":      "app",
+			"meta.helm.sh/release-namespace": "test-ns",
+		}, nil),
+		newAdoptionTestInfo("other-release", map[string]string{
+			"meta.helm.sh/release-name":      "other",
+			"meta.helm.sh/release-namespace": "test-ns",
+		}, []v1.ManagedFieldsEntry{{Manager: "kubectl-edit", Operation: v1.ManagedFieldsOperationUpdate}}),
+		newAdoptionTestInfo("unmanaged", nil, nil),
+		newAdoptionTestInfo("unmanaged-other", nil, nil),
+	}
+
+	adoptions, err := plan.BuildAdoptions(ctx, "app", "test-ns", infos, plan.BuildAdoptionsOptions{
+		AdoptResources: []string{"kind=ConfigMap,name=unmanaged"},
+	})
+	require.NoError(t, err)
+	require.Len(t, adoptions, 3)
+
+	adoptionsByName := lo.KeyBy(adoptions, func(adoption *plan.Adoption) string {
+		return adoption.Name
+	})
+
+	assert.False(t, adoptionsByName["other-release"].Allowed)
+	assert.Equal(t, "other", adoptionsByName["other-release"].FromReleaseName)
+	assert.Equal(t, []string{"kubectl-edit (Update)"}, plan.FieldManagers(adoptionsByName["other-release"].ManagedFieldsBefore))
+	assert.Equal(t, []string{"helm (Apply)"}, plan.FieldManagers(adoptionsByName["other-release"].ManagedFieldsAfter))
+
+	assert.True(t, adoptionsByName["unmanaged"].Allowed)
+	assert.Empty(t, adoptionsByName["unmanaged"].FromReleaseName)
+	assert.False(t, adoptionsByName["unmanaged-other"].Allowed)
+
+	require.Error(t, plan.ValidateRemote(ctx, "app", "test-ns", infos, plan.ValidateRemoteOptions{
+		AdoptResources: []string{"kind=ConfigMap,name=unmanaged"},
+	}))
+	require.NoError(t, plan.ValidateRemote(ctx, "app", "test-ns", infos, plan.ValidateRemoteOptions{
+		AdoptResources: []string{"kind=ConfigMap,name=unmanaged", "kind=ConfigMap,name=unmanaged-other", "name=other-release"},
+	}))
+	require.NoError(t, plan.ValidateRemote(ctx, "app", "test-ns", infos, plan.ValidateRemoteOptions{
+		ForceAdoption: true,
+	}))
+
+	for _, filter := range []string{"kind", "name", "kind=ConfigMap,name"} {
+		_, err := plan.BuildAdoptions(ctx, "app", "test-ns", infos, plan.BuildAdoptionsOptions{
+			AdoptResources: []string{filter},
+		})
+		assert.ErrorContains(t, err, "requires a value", "filter %q", filter)
+	}
+}
+
+func newAdoptionTestInfo(name string, annotations map[string]string, managedFieldsBeforeFix []v1.ManagedFieldsEntry) *plan.InstallableResourceInfo {
+	local := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+	}}
+
+	live := local.DeepCopy()
+	live.SetNamespace("test-ns")
+	live.SetAnnotations(annotations)
+	live.SetManagedFields([]v1.ManagedFieldsEntry{{Manager: common.DefaultFieldManager, Operation: v1.ManagedFieldsOperationApply}})
+
+	resSpec := spec.NewResourceSpec(local, "test-ns", spec.ResourceSpecOptions{})
+
+	return &plan.InstallableResourceInfo{
+		ResourceMeta: resSpec.ResourceMeta,
+		LocalResource: &resource.InstallableResource{
+			ResourceSpec: resSpec,
+			Ownership:    common.OwnershipRelease,
+		},
+		GetResult:              live,
+		ManagedFieldsBeforeFix: managedFieldsBeforeFix,
+	}
+}
@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+const dryApplyCacheEntryExt = ".json"
+
+// Caches results of server-side dry-run applies on the filesystem, so that `release install` can
+// reuse what the preceding `release plan install` got instead of doing the same dry-run applies
+// again. An entry is keyed by a hash of the applied manifest, the apply options and the UID and
+// resourceVersion of the resource in the cluster, so it is only reused while neither the manifest
+// nor the resource in the cluster have changed.
+type DryApplyCache struct {
+	dir      string
+	lifetime time.Duration
+}
+
+// Creates the cache directory and removes expired entries from it.
+func NewDryApplyCache(ctx context.Context, dir string, lifetime time.Duration) (*DryApplyCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create dry apply cache dir %q: %w", dir, err)
+	}
+
+	cache := &DryApplyCache{
+		dir:      dir,
+		lifetime: lifetime,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dry apply cache dir %q: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && cache.expired(info) {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				log.Default.Debug(ctx, "Unable to remove expired dry apply cache entry %q: %s", entry.Name(), err)
+			}
+		}
+	}
+
+	return cache, nil
+}
+
+// Returns nil if there is no entry or the entry has expired.
+func (c *DryApplyCache) Get(ctx context.Context, key string) *unstructured.Unstructured {
+	path := c.entryPath(key)
+
+	info, err := os.Stat(path)
+	if err != nil || c.expired(info) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Default.Debug(ctx, "Unable to read dry apply cache entry %q: %s", path, err)
+
+		return nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		log.Default.Debug(ctx, "Unable to decode dry apply cache entry %q: %s", path, err)
+
+		return nil
+	}
+
+	return obj
+}
+
+// Failing to save an entry is not an error, the dry-run apply will just be done again next time.
+func (c *DryApplyCache) Put(ctx context.Context, key string, obj *unstructured.Unstructured) {
+	if err := c.put(key, obj); err != nil {
+		log.Default.Debug(ctx, "Unable to save dry apply cache entry: %s", err)
+	}
+}
+
+func (c *DryApplyCache) put(key string, obj *unstructured.Unstructured) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal dry apply result: %w", err)
+	}
+
+	// Secret values might end up in any resource, e.g. in a ConfigMap. Results with them are not
+	// cached, since masking them would break the diffs made from the cached results.
+	if log.MaskSecrets(string(data)) != string(data) {
+		return nil
+	}
+
+	// Concurrent nelm processes might write the same entry, so it's written to a temp file first,
+	// which is then atomically renamed.
+	file, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return fmt.Errorf("write temp file %q: %w", file.Name(), err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close temp file %q: %w", file.Name(), err)
+	}
+
+	if err := os.Rename(file.Name(), c.entryPath(key)); err != nil {
+		return fmt.Errorf("rename temp file %q: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+func (c *DryApplyCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+dryApplyCacheEntryExt)
+}
+
+func (c *DryApplyCache) expired(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) > c.lifetime
+}
+
+// Returns an empty key if the cache is disabled or results of dry-run applies of the resource must
+// not be cached, e.g. because they contain secret data, which we don't want to keep on disk.
+func buildDryApplyCacheKey(ctx context.Context, cache *DryApplyCache, localRes *resource.InstallableResource, liveObj *unstructured.Unstructured, releaseNamespace, fieldManager string, noForceConflicts bool) string {
+	if cache == nil ||
+		liveObj == nil ||
+		liveObj.GetUID() == "" ||
+		liveObj.GetResourceVersion() == "" ||
+		localRes.GroupVersionKind.GroupKind() == (schema.GroupKind{Kind: "Secret"}) {
+		return ""
+	}
+
+	manifest, err := json.Marshal(localRes.Unstruct.UnstructuredContent())
+	if err != nil {
+		log.Default.Debug(ctx, "Skipping dry apply cache for resource %q: marshal manifest: %s", localRes.IDHuman(), err)
+
+		return ""
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(strings.Join([]string{
+		string(liveObj.GetUID()),
+		liveObj.GetResourceVersion(),
+		releaseNamespace,
+		fieldManager,
+		strconv.FormatBool(noForceConflicts),
+	}, "\x00")))
+	hash.Write([]byte{0})
+	hash.Write(manifest)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
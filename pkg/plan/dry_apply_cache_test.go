@@ -0,0 +1,82 @@
+package plan_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/plan"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+func TestDryApplyCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cache, err := plan.NewDryApplyCache(ctx, dir, time.Hour)
+	require.NoError(t, err)
+
+	localRes := &resource.InstallableResource{ResourceSpec: defaultResourceSpec("test-release", "test-namespace")}
+
+	liveObj := localRes.Unstruct.DeepCopy()
+	liveObj.SetUID("uid")
+	liveObj.SetResourceVersion("1")
+
+	key := plan.BuildDryApplyCacheKey(ctx, cache, localRes, liveObj, "test-namespace", common.DefaultFieldManager, false)
+	require.NotEmpty(t, key)
+	assert.Equal(t, key, plan.BuildDryApplyCacheKey(ctx, cache, localRes, liveObj.DeepCopy(), "test-namespace", common.DefaultFieldManager, false))
+	assert.NotEqual(t, key, plan.BuildDryApplyCacheKey(ctx, cache, localRes, liveObj, "test-namespace", common.DefaultFieldManager, true))
+	assert.Empty(t, plan.BuildDryApplyCacheKey(ctx, nil, localRes, liveObj, "test-namespace", common.DefaultFieldManager, false))
+
+	assert.Nil(t, cache.Get(ctx, key))
+
+	dryApplyObj := liveObj.DeepCopy()
+	require.NoError(t, unstructured.SetNestedField(dryApplyObj.Object, "applied", "data", "key"))
+
+	cache.Put(ctx, key, dryApplyObj)
+	assert.Equal(t, dryApplyObj, cache.Get(ctx, key))
+
+	// The resource changed in the cluster.
+	liveObj.SetResourceVersion("2")
+	assert.NotEqual(t, key, plan.BuildDryApplyCacheKey(ctx, cache, localRes, liveObj, "test-namespace", common.DefaultFieldManager, false))
+
+	// Secret data is never saved to disk.
+	secretRes := &resource.InstallableResource{ResourceSpec: defaultResourceSpec("test-release", "test-namespace")}
+	secretRes.GroupVersionKind.Kind = "Secret"
+	assert.Empty(t, plan.BuildDryApplyCacheKey(ctx, cache, secretRes, liveObj, "test-namespace", common.DefaultFieldManager, false))
+
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	expiredTime := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(entries[0], expiredTime, expiredTime))
+	assert.Nil(t, cache.Get(ctx, key))
+
+	_, err = plan.NewDryApplyCache(ctx, dir, time.Hour)
+	require.NoError(t, err)
+	assert.NoFileExists(t, entries[0])
+}
+
+func TestDryApplyCacheSkipsSecretValues(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := plan.NewDryApplyCache(ctx, t.TempDir(), time.Hour)
+	require.NoError(t, err)
+
+	log.AddSecretValuesToMask("dry-apply-cache-secret")
+
+	dryApplyObj := defaultResourceSpec("test-release", "test-namespace").Unstruct.DeepCopy()
+	require.NoError(t, unstructured.SetNestedField(dryApplyObj.Object, "dry-apply-cache-secret", "data", "key"))
+
+	cache.Put(ctx, "key", dryApplyObj)
+	assert.Nil(t, cache.Get(ctx, "key"))
+}
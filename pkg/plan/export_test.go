@@ -3,5 +3,7 @@ package plan
 var (
 	BuildInstallableResourceInfo                    = buildInstallableResourceInfo
 	BuildDeletableResourceInfo                      = buildDeletableResourceInfo
+	BuildDryApplyCacheKey                           = buildDryApplyCacheKey
+	FixManagedFields                                = fixManagedFields
 	ForceReadinessTrackingForReadyDependencyTargets = forceReadinessTrackingForReadyDependencyTargets
 )
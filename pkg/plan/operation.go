@@ -31,6 +31,7 @@ const (
 	OperationTypeTrackAbsence   OperationType = "track-absence"
 	OperationTypeTrackPresence  OperationType = "track-presence"
 	OperationTypeTrackReadiness OperationType = "track-readiness"
+	OperationTypeTrackRelease   OperationType = "track-release"
 	OperationTypeUpdate         OperationType = "update"
 	OperationTypeUpdateRelease  OperationType = "update-release"
 
@@ -44,6 +45,7 @@ const (
 	OperationVersionTrackAbsence   OperationVersion = 1
 	OperationVersionTrackPresence  OperationVersion = 1
 	OperationVersionTrackReadiness OperationVersion = 1
+	OperationVersionTrackRelease   OperationVersion = 1
 	OperationVersionUpdate         OperationVersion = 1
 	OperationVersionUpdateRelease  OperationVersion = 1
 )
@@ -116,6 +118,8 @@ func (o *Operation) UnmarshalJSON(data []byte) error {
 		o.Config = &OperationConfigTrackPresence{}
 	case OperationTypeTrackAbsence:
 		o.Config = &OperationConfigTrackAbsence{}
+	case OperationTypeTrackRelease:
+		o.Config = &OperationConfigTrackRelease{}
 	case OperationTypeCreateRelease:
 		o.Config = &OperationConfigCreateRelease{}
 	case OperationTypeUpdateRelease:
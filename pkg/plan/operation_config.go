@@ -8,6 +8,7 @@ import (
 
 	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
 	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
 	"github.com/werf/nelm/pkg/resource/spec"
 )
 
@@ -22,6 +23,7 @@ var (
 	_ OperationConfig = (*OperationConfigTrackAbsence)(nil)
 	_ OperationConfig = (*OperationConfigTrackPresence)(nil)
 	_ OperationConfig = (*OperationConfigTrackReadiness)(nil)
+	_ OperationConfig = (*OperationConfigTrackRelease)(nil)
 	_ OperationConfig = (*OperationConfigUpdate)(nil)
 	_ OperationConfig = (*OperationConfigUpdateRelease)(nil)
 )
@@ -47,8 +49,10 @@ func (c *OperationConfigNoop) IDHuman() string {
 }
 
 type OperationConfigCreate struct {
-	ResourceSpec  *spec.ResourceSpec `json:"resourceSpec"`
-	ForceReplicas *int               `json:"forceReplicas,omitempty"`
+	ResourceSpec     *spec.ResourceSpec `json:"resourceSpec"`
+	FieldManager     string             `json:"fieldManager,omitempty"`
+	ForceReplicas    *int               `json:"forceReplicas,omitempty"`
+	NoForceConflicts bool               `json:"noForceConflicts,omitempty"`
 }
 
 func (c *OperationConfigCreate) ID() string {
@@ -62,7 +66,9 @@ func (c *OperationConfigCreate) IDHuman() string {
 type OperationConfigRecreate struct {
 	ResourceSpec      *spec.ResourceSpec         `json:"resourceSpec"`
 	DeletePropagation metav1.DeletionPropagation `json:"deletePropagation"`
+	FieldManager      string                     `json:"fieldManager,omitempty"`
 	ForceReplicas     *int                       `json:"forceReplicas,omitempty"`
+	NoForceConflicts  bool                       `json:"noForceConflicts,omitempty"`
 }
 
 func (c *OperationConfigRecreate) ID() string {
@@ -74,7 +80,9 @@ func (c *OperationConfigRecreate) IDHuman() string {
 }
 
 type OperationConfigUpdate struct {
-	ResourceSpec *spec.ResourceSpec `json:"resourceSpec"`
+	ResourceSpec     *spec.ResourceSpec `json:"resourceSpec"`
+	FieldManager     string             `json:"fieldManager,omitempty"`
+	NoForceConflicts bool               `json:"noForceConflicts,omitempty"`
 }
 
 func (c *OperationConfigUpdate) ID() string {
@@ -86,7 +94,9 @@ func (c *OperationConfigUpdate) IDHuman() string {
 }
 
 type OperationConfigApply struct {
-	ResourceSpec *spec.ResourceSpec `json:"resourceSpec"`
+	ResourceSpec     *spec.ResourceSpec `json:"resourceSpec"`
+	FieldManager     string             `json:"fieldManager,omitempty"`
+	NoForceConflicts bool               `json:"noForceConflicts,omitempty"`
 }
 
 func (c *OperationConfigApply) ID() string {
@@ -160,6 +170,18 @@ func (c *OperationConfigTrackAbsence) IDHuman() string {
 	return c.ResourceMeta.IDHuman()
 }
 
+type OperationConfigTrackRelease struct {
+	ReleaseDependency *resource.ExternalReleaseDependency `json:"releaseDependency"`
+}
+
+func (c *OperationConfigTrackRelease) ID() string {
+	return c.ReleaseDependency.ID()
+}
+
+func (c *OperationConfigTrackRelease) IDHuman() string {
+	return c.ReleaseDependency.IDHuman()
+}
+
 type OperationConfigCreateRelease struct {
 	Release *helmrelease.Release `json:"release"`
 }
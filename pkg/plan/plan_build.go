@@ -13,6 +13,9 @@ import (
 )
 
 type BuildPlanOptions struct {
+	// Field manager name used for server-side apply of resources. Defaults to
+	// common.DefaultFieldManager if not set.
+	FieldManager    string
 	NoFinalTracking bool
 }
 
@@ -67,7 +70,7 @@ func BuildPlan(installableInfos []*InstallableResourceInfo, deletableInfos []*De
 		return plan, fmt.Errorf("add delete resources operations: %w", err)
 	}
 
-	if err := addInstallResourceOps(plan, installableInfos); err != nil {
+	if err := addInstallResourceOps(plan, installableInfos, opts.FieldManager); err != nil {
 		return plan, fmt.Errorf("add install resource operations: %w", err)
 	}
 
@@ -383,7 +386,7 @@ func addFailureResourceOperations(failedPlan, plan *Plan, infos []*InstallableRe
 	return nil
 }
 
-func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
+func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo, fieldManager string) error {
 	for _, info := range infos {
 		chain := plan.AddOperationChain()
 
@@ -406,6 +409,18 @@ func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
 				}
 				chain.AddOperation(trackOp).Stage(stg).SkipOnDuplicate()
 			}
+
+			for _, relDep := range info.LocalResource.ExternalReleaseDependencies {
+				trackOp := &Operation{
+					Type:     OperationTypeTrackRelease,
+					Version:  OperationVersionTrackRelease,
+					Category: OperationCategoryTrack,
+					Config: &OperationConfigTrackRelease{
+						ReleaseDependency: relDep,
+					},
+				}
+				chain.AddOperation(trackOp).Stage(stg).SkipOnDuplicate()
+			}
 		}
 
 		switch info.MustInstall {
@@ -416,8 +431,10 @@ func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
 				Category:  OperationCategoryResource,
 				Iteration: OperationIteration(info.Iteration),
 				Config: &OperationConfigCreate{
-					ResourceSpec:  info.LocalResource.ResourceSpec,
-					ForceReplicas: info.LocalResource.DefaultReplicasOnCreation,
+					ResourceSpec:     info.LocalResource.ResourceSpec,
+					FieldManager:     fieldManager,
+					ForceReplicas:    info.LocalResource.DefaultReplicasOnCreation,
+					NoForceConflicts: info.LocalResource.ApplyConflicts == common.ApplyConflictsFail,
 				},
 			}
 			chain.AddOperation(createOp).Stage(stg)
@@ -429,8 +446,10 @@ func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
 				Iteration: OperationIteration(info.Iteration),
 				Config: &OperationConfigRecreate{
 					ResourceSpec:      info.LocalResource.ResourceSpec,
-					ForceReplicas:     info.LocalResource.DefaultReplicasOnCreation,
 					DeletePropagation: info.LocalResource.DeletePropagation,
+					FieldManager:      fieldManager,
+					ForceReplicas:     info.LocalResource.DefaultReplicasOnCreation,
+					NoForceConflicts:  info.LocalResource.ApplyConflicts == common.ApplyConflictsFail,
 				},
 			}
 			chain.AddOperation(recreateOp).Stage(stg)
@@ -441,7 +460,9 @@ func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
 				Category:  OperationCategoryResource,
 				Iteration: OperationIteration(info.Iteration),
 				Config: &OperationConfigUpdate{
-					ResourceSpec: info.LocalResource.ResourceSpec,
+					ResourceSpec:     info.LocalResource.ResourceSpec,
+					FieldManager:     fieldManager,
+					NoForceConflicts: info.LocalResource.ApplyConflicts == common.ApplyConflictsFail,
 				},
 			}
 			chain.AddOperation(updateOp).Stage(stg)
@@ -452,7 +473,9 @@ func addInstallResourceOps(plan *Plan, infos []*InstallableResourceInfo) error {
 				Category:  OperationCategoryResource,
 				Iteration: OperationIteration(info.Iteration),
 				Config: &OperationConfigApply{
-					ResourceSpec: info.LocalResource.ResourceSpec,
+					ResourceSpec:     info.LocalResource.ResourceSpec,
+					FieldManager:     fieldManager,
+					NoForceConflicts: info.LocalResource.ApplyConflicts == common.ApplyConflictsFail,
 				},
 			}
 			chain.AddOperation(applyOp).Stage(stg)
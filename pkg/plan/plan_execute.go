@@ -9,7 +9,6 @@ import (
 	"github.com/samber/lo"
 	"github.com/sourcegraph/conc/pool"
 
-	"github.com/werf/kubedog/pkg/informer"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/logstore"
 	"github.com/werf/kubedog/pkg/trackers/dyntracker/statestore"
@@ -23,9 +22,13 @@ import (
 	"github.com/werf/nelm/pkg/util"
 )
 
+const trackReleaseInterval = 3 * time.Second
+
 type ExecutePlanOptions struct {
 	common.TrackingOptions
 
+	// GetReleaseStorage is used to check the state of other releases the resources depend on.
+	GetReleaseStorage        release.ReleaseStorageGetter
 	InstallableResourceInfos []*InstallableResourceInfo
 	LegacyProgressReporter   *LegacyProgressReporter
 	NetworkParallelism       int
@@ -35,7 +38,7 @@ type ExecutePlanOptions struct {
 // etc.). All the differences between these plans must be figured out earlier, e.g. in BuildPlan.
 // This generic design must be preserved. Keep it simple: if something can be done on earlier
 // stages, do it there.
-func ExecutePlan(parentCtx context.Context, releaseNamespace string, plan *Plan, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], history release.Historier, clientFactory kube.ClientFactorier, opts ExecutePlanOptions) error {
+func ExecutePlan(parentCtx context.Context, releaseNamespace string, plan *Plan, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, history release.Historier, clientFactory kube.ClientFactorier, opts ExecutePlanOptions) error {
 	ctx, ctxCancelFn := context.WithCancelCause(parentCtx)
 	defer ctxCancelFn(fmt.Errorf("context canceled: plan execution finished"))
 
@@ -78,7 +81,7 @@ func ExecutePlan(parentCtx context.Context, releaseNamespace string, plan *Plan,
 		executableOpsIDs := findExecutableOpsIDs(opsMap)
 		for _, opID := range executableOpsIDs {
 			delete(opsMap, opID)
-			execOperation(opID, releaseNamespace, completedOpsIDsCh, workerPool, plan, taskStore, logStore, informerFactory, history, clientFactory, ctxCancelFn, opts.TrackReadinessTimeout, opts.TrackCreationTimeout, opts.TrackDeletionTimeout, opts.LegacyProgressReporter)
+			execOperation(opID, releaseNamespace, completedOpsIDsCh, workerPool, plan, taskStore, logStore, getInformerFactory, history, opts.GetReleaseStorage, clientFactory, ctxCancelFn, opts.TrackReadinessTimeout, opts.TrackCreationTimeout, opts.TrackDeletionTimeout, opts.LegacyProgressReporter)
 		}
 	}
 
@@ -95,7 +98,7 @@ func ExecutePlan(parentCtx context.Context, releaseNamespace string, plan *Plan,
 	return nil
 }
 
-func execOperation(opID, releaseNamespace string, completedOpsIDsCh chan string, workerPool *pool.ContextPool, plan *Plan, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], history release.Historier, clientFactory kube.ClientFactorier, ctxCancelFn context.CancelCauseFunc, readinessTimeout, presenceTimeout, absenceTimeout time.Duration, reporter *LegacyProgressReporter) {
+func execOperation(opID, releaseNamespace string, completedOpsIDsCh chan string, workerPool *pool.ContextPool, plan *Plan, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, history release.Historier, getReleaseStorage release.ReleaseStorageGetter, clientFactory kube.ClientFactorier, ctxCancelFn context.CancelCauseFunc, readinessTimeout, presenceTimeout, absenceTimeout time.Duration, reporter *LegacyProgressReporter) {
 	workerPool.Go(func(ctx context.Context) error {
 		var err error
 		defer func() {
@@ -109,7 +112,7 @@ func execOperation(opID, releaseNamespace string, completedOpsIDsCh chan string,
 
 		log.Default.Debug(ctx, util.Capitalize(op.IDHuman()))
 
-		if err = execOp(ctx, op, releaseNamespace, taskStore, logStore, informerFactory, history, clientFactory, readinessTimeout, presenceTimeout, absenceTimeout); err != nil {
+		if err = execOp(ctx, op, releaseNamespace, taskStore, logStore, getInformerFactory, history, getReleaseStorage, clientFactory, readinessTimeout, presenceTimeout, absenceTimeout); err != nil {
 			reportOperationStatus(op, OperationStatusFailed, reporter)
 
 			return fmt.Errorf("execute operation: %w", err)
@@ -123,12 +126,12 @@ func execOperation(opID, releaseNamespace string, completedOpsIDsCh chan string,
 	})
 }
 
-func execOp(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], history release.Historier, clientFactory kube.ClientFactorier, readinessTimeout, presenceTimeout, absenceTimeout time.Duration) error {
+func execOp(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, history release.Historier, getReleaseStorage release.ReleaseStorageGetter, clientFactory kube.ClientFactorier, readinessTimeout, presenceTimeout, absenceTimeout time.Duration) error {
 	switch op.Type {
 	case OperationTypeCreate:
 		return execOpCreate(ctx, op, releaseNamespace, clientFactory)
 	case OperationTypeRecreate:
-		return execOpRecreate(ctx, op, releaseNamespace, taskStore, informerFactory, absenceTimeout, clientFactory)
+		return execOpRecreate(ctx, op, releaseNamespace, taskStore, getInformerFactory, absenceTimeout, clientFactory)
 	case OperationTypeUpdate:
 		return execOpUpdate(ctx, op, releaseNamespace, clientFactory)
 	case OperationTypeApply:
@@ -136,11 +139,13 @@ func execOp(ctx context.Context, op *Operation, releaseNamespace string, taskSto
 	case OperationTypeDelete:
 		return execOpDelete(ctx, op, releaseNamespace, clientFactory)
 	case OperationTypeTrackReadiness:
-		return execOpTrackReadiness(ctx, op, releaseNamespace, taskStore, logStore, informerFactory, readinessTimeout, clientFactory)
+		return execOpTrackReadiness(ctx, op, releaseNamespace, taskStore, logStore, getInformerFactory, readinessTimeout, clientFactory)
 	case OperationTypeTrackPresence:
-		return execOpTrackPresence(ctx, op, releaseNamespace, taskStore, informerFactory, presenceTimeout, clientFactory)
+		return execOpTrackPresence(ctx, op, releaseNamespace, taskStore, getInformerFactory, presenceTimeout, clientFactory)
 	case OperationTypeTrackAbsence:
-		return execOpTrackAbsence(ctx, op, releaseNamespace, taskStore, informerFactory, absenceTimeout, clientFactory)
+		return execOpTrackAbsence(ctx, op, releaseNamespace, taskStore, getInformerFactory, absenceTimeout, clientFactory)
+	case OperationTypeTrackRelease:
+		return execOpTrackRelease(ctx, op, getReleaseStorage, presenceTimeout)
 	case OperationTypeCreateRelease:
 		return execOpCreateRelease(ctx, op, history)
 	case OperationTypeUpdateRelease:
@@ -155,7 +160,7 @@ func execOp(ctx context.Context, op *Operation, releaseNamespace string, taskSto
 	return nil
 }
 
-func execOpRecreate(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], absenceTimeout time.Duration, clientFactory kube.ClientFactorier) error {
+func execOpRecreate(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], getInformerFactory kube.InformerFactoryGetter, absenceTimeout time.Duration, clientFactory kube.ClientFactorier) error {
 	opConfig := op.Config.(*OperationConfigRecreate)
 
 	if err := clientFactory.KubeClient().Delete(ctx, opConfig.ResourceSpec.ResourceMeta, kube.KubeClientDeleteOptions{
@@ -178,6 +183,13 @@ func execOpRecreate(ctx context.Context, op *Operation, releaseNamespace string,
 		ts.AddAbsenceTaskState(taskState)
 	})
 
+	informerFactory, releaseInformerFactory, err := getInformerFactory(opConfig.ResourceSpec.GroupVersionKind, namespace)
+	if err != nil {
+		return fmt.Errorf("get informer factory: %w", err)
+	}
+
+	defer releaseInformerFactory()
+
 	tracker := dyntracker.NewDynamicAbsenceTracker(taskState, informerFactory, clientFactory.Dynamic(), clientFactory.Mapper(), dyntracker.DynamicAbsenceTrackerOptions{
 		Timeout: absenceTimeout,
 	})
@@ -188,7 +200,9 @@ func execOpRecreate(ctx context.Context, op *Operation, releaseNamespace string,
 
 	if _, err := clientFactory.KubeClient().Create(ctx, opConfig.ResourceSpec, kube.KubeClientCreateOptions{
 		DefaultNamespace:    releaseNamespace,
+		FieldManager:        opConfig.FieldManager,
 		ForceReplicas:       opConfig.ForceReplicas,
+		NoForceConflicts:    opConfig.NoForceConflicts,
 		RetryOnWebhookError: true,
 	}); err != nil {
 		return fmt.Errorf("create resource: %w", err)
@@ -197,7 +211,7 @@ func execOpRecreate(ctx context.Context, op *Operation, releaseNamespace string,
 	return nil
 }
 
-func execOpTrackAbsence(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], timeout time.Duration, clientFactory kube.ClientFactorier) error {
+func execOpTrackAbsence(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], getInformerFactory kube.InformerFactoryGetter, timeout time.Duration, clientFactory kube.ClientFactorier) error {
 	opConfig := op.Config.(*OperationConfigTrackAbsence)
 
 	namespace, err := getNamespace(ctx, opConfig.ResourceMeta, releaseNamespace, clientFactory)
@@ -213,6 +227,13 @@ func execOpTrackAbsence(ctx context.Context, op *Operation, releaseNamespace str
 		ts.AddAbsenceTaskState(taskState)
 	})
 
+	informerFactory, releaseInformerFactory, err := getInformerFactory(opConfig.ResourceMeta.GroupVersionKind, namespace)
+	if err != nil {
+		return fmt.Errorf("get informer factory: %w", err)
+	}
+
+	defer releaseInformerFactory()
+
 	tracker := dyntracker.NewDynamicAbsenceTracker(taskState, informerFactory, clientFactory.Dynamic(), clientFactory.Mapper(), dyntracker.DynamicAbsenceTrackerOptions{
 		Timeout: timeout,
 	})
@@ -224,7 +245,7 @@ func execOpTrackAbsence(ctx context.Context, op *Operation, releaseNamespace str
 	return nil
 }
 
-func execOpTrackPresence(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], timeout time.Duration, clientFactory kube.ClientFactorier) error {
+func execOpTrackPresence(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], getInformerFactory kube.InformerFactoryGetter, timeout time.Duration, clientFactory kube.ClientFactorier) error {
 	opConfig := op.Config.(*OperationConfigTrackPresence)
 
 	namespace, err := getNamespace(ctx, opConfig.ResourceMeta, releaseNamespace, clientFactory)
@@ -240,6 +261,13 @@ func execOpTrackPresence(ctx context.Context, op *Operation, releaseNamespace st
 		ts.AddPresenceTaskState(taskState)
 	})
 
+	informerFactory, releaseInformerFactory, err := getInformerFactory(opConfig.ResourceMeta.GroupVersionKind, namespace)
+	if err != nil {
+		return fmt.Errorf("get informer factory: %w", err)
+	}
+
+	defer releaseInformerFactory()
+
 	tracker := dyntracker.NewDynamicPresenceTracker(taskState, informerFactory, clientFactory.Dynamic(), clientFactory.Mapper(), dyntracker.DynamicPresenceTrackerOptions{
 		Timeout: timeout,
 	})
@@ -251,7 +279,44 @@ func execOpTrackPresence(ctx context.Context, op *Operation, releaseNamespace st
 	return nil
 }
 
-func execOpTrackReadiness(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], informerFactory *kdutil.Concurrent[*informer.InformerFactory], timeout time.Duration, clientFactory kube.ClientFactorier) error {
+func execOpTrackRelease(ctx context.Context, op *Operation, getReleaseStorage release.ReleaseStorageGetter, timeout time.Duration) error {
+	opConfig := op.Config.(*OperationConfigTrackRelease)
+
+	if getReleaseStorage == nil {
+		return fmt.Errorf("can't track %s: release storage is not available", opConfig.IDHuman())
+	}
+
+	if timeout > 0 {
+		var ctxCancelFn context.CancelFunc
+
+		ctx, ctxCancelFn = context.WithTimeout(ctx, timeout)
+		defer ctxCancelFn()
+	}
+
+	ticker := time.NewTicker(trackReleaseInterval)
+	defer ticker.Stop()
+
+	for {
+		satisfied, reason, err := release.ReleaseDependencySatisfied(opConfig.ReleaseDependency, getReleaseStorage)
+		if err != nil {
+			return fmt.Errorf("check release dependency: %w", err)
+		}
+
+		if satisfied {
+			return nil
+		}
+
+		log.Default.Debug(ctx, "Waiting for %s: %s", opConfig.IDHuman(), reason)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for %s: %s: %w", opConfig.IDHuman(), reason, context.Cause(ctx))
+		case <-ticker.C:
+		}
+	}
+}
+
+func execOpTrackReadiness(ctx context.Context, op *Operation, releaseNamespace string, taskStore *kdutil.Concurrent[*statestore.TaskStore], logStore *kdutil.Concurrent[*logstore.LogStore], getInformerFactory kube.InformerFactoryGetter, timeout time.Duration, clientFactory kube.ClientFactorier) error {
 	opConfig := op.Config.(*OperationConfigTrackReadiness)
 
 	namespace, err := getNamespace(ctx, opConfig.ResourceMeta, releaseNamespace, clientFactory)
@@ -270,6 +335,13 @@ func execOpTrackReadiness(ctx context.Context, op *Operation, releaseNamespace s
 		ts.AddReadinessTaskState(taskState)
 	})
 
+	informerFactory, releaseInformerFactory, err := getInformerFactory(opConfig.ResourceMeta.GroupVersionKind, namespace)
+	if err != nil {
+		return fmt.Errorf("get informer factory: %w", err)
+	}
+
+	defer releaseInformerFactory()
+
 	tracker, err := dyntracker.NewDynamicReadinessTracker(ctx, taskState, logStore, informerFactory, clientFactory.Static(), clientFactory.Dynamic(), clientFactory.Discovery(), clientFactory.Mapper(), dyntracker.DynamicReadinessTrackerOptions{
 		Timeout:                                  timeout,
 		NoActivityTimeout:                        opConfig.NoActivityTimeout,
@@ -301,6 +373,8 @@ func execOpApply(ctx context.Context, op *Operation, releaseNamespace string, cl
 
 	if _, err := clientFactory.KubeClient().Apply(ctx, opConfig.ResourceSpec, kube.KubeClientApplyOptions{
 		DefaultNamespace:    releaseNamespace,
+		FieldManager:        opConfig.FieldManager,
+		NoForceConflicts:    opConfig.NoForceConflicts,
 		RetryOnWebhookError: true,
 	}); err != nil {
 		return fmt.Errorf("apply resource: %w", err)
@@ -314,7 +388,9 @@ func execOpCreate(ctx context.Context, op *Operation, releaseNamespace string, c
 
 	if _, err := clientFactory.KubeClient().Create(ctx, opConfig.ResourceSpec, kube.KubeClientCreateOptions{
 		DefaultNamespace:    releaseNamespace,
+		FieldManager:        opConfig.FieldManager,
 		ForceReplicas:       opConfig.ForceReplicas,
+		NoForceConflicts:    opConfig.NoForceConflicts,
 		RetryOnWebhookError: true,
 	}); err != nil {
 		return fmt.Errorf("create resource: %w", err)
@@ -361,6 +437,8 @@ func execOpUpdate(ctx context.Context, op *Operation, releaseNamespace string, c
 
 	if _, err := clientFactory.KubeClient().Apply(ctx, opConfig.ResourceSpec, kube.KubeClientApplyOptions{
 		DefaultNamespace:    releaseNamespace,
+		FieldManager:        opConfig.FieldManager,
+		NoForceConflicts:    opConfig.NoForceConflicts,
 		RetryOnWebhookError: true,
 	}); err != nil {
 		return fmt.Errorf("apply resource: %w", err)
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/sourcegraph/conc/pool"
 	"github.com/wI2L/jsondiff"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,6 +34,9 @@ const (
 	ResourceInstallTypeUpdate   ResourceInstallType = "update"
 	// When we can't figure out whether to create or update the resource, we blindly apply it
 	ResourceInstallTypeApply ResourceInstallType = "apply"
+
+	// How many times an operation throttled by the API server is attempted in total.
+	maxThrottledAttempts = 3
 )
 
 var OrderedResourceInstallTypes = []ResourceInstallType{
@@ -53,6 +58,8 @@ type InstallableResourceInfo struct {
 	GetResult      *unstructured.Unstructured    `json:"getResult"`
 	DryApplyResult *unstructured.Unstructured    `json:"dryApplyResult"`
 	DryApplyErr    error                         `json:"dryApplyErr"`
+	// Managed fields of the resource in the cluster before we fixed them. Nil if no fix was needed.
+	ManagedFieldsBeforeFix []v1.ManagedFieldsEntry `json:"managedFieldsBeforeFix,omitempty"`
 
 	MustInstall                   ResourceInstallType `json:"mustInstall"`
 	MustDeleteOnSuccessfulInstall bool                `json:"mustDeleteOnSuccessfulInstall"`
@@ -78,9 +85,15 @@ type DeletableResourceInfo struct {
 }
 
 type BuildResourceInfosOptions struct {
+	// If set, results of server-side dry-run applies are reused from and saved to this cache.
+	DryApplyCache *DryApplyCache
+	// Defaults to common.DefaultFieldManager if not set.
+	FieldManager                       string
 	LastDeployedOrLastRelResourceSpecs []*spec.ResourceSpec
-	NetworkParallelism                 int
-	NoRemoveManualChanges              bool
+	// The max number of concurrent requests. Reduced automatically while the API server throttles
+	// us.
+	NetworkParallelism    int
+	NoRemoveManualChanges bool
 }
 
 // From Installable/DeletableResource builds Installable/DeletableResourceInfo. If you can do
@@ -89,13 +102,23 @@ type BuildResourceInfosOptions struct {
 // was in BuildPlan, but it became way too complex, so we extracted it here.
 func BuildResourceInfos(ctx context.Context, deployType common.DeployType, releaseName, releaseNamespace string, instResources []*resource.InstallableResource, delResources []*resource.DeletableResource, prevReleaseFailed bool, clientFactory kube.ClientFactorier, opts BuildResourceInfosOptions) (instResourceInfos []*InstallableResourceInfo, delResourceInfos []*DeletableResourceInfo, err error) {
 	totalResourcesCount := len(instResources) + len(delResources)
+	fieldManager := lo.CoalesceOrEmpty(opts.FieldManager, common.DefaultFieldManager)
 
 	routines := lo.Max([]int{len(instResources) / lo.Max([]int{totalResourcesCount, 1}) * opts.NetworkParallelism, 1})
 
+	var throttling *kube.ThrottleCounter
+	if kubeConfig := clientFactory.KubeConfig(); kubeConfig != nil {
+		throttling = kubeConfig.Throttling
+	}
+
+	limiter := util.NewAdaptiveLimiter(opts.NetworkParallelism, throttling.Count)
+
 	instResourcesPool := pool.NewWithResults[[]*InstallableResourceInfo]().WithContext(ctx).WithMaxGoroutines(routines).WithCancelOnError().WithFirstError()
 	for _, res := range instResources {
 		instResourcesPool.Go(func(ctx context.Context) ([]*InstallableResourceInfo, error) {
-			infos, err := buildInstallableResourceInfo(ctx, res, deployType, releaseNamespace, prevReleaseFailed, opts.NoRemoveManualChanges, clientFactory, opts.LastDeployedOrLastRelResourceSpecs)
+			infos, err := withAdaptiveLimit(ctx, limiter, func() ([]*InstallableResourceInfo, error) {
+				return buildInstallableResourceInfo(ctx, res, deployType, releaseNamespace, fieldManager, prevReleaseFailed, opts.NoRemoveManualChanges, clientFactory, opts.LastDeployedOrLastRelResourceSpecs, opts.DryApplyCache)
+			})
 			if err != nil {
 				return nil, fmt.Errorf("build installable resource info: %w", err)
 			}
@@ -109,7 +132,9 @@ func BuildResourceInfos(ctx context.Context, deployType common.DeployType, relea
 	delResourcesPool := pool.NewWithResults[*DeletableResourceInfo]().WithContext(ctx).WithMaxGoroutines(routines).WithCancelOnError().WithFirstError()
 	for _, res := range delResources {
 		delResourcesPool.Go(func(ctx context.Context) (*DeletableResourceInfo, error) {
-			info, err := buildDeletableResourceInfo(ctx, res, deployType, releaseName, releaseNamespace, clientFactory)
+			info, err := withAdaptiveLimit(ctx, limiter, func() (*DeletableResourceInfo, error) {
+				return buildDeletableResourceInfo(ctx, res, deployType, releaseName, releaseNamespace, clientFactory)
+			})
 			if err != nil {
 				return nil, fmt.Errorf("build deletable resource info: %w", err)
 			}
@@ -158,7 +183,7 @@ func ResourceInstallTypeSortHandler(type1, type2 ResourceInstallType) bool {
 }
 
 // TODO(major): keep annotation should probably forbid resource recreations
-func buildInstallableResourceInfo(ctx context.Context, localRes *resource.InstallableResource, deployType common.DeployType, releaseNamespace string, prevRelFailed, noRemoveManualChanges bool, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) ([]*InstallableResourceInfo, error) {
+func buildInstallableResourceInfo(ctx context.Context, localRes *resource.InstallableResource, deployType common.DeployType, releaseNamespace, fieldManager string, prevRelFailed, noRemoveManualChanges bool, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec, dryApplyCache *DryApplyCache) ([]*InstallableResourceInfo, error) {
 	var stages []common.Stage
 	switch deployType {
 	case common.DeployTypeInitial, common.DeployTypeInstall:
@@ -186,26 +211,59 @@ func buildInstallableResourceInfo(ctx context.Context, localRes *resource.Instal
 	}
 
 	var (
-		getMeta          *spec.ResourceMeta
-		dryApplyObj      *unstructured.Unstructured
-		dryApplyErr      error
-		resourcePolicies = localRes.ResourcePolicies
+		getMeta                *spec.ResourceMeta
+		dryApplyObj            *unstructured.Unstructured
+		dryApplyErr            error
+		managedFieldsBeforeFix []v1.ManagedFieldsEntry
+		resourcePolicies       = localRes.ResourcePolicies
 	)
 	if getErr == nil {
-		var err error
+		managedFields := getObj.GetManagedFields()
+
+		var (
+			fixed bool
+			err   error
+		)
 
-		getObj, err = fixManagedFieldsInCluster(ctx, releaseNamespace, getObj, localRes, noRemoveManualChanges, clientFactory, lastDeployedOrLastRelResSpecs)
+		getObj, fixed, err = fixManagedFieldsInCluster(ctx, releaseNamespace, fieldManager, getObj, localRes, noRemoveManualChanges, clientFactory, lastDeployedOrLastRelResSpecs)
 		if err != nil {
 			return nil, fmt.Errorf("fix managed fields for resource %q: %w", localRes.IDHuman(), err)
 		}
 
+		if fixed {
+			managedFieldsBeforeFix = managedFields
+		}
+
 		getMeta = spec.NewResourceMetaFromUnstructured(getObj, releaseNamespace, localRes.FilePath)
 		resourcePolicies = resource.ResolveResourcePolicies(localRes, getMeta, releaseNamespace)
 
-		dryApplyObj, dryApplyErr = clientFactory.KubeClient().Apply(ctx, localRes.ResourceSpec, kube.KubeClientApplyOptions{
-			DefaultNamespace: releaseNamespace,
-			DryRun:           true,
-		})
+		noForceConflicts := localRes.ApplyConflicts == common.ApplyConflictsFail
+		dryApplyCacheKey := buildDryApplyCacheKey(ctx, dryApplyCache, localRes, getObj, releaseNamespace, fieldManager, noForceConflicts)
+
+		if dryApplyCacheKey != "" {
+			dryApplyObj = dryApplyCache.Get(ctx, dryApplyCacheKey)
+		}
+
+		if dryApplyObj != nil {
+			log.Default.Debug(ctx, "Using cached server-side dry-run apply result for resource %q", localRes.IDHuman())
+		} else {
+			dryApplyObj, dryApplyErr = clientFactory.KubeClient().Apply(ctx, localRes.ResourceSpec, kube.KubeClientApplyOptions{
+				DefaultNamespace: releaseNamespace,
+				DryRun:           true,
+				FieldManager:     fieldManager,
+				NoForceConflicts: noForceConflicts,
+			})
+
+			// Otherwise we'd fall back to applying blindly, while the dry-run apply just needs to be
+			// retried later.
+			if kube.IsTooManyRequestsErr(dryApplyErr) {
+				return nil, fmt.Errorf("dry-run apply resource %q: %w", localRes.IDHuman(), dryApplyErr)
+			}
+
+			if dryApplyErr == nil && dryApplyCacheKey != "" {
+				dryApplyCache.Put(ctx, dryApplyCacheKey, dryApplyObj)
+			}
+		}
 	}
 
 	installType, skippedByPolicy, err := resourceInstallType(ctx, localRes, getObj, dryApplyObj, dryApplyErr, resourcePolicies)
@@ -224,6 +282,7 @@ func buildInstallableResourceInfo(ctx context.Context, localRes *resource.Instal
 			FailMode:                       localRes.FailMode,
 			GetResult:                      getObj,
 			LocalResource:                  localRes,
+			ManagedFieldsBeforeFix:         managedFieldsBeforeFix,
 			MustDeleteOnFailedInstall:      mustDeleteOnFailedDeploy(localRes, getMeta, installType, releaseNamespace, trackReadiness, skippedByPolicy),
 			MustDeleteOnSuccessfulInstall:  mustDeleteOnSuccess,
 			MustInstall:                    installType,
@@ -234,11 +293,48 @@ func buildInstallableResourceInfo(ctx context.Context, localRes *resource.Instal
 	}), nil
 }
 
-func fixManagedFieldsInCluster(ctx context.Context, releaseNamespace string, getObj *unstructured.Unstructured, localRes *resource.InstallableResource, noRemoveManualChanges bool, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (*unstructured.Unstructured, error) {
-	if changed, err := fixManagedFields(ctx, getObj, localRes, noRemoveManualChanges, releaseNamespace, clientFactory, lastDeployedOrLastRelResSpecs); err != nil {
-		return nil, fmt.Errorf("fix managed fields for resource %q: %w", localRes.IDHuman(), err)
+// Runs the operation within the limit of the limiter. If the API server throttled the operation
+// even after retries of client-go, the operation is retried a few more times after the delay
+// suggested by the API server.
+func withAdaptiveLimit[T any](ctx context.Context, limiter *util.AdaptiveLimiter, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		if err := limiter.Acquire(ctx); err != nil {
+			var zero T
+
+			return zero, err
+		}
+
+		result, err := fn()
+
+		throttled := kube.IsTooManyRequestsErr(err)
+		limiter.Release(throttled)
+
+		if !throttled || attempt >= maxThrottledAttempts {
+			return result, err
+		}
+
+		delay := time.Second
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+
+		log.Default.Debug(ctx, "Throttled by the API server, retrying in %s with concurrency %d: %s", delay, limiter.Limit(), err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			var zero T
+
+			return zero, context.Cause(ctx)
+		}
+	}
+}
+
+func fixManagedFieldsInCluster(ctx context.Context, releaseNamespace, fieldManager string, getObj *unstructured.Unstructured, localRes *resource.InstallableResource, noRemoveManualChanges bool, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (obj *unstructured.Unstructured, fixed bool, err error) {
+	if changed, err := fixManagedFields(ctx, getObj, localRes, noRemoveManualChanges, releaseNamespace, fieldManager, clientFactory, lastDeployedOrLastRelResSpecs); err != nil {
+		return nil, false, fmt.Errorf("fix managed fields for resource %q: %w", localRes.IDHuman(), err)
 	} else if !changed {
-		return getObj, nil
+		return getObj, false, nil
 	}
 
 	unstruct := unstructured.Unstructured{Object: map[string]interface{}{}}
@@ -246,28 +342,29 @@ func fixManagedFieldsInCluster(ctx context.Context, releaseNamespace string, get
 
 	patch, err := json.Marshal(unstruct.UnstructuredContent())
 	if err != nil {
-		return nil, fmt.Errorf("marshal fixed managed fields for resource %q: %w", localRes.IDHuman(), err)
+		return nil, false, fmt.Errorf("marshal fixed managed fields for resource %q: %w", localRes.IDHuman(), err)
 	}
 
 	log.Default.Debug(ctx, "Fixing managed fields for resource %q", localRes.IDHuman())
 
 	patchedObj, err := clientFactory.KubeClient().MergePatch(ctx, localRes.ResourceMeta, patch, kube.KubeClientMergePatchOptions{
 		DefaultNamespace: releaseNamespace,
+		FieldManager:     fieldManager,
 	})
 	if err != nil {
 		if kube.IsNotFoundErr(err) || kube.IsWebhookErr(err) {
 			log.Default.Debug(ctx, "Skipping managed fields fix for resource %q due to transient error: %s", localRes.IDHuman(), err)
 
-			return getObj, nil
+			return getObj, false, nil
 		}
 
-		return nil, fmt.Errorf("patch managed fields: %w", err)
+		return nil, false, fmt.Errorf("patch managed fields: %w", err)
 	}
 
-	return patchedObj, nil
+	return patchedObj, true, nil
 }
 
-func fixManagedFields(ctx context.Context, unstruct *unstructured.Unstructured, localRes *resource.InstallableResource, noRemoveManualChanges bool, releaseNamespace string, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (changed bool, err error) {
+func fixManagedFields(ctx context.Context, unstruct *unstructured.Unstructured, localRes *resource.InstallableResource, noRemoveManualChanges bool, releaseNamespace, fieldManager string, clientFactory kube.ClientFactorier, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (changed bool, err error) {
 	managedFields := unstruct.GetManagedFields()
 	if len(managedFields) == 0 {
 		return false, nil
@@ -275,12 +372,12 @@ func fixManagedFields(ctx context.Context, unstruct *unstructured.Unstructured,
 
 	var oursEntry v1.ManagedFieldsEntry
 	if e, found := lo.Find(managedFields, func(e v1.ManagedFieldsEntry) bool {
-		return e.Manager == common.DefaultFieldManager && e.Operation == v1.ManagedFieldsOperationApply
+		return e.Manager == fieldManager && e.Operation == v1.ManagedFieldsOperationApply
 	}); found {
 		oursEntry = e
 	} else {
 		oursEntry = v1.ManagedFieldsEntry{
-			Manager:    common.DefaultFieldManager,
+			Manager:    fieldManager,
 			Operation:  v1.ManagedFieldsOperationApply,
 			APIVersion: unstruct.GetAPIVersion(),
 			Time:       lo.ToPtr(v1.Now()),
@@ -290,9 +387,9 @@ func fixManagedFields(ctx context.Context, unstruct *unstructured.Unstructured,
 	}
 
 	if _, found := lo.Find(managedFields, func(e v1.ManagedFieldsEntry) bool {
-		return e.Manager == common.DefaultFieldManager && e.Operation == v1.ManagedFieldsOperationUpdate
+		return isOurFieldManager(e.Manager, fieldManager) && e.Operation == v1.ManagedFieldsOperationUpdate
 	}); found {
-		if newFields, fixed, err := fixHelmUpdateManagedFields(ctx, localRes, releaseNamespace, clientFactory, oursEntry.FieldsV1, lastDeployedOrLastRelResSpecs); err != nil {
+		if newFields, fixed, err := fixHelmUpdateManagedFields(ctx, localRes, releaseNamespace, fieldManager, clientFactory, oursEntry.FieldsV1, lastDeployedOrLastRelResSpecs); err != nil {
 			return false, err
 		} else if fixed {
 			oursEntry.FieldsV1 = newFields
@@ -446,7 +543,7 @@ func exclusiveOwnershipForOurManager(managedFields []v1.ManagedFieldsEntry, ours
 
 		fieldsByte := lo.Must(json.Marshal(managedField.FieldsV1))
 
-		if managedField.Manager == common.DefaultFieldManager ||
+		if isOurFieldManager(managedField.Manager, oursEntry.Manager) ||
 			managedField.Manager == common.KubectlEditFieldManager ||
 			(featgate.FeatGateAdoptDeckhouseControllerFields.Enabled() && managedField.Manager == common.OldDeckhouseControllerManager) ||
 			strings.HasPrefix(managedField.Manager, common.OldFieldManagerPrefix) {
@@ -505,7 +602,7 @@ func findServiceAccountRefBySubPath(data map[string]interface{}, subPath []strin
 	return false, nil
 }
 
-func fixHelmUpdateManagedFields(ctx context.Context, localRes *resource.InstallableResource, releaseNamespace string, clientFactory kube.ClientFactorier, origFields *v1.FieldsV1, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (*v1.FieldsV1, bool, error) {
+func fixHelmUpdateManagedFields(ctx context.Context, localRes *resource.InstallableResource, releaseNamespace, fieldManager string, clientFactory kube.ClientFactorier, origFields *v1.FieldsV1, lastDeployedOrLastRelResSpecs []*spec.ResourceSpec) (*v1.FieldsV1, bool, error) {
 	prevRelResSpec, found := lo.Find(lastDeployedOrLastRelResSpecs, func(s *spec.ResourceSpec) bool {
 		return s.ID() == localRes.ID()
 	})
@@ -516,6 +613,7 @@ func fixHelmUpdateManagedFields(ctx context.Context, localRes *resource.Installa
 	dryApplyObj, err := clientFactory.KubeClient().Apply(ctx, prevRelResSpec, kube.KubeClientApplyOptions{
 		DefaultNamespace: releaseNamespace,
 		DryRun:           true,
+		FieldManager:     fieldManager,
 	})
 	if err != nil {
 		if kube.IsInvalidErr(err) || kube.IsTypedObjectErr(err) {
@@ -528,7 +626,7 @@ func fixHelmUpdateManagedFields(ctx context.Context, localRes *resource.Installa
 	}
 
 	fixedEntry, found := lo.Find(dryApplyObj.GetManagedFields(), func(e v1.ManagedFieldsEntry) bool {
-		return e.Manager == common.DefaultFieldManager && e.Operation == v1.ManagedFieldsOperationApply
+		return e.Manager == fieldManager && e.Operation == v1.ManagedFieldsOperationApply
 	})
 	if !found {
 		return nil, false, fmt.Errorf("find apply managed fields entry in dry-run result")
@@ -679,6 +777,12 @@ func isServiceAccountFieldManaged(entry v1.ManagedFieldsEntry, subPath []string)
 	return false, nil
 }
 
+// The default field manager is also considered ours if a custom field manager is used, so that
+// fields owned by the default field manager are migrated to the custom one.
+func isOurFieldManager(manager, fieldManager string) bool {
+	return manager == fieldManager || manager == common.DefaultFieldManager
+}
+
 func iterateInstallableResourceInfos(infos []*InstallableResourceInfo) {
 	var seenInfos []*InstallableResourceInfo
 	for _, info := range infos {
@@ -812,8 +916,8 @@ func removeUndesirableManagers(managedFields []v1.ManagedFieldsEntry, oursEntry
 
 		fieldsByte := lo.Must(json.Marshal(managedField.FieldsV1))
 
-		if managedField.Manager == common.DefaultFieldManager {
-			if managedField.Operation == v1.ManagedFieldsOperationApply {
+		if isOurFieldManager(managedField.Manager, oursEntry.Manager) {
+			if managedField.Manager == oursEntry.Manager && managedField.Operation == v1.ManagedFieldsOperationApply {
 				continue
 			}
 
@@ -872,6 +976,10 @@ func resourceInstallType(ctx context.Context, localRes *resource.InstallableReso
 			return ResourceInstallTypeNone, true, nil
 		}
 
+		if kube.IsConflictErr(dryApplyErr) {
+			return "", false, fmt.Errorf("conflicts with other field managers in resource %q, but taking ownership of conflicting fields is disabled with %q annotation: %w", localRes.IDHuman(), common.AnnotationKeyHumanApplyConflicts, dryApplyErr)
+		}
+
 		return ResourceInstallTypeApply, false, nil
 	}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/werf/kubedog/pkg/trackers/rollout/multitrack"
@@ -571,6 +572,42 @@ type buildResourceInfosTestCase struct {
 	skip    bool
 }
 
+func (s *ResourceInfoSuite) TestFixManagedFieldsForCustomFieldManager() {
+	localRes := defaultInstallableResource(s.releaseName, s.releaseNamespace)
+
+	unstruct := localRes.Unstruct.DeepCopy()
+	unstruct.SetManagedFields([]v1.ManagedFieldsEntry{
+		{
+			Manager:    common.DefaultFieldManager,
+			Operation:  v1.ManagedFieldsOperationApply,
+			APIVersion: "v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &v1.FieldsV1{Raw: []byte(`{"f:data":{"f:key":{}}}`)},
+		},
+		{
+			Manager:    "some-controller",
+			Operation:  v1.ManagedFieldsOperationUpdate,
+			APIVersion: "v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &v1.FieldsV1{Raw: []byte(`{"f:data":{"f:key":{},"f:other":{}}}`)},
+		},
+	})
+
+	changed, err := plan.FixManagedFields(context.Background(), unstruct, localRes, false, s.releaseNamespace, "custom-manager", s.clientFactory, nil)
+	s.Require().NoError(err)
+	s.Require().True(changed)
+
+	managedFields := unstruct.GetManagedFields()
+	s.Require().Len(managedFields, 2)
+
+	s.Equal("some-controller", managedFields[0].Manager)
+	s.JSONEq(`{"f:data":{"f:other":{}}}`, string(managedFields[0].FieldsV1.Raw))
+
+	s.Equal("custom-manager", managedFields[1].Manager)
+	s.Equal(v1.ManagedFieldsOperationApply, managedFields[1].Operation)
+	s.JSONEq(`{"f:data":{"f:key":{}}}`, string(managedFields[1].FieldsV1.Raw))
+}
+
 func TestResourceSuites(t *testing.T) {
 	suite.Run(t, new(ResourceInfoSuite))
 }
@@ -697,7 +734,7 @@ func runBuildInstallableResourceInfoTest(tc buildInstallableResourceInfoTestCase
 
 		localRes, deployType, prevRelFailed := tc.input()
 
-		resInfos, err := plan.BuildInstallableResourceInfo(context.Background(), localRes, deployType, s.releaseNamespace, prevRelFailed, true, s.clientFactory, nil)
+		resInfos, err := plan.BuildInstallableResourceInfo(context.Background(), localRes, deployType, s.releaseNamespace, common.DefaultFieldManager, prevRelFailed, true, s.clientFactory, nil, nil)
 		s.Require().NoError(err)
 
 		expectResInfos := tc.expect(localRes)
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -11,30 +12,36 @@ import (
 	"github.com/werf/nelm/pkg/util"
 )
 
+type ValidateRemoteOptions struct {
+	// AdoptResources are resource filters in the "key1=value1,key2=value2" format. Resources
+	// matching any of them can be adopted from other releases or from no release.
+	AdoptResources []string
+	// ForceAdoption, when true, allows adopting any resource.
+	ForceAdoption bool
+}
+
 // Should only be called if cluster access is allowed.
-func ValidateRemote(releaseName, releaseNamespace string, installableResourceInfos []*InstallableResourceInfo, forceAdoption bool) error {
-	if !forceAdoption {
-		if err := validateAdoptableResources(releaseName, releaseNamespace, installableResourceInfos); err != nil {
-			return fmt.Errorf("validate adoptable resources: %w", err)
-		}
+func ValidateRemote(ctx context.Context, releaseName, releaseNamespace string, installableResourceInfos []*InstallableResourceInfo, opts ValidateRemoteOptions) error {
+	if err := validateAdoptableResources(ctx, releaseName, releaseNamespace, installableResourceInfos, opts); err != nil {
+		return fmt.Errorf("validate adoptable resources: %w", err)
 	}
 
 	return nil
 }
 
-func validateAdoptableResources(releaseName, releaseNamespace string, resourceInfos []*InstallableResourceInfo) error {
-	validationErrs := &util.MultiError{}
-	for _, info := range resourceInfos {
-		if info.GetResult == nil {
-			continue
-		}
-
-		if info.LocalResource.Ownership == common.OwnershipAnyone {
-			continue
-		}
+func validateAdoptableResources(ctx context.Context, releaseName, releaseNamespace string, resourceInfos []*InstallableResourceInfo, opts ValidateRemoteOptions) error {
+	adoptions, err := BuildAdoptions(ctx, releaseName, releaseNamespace, resourceInfos, BuildAdoptionsOptions{
+		AdoptResources: opts.AdoptResources,
+		ForceAdoption:  opts.ForceAdoption,
+	})
+	if err != nil {
+		return fmt.Errorf("build adoptions: %w", err)
+	}
 
-		if adoptable, nonAdoptableReason := adoptableBy(info.GetResult, releaseName, releaseNamespace); !adoptable {
-			validationErrs.Add(fmt.Errorf("adopt %q: %s", info.IDHuman(), nonAdoptableReason))
+	validationErrs := &util.MultiError{}
+	for _, adoption := range adoptions {
+		if !adoption.Allowed {
+			validationErrs.Add(fmt.Errorf("adopt %q: %s", adoption.IDHuman(), adoption.NonAdoptableReason))
 		}
 	}
 
@@ -5,11 +5,14 @@ import (
 	"hash"
 	"hash/fnv"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/yaml"
@@ -27,6 +30,7 @@ const (
 	ReleaseOutdatedReasonNoPreviousRelease        ReleaseOutdatedReason = "there is no previously deployed release"
 	ReleaseOutdatedReasonReleaseStatusNotDeployed ReleaseOutdatedReason = "the previously deployed release was not successful"
 	ReleaseOutdatedReasonNotesChanged             ReleaseOutdatedReason = "the release notes changed"
+	ReleaseOutdatedReasonTTLChanged               ReleaseOutdatedReason = "the release TTL changed"
 	ReleaseOutdatedReasonValuesChanged            ReleaseOutdatedReason = "the release values changed"
 	ReleaseOutdatedReasonHooksChanged             ReleaseOutdatedReason = "the release hooks changed"
 	ReleaseOutdatedReasonManifestsChanged         ReleaseOutdatedReason = "the release manifests changed"
@@ -45,6 +49,28 @@ type IsReleaseUpToDateResult struct {
 	UpToDate bool
 }
 
+// Check if the Release is marked protected with the "werf.io/protect" info annotation or label.
+// Protected releases must not be uninstalled or rolled back unless the protection is explicitly
+// overridden.
+func IsReleaseProtected(rel *helmrelease.Release) bool {
+	if rel == nil {
+		return false
+	}
+
+	var infoAnnotations map[string]string
+	if rel.Info != nil {
+		infoAnnotations = rel.Info.Annotations
+	}
+
+	for _, values := range []map[string]string{infoAnnotations, rel.Labels} {
+		if protected, err := strconv.ParseBool(values[common.AnnotationKeyHumanProtect]); err == nil && protected {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Check if the new Release is up-to-date compared to the old Release. It doesn't check any
 // resources of the release in the cluster, just compares Release objects.
 func IsReleaseUpToDate(oldRel, newRel *helmrelease.Release) (IsReleaseUpToDateResult, error) {
@@ -68,6 +94,11 @@ func IsReleaseUpToDate(oldRel, newRel *helmrelease.Release) (IsReleaseUpToDateRe
 		return IsReleaseUpToDateResult{Reason: ReleaseOutdatedReasonValuesChanged}, nil
 	}
 
+	// The expiry time is different on every install with TTL, so only the TTL itself is compared.
+	if oldRel.Info.Annotations[common.AnnotationKeyHumanTTL] != newRel.Info.Annotations[common.AnnotationKeyHumanTTL] {
+		return IsReleaseUpToDateResult{Reason: ReleaseOutdatedReasonTTLChanged}, nil
+	}
+
 	oldHookResourcesHash := fnv.New32a()
 	for _, oldHook := range oldRel.Hooks {
 		obj, _, err := scheme.Codecs.UniversalDecoder().Decode([]byte(oldHook.Manifest), nil, &unstructured.Unstructured{})
@@ -223,6 +254,64 @@ func NewRelease(name, namespace string, revision int, deployType common.DeployTy
 	}, nil
 }
 
+// Returns the info annotations of the Release that must be carried over to its next revision, so
+// that protection of the release survives upgrades and rollbacks.
+func ProtectionInfoAnnotations(rel *helmrelease.Release) map[string]string {
+	if !IsReleaseProtected(rel) {
+		return nil
+	}
+
+	return map[string]string{common.AnnotationKeyHumanProtect: "true"}
+}
+
+// Returns the info annotations recording the TTL and that the Release expires after the TTL from
+// now. Returns nil if TTL is not positive.
+func TTLInfoAnnotations(ttl time.Duration) map[string]string {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return map[string]string{
+		common.AnnotationKeyHumanExpiresAt: time.Now().Add(ttl).UTC().Format(time.RFC3339),
+		common.AnnotationKeyHumanTTL:       ttl.String(),
+	}
+}
+
+// Returns the info annotations of the Release recording its TTL and expiry, which must be carried
+// over to its next revision on rollback.
+func ExpiryInfoAnnotations(rel *helmrelease.Release) map[string]string {
+	if rel == nil || rel.Info == nil {
+		return nil
+	}
+
+	annotations := lo.PickByKeys(rel.Info.Annotations, []string{common.AnnotationKeyHumanExpiresAt, common.AnnotationKeyHumanTTL})
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	return annotations
+}
+
+// Returns the expiry time of the Release from the "werf.io/expires-at" info annotation. The second
+// returned value is false if the Release never expires.
+func ReleaseExpiresAt(rel *helmrelease.Release) (time.Time, bool, error) {
+	if rel == nil || rel.Info == nil {
+		return time.Time{}, false, nil
+	}
+
+	value, found := rel.Info.Annotations[common.AnnotationKeyHumanExpiresAt]
+	if !found {
+		return time.Time{}, false, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parse %q info annotation value %q: %w", common.AnnotationKeyHumanExpiresAt, value, err)
+	}
+
+	return expiresAt, true, nil
+}
+
 // Constructs ResourceSpecs from a Release object.
 func ReleaseToResourceSpecs(rel *helmrelease.Release, releaseNamespace string, noCleanNullFields bool /* TODO(major): get rid */) ([]*spec.ResourceSpec, error) {
 	var resources []*spec.ResourceSpec
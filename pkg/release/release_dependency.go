@@ -0,0 +1,58 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+
+	helmdriver "github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+// Checks whether the latest revision of the release from the dependency has the expected status and
+// chart version. If not, returns the reason why.
+func ReleaseDependencySatisfied(dep *resource.ExternalReleaseDependency, getStorage ReleaseStorageGetter) (satisfied bool, reason string, err error) {
+	storage, err := getStorage(dep.ReleaseNamespace)
+	if err != nil {
+		return false, "", fmt.Errorf("get release storage for namespace %q: %w", dep.ReleaseNamespace, err)
+	}
+
+	rel, err := storage.GetRelease(dep.ReleaseName, 0)
+	if err != nil {
+		if errors.Is(err, helmdriver.ErrReleaseNotFound) {
+			return false, "release not found", nil
+		}
+
+		return false, "", fmt.Errorf("get latest revision of release %q (namespace: %q): %w", dep.ReleaseName, dep.ReleaseNamespace, err)
+	}
+
+	if rel.Info.Status != dep.Status {
+		return false, fmt.Sprintf("revision %d has status %q", rel.Version, rel.Info.Status), nil
+	}
+
+	if dep.ChartVersion == "" {
+		return true, "", nil
+	}
+
+	constraint, err := semver.NewConstraint(dep.ChartVersion)
+	if err != nil {
+		return false, "", fmt.Errorf("parse chart version constraint %q: %w", dep.ChartVersion, err)
+	}
+
+	var chartVersion string
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		chartVersion = rel.Chart.Metadata.Version
+	}
+
+	version, err := semver.NewVersion(chartVersion)
+	if err != nil {
+		return false, fmt.Sprintf("revision %d has invalid chart version %q", rel.Version, chartVersion), nil
+	}
+
+	if !constraint.Check(version) {
+		return false, fmt.Sprintf("revision %d has chart version %q", rel.Version, chartVersion), nil
+	}
+
+	return true, "", nil
+}
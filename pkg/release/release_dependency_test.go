@@ -0,0 +1,67 @@
+package release_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/helm/pkg/chart"
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+func TestReleaseDependencySatisfied(t *testing.T) {
+	dir := t.TempDir()
+
+	deployed := newMigrateTestRelease("db", "infra", 1)
+	deployed.Chart = &chart.Chart{Metadata: &chart.Metadata{Name: "postgres", Version: "1.4.0"}}
+
+	failed := newMigrateTestRelease("cache", "infra", 1)
+	failed.Info.Status = helmrelease.StatusFailed
+
+	createMigrateTestReleases(t, dir, deployed, failed)
+
+	tests := []struct {
+		name      string
+		dep       *resource.ExternalReleaseDependency
+		satisfied bool
+		reason    string
+	}{
+		{
+			name:      "deployed",
+			dep:       &resource.ExternalReleaseDependency{ReleaseName: "db", ReleaseNamespace: "infra", Status: helmrelease.StatusDeployed},
+			satisfied: true,
+		},
+		{
+			name:      "matching chart version",
+			dep:       &resource.ExternalReleaseDependency{ReleaseName: "db", ReleaseNamespace: "infra", Status: helmrelease.StatusDeployed, ChartVersion: ">=1.2.0 <2.0.0"},
+			satisfied: true,
+		},
+		{
+			name:   "not matching chart version",
+			dep:    &resource.ExternalReleaseDependency{ReleaseName: "db", ReleaseNamespace: "infra", Status: helmrelease.StatusDeployed, ChartVersion: "^2"},
+			reason: `revision 1 has chart version "1.4.0"`,
+		},
+		{
+			name:   "not matching status",
+			dep:    &resource.ExternalReleaseDependency{ReleaseName: "cache", ReleaseNamespace: "infra", Status: helmrelease.StatusDeployed},
+			reason: `revision 1 has status "failed"`,
+		},
+		{
+			name:   "not found",
+			dep:    &resource.ExternalReleaseDependency{ReleaseName: "db", ReleaseNamespace: "other", Status: helmrelease.StatusDeployed},
+			reason: "release not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			satisfied, reason, err := release.ReleaseDependencySatisfied(tt.dep, filesystemStorageGetter(dir))
+			require.NoError(t, err)
+			assert.Equal(t, tt.satisfied, satisfied)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
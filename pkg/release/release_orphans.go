@@ -0,0 +1,230 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/werf/nelm/pkg/common"
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+const (
+	OrphanReasonNotInRelease       OrphanReason = "not in the last or the last deployed release revision"
+	OrphanReasonReleaseNotFound    OrphanReason = "release not found"
+	OrphanReasonReleaseUninstalled OrphanReason = "release uninstalled"
+)
+
+// Why the resource is considered orphaned.
+type OrphanReason string
+
+// A resource in the cluster with release annotations that point at a release which doesn't
+// reference the resource anymore.
+type OrphanedResource struct {
+	*resource.DeletableResource
+
+	// Namespaced is true if the resource is namespaced. Namespace of the resource is empty if
+	// it matches the release namespace, as with resources stored in the release.
+	Namespaced       bool
+	Reason           OrphanReason
+	ReleaseName      string
+	ReleaseNamespace string
+}
+
+type FindOrphanedResourcesOptions struct {
+	// NetworkParallelism limits the number of resource types listed concurrently.
+	NetworkParallelism int
+	// ReleaseName, if specified, only resources pointing at this release are considered.
+	ReleaseName string
+}
+
+// Scans the namespace (or all namespaces if empty) and cluster-scoped resources for objects
+// managed by Helm whose release annotations point at a non-existent or uninstalled release, or at a
+// release whose last and last deployed revisions don't contain them. Resources with "anyone"
+// ownership, e.g. hooks, are never considered orphaned.
+func FindOrphanedResources(ctx context.Context, namespace string, getStorage ReleaseStorageGetter, clientFactory kube.ClientFactorier, opts FindOrphanedResourcesOptions) ([]*OrphanedResource, error) {
+	opts.NetworkParallelism = lo.Max([]int{opts.NetworkParallelism, 1})
+
+	gvrs, err := listableResources(ctx, clientFactory)
+	if err != nil {
+		return nil, fmt.Errorf("get listable resources: %w", err)
+	}
+
+	objsPool := pool.NewWithResults[[]*unstructured.Unstructured]().WithContext(ctx).WithMaxGoroutines(opts.NetworkParallelism).WithCancelOnError().WithFirstError()
+	for gvr, namespaced := range gvrs {
+		objsPool.Go(func(ctx context.Context) ([]*unstructured.Unstructured, error) {
+			return listHelmManagedObjects(ctx, gvr, lo.Ternary(namespaced, namespace, ""), clientFactory)
+		})
+	}
+
+	objsByType, err := objsPool.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("list resources: %w", err)
+	}
+
+	type releaseKey struct{ name, namespace string }
+
+	candidates := map[releaseKey][]*OrphanedResource{}
+	seenUIDs := map[string]bool{}
+	for _, obj := range lo.Flatten(objsByType) {
+		if seenUIDs[string(obj.GetUID())] {
+			continue
+		}
+
+		seenUIDs[string(obj.GetUID())] = true
+
+		annotations := obj.GetAnnotations()
+
+		relName := annotations[common.AnnotationKeyHumanReleaseName]
+		relNamespace := annotations[common.AnnotationKeyHumanReleaseNamespace]
+		if relName == "" || relNamespace == "" {
+			continue
+		}
+
+		if (namespace != "" && relNamespace != namespace) || (opts.ReleaseName != "" && relName != opts.ReleaseName) {
+			continue
+		}
+
+		res := resource.NewDeletableResource(spec.NewResourceSpec(obj, relNamespace, spec.ResourceSpecOptions{}), nil, relNamespace, resource.DeletableResourceOptions{})
+		if res.Ownership == common.OwnershipAnyone {
+			continue
+		}
+
+		key := releaseKey{name: relName, namespace: relNamespace}
+		candidates[key] = append(candidates[key], &OrphanedResource{
+			DeletableResource: res,
+			Namespaced:        obj.GetNamespace() != "",
+			ReleaseName:       relName,
+			ReleaseNamespace:  relNamespace,
+		})
+	}
+
+	var orphans []*OrphanedResource
+	for key, candidateOrphans := range candidates {
+		storage, err := getStorage(key.namespace)
+		if err != nil {
+			return nil, fmt.Errorf("get release storage for namespace %q: %w", key.namespace, err)
+		}
+
+		history, err := BuildHistory(key.name, storage, HistoryOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("build history of release %q (namespace: %q): %w", key.name, key.namespace, err)
+		}
+
+		referencedIDs, reason, err := releaseReferencedResourceIDs(history, key.namespace)
+		if err != nil {
+			return nil, fmt.Errorf("get resources referenced by release %q (namespace: %q): %w", key.name, key.namespace, err)
+		}
+
+		for _, orphan := range candidateOrphans {
+			if referencedIDs[orphan.ID()] {
+				continue
+			}
+
+			orphan.Reason = reason
+			orphans = append(orphans, orphan)
+		}
+	}
+
+	sort.SliceStable(orphans, func(i, j int) bool {
+		if orphans[i].ReleaseNamespace != orphans[j].ReleaseNamespace {
+			return orphans[i].ReleaseNamespace < orphans[j].ReleaseNamespace
+		}
+
+		if orphans[i].ReleaseName != orphans[j].ReleaseName {
+			return orphans[i].ReleaseName < orphans[j].ReleaseName
+		}
+
+		return spec.ResourceMetaSortHandler(orphans[i].ResourceMeta, orphans[j].ResourceMeta)
+	})
+
+	return orphans, nil
+}
+
+func listHelmManagedObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string, clientFactory kube.ClientFactorier) ([]*unstructured.Unstructured, error) {
+	list, err := clientFactory.Dynamic().Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{common.LabelKeyHumanManagedBy: "Helm"}.String(),
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) || apierrors.IsForbidden(err) {
+			log.Default.Debug(ctx, "Skipping listing of %q: %s", gvr.String(), err)
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("list %q: %w", gvr.String(), err)
+	}
+
+	return lo.ToSlicePtr(list.Items), nil
+}
+
+// Returns listable resource types in their preferred versions, mapped to whether they are
+// namespaced.
+func listableResources(ctx context.Context, clientFactory kube.ClientFactorier) (map[schema.GroupVersionResource]bool, error) {
+	resourceLists, err := discovery.ServerPreferredResources(clientFactory.Discovery())
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("discover server preferred resources: %w", err)
+		}
+
+		log.Default.Warn(ctx, "Some API groups are unavailable, resources of these groups are skipped: %s", err)
+	}
+
+	gvrs := map[schema.GroupVersionResource]bool{}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("parse group version %q: %w", resourceList.GroupVersion, err)
+		}
+
+		for _, apiResource := range resourceList.APIResources {
+			if strings.Contains(apiResource.Name, "/") || !lo.Contains(apiResource.Verbs, "list") {
+				continue
+			}
+
+			gvrs[gv.WithResource(apiResource.Name)] = apiResource.Namespaced
+		}
+	}
+
+	return gvrs, nil
+}
+
+// Returns IDs of resources referenced by the last and the last deployed revisions of the release.
+// If none, returns the reason why resources of the release are orphaned.
+func releaseReferencedResourceIDs(history *History, releaseNamespace string) (map[string]bool, OrphanReason, error) {
+	lastRelease := lo.LastOrEmpty(history.Releases())
+	if lastRelease == nil {
+		return nil, OrphanReasonReleaseNotFound, nil
+	}
+
+	if lastRelease.Info.Status == helmrelease.StatusUninstalled {
+		return nil, OrphanReasonReleaseUninstalled, nil
+	}
+
+	referencedIDs := map[string]bool{}
+	for _, rel := range lo.Uniq(lo.Compact([]*helmrelease.Release{lastRelease, lo.LastOrEmpty(history.FindAllDeployed())})) {
+		resSpecs, err := ReleaseToResourceSpecs(rel, releaseNamespace, false)
+		if err != nil {
+			return nil, "", fmt.Errorf("convert release revision %d to resource specs: %w", rel.Version, err)
+		}
+
+		for _, resSpec := range resSpecs {
+			referencedIDs[resSpec.ID()] = true
+		}
+	}
+
+	return referencedIDs, OrphanReasonNotInRelease, nil
+}
@@ -0,0 +1,129 @@
+package release_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	discfake "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/kube"
+	"github.com/werf/nelm/pkg/kube/fake"
+	"github.com/werf/nelm/pkg/release"
+)
+
+const orphansTestNamespace = "test-ns"
+
+func TestFindOrphanedResources(t *testing.T) {
+	ctx := context.Background()
+
+	fakeClientFactory, err := fake.NewClientFactory(ctx)
+	require.NoError(t, err)
+
+	// Fake discovery reports every kind of the scheme, most of which can't be listed with the fake
+	// dynamic client.
+	clientFactory := &orphansTestClientFactory{
+		ClientFactory: fakeClientFactory,
+		discoveryClient: &fake.CachedDiscoveryClient{FakeDiscovery: &discfake.FakeDiscovery{Fake: &k8stesting.Fake{
+			Resources: []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"list"}}},
+			}},
+		}}},
+	}
+
+	storageDir := t.TempDir()
+
+	deployed := newMigrateTestRelease("app", orphansTestNamespace, 1)
+	deployed.Manifest = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-kept\n"
+
+	uninstalled := newMigrateTestRelease("removed", orphansTestNamespace, 1)
+	uninstalled.Info.Status = helmrelease.StatusUninstalled
+
+	createMigrateTestReleases(t, storageDir, deployed, uninstalled)
+
+	createOrphansTestConfigMap(t, ctx, clientFactory, "app-kept", "app", nil, true)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "app-stale", "app", nil, true)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "app-shared", "app", map[string]string{"werf.io/ownership": "anyone"}, true)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "app-hook", "app", map[string]string{"helm.sh/hook": "pre-install"}, true)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "app-unmanaged", "app", nil, false)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "gone-cm", "gone", nil, true)
+	createOrphansTestConfigMap(t, ctx, clientFactory, "removed-cm", "removed", nil, true)
+
+	t.Run("all releases", func(t *testing.T) {
+		orphans, err := release.FindOrphanedResources(ctx, orphansTestNamespace, filesystemStorageGetter(storageDir), clientFactory, release.FindOrphanedResourcesOptions{})
+		require.NoError(t, err)
+
+		reasons := lo.SliceToMap(orphans, func(orphan *release.OrphanedResource) (string, release.OrphanReason) {
+			return orphan.Name, orphan.Reason
+		})
+		assert.Equal(t, map[string]release.OrphanReason{
+			"app-stale":  release.OrphanReasonNotInRelease,
+			"gone-cm":    release.OrphanReasonReleaseNotFound,
+			"removed-cm": release.OrphanReasonReleaseUninstalled,
+		}, reasons)
+
+		for _, orphan := range orphans {
+			assert.True(t, orphan.Namespaced)
+			assert.Equal(t, orphansTestNamespace, orphan.ReleaseNamespace)
+		}
+	})
+
+	t.Run("single release", func(t *testing.T) {
+		orphans, err := release.FindOrphanedResources(ctx, orphansTestNamespace, filesystemStorageGetter(storageDir), clientFactory, release.FindOrphanedResourcesOptions{
+			ReleaseName: "gone",
+		})
+		require.NoError(t, err)
+		require.Len(t, orphans, 1)
+		assert.Equal(t, "gone-cm", orphans[0].Name)
+		assert.Equal(t, "gone", orphans[0].ReleaseName)
+	})
+
+	t.Run("other namespace", func(t *testing.T) {
+		orphans, err := release.FindOrphanedResources(ctx, "other-ns", filesystemStorageGetter(storageDir), clientFactory, release.FindOrphanedResourcesOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, orphans)
+	})
+}
+
+type orphansTestClientFactory struct {
+	*fake.ClientFactory
+
+	discoveryClient discovery.CachedDiscoveryInterface
+}
+
+func (f *orphansTestClientFactory) Discovery() discovery.CachedDiscoveryInterface {
+	return f.discoveryClient
+}
+
+func createOrphansTestConfigMap(t *testing.T, ctx context.Context, clientFactory kube.ClientFactorier, name, releaseName string, annotations map[string]string, managed bool) {
+	t.Helper()
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+	}}
+	obj.SetName(name)
+	obj.SetNamespace(orphansTestNamespace)
+	obj.SetUID(types.UID("uid-" + name))
+	obj.SetAnnotations(lo.Assign(map[string]string{
+		"meta.helm.sh/release-name":      releaseName,
+		"meta.helm.sh/release-namespace": orphansTestNamespace,
+	}, annotations))
+
+	if managed {
+		obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "Helm"})
+	}
+
+	_, err := clientFactory.Dynamic().Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace(orphansTestNamespace).Create(ctx, obj, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 
+	"github.com/werf/nelm/pkg/common"
 	helmaction "github.com/werf/nelm/pkg/helm/pkg/action"
 	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
 	helmstorage "github.com/werf/nelm/pkg/helm/pkg/storage"
@@ -28,7 +29,11 @@ type ReleaseStorager interface {
 }
 
 type ReleaseStorageOptions struct {
+	// FilesystemDir is the directory to store releases in for the "filesystem" driver.
+	FilesystemDir string
 	HistoryLimit  int
+	// S3URL is the bucket URL for the "s3" driver, see helmdriver.NewS3ObjectStore for the format.
+	S3URL         string
 	SQLConnection string
 }
 
@@ -46,26 +51,39 @@ func NewReleaseStorage(ctx context.Context, namespace, storageDriver string, cli
 
 	switch storageDriver {
 	case "secret", "secrets", "":
-		metadataClient, err := metadata.NewForConfig(clientFactory.KubeConfig().RestConfig)
-		if err != nil {
-			return nil, fmt.Errorf("construct release metadata client: %w", err)
+		var driver *helmdriver.Secrets
+		// No kube config for in-memory clusters, which have only the fake clients.
+		if clientFactory.KubeConfig() == nil {
+			driver = helmdriver.NewSecrets(clientFactory.Static().CoreV1().Secrets(namespace))
+		} else {
+			metadataClient, err := metadata.NewForConfig(clientFactory.KubeConfig().RestConfig)
+			if err != nil {
+				return nil, fmt.Errorf("construct release metadata client: %w", err)
+			}
+
+			driver = helmdriver.NewSecrets(helmaction.NewSecretClient(lazyClient))
+			driver.MetadataClient = metadataClient
 		}
 
-		driver := helmdriver.NewSecrets(helmaction.NewSecretClient(lazyClient))
 		driver.Log = logFn
-		driver.MetadataClient = metadataClient
 		driver.Namespace = namespace
 
 		storage = helmstorage.Init(driver)
 	case "configmap", "configmaps":
-		metadataClient, err := metadata.NewForConfig(clientFactory.KubeConfig().RestConfig)
-		if err != nil {
-			return nil, fmt.Errorf("construct release metadata client: %w", err)
+		var driver *helmdriver.ConfigMaps
+		if clientFactory.KubeConfig() == nil {
+			driver = helmdriver.NewConfigMaps(clientFactory.Static().CoreV1().ConfigMaps(namespace))
+		} else {
+			metadataClient, err := metadata.NewForConfig(clientFactory.KubeConfig().RestConfig)
+			if err != nil {
+				return nil, fmt.Errorf("construct release metadata client: %w", err)
+			}
+
+			driver = helmdriver.NewConfigMaps(helmaction.NewConfigMapClient(lazyClient))
+			driver.MetadataClient = metadataClient
 		}
 
-		driver := helmdriver.NewConfigMaps(helmaction.NewConfigMapClient(lazyClient))
 		driver.Log = logFn
-		driver.MetadataClient = metadataClient
 		driver.Namespace = namespace
 
 		storage = helmstorage.Init(driver)
@@ -80,6 +98,28 @@ func NewReleaseStorage(ctx context.Context, namespace, storageDriver string, cli
 			return nil, fmt.Errorf("construct sql driver: %w", err)
 		}
 
+		storage = helmstorage.Init(driver)
+	case common.ReleaseStorageDriverFilesystem:
+		if opts.FilesystemDir == "" {
+			return nil, fmt.Errorf("release storage directory must be specified for %q release storage driver", storageDriver)
+		}
+
+		driver := helmdriver.NewFilesystem(opts.FilesystemDir, namespace)
+		driver.Log = logFn
+
+		storage = helmstorage.Init(driver)
+	case common.ReleaseStorageDriverS3:
+		if opts.S3URL == "" {
+			return nil, fmt.Errorf("release storage S3 URL must be specified for %q release storage driver", storageDriver)
+		}
+
+		driver, err := helmdriver.NewS3(opts.S3URL, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("construct s3 driver: %w", err)
+		}
+
+		driver.Log = logFn
+
 		storage = helmstorage.Init(driver)
 	default:
 		panic(fmt.Sprintf("Unknown storage driver: %s", storageDriver))
@@ -0,0 +1,244 @@
+package release
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+	"github.com/werf/nelm/pkg/log"
+)
+
+// Returns the release storage operating in the namespace. An empty namespace means all namespaces.
+type ReleaseStorageGetter func(namespace string) (ReleaseStorager, error)
+
+type MigrateReleasesOptions struct {
+	// DeleteSource, when true, deletes all revisions of a release from the source storage after all
+	// of them are copied and verified.
+	DeleteSource bool
+	// DryRun, when true, only reports what would be migrated.
+	DryRun bool
+	// ReleaseName, if specified, migrates only this release.
+	ReleaseName string
+	// SameStorage must be true if the source and the target release storages are the same. Then
+	// releases already in the target namespace are skipped instead of being migrated onto themselves.
+	SameStorage bool
+	// TargetNamespace, if specified, moves the releases to this namespace in the target storage.
+	TargetNamespace string
+}
+
+type MigratedRevision struct {
+	Name            string
+	Namespace       string
+	Revision        int
+	TargetNamespace string
+	// AlreadyExists is true if the identical revision is already in the target storage.
+	AlreadyExists bool
+}
+
+// Copy every revision of the releases from the namespace (all namespaces if empty) of the source
+// storage to the target storage. Each copied revision is read back and its checksum is verified.
+// A revision already present in the target storage is skipped if identical, otherwise it is an
+// error.
+func MigrateReleases(ctx context.Context, namespace string, getSourceStorage, getTargetStorage ReleaseStorageGetter, opts MigrateReleasesOptions) ([]*MigratedRevision, error) {
+	sourceStorage, err := getSourceStorage(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("get source release storage for namespace %q: %w", namespace, err)
+	}
+
+	query := map[string]string{"owner": "helm"}
+	if opts.ReleaseName != "" {
+		query["name"] = opts.ReleaseName
+	}
+
+	rels, err := sourceStorage.Query(query)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, fmt.Errorf("query source releases: %w", err)
+	}
+
+	releaseGroups := lo.GroupBy(rels, func(rel *helmrelease.Release) string {
+		return rel.Namespace + "/" + rel.Name
+	})
+
+	if opts.SameStorage {
+		for groupKey, revisions := range releaseGroups {
+			if revisions[0].Namespace != lo.CoalesceOrEmpty(opts.TargetNamespace, revisions[0].Namespace) {
+				continue
+			}
+
+			log.Default.Info(ctx, "Release %q is already in namespace %q of target release storage, skipping", revisions[0].Name, revisions[0].Namespace)
+			delete(releaseGroups, groupKey)
+		}
+	}
+
+	if opts.TargetNamespace != "" {
+		if err := validateNoMigrateNameCollisions(releaseGroups); err != nil {
+			return nil, err
+		}
+	}
+
+	groupKeys := lo.Keys(releaseGroups)
+	sort.Strings(groupKeys)
+
+	var migrated []*MigratedRevision
+	for _, groupKey := range groupKeys {
+		revisions := releaseGroups[groupKey]
+		sort.Slice(revisions, func(i, j int) bool {
+			return revisions[i].Version < revisions[j].Version
+		})
+
+		releaseMigrated, err := migrateRelease(ctx, revisions, getSourceStorage, getTargetStorage, opts)
+		if err != nil {
+			return nil, fmt.Errorf("migrate release %q (namespace: %q): %w", revisions[0].Name, revisions[0].Namespace, err)
+		}
+
+		migrated = append(migrated, releaseMigrated...)
+	}
+
+	return migrated, nil
+}
+
+func migrateRelease(ctx context.Context, revisions []*helmrelease.Release, getSourceStorage, getTargetStorage ReleaseStorageGetter, opts MigrateReleasesOptions) ([]*MigratedRevision, error) {
+	name := revisions[0].Name
+	namespace := revisions[0].Namespace
+	targetNamespace := lo.CoalesceOrEmpty(opts.TargetNamespace, namespace)
+
+	targetStorage, err := getTargetStorage(targetNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("get target release storage for namespace %q: %w", targetNamespace, err)
+	}
+
+	var migrated []*MigratedRevision
+	for _, rev := range revisions {
+		rev.Namespace = targetNamespace
+		rev.Labels = lo.OmitByKeys(rev.Labels, driver.GetSystemLabels())
+
+		checksum, err := releaseChecksum(rev)
+		if err != nil {
+			return nil, fmt.Errorf("calculate checksum of revision %d: %w", rev.Version, err)
+		}
+
+		migratedRev := &MigratedRevision{
+			Name:            name,
+			Namespace:       namespace,
+			Revision:        rev.Version,
+			TargetNamespace: targetNamespace,
+		}
+
+		if existingRev, err := targetStorage.GetRelease(name, rev.Version); err == nil {
+			existingChecksum, err := releaseChecksum(existingRev)
+			if err != nil {
+				return nil, fmt.Errorf("calculate checksum of existing target revision %d: %w", rev.Version, err)
+			}
+
+			if existingChecksum != checksum {
+				return nil, fmt.Errorf("revision %d already exists in target release storage and differs from the source revision", rev.Version)
+			}
+
+			log.Default.Info(ctx, "Revision %d of release %q (namespace: %q) already exists in target release storage, skipping", rev.Version, name, targetNamespace)
+
+			migratedRev.AlreadyExists = true
+			migrated = append(migrated, migratedRev)
+
+			continue
+		} else if !errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, fmt.Errorf("get revision %d from target release storage: %w", rev.Version, err)
+		}
+
+		if opts.DryRun {
+			log.Default.Info(ctx, "Would copy revision %d of release %q (namespace: %q) to namespace %q", rev.Version, name, namespace, targetNamespace)
+			migrated = append(migrated, migratedRev)
+
+			continue
+		}
+
+		if err := targetStorage.Create(rev); err != nil {
+			return nil, fmt.Errorf("create revision %d in target release storage: %w", rev.Version, err)
+		}
+
+		copiedRev, err := targetStorage.GetRelease(name, rev.Version)
+		if err != nil {
+			return nil, fmt.Errorf("get copied revision %d from target release storage: %w", rev.Version, err)
+		}
+
+		if copiedChecksum, err := releaseChecksum(copiedRev); err != nil {
+			return nil, fmt.Errorf("calculate checksum of copied revision %d: %w", rev.Version, err)
+		} else if copiedChecksum != checksum {
+			return nil, fmt.Errorf("checksum of copied revision %d doesn't match checksum of source revision: %s != %s", rev.Version, copiedChecksum, checksum)
+		}
+
+		log.Default.Info(ctx, "Copied revision %d of release %q (namespace: %q) to namespace %q", rev.Version, name, namespace, targetNamespace)
+
+		migrated = append(migrated, migratedRev)
+	}
+
+	if !opts.DeleteSource {
+		return migrated, nil
+	}
+
+	if opts.DryRun {
+		log.Default.Info(ctx, "Would delete %d revisions of release %q (namespace: %q) from source release storage", len(revisions), name, namespace)
+		return migrated, nil
+	}
+
+	sourceStorage, err := getSourceStorage(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("get source release storage for namespace %q: %w", namespace, err)
+	}
+
+	for _, rev := range revisions {
+		if _, err := sourceStorage.Delete(name, rev.Version); err != nil {
+			return nil, fmt.Errorf("delete revision %d from source release storage: %w", rev.Version, err)
+		}
+	}
+
+	log.Default.Info(ctx, "Deleted %d revisions of release %q (namespace: %q) from source release storage", len(revisions), name, namespace)
+
+	return migrated, nil
+}
+
+// Releases with the same name from different source namespaces can't be moved to a single target
+// namespace. Checked before anything is written or deleted.
+func validateNoMigrateNameCollisions(releaseGroups map[string][]*helmrelease.Release) error {
+	namespacesByName := map[string][]string{}
+	for _, revisions := range releaseGroups {
+		namespacesByName[revisions[0].Name] = append(namespacesByName[revisions[0].Name], revisions[0].Namespace)
+	}
+
+	names := lo.Keys(namespacesByName)
+	sort.Strings(names)
+
+	for _, name := range names {
+		if namespaces := namespacesByName[name]; len(namespaces) > 1 {
+			sort.Strings(namespaces)
+			return fmt.Errorf("releases named %q from namespaces %v can't be moved to the same target namespace", name, namespaces)
+		}
+	}
+
+	return nil
+}
+
+// Checksum of the stored release data and custom labels. Storage system labels are ignored.
+func releaseChecksum(rel *helmrelease.Release) (string, error) {
+	data, err := json.Marshal(struct {
+		Labels  map[string]string    `json:"labels"`
+		Release *helmrelease.Release `json:"release"`
+	}{
+		Labels:  lo.OmitByKeys(rel.Labels, driver.GetSystemLabels()),
+		Release: rel,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal release: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,179 @@
+package release_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	helmstorage "github.com/werf/nelm/pkg/helm/pkg/storage"
+	helmdriver "github.com/werf/nelm/pkg/helm/pkg/storage/driver"
+	"github.com/werf/nelm/pkg/release"
+)
+
+func TestMigrateReleases(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all namespaces", func(t *testing.T) {
+		sourceDir, targetDir := t.TempDir(), t.TempDir()
+		createMigrateTestReleases(t, sourceDir,
+			newMigrateTestRelease("app", "ns-a", 1),
+			newMigrateTestRelease("app", "ns-a", 2),
+			newMigrateTestRelease("app", "ns-b", 1),
+		)
+
+		migrated, err := release.MigrateReleases(ctx, "", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{})
+		require.NoError(t, err)
+		require.Len(t, migrated, 3)
+
+		rel, err := helmstorage.Init(helmdriver.NewFilesystem(targetDir, "ns-a")).GetRelease("app", 2)
+		require.NoError(t, err)
+		assert.Equal(t, "ns-a", rel.Namespace)
+		assert.Equal(t, "bar", rel.Labels["foo"])
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(sourceDir, "ns-b")).GetRelease("app", 1)
+		assert.NoError(t, err, "source release should be kept")
+
+		migrated, err = release.MigrateReleases(ctx, "", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{})
+		require.NoError(t, err)
+		require.Len(t, migrated, 3)
+		assert.True(t, migrated[0].AlreadyExists)
+	})
+
+	t.Run("single release to another namespace with source deletion", func(t *testing.T) {
+		sourceDir, targetDir := t.TempDir(), t.TempDir()
+		createMigrateTestReleases(t, sourceDir,
+			newMigrateTestRelease("app", "ns-a", 1),
+			newMigrateTestRelease("other", "ns-a", 1),
+		)
+
+		migrated, err := release.MigrateReleases(ctx, "ns-a", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{
+			DeleteSource:    true,
+			ReleaseName:     "app",
+			TargetNamespace: "ns-c",
+		})
+		require.NoError(t, err)
+		require.Len(t, migrated, 1)
+		assert.Equal(t, "ns-c", migrated[0].TargetNamespace)
+
+		rel, err := helmstorage.Init(helmdriver.NewFilesystem(targetDir, "ns-c")).GetRelease("app", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "ns-c", rel.Namespace)
+
+		sourceStorage := helmstorage.Init(helmdriver.NewFilesystem(sourceDir, "ns-a"))
+
+		_, err = sourceStorage.GetRelease("app", 1)
+		assert.ErrorIs(t, err, helmdriver.ErrReleaseNotFound)
+
+		_, err = sourceStorage.GetRelease("other", 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		sourceDir, targetDir := t.TempDir(), t.TempDir()
+		createMigrateTestReleases(t, sourceDir, newMigrateTestRelease("app", "ns-a", 1))
+
+		migrated, err := release.MigrateReleases(ctx, "ns-a", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{
+			DeleteSource: true,
+			DryRun:       true,
+		})
+		require.NoError(t, err)
+		require.Len(t, migrated, 1)
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(targetDir, "ns-a")).GetRelease("app", 1)
+		assert.ErrorIs(t, err, helmdriver.ErrReleaseNotFound)
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(sourceDir, "ns-a")).GetRelease("app", 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("conflicting target revision", func(t *testing.T) {
+		sourceDir, targetDir := t.TempDir(), t.TempDir()
+		createMigrateTestReleases(t, sourceDir, newMigrateTestRelease("app", "ns-a", 1))
+
+		conflicting := newMigrateTestRelease("app", "ns-a", 1)
+		conflicting.Info.Description = "different"
+		createMigrateTestReleases(t, targetDir, conflicting)
+
+		_, err := release.MigrateReleases(ctx, "ns-a", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{
+			DeleteSource: true,
+		})
+		require.Error(t, err)
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(sourceDir, "ns-a")).GetRelease("app", 1)
+		assert.NoError(t, err, "source release should be kept on failure")
+	})
+
+	t.Run("name collision in target namespace", func(t *testing.T) {
+		sourceDir, targetDir := t.TempDir(), t.TempDir()
+		createMigrateTestReleases(t, sourceDir,
+			newMigrateTestRelease("app", "ns-a", 1),
+			newMigrateTestRelease("app", "ns-b", 1),
+			newMigrateTestRelease("other", "ns-b", 1),
+		)
+
+		_, err := release.MigrateReleases(ctx, "", filesystemStorageGetter(sourceDir), filesystemStorageGetter(targetDir), release.MigrateReleasesOptions{
+			DeleteSource:    true,
+			TargetNamespace: "ns-c",
+		})
+		require.ErrorContains(t, err, `releases named "app"`)
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(targetDir, "ns-c")).GetRelease("other", 1)
+		assert.ErrorIs(t, err, helmdriver.ErrReleaseNotFound, "nothing should be written on collision")
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(sourceDir, "ns-b")).GetRelease("other", 1)
+		assert.NoError(t, err, "nothing should be deleted on collision")
+	})
+
+	t.Run("same storage skips releases already in target namespace", func(t *testing.T) {
+		dir := t.TempDir()
+		createMigrateTestReleases(t, dir,
+			newMigrateTestRelease("app", "ns-a", 1),
+			newMigrateTestRelease("other", "ns-c", 1),
+		)
+
+		migrated, err := release.MigrateReleases(ctx, "", filesystemStorageGetter(dir), filesystemStorageGetter(dir), release.MigrateReleasesOptions{
+			DeleteSource:    true,
+			SameStorage:     true,
+			TargetNamespace: "ns-c",
+		})
+		require.NoError(t, err)
+		require.Len(t, migrated, 1)
+		assert.Equal(t, "app", migrated[0].Name)
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(dir, "ns-c")).GetRelease("other", 1)
+		assert.NoError(t, err, "release already in target namespace should be kept")
+
+		_, err = helmstorage.Init(helmdriver.NewFilesystem(dir, "ns-c")).GetRelease("app", 1)
+		assert.NoError(t, err)
+	})
+}
+
+func createMigrateTestReleases(t *testing.T, dir string, rels ...*helmrelease.Release) {
+	t.Helper()
+
+	for _, rel := range rels {
+		require.NoError(t, helmstorage.Init(helmdriver.NewFilesystem(dir, rel.Namespace)).Create(rel))
+	}
+}
+
+func filesystemStorageGetter(dir string) release.ReleaseStorageGetter {
+	return func(namespace string) (release.ReleaseStorager, error) {
+		return helmstorage.Init(helmdriver.NewFilesystem(dir, namespace)), nil
+	}
+}
+
+func newMigrateTestRelease(name, namespace string, version int) *helmrelease.Release {
+	return &helmrelease.Release{
+		Name:      name,
+		Namespace: namespace,
+		Version:   version,
+		Info: &helmrelease.Info{
+			Status:      helmrelease.StatusDeployed,
+			Description: "Install complete",
+		},
+		Labels: map[string]string{"foo": "bar"},
+	}
+}
@@ -2,14 +2,111 @@ package release_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/werf/nelm/pkg/common"
 	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
 	"github.com/werf/nelm/pkg/release"
 )
 
+func TestIsReleaseProtected(t *testing.T) {
+	tests := []struct {
+		name string
+		rel  *helmrelease.Release
+		want bool
+	}{
+		{
+			name: "nil release",
+		},
+		{
+			name: "no protection",
+			rel:  &helmrelease.Release{Info: &helmrelease.Info{}},
+		},
+		{
+			name: "protected by info annotation",
+			rel:  &helmrelease.Release{Info: &helmrelease.Info{Annotations: map[string]string{"werf.io/protect": "true"}}},
+			want: true,
+		},
+		{
+			name: "protected by label",
+			rel:  &helmrelease.Release{Labels: map[string]string{"werf.io/protect": "true"}},
+			want: true,
+		},
+		{
+			name: "explicitly unprotected",
+			rel:  &helmrelease.Release{Info: &helmrelease.Info{Annotations: map[string]string{"werf.io/protect": "false"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, release.IsReleaseProtected(tt.rel))
+			assert.Equal(t, tt.want, release.ProtectionInfoAnnotations(tt.rel) != nil)
+		})
+	}
+}
+
+func TestReleaseExpiresAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		rel         *helmrelease.Release
+		wantExpires bool
+		wantAt      time.Time
+		wantErr     bool
+	}{
+		{
+			name: "nil release",
+		},
+		{
+			name: "no expiry",
+			rel:  &helmrelease.Release{Info: &helmrelease.Info{}},
+		},
+		{
+			name:        "expiry",
+			rel:         &helmrelease.Release{Info: &helmrelease.Info{Annotations: map[string]string{"werf.io/expires-at": "2026-01-02T03:04:05Z"}}},
+			wantExpires: true,
+			wantAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:    "invalid expiry",
+			rel:     &helmrelease.Release{Info: &helmrelease.Info{Annotations: map[string]string{"werf.io/expires-at": "72h"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt, expires, err := release.ReleaseExpiresAt(tt.rel)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExpires, expires)
+			assert.True(t, tt.wantAt.Equal(expiresAt))
+			assert.Equal(t, tt.wantExpires, release.ExpiryInfoAnnotations(tt.rel) != nil)
+		})
+	}
+}
+
+func TestTTLInfoAnnotations(t *testing.T) {
+	assert.Nil(t, release.TTLInfoAnnotations(0))
+
+	before := time.Now().Truncate(time.Second)
+	rel := &helmrelease.Release{Info: &helmrelease.Info{Annotations: release.TTLInfoAnnotations(time.Hour)}}
+
+	expiresAt, expires, err := release.ReleaseExpiresAt(rel)
+	require.NoError(t, err)
+	assert.True(t, expires)
+	assert.False(t, expiresAt.Before(before.Add(time.Hour)))
+	assert.False(t, expiresAt.After(time.Now().Add(time.Hour)))
+	assert.Equal(t, "1h0m0s", rel.Info.Annotations[common.AnnotationKeyHumanTTL])
+}
+
 func TestIsReleaseUpToDate(t *testing.T) {
 	cmManifest := func(dataVal string) string {
 		return `apiVersion: v1
@@ -105,6 +202,64 @@ data:
 			expectedUpToDate: false,
 			expectedReason:   release.ReleaseOutdatedReasonValuesChanged,
 		},
+		{
+			name:   "ttl-set",
+			oldRel: baseOldRel(),
+			newRel: func() *helmrelease.Release {
+				r := baseNewRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(time.Hour)
+
+				return r
+			}(),
+			expectedUpToDate: false,
+			expectedReason:   release.ReleaseOutdatedReasonTTLChanged,
+		},
+		{
+			name: "same-ttl",
+			oldRel: func() *helmrelease.Release {
+				r := baseOldRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(time.Hour)
+				r.Info.Annotations[common.AnnotationKeyHumanExpiresAt] = "2020-01-01T00:00:00Z"
+
+				return r
+			}(),
+			newRel: func() *helmrelease.Release {
+				r := baseNewRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(time.Hour)
+
+				return r
+			}(),
+			expectedUpToDate: true,
+		},
+		{
+			name: "ttl-changed",
+			oldRel: func() *helmrelease.Release {
+				r := baseOldRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(time.Hour)
+
+				return r
+			}(),
+			newRel: func() *helmrelease.Release {
+				r := baseNewRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(2 * time.Hour)
+
+				return r
+			}(),
+			expectedUpToDate: false,
+			expectedReason:   release.ReleaseOutdatedReasonTTLChanged,
+		},
+		{
+			name: "ttl-cleared",
+			oldRel: func() *helmrelease.Release {
+				r := baseOldRel()
+				r.Info.Annotations = release.TTLInfoAnnotations(time.Hour)
+
+				return r
+			}(),
+			newRel:           baseNewRel(),
+			expectedUpToDate: false,
+			expectedReason:   release.ReleaseOutdatedReasonTTLChanged,
+		},
 		{
 			name: "hooks-changed",
 			oldRel: func() *helmrelease.Release {
@@ -0,0 +1,167 @@
+package resource
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+// Deprecations and removals of built-in Kubernetes APIs, according to
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var apiDeprecations = lo.Flatten([][]*APIDeprecation{
+	newAPIDeprecations("extensions/v1beta1", []string{"DaemonSet", "Deployment", "ReplicaSet"}, "1.8", "1.16", "apps/v1"),
+	newAPIDeprecations("apps/v1beta1", []string{"Deployment", "StatefulSet"}, "1.9", "1.16", "apps/v1"),
+	newAPIDeprecations("apps/v1beta2", []string{"DaemonSet", "Deployment", "ReplicaSet", "StatefulSet"}, "1.9", "1.16", "apps/v1"),
+	newAPIDeprecations("extensions/v1beta1", []string{"NetworkPolicy"}, "1.9", "1.16", "networking.k8s.io/v1"),
+	newAPIDeprecations("extensions/v1beta1", []string{"PodSecurityPolicy"}, "1.10", "1.16", "policy/v1beta1"),
+
+	newAPIDeprecations("admissionregistration.k8s.io/v1beta1", []string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"}, "1.16", "1.22", "admissionregistration.k8s.io/v1"),
+	newAPIDeprecations("apiextensions.k8s.io/v1beta1", []string{"CustomResourceDefinition"}, "1.16", "1.22", "apiextensions.k8s.io/v1"),
+	newAPIDeprecations("apiregistration.k8s.io/v1beta1", []string{"APIService"}, "1.19", "1.22", "apiregistration.k8s.io/v1"),
+	newAPIDeprecations("authentication.k8s.io/v1beta1", []string{"TokenReview"}, "1.19", "1.22", "authentication.k8s.io/v1"),
+	newAPIDeprecations("authorization.k8s.io/v1beta1", []string{"LocalSubjectAccessReview", "SelfSubjectAccessReview", "SubjectAccessReview"}, "1.19", "1.22", "authorization.k8s.io/v1"),
+	newAPIDeprecations("certificates.k8s.io/v1beta1", []string{"CertificateSigningRequest"}, "1.19", "1.22", "certificates.k8s.io/v1"),
+	newAPIDeprecations("coordination.k8s.io/v1beta1", []string{"Lease"}, "1.19", "1.22", "coordination.k8s.io/v1"),
+	newAPIDeprecations("extensions/v1beta1", []string{"Ingress"}, "1.14", "1.22", "networking.k8s.io/v1"),
+	newAPIDeprecations("networking.k8s.io/v1beta1", []string{"Ingress", "IngressClass"}, "1.19", "1.22", "networking.k8s.io/v1"),
+	newAPIDeprecations("rbac.authorization.k8s.io/v1beta1", []string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"}, "1.17", "1.22", "rbac.authorization.k8s.io/v1"),
+	newAPIDeprecations("scheduling.k8s.io/v1beta1", []string{"PriorityClass"}, "1.14", "1.22", "scheduling.k8s.io/v1"),
+	newAPIDeprecations("storage.k8s.io/v1beta1", []string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"}, "1.19", "1.22", "storage.k8s.io/v1"),
+
+	newAPIDeprecations("autoscaling/v2beta1", []string{"HorizontalPodAutoscaler"}, "1.22", "1.25", "autoscaling/v2"),
+	newAPIDeprecations("batch/v1beta1", []string{"CronJob"}, "1.21", "1.25", "batch/v1"),
+	newAPIDeprecations("discovery.k8s.io/v1beta1", []string{"EndpointSlice"}, "1.21", "1.25", "discovery.k8s.io/v1"),
+	newAPIDeprecations("events.k8s.io/v1beta1", []string{"Event"}, "1.19", "1.25", "events.k8s.io/v1"),
+	newAPIDeprecations("node.k8s.io/v1beta1", []string{"RuntimeClass"}, "1.20", "1.25", "node.k8s.io/v1"),
+	newAPIDeprecations("policy/v1beta1", []string{"PodDisruptionBudget"}, "1.21", "1.25", "policy/v1"),
+	newAPIDeprecations("policy/v1beta1", []string{"PodSecurityPolicy"}, "1.21", "1.25", ""),
+
+	newAPIDeprecations("autoscaling/v2beta2", []string{"HorizontalPodAutoscaler"}, "1.23", "1.26", "autoscaling/v2"),
+	newAPIDeprecations("flowcontrol.apiserver.k8s.io/v1beta1", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.23", "1.26", "flowcontrol.apiserver.k8s.io/v1"),
+	newAPIDeprecations("storage.k8s.io/v1beta1", []string{"CSIStorageCapacity"}, "1.24", "1.27", "storage.k8s.io/v1"),
+	newAPIDeprecations("flowcontrol.apiserver.k8s.io/v1beta2", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.26", "1.29", "flowcontrol.apiserver.k8s.io/v1"),
+	newAPIDeprecations("flowcontrol.apiserver.k8s.io/v1beta3", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.29", "1.32", "flowcontrol.apiserver.k8s.io/v1"),
+
+	newAPIDeprecations("v1", []string{"ComponentStatus"}, "1.19", "", ""),
+	{
+		{
+			GroupVersionKind:            schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"},
+			DeprecatedIn:                semver.MustParse("1.33"),
+			ReplacementGroupVersionKind: &schema.GroupVersionKind{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"},
+		},
+	},
+})
+
+type APIDeprecation struct {
+	GroupVersionKind schema.GroupVersionKind
+	DeprecatedIn     *semver.Version
+	// Nil if the API is not removed yet.
+	RemovedIn *semver.Version
+	// Nil if there is no replacement API.
+	ReplacementGroupVersionKind *schema.GroupVersionKind
+}
+
+type APIDeprecationFinding struct {
+	*APIDeprecation
+
+	ResourceMeta *spec.ResourceMeta
+	// Whether the API is removed in the Kubernetes version, otherwise it is only deprecated.
+	Removed bool
+}
+
+// Checks APIs of the resources against the built-in table of deprecations and removals of
+// Kubernetes APIs. Returns findings for resources with APIs deprecated or removed in the
+// Kubernetes version. The "master" Kubernetes version is newer than any released one.
+func FindAPIDeprecations(resourceMetas []*spec.ResourceMeta, kubeVersion string) ([]*APIDeprecationFinding, error) {
+	var version *semver.Version
+	if kubeVersion == "master" {
+		version = semver.New(math.MaxUint32, 0, 0, "", "")
+	} else {
+		parsedVersion, err := semver.NewVersion(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("parse kube version %q: %w", kubeVersion, err)
+		}
+
+		// Ignore patch versions, prereleases and distribution-specific suffixes, like "+k3s1".
+		version = semver.New(parsedVersion.Major(), parsedVersion.Minor(), 0, "", "")
+	}
+
+	var findings []*APIDeprecationFinding
+	for _, meta := range resourceMetas {
+		deprecation, found := lo.Find(apiDeprecations, func(d *APIDeprecation) bool {
+			return d.GroupVersionKind == meta.GroupVersionKind
+		})
+		if !found || version.LessThan(deprecation.DeprecatedIn) {
+			continue
+		}
+
+		findings = append(findings, &APIDeprecationFinding{
+			APIDeprecation: deprecation,
+			ResourceMeta:   meta,
+			Removed:        deprecation.RemovedIn != nil && !version.LessThan(deprecation.RemovedIn),
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Removed != findings[j].Removed {
+			return findings[i].Removed
+		}
+
+		return findings[i].ResourceMeta.IDHuman() < findings[j].ResourceMeta.IDHuman()
+	})
+
+	return findings, nil
+}
+
+func (f *APIDeprecationFinding) String() string {
+	api := fmt.Sprintf("%s %s", f.GroupVersionKind.GroupVersion(), f.GroupVersionKind.Kind)
+
+	var msg string
+	if f.Removed {
+		msg = fmt.Sprintf("%s: API %s is removed in Kubernetes %s", f.ResourceMeta.IDHuman(), api, formatKubeMinorVersion(f.RemovedIn))
+	} else if f.RemovedIn != nil {
+		msg = fmt.Sprintf("%s: API %s is deprecated since Kubernetes %s and will be removed in %s", f.ResourceMeta.IDHuman(), api, formatKubeMinorVersion(f.DeprecatedIn), formatKubeMinorVersion(f.RemovedIn))
+	} else {
+		msg = fmt.Sprintf("%s: API %s is deprecated since Kubernetes %s", f.ResourceMeta.IDHuman(), api, formatKubeMinorVersion(f.DeprecatedIn))
+	}
+
+	if f.ReplacementGroupVersionKind != nil {
+		msg += fmt.Sprintf(", use %s %s instead", f.ReplacementGroupVersionKind.GroupVersion(), f.ReplacementGroupVersionKind.Kind)
+	} else {
+		msg += ", no replacement API available"
+	}
+
+	return msg
+}
+
+func newAPIDeprecations(apiVersion string, kinds []string, deprecatedIn, removedIn, replacementAPIVersion string) []*APIDeprecation {
+	gv := lo.Must(schema.ParseGroupVersion(apiVersion))
+
+	return lo.Map(kinds, func(kind string, _ int) *APIDeprecation {
+		deprecation := &APIDeprecation{
+			GroupVersionKind: gv.WithKind(kind),
+			DeprecatedIn:     semver.MustParse(deprecatedIn),
+		}
+
+		if removedIn != "" {
+			deprecation.RemovedIn = semver.MustParse(removedIn)
+		}
+
+		if replacementAPIVersion != "" {
+			replacement := lo.Must(schema.ParseGroupVersion(replacementAPIVersion)).WithKind(kind)
+			deprecation.ReplacementGroupVersionKind = &replacement
+		}
+
+		return deprecation
+	})
+}
+
+func formatKubeMinorVersion(version *semver.Version) string {
+	return fmt.Sprintf("%d.%d", version.Major(), version.Minor())
+}
@@ -0,0 +1,99 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+func TestFindAPIDeprecations(t *testing.T) {
+	resourceMeta := func(apiVersion, kind, name string) *spec.ResourceMeta {
+		unstruct := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+
+		return spec.NewResourceMetaFromUnstructured(unstruct, "default", "")
+	}
+
+	resourceMetas := []*spec.ResourceMeta{
+		resourceMeta("apps/v1", "Deployment", "app"),
+		resourceMeta("networking.k8s.io/v1beta1", "Ingress", "ingress"),
+		resourceMeta("batch/v1beta1", "CronJob", "cron"),
+		resourceMeta("policy/v1beta1", "PodSecurityPolicy", "psp"),
+		resourceMeta("v1", "Endpoints", "endpoints"),
+	}
+
+	tests := []struct {
+		name        string
+		kubeVersion string
+		expected    []string
+	}{
+		{
+			name:        "nothing deprecated yet",
+			kubeVersion: "1.18.0",
+			expected:    []string{},
+		},
+		{
+			name:        "deprecated but not removed",
+			kubeVersion: "v1.21.3",
+			expected: []string{
+				"CronJob/cron: API batch/v1beta1 CronJob is deprecated since Kubernetes 1.21 and will be removed in 1.25, use batch/v1 CronJob instead",
+				"Ingress/ingress: API networking.k8s.io/v1beta1 Ingress is deprecated since Kubernetes 1.19 and will be removed in 1.22, use networking.k8s.io/v1 Ingress instead",
+				"PodSecurityPolicy/psp: API policy/v1beta1 PodSecurityPolicy is deprecated since Kubernetes 1.21 and will be removed in 1.25, no replacement API available",
+			},
+		},
+		{
+			name:        "removed",
+			kubeVersion: "1.25.0-rc.1+k3s1",
+			expected: []string{
+				"CronJob/cron: API batch/v1beta1 CronJob is removed in Kubernetes 1.25, use batch/v1 CronJob instead",
+				"Ingress/ingress: API networking.k8s.io/v1beta1 Ingress is removed in Kubernetes 1.22, use networking.k8s.io/v1 Ingress instead",
+				"PodSecurityPolicy/psp: API policy/v1beta1 PodSecurityPolicy is removed in Kubernetes 1.25, no replacement API available",
+			},
+		},
+		{
+			name:        "deprecated without removal",
+			kubeVersion: "1.33.1",
+			expected: []string{
+				"CronJob/cron: API batch/v1beta1 CronJob is removed in Kubernetes 1.25, use batch/v1 CronJob instead",
+				"Ingress/ingress: API networking.k8s.io/v1beta1 Ingress is removed in Kubernetes 1.22, use networking.k8s.io/v1 Ingress instead",
+				"PodSecurityPolicy/psp: API policy/v1beta1 PodSecurityPolicy is removed in Kubernetes 1.25, no replacement API available",
+				"Endpoints/endpoints: API v1 Endpoints is deprecated since Kubernetes 1.33, use discovery.k8s.io/v1 EndpointSlice instead",
+			},
+		},
+		{
+			name:        "master",
+			kubeVersion: "master",
+			expected: []string{
+				"CronJob/cron: API batch/v1beta1 CronJob is removed in Kubernetes 1.25, use batch/v1 CronJob instead",
+				"Ingress/ingress: API networking.k8s.io/v1beta1 Ingress is removed in Kubernetes 1.22, use networking.k8s.io/v1 Ingress instead",
+				"PodSecurityPolicy/psp: API policy/v1beta1 PodSecurityPolicy is removed in Kubernetes 1.25, no replacement API available",
+				"Endpoints/endpoints: API v1 Endpoints is deprecated since Kubernetes 1.33, use discovery.k8s.io/v1 EndpointSlice instead",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := resource.FindAPIDeprecations(resourceMetas, tt.kubeVersion)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, lo.Map(findings, func(f *resource.APIDeprecationFinding, _ int) string {
+				return f.String()
+			}))
+		})
+	}
+
+	t.Run("invalid kube version", func(t *testing.T) {
+		_, err := resource.FindAPIDeprecations(resourceMetas, "latest")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,339 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/yannh/kubeconform/pkg/validator"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource/spec"
+	"github.com/werf/nelm/pkg/util"
+)
+
+const clusterSchemaOpenAPIV3Path = "/openapi/v3"
+
+var clusterSchemaUnsafePathCharsRegex = regexp.MustCompile(`[^\w.-]`)
+
+// Validates resources against OpenAPI v3 schemas served by the cluster, which know about CRDs
+// installed in the cluster. Schemas are fetched lazily, one group version at a time, and cached
+// on disk per server version and schema hash.
+type clusterSchemaValidator struct {
+	discoveryClient discovery.DiscoveryInterface
+
+	mu sync.Mutex
+	// Is nil until the OpenAPI v3 index is fetched.
+	paths       map[string]string
+	unavailable bool
+	cacheDir    string
+	documents   map[string]*clusterSchemaDocument
+	schemas     map[schema.GroupVersionKind]*jsonschema.Schema
+}
+
+type clusterSchemaDocument struct {
+	compiler    *jsonschema.Compiler
+	url         string
+	schemaNames map[schema.GroupVersionKind]string
+}
+
+type openAPIV3Index struct {
+	Paths map[string]struct {
+		ServerRelativeURL string `json:"serverRelativeURL"`
+	} `json:"paths"`
+}
+
+func newClusterSchemaValidator(discoveryClient discovery.DiscoveryInterface) *clusterSchemaValidator {
+	return &clusterSchemaValidator{
+		discoveryClient: discoveryClient,
+		documents:       map[string]*clusterSchemaDocument{},
+		schemas:         map[schema.GroupVersionKind]*jsonschema.Schema{},
+	}
+}
+
+// Returns false if the cluster has no OpenAPI v3 schema for the resource.
+func (v *clusterSchemaValidator) Validate(ctx context.Context, resourceSpec *spec.ResourceSpec) (bool, error) {
+	clusterSchema, err := v.getSchema(ctx, resourceSpec.GroupVersionKind)
+	if err != nil {
+		return false, fmt.Errorf("get cluster schema for %s: %w", resourceSpec.GroupVersionKind, err)
+	} else if clusterSchema == nil {
+		return false, nil
+	}
+
+	jsonBytes, err := json.Marshal(resourceSpec.Unstruct.Object)
+	if err != nil {
+		return true, fmt.Errorf("marshal resource to json: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+
+	var obj interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return true, fmt.Errorf("unmarshal resource json: %w", err)
+	}
+
+	log.Default.Debug(ctx, "Validate resource %s against cluster schema", resourceSpec.IDHuman())
+
+	if err := clusterSchema.Validate(obj); err != nil {
+		var schemaErr *jsonschema.ValidationError
+		if !errors.As(err, &schemaErr) {
+			return true, fmt.Errorf("validate against cluster schema: %w", err)
+		}
+
+		validationErrs := &util.MultiError{}
+		for _, leafErr := range leafSchemaValidationErrors(schemaErr) {
+			validationErr := &validator.ValidationError{
+				Path: leafErr.InstanceLocation,
+				Msg:  leafErr.Message,
+			}
+
+			validationErrs.Add(fmt.Errorf("%s: %w", validationErr.Path, validationErr))
+		}
+
+		return true, validationErrs.OrNilIfNoErrs()
+	}
+
+	return true, nil
+}
+
+// Returns nil if the cluster has no schema for the kind.
+func (v *clusterSchemaValidator) getSchema(ctx context.Context, gvk schema.GroupVersionKind) (*jsonschema.Schema, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if compiledSchema, found := v.schemas[gvk]; found {
+		return compiledSchema, nil
+	}
+
+	if v.unavailable {
+		return nil, nil
+	}
+
+	if v.paths == nil {
+		if err := v.loadIndex(ctx); err != nil {
+			log.Default.Warn(ctx, "Not validating resources against cluster schemas: %s", err)
+			v.unavailable = true
+
+			return nil, nil
+		}
+	}
+
+	gvPath := clusterSchemaGroupVersionPath(gvk.GroupVersion())
+
+	document, found := v.documents[gvPath]
+	if !found {
+		serverRelativeURL, found := v.paths[gvPath]
+		if !found {
+			v.schemas[gvk] = nil
+			return nil, nil
+		}
+
+		var err error
+		document, err = v.loadDocument(ctx, gvPath, serverRelativeURL)
+		if err != nil {
+			return nil, fmt.Errorf("load schemas of %q: %w", gvPath, err)
+		}
+
+		v.documents[gvPath] = document
+	}
+
+	schemaName, found := document.schemaNames[gvk]
+	if !found {
+		v.schemas[gvk] = nil
+		return nil, nil
+	}
+
+	compiledSchema, err := document.compiler.Compile(document.url + "#/components/schemas/" + escapeJSONPointerToken(schemaName))
+	if err != nil {
+		return nil, fmt.Errorf("compile schema %q: %w", schemaName, err)
+	}
+
+	v.schemas[gvk] = compiledSchema
+
+	return compiledSchema, nil
+}
+
+func (v *clusterSchemaValidator) loadIndex(ctx context.Context) error {
+	serverVersion, err := v.discoveryClient.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("get server version: %w", err)
+	}
+
+	indexBytes, err := v.discoveryClient.RESTClient().Get().AbsPath(clusterSchemaOpenAPIV3Path).Do(ctx).Raw()
+	if err != nil {
+		return fmt.Errorf("get %q: %w", clusterSchemaOpenAPIV3Path, err)
+	}
+
+	var index openAPIV3Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return fmt.Errorf("unmarshal %q: %w", clusterSchemaOpenAPIV3Path, err)
+	}
+
+	v.paths = make(map[string]string, len(index.Paths))
+	for gvPath, gv := range index.Paths {
+		v.paths[gvPath] = gv.ServerRelativeURL
+	}
+
+	v.cacheDir = filepath.Join(common.APIResourceValidationJSONSchemasCacheDir, "cluster", clusterSchemaUnsafePathCharsRegex.ReplaceAllString(serverVersion.GitVersion, "_"))
+
+	return nil
+}
+
+func (v *clusterSchemaValidator) loadDocument(ctx context.Context, gvPath, serverRelativeURL string) (*clusterSchemaDocument, error) {
+	locator, err := url.Parse(serverRelativeURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url %q: %w", serverRelativeURL, err)
+	}
+
+	// Without the hash we can't tell whether the cached schemas are up to date, e.g. after CRDs are
+	// updated, so don't cache them.
+	var cachePath string
+	if hash := locator.Query().Get("hash"); hash != "" {
+		cachePath = filepath.Join(v.cacheDir, filepath.FromSlash(gvPath), clusterSchemaUnsafePathCharsRegex.ReplaceAllString(hash, "_")+".json")
+	}
+
+	var documentBytes []byte
+	if cachePath != "" {
+		if documentBytes, err = os.ReadFile(cachePath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read cached schemas %q: %w", cachePath, err)
+		}
+	}
+
+	if documentBytes == nil {
+		log.Default.Debug(ctx, "Fetch cluster schemas of %q", gvPath)
+
+		request := v.discoveryClient.RESTClient().Get().AbsPath(locator.Path).SetHeader("Accept", "application/json")
+		for key, values := range locator.Query() {
+			for _, value := range values {
+				request = request.Param(key, value)
+			}
+		}
+
+		documentBytes, err = request.Do(ctx).Raw()
+		if err != nil {
+			return nil, fmt.Errorf("get %q: %w", serverRelativeURL, err)
+		}
+
+		if cachePath != "" {
+			if err := writeClusterSchemaCache(cachePath, documentBytes); err != nil {
+				log.Default.Warn(ctx, "Unable to cache cluster schemas of %q: %s", gvPath, err)
+			}
+		}
+	}
+
+	return newClusterSchemaDocument(gvPath, documentBytes)
+}
+
+func newClusterSchemaDocument(gvPath string, documentBytes []byte) (*clusterSchemaDocument, error) {
+	var document struct {
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(documentBytes, &document); err != nil {
+		return nil, fmt.Errorf("unmarshal schemas: %w", err)
+	}
+
+	schemaNames := map[schema.GroupVersionKind]string{}
+	convertedSchemas := make(map[string]interface{}, len(document.Components.Schemas))
+	for name, openAPISchema := range document.Components.Schemas {
+		convertedSchemas[name] = openAPIV3SchemaToJSONSchema(openAPISchema)
+
+		gvks, _ := openAPISchema["x-kubernetes-group-version-kind"].([]interface{})
+		for _, g := range gvks {
+			gvkMap, ok := g.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			group, _ := gvkMap["group"].(string)
+			version, _ := gvkMap["version"].(string)
+			kind, _ := gvkMap["kind"].(string)
+
+			gvk := schema.GroupVersionKind{Group: group, Version: version, Kind: kind}
+			// Some schemas, like DeleteOptions, are listed in the documents of all group versions.
+			if clusterSchemaGroupVersionPath(gvk.GroupVersion()) != gvPath {
+				continue
+			}
+
+			schemaNames[gvk] = name
+		}
+	}
+
+	convertedBytes, err := json.Marshal(map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": convertedSchemas,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal converted schemas: %w", err)
+	}
+
+	documentURL := "cluster://" + gvPath + ".json"
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft4
+
+	if err := compiler.AddResource(documentURL, bytes.NewReader(convertedBytes)); err != nil {
+		return nil, fmt.Errorf("add schema resource: %w", err)
+	}
+
+	return &clusterSchemaDocument{
+		compiler:    compiler,
+		url:         documentURL,
+		schemaNames: schemaNames,
+	}, nil
+}
+
+func writeClusterSchemaCache(cachePath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return fmt.Errorf("create dir %q: %w", filepath.Dir(cachePath), err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(cachePath), filepath.Base(cachePath)+"-")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("write %q: %w", tmpFile.Name(), err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", tmpFile.Name(), err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), cachePath); err != nil {
+		return fmt.Errorf("rename %q to %q: %w", tmpFile.Name(), cachePath, err)
+	}
+
+	return nil
+}
+
+func clusterSchemaGroupVersionPath(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return "api/" + gv.Version
+	}
+
+	return "apis/" + gv.Group + "/" + gv.Version
+}
+
+func escapeJSONPointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,293 @@
+package resource_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/featgate"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+const clusterSchemaTestIndex = `{
+  "paths": {
+    "api/v1": {"serverRelativeURL": "/openapi/v3/api/v1?hash=ABC123"},
+    "apis/example.com/v1": {"serverRelativeURL": "/openapi/v3/apis/example.com/v1"}
+  }
+}`
+
+const clusterSchemaTestCoreV1 = `{
+  "openapi": "3.0.0",
+  "components": {
+    "schemas": {
+      "io.k8s.api.core.v1.ConfigMap": {
+        "type": "object",
+        "properties": {
+          "apiVersion": {"type": "string"},
+          "kind": {"type": "string"},
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}], "default": {}},
+          "data": {"type": "object", "additionalProperties": {"type": "string", "default": ""}}
+        },
+        "x-kubernetes-group-version-kind": [{"group": "", "kind": "ConfigMap", "version": "v1"}]
+      },
+      "io.k8s.api.core.v1.Service": {
+        "type": "object",
+        "properties": {
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}], "default": {}},
+          "spec": {
+            "type": "object",
+            "properties": {
+              "ports": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "required": ["port"],
+                  "properties": {
+                    "port": {"type": "integer", "format": "int32"},
+                    "targetPort": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}]}
+                  }
+                }
+              }
+            }
+          }
+        },
+        "x-kubernetes-group-version-kind": [{"group": "", "kind": "Service", "version": "v1"}]
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions": {
+        "type": "object",
+        "x-kubernetes-group-version-kind": [
+          {"group": "", "kind": "DeleteOptions", "version": "v1"},
+          {"group": "apps", "kind": "DeleteOptions", "version": "v1"}
+        ]
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+        }
+      },
+      "io.k8s.apimachinery.pkg.util.intstr.IntOrString": {"type": "string", "format": "int-or-string"}
+    }
+  }
+}`
+
+const clusterSchemaTestExampleV1 = `{
+  "openapi": "3.0.0",
+  "components": {
+    "schemas": {
+      "com.example.v1.Widget": {
+        "type": "object",
+        "properties": {
+          "spec": {
+            "type": "object",
+            "required": ["size"],
+            "properties": {
+              "size": {"type": "integer"},
+              "color": {"type": "string", "nullable": true}
+            }
+          }
+        },
+        "x-kubernetes-group-version-kind": [{"group": "example.com", "kind": "Widget", "version": "v1"}]
+      }
+    }
+  }
+}`
+
+func TestValidateWithClusterSchemas(t *testing.T) {
+	featgate.FeatGateResourceValidation.Enable()
+	t.Cleanup(featgate.FeatGateResourceValidation.Disable)
+
+	cacheDir := common.APIResourceValidationJSONSchemasCacheDir
+	common.APIResourceValidationJSONSchemasCacheDir = t.TempDir()
+	t.Cleanup(func() { common.APIResourceValidationJSONSchemasCacheDir = cacheDir })
+
+	var (
+		requestsMu sync.Mutex
+		requests   = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsMu.Lock()
+		requests[r.URL.Path]++
+		requestsMu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/version":
+			w.Write([]byte(`{"major": "1", "minor": "35", "gitVersion": "v1.35.0+k3s1"}`))
+		case "/openapi/v3":
+			w.Write([]byte(clusterSchemaTestIndex))
+		case "/openapi/v3/api/v1":
+			if r.URL.Query().Get("hash") != "ABC123" {
+				http.NotFound(w, r)
+				return
+			}
+
+			w.Write([]byte(clusterSchemaTestCoreV1))
+		case "/openapi/v3/apis/example.com/v1":
+			w.Write([]byte(clusterSchemaTestExampleV1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, clusterSchemas bool, objs ...map[string]interface{}) error {
+		resources := make([]*resource.InstallableResource, 0, len(objs))
+		for _, obj := range objs {
+			resources = append(resources, newCRDSchemaTestResource(t, obj))
+		}
+
+		return resource.ValidateWithCluster(context.Background(), "default", resources, discoveryClient, common.ResourceValidationOptions{
+			LocalResourceValidation:  true,
+			ValidationClusterSchemas: clusterSchemas,
+		})
+	}
+
+	configMap := func(data map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config"},
+			"data":       data,
+		}
+	}
+
+	service := func(targetPort interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "service"},
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80), "targetPort": targetPort},
+				},
+			},
+		}
+	}
+
+	widget := func(widgetSpec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "widget"},
+			"spec":       widgetSpec,
+		}
+	}
+
+	deployment := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "deployment"},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.NoError(t, validate(t, false, widget(map[string]interface{}{})))
+
+		requestsMu.Lock()
+		defer requestsMu.Unlock()
+		assert.Empty(t, requests)
+	})
+
+	tests := []struct {
+		name        string
+		objs        []map[string]interface{}
+		errContains []string
+	}{
+		{
+			name: "valid",
+			objs: []map[string]interface{}{
+				configMap(map[string]interface{}{"key": "value"}),
+				service("http"),
+				widget(map[string]interface{}{"size": int64(1), "color": nil}),
+			},
+		},
+		{
+			name: "valid int-or-string as integer",
+			objs: []map[string]interface{}{service(int64(8080))},
+		},
+		{
+			name: "kind without cluster schema",
+			objs: []map[string]interface{}{deployment},
+		},
+		{
+			name:        "invalid built-in resource",
+			objs:        []map[string]interface{}{configMap(map[string]interface{}{"key": int64(1)}), service(true)},
+			errContains: []string{"ConfigMap/config", "/data/key", "Service/service", "/spec/ports/0/targetPort"},
+		},
+		{
+			name:        "invalid custom resource",
+			objs:        []map[string]interface{}{widget(map[string]interface{}{"color": int64(1)})},
+			errContains: []string{"Widget/widget", "size", "/spec/color"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(t, true, tt.objs...)
+			if len(tt.errContains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+
+			for _, substr := range tt.errContains {
+				assert.Contains(t, err.Error(), substr)
+			}
+		})
+	}
+
+	requestsMu.Lock()
+	defer requestsMu.Unlock()
+
+	assert.Equal(t, 1, requests["/openapi/v3/api/v1"], "schemas with hash must be fetched once and then read from cache")
+	assert.Greater(t, requests["/openapi/v3/apis/example.com/v1"], 1, "schemas without hash must not be cached")
+	assert.FileExists(t, filepath.Join(common.APIResourceValidationJSONSchemasCacheDir, "cluster", "v1.35.0_k3s1", "api", "v1", "ABC123.json"))
+}
+
+func TestValidateWithClusterSchemasUnavailable(t *testing.T) {
+	featgate.FeatGateResourceValidation.Enable()
+	t.Cleanup(featgate.FeatGateResourceValidation.Disable)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"major": "1", "minor": "22", "gitVersion": "v1.22.0"}`))
+
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	res := newCRDSchemaTestResource(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config"},
+		"data":       map[string]interface{}{"key": "value"},
+	})
+
+	assert.NoError(t, resource.ValidateWithCluster(context.Background(), "default", []*resource.InstallableResource{res}, discoveryClient, common.ResourceValidationOptions{
+		LocalResourceValidation:  true,
+		ValidationClusterSchemas: true,
+	}))
+}
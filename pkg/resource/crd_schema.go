@@ -0,0 +1,261 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/yannh/kubeconform/pkg/validator"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/resource/spec"
+	"github.com/werf/nelm/pkg/util"
+)
+
+// Validates custom resources against openAPIV3Schema of CRDs, which are deployed together with
+// them, so that such custom resources can be validated without any external schema sources.
+type crdSchemaValidator struct {
+	schemas map[schema.GroupVersionKind]*jsonschema.Schema
+}
+
+func newCRDSchemaValidator(ctx context.Context, resources []*InstallableResource) *crdSchemaValidator {
+	schemas := map[schema.GroupVersionKind]*jsonschema.Schema{}
+	for _, res := range resources {
+		if !spec.IsCRD(res.GroupVersionKind.GroupKind()) {
+			continue
+		}
+
+		crdSchemas, err := crdOpenAPIV3Schemas(res.Unstruct)
+		if err != nil {
+			log.Default.Warn(ctx, "Not validating custom resources against schemas of %s: %s", res.IDHuman(), err)
+			continue
+		}
+
+		for gvk, crdSchema := range crdSchemas {
+			compiledSchema, err := compileCRDSchema(gvk, crdSchema)
+			if err != nil {
+				log.Default.Warn(ctx, "Not validating custom resources %s against schema of %s: %s", gvk, res.IDHuman(), err)
+				continue
+			}
+
+			schemas[gvk] = compiledSchema
+		}
+	}
+
+	return &crdSchemaValidator{
+		schemas: schemas,
+	}
+}
+
+// Returns false if there is no CRD schema for the resource.
+func (v *crdSchemaValidator) Validate(ctx context.Context, resourceSpec *spec.ResourceSpec) (bool, error) {
+	crdSchema, found := v.schemas[resourceSpec.GroupVersionKind]
+	if !found {
+		return false, nil
+	}
+
+	jsonBytes, err := json.Marshal(resourceSpec.Unstruct.Object)
+	if err != nil {
+		return true, fmt.Errorf("marshal resource to json: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+
+	var obj interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return true, fmt.Errorf("unmarshal resource json: %w", err)
+	}
+
+	log.Default.Debug(ctx, "Validate resource %s against CRD schema", resourceSpec.IDHuman())
+
+	if err := crdSchema.Validate(obj); err != nil {
+		var schemaErr *jsonschema.ValidationError
+		if !errors.As(err, &schemaErr) {
+			return true, fmt.Errorf("validate against CRD schema: %w", err)
+		}
+
+		validationErrs := &util.MultiError{}
+		for _, leafErr := range leafSchemaValidationErrors(schemaErr) {
+			validationErr := &validator.ValidationError{
+				Path: leafErr.InstanceLocation,
+				Msg:  leafErr.Message,
+			}
+
+			validationErrs.Add(fmt.Errorf("%s: %w", validationErr.Path, validationErr))
+		}
+
+		return true, validationErrs.OrNilIfNoErrs()
+	}
+
+	return true, nil
+}
+
+func crdOpenAPIV3Schemas(crd *unstructured.Unstructured) (map[schema.GroupVersionKind]map[string]interface{}, error) {
+	group, _, err := unstructured.NestedString(crd.Object, "spec", "group")
+	if err != nil {
+		return nil, fmt.Errorf("get spec.group: %w", err)
+	}
+
+	kind, _, err := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	if err != nil {
+		return nil, fmt.Errorf("get spec.names.kind: %w", err)
+	}
+
+	if group == "" || kind == "" {
+		return nil, fmt.Errorf("spec.group or spec.names.kind not specified")
+	}
+
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return nil, fmt.Errorf("get spec.versions: %w", err)
+	}
+
+	// Only apiextensions.k8s.io/v1beta1 CRDs can have a single schema for all versions.
+	commonSchema, _, err := unstructured.NestedMap(crd.Object, "spec", "validation", "openAPIV3Schema")
+	if err != nil {
+		return nil, fmt.Errorf("get spec.validation.openAPIV3Schema: %w", err)
+	}
+
+	schemas := map[schema.GroupVersionKind]map[string]interface{}{}
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(version, "name")
+		if name == "" {
+			continue
+		}
+
+		versionSchema, _, err := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if err != nil {
+			return nil, fmt.Errorf("get schema.openAPIV3Schema of version %q: %w", name, err)
+		}
+
+		if versionSchema == nil {
+			versionSchema = commonSchema
+		}
+
+		if versionSchema == nil {
+			continue
+		}
+
+		schemas[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = versionSchema
+	}
+
+	return schemas, nil
+}
+
+func compileCRDSchema(gvk schema.GroupVersionKind, crdSchema map[string]interface{}) (*jsonschema.Schema, error) {
+	schemaBytes, err := json.Marshal(openAPIV3SchemaToJSONSchema(crdSchema))
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+
+	url := fmt.Sprintf("crd://%s/%s/%s.json", gvk.Group, gvk.Version, gvk.Kind)
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft4
+
+	if err := compiler.AddResource(url, bytes.NewReader(schemaBytes)); err != nil {
+		return nil, fmt.Errorf("add schema resource: %w", err)
+	}
+
+	compiledSchema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+
+	return compiledSchema, nil
+}
+
+// Converts Kubernetes structural schema extensions to their JSON schema equivalents.
+func openAPIV3SchemaToJSONSchema(openAPISchema map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(openAPISchema))
+	for key, value := range openAPISchema {
+		switch key {
+		case "properties", "patternProperties", "definitions":
+			if props, ok := value.(map[string]interface{}); ok {
+				result[key] = lo.MapValues(props, func(prop interface{}, _ string) interface{} {
+					return convertOpenAPIV3SubSchema(prop)
+				})
+
+				continue
+			}
+		case "items", "additionalProperties", "not":
+			result[key] = convertOpenAPIV3SubSchema(value)
+			continue
+		case "allOf", "anyOf", "oneOf":
+			if subSchemas, ok := value.([]interface{}); ok {
+				result[key] = lo.Map(subSchemas, func(subSchema interface{}, _ int) interface{} {
+					return convertOpenAPIV3SubSchema(subSchema)
+				})
+
+				continue
+			}
+		}
+
+		result[key] = value
+	}
+
+	// Built-in types, e.g. IntOrString, might use the format instead of the extension.
+	intOrString, _ := result["x-kubernetes-int-or-string"].(bool)
+	if format, _ := result["format"].(string); format == "int-or-string" {
+		intOrString = true
+	}
+
+	if intOrString {
+		delete(result, "type")
+		delete(result, "format")
+
+		if _, hasAnyOf := result["anyOf"]; !hasAnyOf {
+			if _, hasOneOf := result["oneOf"]; !hasOneOf {
+				result["anyOf"] = []interface{}{
+					map[string]interface{}{"type": "integer"},
+					map[string]interface{}{"type": "string"},
+				}
+			}
+		}
+	}
+
+	if nullable, _ := result["nullable"].(bool); nullable {
+		if typ, ok := result["type"].(string); ok {
+			result["type"] = []interface{}{typ, "null"}
+		}
+
+		if enum, ok := result["enum"].([]interface{}); ok && !lo.Contains(enum, nil) {
+			result["enum"] = append(append([]interface{}{}, enum...), nil)
+		}
+	}
+
+	return result
+}
+
+func convertOpenAPIV3SubSchema(subSchema interface{}) interface{} {
+	if subSchemaMap, ok := subSchema.(map[string]interface{}); ok {
+		return openAPIV3SchemaToJSONSchema(subSchemaMap)
+	}
+
+	return subSchema
+}
+
+func leafSchemaValidationErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var leafErrs []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leafErrs = append(leafErrs, leafSchemaValidationErrors(cause)...)
+	}
+
+	return leafErrs
+}
@@ -0,0 +1,131 @@
+package resource_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/featgate"
+	"github.com/werf/nelm/pkg/resource"
+	"github.com/werf/nelm/pkg/resource/spec"
+)
+
+func TestValidateLocalWithCRDSchemas(t *testing.T) {
+	featgate.FeatGateResourceValidation.Enable()
+	t.Cleanup(featgate.FeatGateResourceValidation.Disable)
+
+	crd := map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "widgets.example.com"},
+		"spec": map[string]interface{}{
+			"group": "example.com",
+			"names": map[string]interface{}{"kind": "Widget", "plural": "widgets"},
+			"scope": "Namespaced",
+			"versions": []interface{}{
+				map[string]interface{}{
+					"name":    "v1",
+					"served":  true,
+					"storage": true,
+					"schema": map[string]interface{}{
+						"openAPIV3Schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"type":     "object",
+									"required": []interface{}{"size"},
+									"properties": map[string]interface{}{
+										"size": map[string]interface{}{
+											"type":    "integer",
+											"minimum": int64(1),
+										},
+										"port": map[string]interface{}{
+											"x-kubernetes-int-or-string": true,
+										},
+										"color": map[string]interface{}{
+											"type":     "string",
+											"nullable": true,
+											"enum":     []interface{}{"red", "green"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	widget := func(widgetSpec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "widget"},
+			"spec":       widgetSpec,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		widgetSpec  map[string]interface{}
+		errContains []string
+	}{
+		{
+			name:       "valid",
+			widgetSpec: map[string]interface{}{"size": int64(3), "port": "http", "color": nil},
+		},
+		{
+			name:       "valid int-or-string as integer",
+			widgetSpec: map[string]interface{}{"size": int64(3), "port": int64(8080), "color": "red"},
+		},
+		{
+			name:        "missing required field",
+			widgetSpec:  map[string]interface{}{"port": int64(8080)},
+			errContains: []string{"/spec", "size"},
+		},
+		{
+			name:        "invalid values",
+			widgetSpec:  map[string]interface{}{"size": int64(0), "port": true, "color": "blue"},
+			errContains: []string{"/spec/size", "/spec/port", "/spec/color"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := []*resource.InstallableResource{
+				newCRDSchemaTestResource(t, crd),
+				newCRDSchemaTestResource(t, widget(tt.widgetSpec)),
+			}
+
+			err := resource.ValidateLocal(context.Background(), "default", resources, common.ResourceValidationOptions{
+				LocalResourceValidation: true,
+			})
+			if len(tt.errContains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+
+			for _, substr := range tt.errContains {
+				assert.Contains(t, err.Error(), substr)
+			}
+		})
+	}
+}
+
+func newCRDSchemaTestResource(t *testing.T, obj map[string]interface{}) *resource.InstallableResource {
+	t.Helper()
+
+	resSpec := spec.NewResourceSpec(&unstructured.Unstructured{Object: obj}, "default", spec.ResourceSpecOptions{})
+
+	res, err := resource.NewInstallableResource(resSpec, nil, "default", nil, resource.InstallableResourceOptions{})
+	require.NoError(t, err)
+
+	return res
+}
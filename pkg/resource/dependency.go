@@ -1,11 +1,14 @@
 package resource
 
 import (
+	"fmt"
+
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/werf/nelm/pkg/common"
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
 	"github.com/werf/nelm/pkg/resource/spec"
 )
 
@@ -21,6 +24,28 @@ type ExternalDependency struct {
 	*spec.ResourceMeta `json:"resourceMeta"`
 }
 
+// Represents a dependency on the state of another Helm release: its latest revision must have the
+// specified status and, optionally, a chart version matching the semver constraint.
+type ExternalReleaseDependency struct {
+	ReleaseName      string             `json:"releaseName"`
+	ReleaseNamespace string             `json:"releaseNamespace"`
+	Status           helmrelease.Status `json:"status"`
+	ChartVersion     string             `json:"chartVersion,omitempty"`
+}
+
+func (d *ExternalReleaseDependency) ID() string {
+	return fmt.Sprintf("%s:%s:%s:%s", d.ReleaseNamespace, d.ReleaseName, d.Status, d.ChartVersion)
+}
+
+func (d *ExternalReleaseDependency) IDHuman() string {
+	id := fmt.Sprintf("release/%s (namespace: %s, status: %s", d.ReleaseName, d.ReleaseNamespace, d.Status)
+	if d.ChartVersion != "" {
+		id += fmt.Sprintf(", chart version: %s", d.ChartVersion)
+	}
+
+	return id + ")"
+}
+
 // Automatically detects internal dependencies on resources by examining specific fields in the
 // resource spec. As an example, examining "envFrom" in a Pod container spec produces an internal
 // dependency on a ConfigMap or a Secret.
@@ -182,8 +182,11 @@ func (kc *kubeConformValidator) validateSchemasSources(ctx context.Context) erro
 	httpClient := util.NewRestyClient(ctx)
 
 	for _, schemaSource := range kc.schemaSources {
-		patchedSource, err := patchKubeConformSchemaSource(schemaSource, "deployment",
-			"apps", "v1", false, kc.kubeVersion)
+		patchedSource, err := patchKubeConformSchemaSource(schemaSource, schema.GroupVersionKind{
+			Group:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+		}, false, kc.kubeVersion)
 		if err != nil {
 			return fmt.Errorf("%w: patch schema source %s: %w", ErrResourceValidationSourceSanityCheck, schemaSource, err)
 		}
@@ -474,10 +477,14 @@ func getKubeConformEntryHash(kubeVersion string, gvk schema.GroupVersionKind) st
 	return getHash(fmt.Sprintf("%s-%s-%s", gvk.Kind, gvk.GroupVersion(), kubeVersion))
 }
 
-func patchKubeConformSchemaSource(source, kind, group, apiVersion string, strict bool, kubeVersion string) (string, error) {
-	kindSuffix := "-" + group + "-" + apiVersion
-	if group == "" {
-		kindSuffix = "-" + kubeVersion
+// Renders the schema source template for the kind the same way kubeconform does.
+func patchKubeConformSchemaSource(source string, gvk schema.GroupVersionKind, strict bool, kubeVersion string) (string, error) {
+	groupParts := strings.Split(gvk.GroupVersion().String(), "/")
+	versionParts := strings.Split(groupParts[0], ".")
+
+	kindSuffix := "-" + strings.ToLower(versionParts[0])
+	if len(groupParts) > 1 {
+		kindSuffix += "-" + strings.ToLower(groupParts[1])
 	}
 
 	params := struct {
@@ -488,10 +495,10 @@ func patchKubeConformSchemaSource(source, kind, group, apiVersion string, strict
 		StrictSuffix                string
 		KindSuffix                  string
 	}{
-		Group:                       group,
+		Group:                       groupParts[0],
 		NormalizedKubernetesVersion: kubeVersion,
-		ResourceAPIVersion:          apiVersion,
-		ResourceKind:                kind,
+		ResourceAPIVersion:          groupParts[len(groupParts)-1],
+		ResourceKind:                strings.ToLower(gvk.Kind),
 		KindSuffix:                  kindSuffix,
 	}
 
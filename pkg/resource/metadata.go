@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/ohler55/ojg/jp"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -82,6 +83,33 @@ func ValidateResourcePolicy(meta *spec.ResourceMeta) error {
 	return nil
 }
 
+// Parses "werf.io/release-dependency-<name>" annotations. Used both for resource annotations and for
+// Chart.yaml annotations, which make the whole release depend on other releases.
+func ReleaseDependencies(annotations map[string]string, releaseNamespace string) ([]*ExternalReleaseDependency, error) {
+	depAnnotations, found := spec.FindAnnotationsOrLabelsByKeyPattern(annotations, common.AnnotationKeyPatternReleaseDependency)
+	if !found {
+		return nil, nil
+	}
+
+	var deps []*ExternalReleaseDependency
+	for key, value := range depAnnotations {
+		dep, err := parseReleaseDependency(value, releaseNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for annotation %q: %w", value, key, err)
+		}
+
+		deps = append(deps, dep)
+	}
+
+	sort.SliceStable(deps, func(i, j int) bool {
+		return deps[i].ID() < deps[j].ID()
+	})
+
+	return lo.UniqBy(deps, func(dep *ExternalReleaseDependency) string {
+		return dep.ID()
+	}), nil
+}
+
 func deleteOnFailed(meta *spec.ResourceMeta) bool {
 	deletePolicies := deletePolicies(meta)
 
@@ -167,6 +195,15 @@ func externalDependencies(meta *spec.ResourceMeta, releaseNamespace string, clie
 	return uniqResult, nil
 }
 
+func protect(meta *spec.ResourceMeta) bool {
+	_, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternProtect)
+	if !found {
+		return false
+	}
+
+	return lo.Must(strconv.ParseBool(value))
+}
+
 func recreate(meta *spec.ResourceMeta) bool {
 	deletePolicies := deletePolicies(meta)
 
@@ -234,6 +271,14 @@ func deletePolicies(meta *spec.ResourceMeta) []common.DeletePolicy {
 	return deletePolicies
 }
 
+func applyConflicts(meta *spec.ResourceMeta) common.ApplyConflicts {
+	if _, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternApplyConflicts); found {
+		return common.ApplyConflicts(value)
+	}
+
+	return common.ApplyConflictsForce
+}
+
 func deletePropagation(meta *spec.ResourceMeta, defaultDeletePropagation apiv1.DeletionPropagation) apiv1.DeletionPropagation {
 	if _, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternDeletePropagation); found {
 		return apiv1.DeletionPropagation(value)
@@ -463,6 +508,55 @@ func legacyExternalDeps(resMeta *spec.ResourceMeta, releaseNamespace string, map
 	return deps, nil
 }
 
+func parseReleaseDependency(value, releaseNamespace string) (*ExternalReleaseDependency, error) {
+	valueElems := strings.Split(value, ":")
+	if len(valueElems) > 3 {
+		return nil, fmt.Errorf("expected format: [namespace/]name[:status[:chartVersionConstraint]]")
+	}
+
+	dep := &ExternalReleaseDependency{
+		ReleaseName:      valueElems[0],
+		ReleaseNamespace: releaseNamespace,
+		Status:           helmrelease.StatusDeployed,
+	}
+
+	if namespace, name, found := strings.Cut(valueElems[0], "/"); found {
+		dep.ReleaseNamespace = namespace
+		dep.ReleaseName = name
+	}
+
+	if dep.ReleaseName == "" || dep.ReleaseNamespace == "" {
+		return nil, fmt.Errorf("release name and namespace must not be empty")
+	}
+
+	if len(valueElems) > 1 {
+		dep.Status = helmrelease.Status(valueElems[1])
+
+		switch dep.Status {
+		case helmrelease.StatusDeployed,
+			helmrelease.StatusFailed,
+			helmrelease.StatusSuperseded,
+			helmrelease.StatusUninstalled,
+			helmrelease.StatusUninstalling,
+			helmrelease.StatusPendingInstall,
+			helmrelease.StatusPendingUpgrade,
+			helmrelease.StatusPendingRollback:
+		default:
+			return nil, fmt.Errorf("unknown release status %q", dep.Status)
+		}
+	}
+
+	if len(valueElems) > 2 {
+		if _, err := semver.NewConstraint(valueElems[2]); err != nil {
+			return nil, fmt.Errorf("parse chart version constraint %q: %w", valueElems[2], err)
+		}
+
+		dep.ChartVersion = valueElems[2]
+	}
+
+	return dep, nil
+}
+
 func logRegex(meta *spec.ResourceMeta) *regexp.Regexp {
 	_, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternLogRegex)
 	if !found {
@@ -922,6 +1016,22 @@ func validateDeletePolicy(meta *spec.ResourceMeta) error {
 	return nil
 }
 
+func validateApplyConflicts(meta *spec.ResourceMeta) error {
+	if key, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternApplyConflicts); found {
+		if value == "" {
+			return fmt.Errorf("invalid value %q for annotation %q, expected non-empty string value", value, key)
+		}
+
+		switch common.ApplyConflicts(value) {
+		case common.ApplyConflictsForce, common.ApplyConflictsFail:
+		default:
+			return fmt.Errorf("invalid unknown value %q for annotation %q", value, key)
+		}
+	}
+
+	return nil
+}
+
 func validateDeletePropagation(meta *spec.ResourceMeta) error {
 	if key, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternDeletePropagation); found {
 		if value == "" {
@@ -1217,6 +1327,20 @@ func validateOwnership(meta *spec.ResourceMeta) error {
 	return nil
 }
 
+func validateProtect(meta *spec.ResourceMeta) error {
+	if key, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternProtect); found {
+		if value == "" {
+			return fmt.Errorf("invalid value %q for annotation %q, expected non-empty boolean value", value, key)
+		}
+
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid value %q for annotation %q, expected boolean value", value, key)
+		}
+	}
+
+	return nil
+}
+
 func validateReplicasOnCreation(meta *spec.ResourceMeta) error {
 	if key, value, found := spec.FindAnnotationOrLabelByKeyPattern(meta.Annotations, common.AnnotationKeyPatternReplicasOnCreation); found {
 		if value == "" {
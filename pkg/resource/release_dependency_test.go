@@ -0,0 +1,30 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	helmrelease "github.com/werf/nelm/pkg/helm/pkg/release"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+func TestReleaseDependencies(t *testing.T) {
+	deps, err := resource.ReleaseDependencies(map[string]string{
+		"werf.io/release-dependency-db":    "infra/postgres:deployed:^1.2",
+		"werf.io/release-dependency-cache": "redis",
+		"werf.io/weight":                   "10",
+	}, "app-ns")
+	require.NoError(t, err)
+
+	assert.Equal(t, []*resource.ExternalReleaseDependency{
+		{ReleaseName: "redis", ReleaseNamespace: "app-ns", Status: helmrelease.StatusDeployed},
+		{ReleaseName: "postgres", ReleaseNamespace: "infra", Status: helmrelease.StatusDeployed, ChartVersion: "^1.2"},
+	}, deps)
+
+	for _, value := range []string{"db:unknown-status", "db:deployed:not-a-version", "a:b:c:d", "infra/"} {
+		_, err := resource.ReleaseDependencies(map[string]string{"werf.io/release-dependency-db": value}, "app-ns")
+		assert.Error(t, err, value)
+	}
+}
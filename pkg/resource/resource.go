@@ -23,6 +23,7 @@ import (
 type InstallableResource struct {
 	*spec.ResourceSpec `json:"resourceSpec"`
 
+	ApplyConflicts                         common.ApplyConflicts           `json:"applyConflicts"`
 	Ownership                              common.Ownership                `json:"ownership"`
 	Recreate                               bool                            `json:"recreate"`
 	RecreateOnImmutable                    bool                            `json:"recreateOnImmutable"`
@@ -48,6 +49,7 @@ type InstallableResource struct {
 	ManualInternalDependencies             []*InternalDependency           `json:"manualInternalDependencies,omitempty"`
 	AutoInternalDependencies               []*InternalDependency           `json:"autoInternalDependencies,omitempty"`
 	ExternalDependencies                   []*ExternalDependency           `json:"externalDependencies,omitempty"`
+	ExternalReleaseDependencies            []*ExternalReleaseDependency    `json:"externalReleaseDependencies,omitempty"`
 	DeployConditions                       map[common.On][]common.Stage    `json:"deployConditions"`
 	DeletePropagation                      metav1.DeletionPropagation      `json:"deletePropagation"`
 }
@@ -107,11 +109,28 @@ func NewInstallableResource(res *spec.ResourceSpec, otherResSpecs []*spec.Resour
 		return nil, fmt.Errorf("validate delete propagation: %w", err)
 	}
 
+	if err := validateProtect(res.ResourceMeta); err != nil {
+		return nil, fmt.Errorf("validate protect: %w", err)
+	}
+
+	if err := validateApplyConflicts(res.ResourceMeta); err != nil {
+		return nil, fmt.Errorf("validate apply conflicts: %w", err)
+	}
+
 	extDeps, err := externalDependencies(res.ResourceMeta, releaseNamespace, clientFactory, opts.Remote)
 	if err != nil {
 		return nil, fmt.Errorf("get external dependencies: %w", err)
 	}
 
+	relDeps, err := ReleaseDependencies(res.Annotations, releaseNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("get release dependencies: %w", err)
+	}
+
+	relDeps = lo.UniqBy(append(relDeps, opts.ReleaseDependencies...), func(dep *ExternalReleaseDependency) string {
+		return dep.ID()
+	})
+
 	manIntDeps := manualInternalDeployDependencies(res.ResourceMeta, releaseNamespace)
 
 	otherUnstructs := lo.Map(otherResSpecs, func(resSpec *spec.ResourceSpec, _ int) *unstructured.Unstructured {
@@ -120,6 +139,7 @@ func NewInstallableResource(res *spec.ResourceSpec, otherResSpecs []*spec.Resour
 
 	return &InstallableResource{
 		ResourceSpec:                           res,
+		ApplyConflicts:                         applyConflicts(res.ResourceMeta),
 		AutoInternalDependencies:               internalDeployDependencies(res.Unstruct, otherUnstructs),
 		DefaultReplicasOnCreation:              defaultReplicasOnCreation(res.ResourceMeta, releaseNamespace),
 		DeleteOnFailed:                         deleteOnFailed(res.ResourceMeta),
@@ -127,6 +147,7 @@ func NewInstallableResource(res *spec.ResourceSpec, otherResSpecs []*spec.Resour
 		DeletePropagation:                      deletePropagation(res.ResourceMeta, opts.DefaultDeletePropagation),
 		DeployConditions:                       deployConditions(res.ResourceMeta, len(manIntDeps) > 0),
 		ExternalDependencies:                   extDeps,
+		ExternalReleaseDependencies:            relDeps,
 		FailMode:                               failMode(res.ResourceMeta),
 		FailuresAllowed:                        failuresAllowed(res.Unstruct),
 		IgnoreReadinessProbeFailsForContainers: ignoreReadinessProbeFailsForContainers(res.ResourceMeta),
@@ -153,7 +174,9 @@ func NewInstallableResource(res *spec.ResourceSpec, otherResSpecs []*spec.Resour
 type InstallableResourceOptions struct {
 	DefaultDeletePropagation metav1.DeletionPropagation
 	NoPodLogs                bool
-	Remote                   bool
+	// Dependencies of the whole release on other releases, added to the resource dependencies.
+	ReleaseDependencies []*ExternalReleaseDependency
+	Remote              bool
 }
 
 // Represent a Kubernetes resource that can be deleted. Higher level than ResourceMeta, but lower
@@ -166,7 +189,9 @@ type DeletableResource struct {
 	DeletePropagation          metav1.DeletionPropagation
 	ManualInternalDependencies []*InternalDependency
 	Ownership                  common.Ownership
-	ResourcePolicies           []common.ResourcePolicy
+	// Protected resources must not be deleted unless the protection is explicitly overridden.
+	Protected        bool
+	ResourcePolicies []common.ResourcePolicy
 }
 
 // Construct a DeletableResource from a ResourceSpec. Must never contact the cluster, because
@@ -193,6 +218,13 @@ func NewDeletableResource(resourceSpec *spec.ResourceSpec, otherResourceSpecs []
 		delPropagation = deletePropagation(resourceSpec.ResourceMeta, opts.DefaultDeletePropagation)
 	}
 
+	var prot bool
+	if err := validateProtect(resourceSpec.ResourceMeta); err != nil {
+		prot = true
+	} else {
+		prot = protect(resourceSpec.ResourceMeta)
+	}
+
 	var manIntDeps []*InternalDependency
 	if err := validateDeleteDependencies(resourceSpec.ResourceMeta); err == nil {
 		manIntDeps = manualInternalDeleteDependencies(resourceSpec.ResourceMeta)
@@ -208,6 +240,7 @@ func NewDeletableResource(resourceSpec *spec.ResourceSpec, otherResourceSpecs []
 		DeletePropagation:          delPropagation,
 		ManualInternalDependencies: manIntDeps,
 		Ownership:                  owner,
+		Protected:                  prot,
 		ResourcePolicies:           policies,
 	}
 }
@@ -219,7 +252,10 @@ type DeletableResourceOptions struct {
 type BuildResourcesOptions struct {
 	DefaultDeletePropagation metav1.DeletionPropagation
 	NoPodLogs                bool
-	Remote                   bool
+	// Dependencies of the whole release on other releases, added to the dependencies of every
+	// installable resource.
+	ReleaseDependencies []*ExternalReleaseDependency
+	Remote              bool
 }
 
 // Build Installable/DeletableResources from ResourceSpecs. Resulting Resources can be used to
@@ -240,6 +276,7 @@ func BuildResources(ctx context.Context, deployType common.DeployType, releaseNa
 		installableResource, err := NewInstallableResource(resSpec, lo.Without(prevRelResSpecs, resSpec), releaseNamespace, clientFactory, InstallableResourceOptions{
 			DefaultDeletePropagation: opts.DefaultDeletePropagation,
 			NoPodLogs:                opts.NoPodLogs,
+			ReleaseDependencies:      opts.ReleaseDependencies,
 			Remote:                   opts.Remote,
 		})
 		if err != nil {
@@ -254,6 +291,7 @@ func BuildResources(ctx context.Context, deployType common.DeployType, releaseNa
 		installableResource, err := NewInstallableResource(resSpec, lo.Without(newRelResSpecs, resSpec), releaseNamespace, clientFactory, InstallableResourceOptions{
 			DefaultDeletePropagation: opts.DefaultDeletePropagation,
 			NoPodLogs:                opts.NoPodLogs,
+			ReleaseDependencies:      opts.ReleaseDependencies,
 			Remote:                   opts.Remote,
 		})
 		if err != nil {
@@ -351,6 +389,7 @@ func BuildResources(ctx context.Context, deployType common.DeployType, releaseNa
 			instRes, err = NewInstallableResource(resSpec, newRelResSpecs, releaseNamespace, clientFactory, InstallableResourceOptions{
 				DefaultDeletePropagation: opts.DefaultDeletePropagation,
 				NoPodLogs:                opts.NoPodLogs,
+				ReleaseDependencies:      opts.ReleaseDependencies,
 				Remote:                   opts.Remote,
 			})
 			if err != nil {
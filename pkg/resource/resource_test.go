@@ -124,6 +124,46 @@ func (s *InstallableResourceSuite) TestNewInstallableResourceForDefaults() {
 	}
 }
 
+func (s *InstallableResourceSuite) TestNewInstallableResourceForApplyConflicts() {
+	testCases := []installableResourceTestCase{
+		{
+			expect: func(resSpec *spec.ResourceSpec) *resource.InstallableResource {
+				return defaultInstallableResource(resSpec)
+			},
+			input: func() *spec.ResourceSpec {
+				resSpec := defaultResourceSpec(s.releaseNamespace)
+				resSpec.SetAnnotations(lo.Assign(resSpec.Annotations, map[string]string{
+					"werf.io/apply-conflicts": "force",
+				}))
+
+				return resSpec
+			},
+			name: `for resource with werf.io/apply-conflicts="force"`,
+		},
+		{
+			expect: func(resSpec *spec.ResourceSpec) *resource.InstallableResource {
+				res := defaultInstallableResource(resSpec)
+				res.ApplyConflicts = common.ApplyConflictsFail
+
+				return res
+			},
+			input: func() *spec.ResourceSpec {
+				resSpec := defaultResourceSpec(s.releaseNamespace)
+				resSpec.SetAnnotations(lo.Assign(resSpec.Annotations, map[string]string{
+					"werf.io/apply-conflicts": "fail",
+				}))
+
+				return resSpec
+			},
+			name: `for resource with werf.io/apply-conflicts="fail"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, runInstallableResourceTest(tc, s))
+	}
+}
+
 func (s *InstallableResourceSuite) TestNewInstallableResourceForDeletePolicies() {
 	testCases := []installableResourceTestCase{
 		{
@@ -1561,6 +1601,63 @@ func (s *DeletableResourceSuite) TestNewDeletableResourceForOwnership() {
 	}
 }
 
+func (s *DeletableResourceSuite) TestNewDeletableResourceForProtect() {
+	testCases := []deletableResourceTestCase{
+		{
+			expectFunc: func(resSpec *spec.ResourceSpec) *resource.DeletableResource {
+				res := defaultDeletableResource(resSpec.ResourceMeta)
+				res.Protected = true
+
+				return res
+			},
+			inputFunc: func() *spec.ResourceSpec {
+				resSpec := defaultResourceSpec(s.releaseNamespace)
+				resSpec.SetAnnotations(lo.Assign(resSpec.Annotations, map[string]string{
+					"werf.io/protect": "true",
+				}))
+
+				return resSpec
+			},
+			name: `for resource with werf.io/protect="true"`,
+		},
+		{
+			expectFunc: func(resSpec *spec.ResourceSpec) *resource.DeletableResource {
+				return defaultDeletableResource(resSpec.ResourceMeta)
+			},
+			inputFunc: func() *spec.ResourceSpec {
+				resSpec := defaultResourceSpec(s.releaseNamespace)
+				resSpec.SetAnnotations(lo.Assign(resSpec.Annotations, map[string]string{
+					"werf.io/protect": "false",
+				}))
+
+				return resSpec
+			},
+			name: `for resource with werf.io/protect="false"`,
+		},
+		{
+			expectFunc: func(resSpec *spec.ResourceSpec) *resource.DeletableResource {
+				res := defaultDeletableResource(resSpec.ResourceMeta)
+				res.Protected = true
+
+				return res
+			},
+			inputFunc: func() *spec.ResourceSpec {
+				resSpec := defaultResourceSpec(s.releaseNamespace)
+				resSpec.SetAnnotations(lo.Assign(resSpec.Annotations, map[string]string{
+					"werf.io/protect": "invalid",
+				}))
+
+				return resSpec
+			},
+			name: `for resource with invalid werf.io/protect`,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, runDeletableResourceTest(tc, s))
+	}
+}
+
 type deletableResourceTestCase struct {
 	expectFunc     func(resSpec *spec.ResourceSpec) *resource.DeletableResource
 	inputFunc      func() *spec.ResourceSpec
@@ -1687,6 +1784,7 @@ func defaultDeploymentResourceSpec(releaseNamespace string) *spec.ResourceSpec {
 func defaultInstallableResource(resSpec *spec.ResourceSpec) *resource.InstallableResource {
 	return &resource.InstallableResource{
 		ResourceSpec:                    resSpec,
+		ApplyConflicts:                  common.ApplyConflictsForce,
 		Ownership:                       common.OwnershipRelease,
 		FailMode:                        multitrack.FailWholeDeployProcessImmediately,
 		NoActivityTimeout:               4 * time.Minute,
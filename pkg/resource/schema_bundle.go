@@ -0,0 +1,500 @@
+package resource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-resty/resty/v2"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/log"
+	"github.com/werf/nelm/pkg/util"
+)
+
+const (
+	SchemaBundleManifestFilename = "manifest.json"
+
+	schemaBundleManifestAPIVersion = "v1"
+	schemaBundleKubernetesTemplate = "kubernetes/{{ .NormalizedKubernetesVersion }}-standalone/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+	schemaBundleCRDsTemplate       = "crds/{{ .Group }}/{{ .ResourceKind }}_{{ .ResourceAPIVersion }}.json"
+)
+
+var errSchemaNotFound = errors.New("schema not found")
+
+// Kinds registered in every API group version, which are not Kubernetes resources.
+var nonResourceKinds = []string{
+	"APIGroup",
+	"APIGroupList",
+	"APIResourceList",
+	"APIVersions",
+	"CreateOptions",
+	"DeleteOptions",
+	"ExportOptions",
+	"GetOptions",
+	"ListOptions",
+	"PatchOptions",
+	"Status",
+	"UpdateOptions",
+	"WatchEvent",
+}
+
+type SchemaBundleManifest struct {
+	APIVersion  string               `json:"apiVersion"`
+	KubeVersion string               `json:"kubeVersion"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	Sources     []string             `json:"sources"`
+	Schemas     []*SchemaBundleEntry `json:"schemas"`
+}
+
+type SchemaBundleEntry struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+}
+
+// An extracted offline bundle of resource validation JSON schemas.
+type SchemaBundle struct {
+	Dir      string
+	Manifest *SchemaBundleManifest
+}
+
+type PullSchemaBundleOptions struct {
+	// ExtraKinds are kinds, usually of custom resources, to pull schemas for in addition to
+	// built-in kinds. Fails if no schema found for any of them.
+	ExtraKinds         []schema.GroupVersionKind
+	NetworkParallelism int
+	// Sources are schema sources in the same format as ResourceValidationOptions.ValidationSchemas.
+	Sources []string
+}
+
+// Downloads schemas of all built-in Kubernetes kinds and of the extra kinds for the Kubernetes
+// version from the sources and saves them to the tar.gz archive, along with the manifest listing
+// versions and checksums of the schemas.
+func PullSchemaBundle(ctx context.Context, kubeVersion, bundlePath string, opts PullSchemaBundleOptions) (*SchemaBundleManifest, error) {
+	kubeVersion, err := normalizeSchemaBundleKubeVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("normalize kube version: %w", err)
+	}
+
+	if opts.NetworkParallelism <= 0 {
+		opts.NetworkParallelism = common.DefaultNetworkParallelism
+	}
+
+	type pulledSchema struct {
+		entry *SchemaBundleEntry
+		data  []byte
+	}
+
+	httpClient := util.NewRestyClient(ctx)
+	pullPool := pool.NewWithResults[*pulledSchema]().WithContext(ctx).WithMaxGoroutines(opts.NetworkParallelism).WithCancelOnError().WithFirstError()
+
+	pullKind := func(gvk schema.GroupVersionKind, pathTemplate string, required bool) {
+		pullPool.Go(func(ctx context.Context) (*pulledSchema, error) {
+			data, err := downloadSchema(ctx, httpClient, opts.Sources, gvk, kubeVersion)
+			if err != nil {
+				if errors.Is(err, errSchemaNotFound) && !required {
+					log.Default.Debug(ctx, "Skip pulling schema for %s: %s", gvk, err)
+					return nil, nil
+				}
+
+				return nil, fmt.Errorf("pull schema for %s: %w", gvk, err)
+			}
+
+			schemaPath, err := patchKubeConformSchemaSource(pathTemplate, gvk, false, kubeVersion)
+			if err != nil {
+				return nil, fmt.Errorf("build schema path for %s: %w", gvk, err)
+			}
+
+			return &pulledSchema{
+				entry: &SchemaBundleEntry{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+					Path:    schemaPath,
+					SHA256:  getBytesHash(data),
+				},
+				data: data,
+			}, nil
+		})
+	}
+
+	for _, gvk := range builtinSchemaKinds() {
+		pullKind(gvk, schemaBundleKubernetesTemplate, false)
+	}
+
+	for _, gvk := range opts.ExtraKinds {
+		pullKind(gvk, schemaBundleCRDsTemplate, true)
+	}
+
+	results, err := pullPool.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	pulledSchemas := lo.UniqBy(lo.Compact(results), func(s *pulledSchema) string {
+		return s.entry.Path
+	})
+
+	sort.Slice(pulledSchemas, func(i, j int) bool {
+		return pulledSchemas[i].entry.Path < pulledSchemas[j].entry.Path
+	})
+
+	manifest := &SchemaBundleManifest{
+		APIVersion:  schemaBundleManifestAPIVersion,
+		KubeVersion: kubeVersion,
+		CreatedAt:   time.Now().UTC(),
+		Sources:     opts.Sources,
+		Schemas: lo.Map(pulledSchemas, func(s *pulledSchema, _ int) *SchemaBundleEntry {
+			return s.entry
+		}),
+	}
+
+	files := lo.SliceToMap(pulledSchemas, func(s *pulledSchema) (string, []byte) {
+		return s.entry.Path, s.data
+	})
+
+	if err := writeSchemaBundle(bundlePath, manifest, files); err != nil {
+		return nil, fmt.Errorf("write schema bundle %q: %w", bundlePath, err)
+	}
+
+	return manifest, nil
+}
+
+// Extracts the schema bundle into the schema cache directory, verifying checksums of the schemas.
+// The bundle is extracted only once.
+func OpenSchemaBundle(ctx context.Context, bundlePath string) (*SchemaBundle, error) {
+	bundleHash, err := getFileHash(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("get schema bundle %q checksum: %w", bundlePath, err)
+	}
+
+	bundlesDir := filepath.Join(common.APIResourceValidationJSONSchemasCacheDir, "bundles")
+	bundleDir := filepath.Join(bundlesDir, bundleHash)
+
+	if manifest, err := readSchemaBundleManifest(bundleDir); err == nil {
+		return &SchemaBundle{Dir: bundleDir, Manifest: manifest}, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read extracted schema bundle manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(bundlesDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create dir %q: %w", bundlesDir, err)
+	}
+
+	tmpDir, err := os.MkdirTemp(bundlesDir, bundleHash+"-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	log.Default.Debug(ctx, "Extract schema bundle %q to %q", bundlePath, bundleDir)
+
+	if err := extractSchemaBundle(bundlePath, tmpDir); err != nil {
+		return nil, fmt.Errorf("extract schema bundle %q: %w", bundlePath, err)
+	}
+
+	manifest, err := readSchemaBundleManifest(tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("read schema bundle %q manifest: %w", bundlePath, err)
+	}
+
+	if err := verifySchemaBundle(tmpDir, manifest); err != nil {
+		return nil, fmt.Errorf("verify schema bundle %q: %w", bundlePath, err)
+	}
+
+	if err := os.Rename(tmpDir, bundleDir); err != nil {
+		// The same bundle might have been extracted concurrently.
+		if _, statErr := os.Stat(filepath.Join(bundleDir, SchemaBundleManifestFilename)); statErr != nil {
+			return nil, fmt.Errorf("rename %q to %q: %w", tmpDir, bundleDir, err)
+		}
+	}
+
+	return &SchemaBundle{Dir: bundleDir, Manifest: manifest}, nil
+}
+
+// Returns schema sources in the same format as ResourceValidationOptions.ValidationSchemas, which
+// point at the extracted bundle.
+func (b *SchemaBundle) Sources() []string {
+	return []string{
+		filepath.Join(b.Dir, schemaBundleKubernetesTemplate),
+		filepath.Join(b.Dir, schemaBundleCRDsTemplate),
+	}
+}
+
+func builtinSchemaKinds() []schema.GroupVersionKind {
+	var gvks []schema.GroupVersionKind
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal ||
+			strings.HasSuffix(gvk.Kind, "List") ||
+			lo.Contains(nonResourceKinds, gvk.Kind) {
+			continue
+		}
+
+		gvks = append(gvks, gvk)
+	}
+
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+
+	return gvks
+}
+
+// Returns the schema from the first source which has it.
+func downloadSchema(ctx context.Context, httpClient *resty.Client, sources []string, gvk schema.GroupVersionKind, kubeVersion string) ([]byte, error) {
+	for _, source := range sources {
+		if !strings.HasSuffix(source, "json") {
+			// This matches the default kubeconform logic.
+			source = strings.TrimRight(source, "/") + "/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+		}
+
+		schemaPath, err := patchKubeConformSchemaSource(source, gvk, false, kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("build schema path for source %q: %w", source, err)
+		}
+
+		if isLocalFSSource(source) {
+			data, err := os.ReadFile(schemaPath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+
+				return nil, fmt.Errorf("read %q: %w", schemaPath, err)
+			}
+
+			return data, nil
+		}
+
+		response, err := httpClient.R().SetContext(ctx).Get(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("get %q: %w", schemaPath, err)
+		}
+
+		switch response.StatusCode() {
+		case http.StatusOK:
+			return response.Body(), nil
+		case http.StatusNotFound:
+			continue
+		default:
+			return nil, fmt.Errorf("get %q: unexpected status code %d", schemaPath, response.StatusCode())
+		}
+	}
+
+	return nil, errSchemaNotFound
+}
+
+func normalizeSchemaBundleKubeVersion(kubeVersion string) (string, error) {
+	kubeVersion = strings.TrimLeft(kubeVersion, "v")
+	if kubeVersion == "master" {
+		return kubeVersion, nil
+	}
+
+	version, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return "", fmt.Errorf("parse kube version %q: %w", kubeVersion, err)
+	}
+
+	return version.String(), nil
+}
+
+func writeSchemaBundle(bundlePath string, manifest *SchemaBundleManifest, files map[string][]byte) error {
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(bundlePath), filepath.Base(bundlePath)+"-")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	gzipWriter := gzip.NewWriter(tmpFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	writeFile := func(name string, data []byte) error {
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: manifest.CreatedAt,
+		}); err != nil {
+			return fmt.Errorf("write header of %q: %w", name, err)
+		}
+
+		if _, err := tarWriter.Write(data); err != nil {
+			return fmt.Errorf("write %q: %w", name, err)
+		}
+
+		return nil
+	}
+
+	if err := writeFile(SchemaBundleManifestFilename, manifestBytes); err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Schemas {
+		if err := writeFile(entry.Path, files[entry.Path]); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("close tar writer: %w", err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("close gzip writer: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	// CreateTemp creates the file readable only by the owner.
+	if err := os.Chmod(tmpFile.Name(), 0o644); err != nil {
+		return fmt.Errorf("chmod %q: %w", tmpFile.Name(), err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), bundlePath); err != nil {
+		return fmt.Errorf("rename %q to %q: %w", tmpFile.Name(), bundlePath, err)
+	}
+
+	return nil
+}
+
+func extractSchemaBundle(bundlePath, dir string) error {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("read gzip: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid file path %q in archive", header.Name)
+		}
+
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return fmt.Errorf("create dir for %q: %w", name, err)
+		}
+
+		if err := extractSchemaBundleFile(tarReader, filePath); err != nil {
+			return fmt.Errorf("extract %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func extractSchemaBundleFile(reader io.Reader, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func readSchemaBundleManifest(dir string) (*SchemaBundleManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SchemaBundleManifestFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &SchemaBundleManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal manifest: %w", err)
+	}
+
+	if manifest.APIVersion != schemaBundleManifestAPIVersion {
+		return nil, fmt.Errorf("unsupported manifest API version %q", manifest.APIVersion)
+	}
+
+	return manifest, nil
+}
+
+func verifySchemaBundle(dir string, manifest *SchemaBundleManifest) error {
+	for _, entry := range manifest.Schemas {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			return fmt.Errorf("read schema %q: %w", entry.Path, err)
+		}
+
+		if hash := getBytesHash(data); hash != entry.SHA256 {
+			return fmt.Errorf("checksum mismatch for schema %q: expected %s, got %s", entry.Path, entry.SHA256, hash)
+		}
+	}
+
+	return nil
+}
+
+func getBytesHash(data []byte) string {
+	digest := sha256.Sum256(data)
+
+	return hex.EncodeToString(digest[:])
+}
+
+func getFileHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,227 @@
+package resource_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/featgate"
+	"github.com/werf/nelm/pkg/resource"
+)
+
+const schemaBundleTestConfigMapSchema = `{
+  "type": "object",
+  "properties": {
+    "apiVersion": {"type": "string"},
+    "kind": {"type": "string"},
+    "metadata": {"type": "object"},
+    "data": {"type": "object", "additionalProperties": {"type": "string"}}
+  }
+}`
+
+const schemaBundleTestWidgetSchema = `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "required": ["size"],
+      "properties": {"size": {"type": "integer"}}
+    }
+  }
+}`
+
+func TestSchemaBundle(t *testing.T) {
+	featgate.FeatGateResourceValidation.Enable()
+	t.Cleanup(featgate.FeatGateResourceValidation.Disable)
+
+	cacheDir := common.APIResourceValidationJSONSchemasCacheDir
+	common.APIResourceValidationJSONSchemasCacheDir = t.TempDir()
+	t.Cleanup(func() { common.APIResourceValidationJSONSchemasCacheDir = cacheDir })
+
+	sourceDir := t.TempDir()
+	writeSchemaBundleTestFile(t, filepath.Join(sourceDir, "v1.35.0-standalone", "configmap-v1.json"), schemaBundleTestConfigMapSchema)
+	writeSchemaBundleTestFile(t, filepath.Join(sourceDir, "v1.35.0-standalone", "deployment-apps-v1.json"), `{"type": "object"}`)
+	writeSchemaBundleTestFile(t, filepath.Join(sourceDir, "v1.35.0-standalone", "widget-example-v1.json"), schemaBundleTestWidgetSchema)
+
+	bundlePath := filepath.Join(t.TempDir(), "schemas.tar.gz")
+
+	manifest, err := resource.PullSchemaBundle(context.Background(), "1.35", bundlePath, resource.PullSchemaBundleOptions{
+		ExtraKinds: []schema.GroupVersionKind{{Group: "example.com", Version: "v1", Kind: "Widget"}},
+		Sources:    []string{sourceDir},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.35.0", manifest.KubeVersion)
+	assert.Equal(t, []string{
+		"crds/example.com/widget_v1.json",
+		"kubernetes/v1.35.0-standalone/configmap-v1.json",
+		"kubernetes/v1.35.0-standalone/deployment-apps-v1.json",
+	}, lo.Map(manifest.Schemas, func(entry *resource.SchemaBundleEntry, _ int) string {
+		return entry.Path
+	}))
+
+	bundleStat, err := os.Stat(bundlePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), bundleStat.Mode().Perm())
+
+	bundle, err := resource.OpenSchemaBundle(context.Background(), bundlePath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Schemas, bundle.Manifest.Schemas)
+
+	validate := func(t *testing.T, objs ...map[string]interface{}) error {
+		resources := make([]*resource.InstallableResource, 0, len(objs))
+		for _, obj := range objs {
+			resources = append(resources, newCRDSchemaTestResource(t, obj))
+		}
+
+		return resource.ValidateLocal(context.Background(), "default", resources, common.ResourceValidationOptions{
+			ValidationKubeVersion:  common.DefaultResourceValidationKubeVersion,
+			ValidationSchemaBundle: bundlePath,
+			ValidationSchemas:      []string{"https://schemas.invalid/{{ .ResourceKind }}.json"},
+		})
+	}
+
+	configMap := func(data map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config"},
+			"data":       data,
+		}
+	}
+
+	widget := func(widgetSpec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "widget"},
+			"spec":       widgetSpec,
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, validate(t, configMap(map[string]interface{}{"key": "value"}), widget(map[string]interface{}{"size": int64(1)})))
+	})
+
+	t.Run("invalid built-in resource", func(t *testing.T) {
+		err := validate(t, configMap(map[string]interface{}{"key": int64(1)}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/data/key")
+	})
+
+	t.Run("invalid custom resource", func(t *testing.T) {
+		err := validate(t, widget(map[string]interface{}{}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "size")
+	})
+}
+
+func TestPullSchemaBundleMissingExtraKind(t *testing.T) {
+	bundlePath := filepath.Join(t.TempDir(), "schemas.tar.gz")
+
+	_, err := resource.PullSchemaBundle(context.Background(), "1.35.0", bundlePath, resource.PullSchemaBundleOptions{
+		ExtraKinds: []schema.GroupVersionKind{{Group: "example.com", Version: "v1", Kind: "Widget"}},
+		Sources:    []string{t.TempDir()},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "example.com/v1, Kind=Widget")
+
+	assert.NoFileExists(t, bundlePath)
+}
+
+func TestOpenSchemaBundleInvalid(t *testing.T) {
+	cacheDir := common.APIResourceValidationJSONSchemasCacheDir
+	common.APIResourceValidationJSONSchemasCacheDir = t.TempDir()
+	t.Cleanup(func() { common.APIResourceValidationJSONSchemasCacheDir = cacheDir })
+
+	manifest := `{
+  "apiVersion": "v1",
+  "kubeVersion": "1.35.0",
+  "schemas": [
+    {"version": "v1", "kind": "ConfigMap", "path": "kubernetes/v1.35.0-standalone/configmap-v1.json", "sha256": "0000000000000000000000000000000000000000000000000000000000000000"}
+  ]
+}`
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		errContains string
+	}{
+		{
+			name: "checksum mismatch",
+			files: map[string]string{
+				"manifest.json": manifest,
+				"kubernetes/v1.35.0-standalone/configmap-v1.json": schemaBundleTestConfigMapSchema,
+			},
+			errContains: "checksum",
+		},
+		{
+			name: "path traversal",
+			files: map[string]string{
+				"manifest.json":   manifest,
+				"../outside.json": schemaBundleTestConfigMapSchema,
+			},
+			errContains: "../outside.json",
+		},
+		{
+			name: "no manifest",
+			files: map[string]string{
+				"kubernetes/v1.35.0-standalone/configmap-v1.json": schemaBundleTestConfigMapSchema,
+			},
+			errContains: "manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundlePath := filepath.Join(t.TempDir(), "schemas.tar.gz")
+			writeSchemaBundleTestArchive(t, bundlePath, tt.files)
+
+			_, err := resource.OpenSchemaBundle(context.Background(), bundlePath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func writeSchemaBundleTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func writeSchemaBundleTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+
+		_, err := tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+}
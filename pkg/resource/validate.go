@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/yannh/kubeconform/pkg/validator"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/werf/nelm/pkg/common"
@@ -25,12 +26,71 @@ var (
 
 // Can be called even without cluster access.
 func ValidateLocal(ctx context.Context, releaseNamespace string, transformedResources []*InstallableResource, opts common.ResourceValidationOptions) error {
+	return validate(ctx, releaseNamespace, transformedResources, nil, opts)
+}
+
+// Same as ValidateLocal, but if ValidationClusterSchemas enabled, resources are also validated
+// against OpenAPI v3 schemas served by the cluster, which are preferred over json schema sources.
+// Should only be called if cluster access is allowed.
+func ValidateWithCluster(ctx context.Context, releaseNamespace string, transformedResources []*InstallableResource, discoveryClient discovery.DiscoveryInterface, opts common.ResourceValidationOptions) error {
+	var clusterValidator *clusterSchemaValidator
+	if opts.ValidationClusterSchemas {
+		clusterValidator = newClusterSchemaValidator(discoveryClient)
+	}
+
+	return validate(ctx, releaseNamespace, transformedResources, clusterValidator, opts)
+}
+
+// Matches the resource against filters in the "key1=value1,key2=value2" format. Supported keys:
+// group, version, kind, name, namespace. Returns true if any of the filters matches.
+func MatchResourceFilters(ctx context.Context, filters []string, releaseNamespace string, meta *spec.ResourceMeta) (bool, error) {
+	for _, filter := range filters {
+		properties, err := util.ParseProperties(ctx, filter)
+		if err != nil {
+			return false, fmt.Errorf("parse resource filter %q: %w", filter, err)
+		}
+
+		var matcher spec.ResourceMatcher
+
+		for property, value := range properties {
+			valueString, ok := value.(string)
+			if !ok {
+				return false, fmt.Errorf("filter %q requires a value for %q", filter, property)
+			}
+
+			switch property {
+			case "group":
+				matcher.Groups = append(matcher.Groups, valueString)
+			case "version":
+				matcher.Versions = append(matcher.Versions, valueString)
+			case "kind":
+				matcher.Kinds = append(matcher.Kinds, valueString)
+			case "namespace":
+				if valueString == "" {
+					valueString = releaseNamespace
+				}
+
+				matcher.Namespaces = append(matcher.Namespaces, valueString)
+			case "name":
+				matcher.Names = append(matcher.Names, valueString)
+			}
+		}
+
+		if matcher.Match(meta) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func validate(ctx context.Context, releaseNamespace string, transformedResources []*InstallableResource, clusterValidator *clusterSchemaValidator, opts common.ResourceValidationOptions) error {
 	if err := validateNoDuplicates(releaseNamespace, transformedResources); err != nil {
 		return fmt.Errorf("%w: %w", ErrResourceDuplicatesFound, err)
 	}
 
 	if featgate.FeatGateResourceValidation.Enabled() && !opts.NoResourceValidation {
-		if err := validateResourceSchemas(ctx, releaseNamespace, transformedResources, opts); err != nil {
+		if err := validateResourceSchemas(ctx, releaseNamespace, transformedResources, clusterValidator, opts); err != nil {
 			return fmt.Errorf("validate resource schemas: %w", err)
 		}
 	}
@@ -38,23 +98,39 @@ func ValidateLocal(ctx context.Context, releaseNamespace string, transformedReso
 	return nil
 }
 
-func validateResourceSchemas(ctx context.Context, releaseNamespace string, resources []*InstallableResource, opts common.ResourceValidationOptions) error {
+func validateResourceSchemas(ctx context.Context, releaseNamespace string, resources []*InstallableResource, clusterValidator *clusterSchemaValidator, opts common.ResourceValidationOptions) error {
 	if len(resources) == 0 {
 		return nil
 	}
 
-	kubeConformValidator, err := newKubeConformValidator(
-		opts.ValidationKubeVersion,
-		opts.ValidationSchemaCacheLifetime,
-		append(opts.ValidationExtraSchemas, opts.ValidationSchemas...))
+	kubeVersion := opts.ValidationKubeVersion
+	schemaSources := lo.Flatten([][]string{opts.ValidationExtraSchemas, opts.ValidationSchemas})
+
+	if opts.ValidationSchemaBundle != "" && !opts.LocalResourceValidation {
+		bundle, err := OpenSchemaBundle(ctx, opts.ValidationSchemaBundle)
+		if err != nil {
+			return fmt.Errorf("open schema bundle: %w", err)
+		}
+
+		if kubeVersion != "" && kubeVersion != common.DefaultResourceValidationKubeVersion && strings.TrimLeft(kubeVersion, "v") != bundle.Manifest.KubeVersion {
+			log.Default.Warn(ctx, "Schema bundle has schemas for kube version %s, not %s, using them anyway", bundle.Manifest.KubeVersion, kubeVersion)
+		}
+
+		kubeVersion = bundle.Manifest.KubeVersion
+		schemaSources = lo.Flatten([][]string{opts.ValidationExtraSchemas, bundle.Sources()})
+	}
+
+	kubeConformValidator, err := newKubeConformValidator(kubeVersion, opts.ValidationSchemaCacheLifetime, schemaSources)
 	if err != nil {
 		return fmt.Errorf("get schema validator: %w", err)
 	}
 
+	crdSchemaValidator := newCRDSchemaValidator(ctx, resources)
+
 	validationErrs := &util.MultiError{}
 
 	for _, res := range resources {
-		if ok, err := shouldSkipValidation(ctx, opts.ValidationSkip, releaseNamespace, res.ResourceMeta); err != nil {
+		if ok, err := MatchResourceFilters(ctx, opts.ValidationSkip, releaseNamespace, res.ResourceMeta); err != nil {
 			return fmt.Errorf("skip validation: %w", err)
 		} else if ok {
 			log.Default.Debug(ctx, "Skip local validation for resource (due to ValidationSkip): %s", res.IDHuman())
@@ -62,7 +138,23 @@ func validateResourceSchemas(ctx context.Context, releaseNamespace string, resou
 			continue
 		}
 
-		if !opts.LocalResourceValidation {
+		validated, err := crdSchemaValidator.Validate(ctx, res.ResourceSpec)
+		if err == nil && !validated && clusterValidator != nil {
+			validated, err = clusterValidator.Validate(ctx, res.ResourceSpec)
+		}
+
+		if err != nil {
+			e := fmt.Errorf("validate %s: %w", res.IDHuman(), err)
+
+			var vErr *validator.ValidationError
+			if errors.As(err, &vErr) {
+				validationErrs.Add(e)
+
+				continue
+			}
+
+			return e
+		} else if !validated && !opts.LocalResourceValidation {
 			if err := kubeConformValidator.Validate(ctx, res.ResourceSpec); err != nil {
 				e := fmt.Errorf("validate %s: %w", res.IDHuman(), err)
 
@@ -93,41 +185,6 @@ func validateResourceSchemas(ctx context.Context, releaseNamespace string, resou
 	return validationErrs.OrNilIfNoErrs()
 }
 
-func shouldSkipValidation(ctx context.Context, filters []string, releaseNamespace string, meta *spec.ResourceMeta) (bool, error) {
-	for _, filter := range filters {
-		properties := lo.Must(util.ParseProperties(ctx, filter))
-
-		var matcher spec.ResourceMatcher
-
-		for property, value := range properties {
-			valueString := value.(string)
-
-			switch property {
-			case "group":
-				matcher.Groups = append(matcher.Groups, valueString)
-			case "version":
-				matcher.Versions = append(matcher.Versions, valueString)
-			case "kind":
-				matcher.Kinds = append(matcher.Kinds, valueString)
-			case "namespace":
-				if valueString == "" {
-					valueString = releaseNamespace
-				}
-
-				matcher.Namespaces = append(matcher.Namespaces, valueString)
-			case "name":
-				matcher.Names = append(matcher.Names, valueString)
-			}
-		}
-
-		if matcher.Match(meta) {
-			return true, nil
-		}
-	}
-
-	return false, nil
-}
-
 func validateNoDuplicates(releaseNamespace string, transformedResources []*InstallableResource) error {
 	for _, res := range transformedResources {
 		if spec.IsReleaseNamespace(res.Unstruct.GetName(), res.Unstruct.GroupVersionKind(), releaseNamespace) {
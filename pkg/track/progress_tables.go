@@ -130,7 +130,7 @@ func (b *tablesBuilder) BuildEventTables() (tables map[string]prtable.Writer, no
 								continue
 							}
 
-							table.AppendRow(prtable.Row{event.Message})
+							table.AppendRow(prtable.Row{log.MaskSecrets(event.Message)})
 
 							nextEventPointer++
 						}
@@ -175,7 +175,7 @@ func (b *tablesBuilder) BuildLogTables() (tables map[string]prtable.Writer, nonE
 							continue
 						}
 
-						table.AppendRow(prtable.Row{logLine.Line})
+						table.AppendRow(prtable.Row{log.MaskSecrets(logLine.Line)})
 
 						nextLogPointer++
 					}
@@ -0,0 +1,75 @@
+package ts
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/werf/nelm/pkg/common"
+)
+
+// BundleCache keeps TypeScript bundles between renders of the same charts, so that the charts are
+// re-bundled only when their TypeScript sources change. Not safe for concurrent use.
+type BundleCache struct {
+	bundles     map[string][]byte
+	invalidated map[string]bool
+}
+
+func NewBundleCache() *BundleCache {
+	return &BundleCache{
+		bundles:     make(map[string][]byte),
+		invalidated: make(map[string]bool),
+	}
+}
+
+// Drop the cached bundles of the charts whose TypeScript sources are among the changed files.
+// Dropped bundles are rebuilt on the next render, even if there is a prebuilt bundle in the chart.
+// Returns true if any bundle was invalidated.
+func (c *BundleCache) InvalidateForChangedFiles(changedPaths []string) bool {
+	var invalidated bool
+	for chartPath := range c.bundles {
+		tsDir := filepath.Join(chartPath, common.ChartTSSourceDir) + string(filepath.Separator)
+
+		for _, path := range changedPaths {
+			if strings.HasPrefix(bundleCacheKey(path), tsDir) {
+				delete(c.bundles, chartPath)
+				c.invalidated[chartPath] = true
+				invalidated = true
+
+				break
+			}
+		}
+	}
+
+	return invalidated
+}
+
+func (c *BundleCache) get(chartPath string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	bundle, found := c.bundles[bundleCacheKey(chartPath)]
+
+	return bundle, found
+}
+
+func (c *BundleCache) isInvalidated(chartPath string) bool {
+	return c != nil && c.invalidated[bundleCacheKey(chartPath)]
+}
+
+func (c *BundleCache) set(chartPath string, bundle []byte) {
+	if c == nil {
+		return
+	}
+
+	c.bundles[bundleCacheKey(chartPath)] = bundle
+	delete(c.invalidated, bundleCacheKey(chartPath))
+}
+
+func bundleCacheKey(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		return absPath
+	}
+
+	return filepath.Clean(path)
+}
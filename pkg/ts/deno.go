@@ -31,7 +31,7 @@ func BundleChartsRecursive(ctx context.Context, chart *helmchart.Chart, path str
 		return fmt.Errorf("ensure Deno is available: %w", err)
 	}
 
-	return bundleChartsRecursive(ctx, chart, path, rebuildBundle, denoBin)
+	return bundleChartsRecursive(ctx, chart, path, rebuildBundle, nil, denoBin)
 }
 
 func RunDenoInstall(ctx context.Context, chartPath, binaryPath string) error {
@@ -56,11 +56,19 @@ func RunDenoInstall(ctx context.Context, chartPath, binaryPath string) error {
 	return nil
 }
 
-func bundleChartsRecursive(ctx context.Context, chart *helmchart.Chart, path string, rebuildBundle bool, denoBin string) error {
+func bundleChartsRecursive(ctx context.Context, chart *helmchart.Chart, path string, rebuildBundle bool, bundleCache *BundleCache, denoBin string) error {
 	entrypoint, bundle := getEntrypointAndBundle(chart.RuntimeFiles)
 
 	if entrypoint != "" {
-		if bundle == nil || rebuildBundle {
+		if cachedBundle, found := bundleCache.get(path); found && !rebuildBundle {
+			log.Default.Debug(ctx, "Use cached TypeScript bundle for chart %q", chart.Name())
+
+			if bundle != nil {
+				chart.RemoveRuntimeFile(common.ChartTSBundleFile)
+			}
+
+			chart.AddRuntimeFile(common.ChartTSBundleFile, cachedBundle)
+		} else if bundle == nil || rebuildBundle || bundleCache.isInvalidated(path) {
 			log.Default.Info(ctx, "Bundle TypeScript for chart %q (entrypoint: %s)", chart.Name(), entrypoint)
 
 			bundleRes, err := runDenoBundle(ctx, path, entrypoint, denoBin)
@@ -73,6 +81,9 @@ func bundleChartsRecursive(ctx context.Context, chart *helmchart.Chart, path str
 			}
 
 			chart.AddRuntimeFile(common.ChartTSBundleFile, bundleRes)
+			bundleCache.set(path, bundleRes)
+		} else {
+			bundleCache.set(path, bundle.Data)
 		}
 	}
 
@@ -85,7 +96,7 @@ func bundleChartsRecursive(ctx context.Context, chart *helmchart.Chart, path str
 			continue
 		}
 
-		if err := bundleChartsRecursive(ctx, dep, depPath, rebuildBundle, denoBin); err != nil {
+		if err := bundleChartsRecursive(ctx, dep, depPath, rebuildBundle, bundleCache, denoBin); err != nil {
 			return fmt.Errorf("process dependency %q: %w", dep.Name(), err)
 		}
 	}
@@ -205,7 +216,8 @@ func getDenoBinary(ctx context.Context, binaryPath string) (string, error) {
 	return denoPath, nil
 }
 
-func runApp(ctx context.Context, bundleData []byte, renderDir, denoBin string) error {
+// Run the bundled app in the sandbox. Returns the lookup requests written by the app to stdout.
+func runApp(ctx context.Context, bundleData []byte, renderDir, denoBin string) ([]*lookupRequest, error) {
 	args := []string{
 		"run",
 		"--no-remote",
@@ -229,17 +241,17 @@ func runApp(ctx context.Context, bundleData []byte, renderDir, denoBin string) e
 
 	stdinPipe, err := cmd.StdinPipe()
 	if err != nil {
-		return fmt.Errorf("get stdin pipe: %w", err)
+		return nil, fmt.Errorf("get stdin pipe: %w", err)
 	}
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("get stdout pipe: %w", err)
+		return nil, fmt.Errorf("get stdout pipe: %w", err)
 	}
 
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("get stderr pipe: %w", err)
+		return nil, fmt.Errorf("get stderr pipe: %w", err)
 	}
 
 	stdinErrChan := make(chan error, 1)
@@ -253,14 +265,26 @@ func runApp(ctx context.Context, bundleData []byte, renderDir, denoBin string) e
 	}()
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start process: %w", err)
+		return nil, fmt.Errorf("start process: %w", err)
 	}
 
+	var lookupRequests []*lookupRequest
+
 	stdoutErrChan := make(chan error, 1)
 	go func() {
 		scanner := bufio.NewScanner(stdoutPipe)
 		for scanner.Scan() {
 			text := scanner.Text()
+
+			req, isLookupRequest, err := parseLookupRequest(text)
+			if err != nil {
+				log.Default.Error(ctx, "invalid lookup request from deno app: %s", err)
+				continue
+			} else if isLookupRequest {
+				lookupRequests = append(lookupRequests, req)
+				continue
+			}
+
 			log.Default.Debug(ctx, text)
 		}
 
@@ -278,22 +302,22 @@ func runApp(ctx context.Context, bundleData []byte, renderDir, denoBin string) e
 	}()
 
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("wait process: %w", err)
+		return nil, fmt.Errorf("wait process: %w", err)
 	}
 
 	if err := <-stdinErrChan; err != nil {
-		return fmt.Errorf("write bundle data to stdinPipe: %w", err)
+		return nil, fmt.Errorf("write bundle data to stdinPipe: %w", err)
 	}
 
 	if err := <-stdoutErrChan; err != nil {
-		return fmt.Errorf("read stdout: %w", err)
+		return nil, fmt.Errorf("read stdout: %w", err)
 	}
 
 	if err := <-stderrErrChan; err != nil {
-		return fmt.Errorf("read stderr: %w", err)
+		return nil, fmt.Errorf("read stderr: %w", err)
 	}
 
-	return nil
+	return lookupRequests, nil
 }
 
 func runDenoBundle(ctx context.Context, chartPath, entryPoint, denoBin string) ([]uint8, error) {
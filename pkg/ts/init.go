@@ -24,12 +24,13 @@ type InitTSBoilerplateOptions struct {
 }
 
 type initTmplData struct {
-	BuildScript       string
-	ChartName         string
-	DevScript         string
-	IsWerfChart       bool
-	RenderContextType string
-	StartScript       string
+	BuildScript         string
+	ChartName           string
+	DevScript           string
+	IsWerfChart         bool
+	LookupRequestPrefix string
+	RenderContextType   string
+	StartScript         string
 }
 
 // EnsureGitignore adds TypeScript entries to .gitignore, creating if needed.
@@ -88,12 +89,13 @@ func InitTSBoilerplate(ctx context.Context, chartPath, chartName string, opts In
 	}
 
 	data := initTmplData{
-		BuildScript:       denoBuildScript,
-		ChartName:         chartName,
-		DevScript:         denoDevScript,
-		IsWerfChart:       ctxType == common.TSWerfRenderContextType,
-		RenderContextType: ctxType,
-		StartScript:       denoStartScript,
+		BuildScript:         denoBuildScript,
+		ChartName:           chartName,
+		DevScript:           denoDevScript,
+		IsWerfChart:         ctxType == common.TSWerfRenderContextType,
+		LookupRequestPrefix: common.ChartTSLookupRequestPrefix,
+		RenderContextType:   ctxType,
+		StartScript:         denoStartScript,
 	}
 
 	files := []struct {
@@ -104,6 +106,7 @@ func InitTSBoilerplate(ctx context.Context, chartPath, chartName string, opts In
 		{tmpl: helpersTSTmpl, path: filepath.Join(srcDir, "helpers.ts")},
 		{tmpl: deploymentTSTmpl, path: filepath.Join(srcDir, "deployment.ts")},
 		{tmpl: serviceTSTmpl, path: filepath.Join(srcDir, "service.ts")},
+		{tmpl: lookupTSTmpl, path: filepath.Join(srcDir, "lookup.ts")},
 		{tmpl: denoJSONTmpl, path: filepath.Join(tsDir, "deno.json")},
 		{tmpl: inputExampleTmpl, path: filepath.Join(tsDir, "input.example.yaml")},
 	}
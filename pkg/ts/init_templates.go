@@ -17,14 +17,15 @@ const (
 	}
   },
   "imports": {
-    "@nelm/chart-ts-sdk": "npm:@nelm/chart-ts-sdk@^0.1.5"
+    "@nelm/chart-ts-sdk": "npm:@nelm/chart-ts-sdk@^0.2.0"
   }
 }
 `
 	deploymentTSTmpl = `import type { {{ .RenderContextType }} } from '@nelm/chart-ts-sdk';
+import type { Values } from './values.ts';
 import { getFullname, getLabels, getSelectorLabels } from './helpers.ts';
 
-export function newDeployment($: {{ .RenderContextType }}): object {
+export function newDeployment($: {{ .RenderContextType }}<Values>): object {
   const name = getFullname($);
 
   return {
@@ -71,6 +72,7 @@ ts/vendor/
 ts/node_modules/
 `
 	helpersTSTmpl = `import type { {{ .RenderContextType }} } from '@nelm/chart-ts-sdk';
+import type { Values } from './values.ts';
 
 /**
  * Truncate string to max length, removing trailing hyphens.
@@ -84,7 +86,7 @@ export function trunc(str: string, max: number): string {
  * Get the fully qualified app name.
  * Truncated at 63 chars (DNS naming spec limit).
  */
-export function getFullname($: {{ .RenderContextType }}): string {
+export function getFullname($: {{ .RenderContextType }}<Values>): string {
   if ($.Values.fullnameOverride) {
     return trunc($.Values.fullnameOverride, 63);
   }
@@ -98,14 +100,14 @@ export function getFullname($: {{ .RenderContextType }}): string {
   return trunc(` + "`${$.Release.Name}-${chartName}`" + `, 63);
 }
 
-export function getLabels($: {{ .RenderContextType }}): Record<string, string> {
+export function getLabels($: {{ .RenderContextType }}<Values>): Record<string, string> {
   return {
     'app.kubernetes.io/name': $.Chart.Name,
     'app.kubernetes.io/instance': $.Release.Name,
   };
 }
 
-export function getSelectorLabels($: {{ .RenderContextType }}): Record<string, string> {
+export function getSelectorLabels($: {{ .RenderContextType }}<Values>): Record<string, string> {
   return {
     'app.kubernetes.io/name': $.Chart.Name,
     'app.kubernetes.io/instance': $.Release.Name,
@@ -115,8 +117,9 @@ export function getSelectorLabels($: {{ .RenderContextType }}): Record<string, s
 	indexTSTmpl = `import { {{ .RenderContextType }}, RenderResult, render } from '@nelm/chart-ts-sdk';
 import { newDeployment } from './deployment.ts';
 import { newService } from './service.ts';
+import type { Values } from './values.ts';
 
-function generate($: {{ .RenderContextType }}): RenderResult {
+function generate($: {{ .RenderContextType }}<Values>): RenderResult {
   const manifests: object[] = [];
 
   manifests.push(newDeployment($));
@@ -163,6 +166,7 @@ Chart:
   Version: 0.1.0
 Files:
   myfile: "content"
+Lookups: []
 Release:
   IsInstall: false
   IsUpgrade: true
@@ -208,11 +212,65 @@ Values:
     enabled: true
     port: 80
     type: ClusterIP
+`
+	lookupTSTmpl = `import type { {{ .RenderContextType }} } from '@nelm/chart-ts-sdk';
+
+const lookupRequestPrefix = '{{ .LookupRequestPrefix }}';
+
+interface LookupResult {
+  APIVersion: string;
+  Kind: string;
+  Namespace?: string;
+  Name?: string;
+  Object: Record<string, unknown>;
+}
+
+/**
+ * Look up an object in the cluster, like the "lookup" function of Go templates.
+ * Returns a List object if the name is empty and an empty object if nothing is found.
+ *
+ * The chart has no access to the cluster. Objects which are not in $.Lookups yet are
+ * requested from nelm by writing a line to stdout, then nelm looks them up and renders
+ * the chart again with the results in $.Lookups.
+ */
+export function lookup<T>(
+  $: {{ .RenderContextType }}<T>,
+  apiVersion: string,
+  kind: string,
+  namespace = '',
+  name = '',
+): Record<string, unknown> {
+  const lookups = ($ as unknown as { Lookups?: LookupResult[] }).Lookups ?? [];
+
+  const result = lookups.find(
+    (r) =>
+      r.APIVersion === apiVersion &&
+      r.Kind === kind &&
+      (r.Namespace ?? '') === namespace &&
+      (r.Name ?? '') === name,
+  );
+  if (result) {
+    return result.Object ?? {};
+  }
+
+  console.log(
+    lookupRequestPrefix +
+      JSON.stringify({
+        APIVersion: apiVersion,
+        Kind: kind,
+        Namespace: namespace || undefined,
+        Name: name || undefined,
+      }),
+  );
+
+  return {};
+}
 `
 	serviceTSTmpl = `import type { {{ .RenderContextType }} } from '@nelm/chart-ts-sdk';
+import type { Values } from './values.ts';
 import { getFullname, getLabels, getSelectorLabels } from './helpers.ts';
 
-export function newService($: {{ .RenderContextType }}): object {
+export function newService($: {{ .RenderContextType }}<Values>): object {
   return {
     apiVersion: 'v1',
     kind: 'Service',
@@ -233,7 +291,10 @@ export function newService($: {{ .RenderContextType }}): object {
   };
 }
 `
-	valuesYamlContent = `replicaCount: 1
+	valuesYamlContent = `nameOverride: ""
+fullnameOverride: ""
+
+replicaCount: 1
 
 image:
   repository: nginx
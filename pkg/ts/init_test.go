@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/werf/nelm/pkg/common"
 	"github.com/werf/nelm/pkg/ts"
 )
 
@@ -183,6 +184,7 @@ func TestInitTSBoilerplate(t *testing.T) {
 		assert.FileExists(t, filepath.Join(chartPath, "ts", "src", "helpers.ts"))
 		assert.FileExists(t, filepath.Join(chartPath, "ts", "src", "deployment.ts"))
 		assert.FileExists(t, filepath.Join(chartPath, "ts", "src", "service.ts"))
+		assert.FileExists(t, filepath.Join(chartPath, "ts", "src", "lookup.ts"))
 
 		assert.FileExists(t, filepath.Join(chartPath, "ts", "deno.json"))
 		assert.FileExists(t, filepath.Join(chartPath, "ts", "input.example.yaml"))
@@ -241,7 +243,7 @@ func TestInitTSBoilerplate(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		for _, file := range []string{"index.ts", "helpers.ts", "deployment.ts", "service.ts"} {
+		for _, file := range []string{"index.ts", "helpers.ts", "deployment.ts", "service.ts", "lookup.ts"} {
 			content, err := os.ReadFile(filepath.Join(chartPath, "ts", "src", file))
 			require.NoError(t, err)
 			assert.Contains(t, string(content), "WerfRenderContext", "file %s should use WerfRenderContext", file)
@@ -263,6 +265,19 @@ func TestInitTSBoilerplate(t *testing.T) {
 		assert.Contains(t, string(content), "export function getSelectorLabels")
 	})
 
+	t.Run("includes lookup function in lookup.ts", func(t *testing.T) {
+		chartPath := filepath.Join(t.TempDir(), "test-chart")
+		require.NoError(t, os.MkdirAll(chartPath, 0o755))
+
+		err := ts.InitTSBoilerplate(context.Background(), chartPath, "test-chart", ts.InitTSBoilerplateOptions{})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(chartPath, "ts", "src", "lookup.ts"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "export function lookup")
+		assert.Contains(t, string(content), fmt.Sprintf("const lookupRequestPrefix = '%s';", common.ChartTSLookupRequestPrefix))
+	})
+
 	t.Run("includes resource generators", func(t *testing.T) {
 		chartPath := filepath.Join(t.TempDir(), "test-chart")
 		require.NoError(t, os.MkdirAll(chartPath, 0o755))
@@ -289,6 +304,7 @@ func TestInitTSBoilerplate(t *testing.T) {
 		content, err := os.ReadFile(filepath.Join(chartPath, "ts", "deno.json"))
 		require.NoError(t, err)
 		assert.Contains(t, string(content), `"@nelm/chart-ts-sdk"`)
+		assert.Contains(t, string(content), `npm:@nelm/chart-ts-sdk@^0.2.0`, "generic RenderContext requires SDK 0.2")
 	})
 
 	t.Run("includes chart name in input.example.yaml", func(t *testing.T) {
@@ -0,0 +1,93 @@
+package ts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/helm/pkg/engine"
+)
+
+// The Deno app can't access the cluster, so lookups are resolved in rounds: the app requests
+// objects it has no results for in the Lookups of the input file by writing lookup requests to
+// stdout, then nelm resolves them and reruns the app with the results added to the input file.
+const maxLookupRounds = 10
+
+type lookupFunc func(apiVersion, kind, namespace, name string) (map[string]interface{}, error)
+
+type lookupRequest struct {
+	APIVersion string `json:"APIVersion"`
+	Kind       string `json:"Kind"`
+	Namespace  string `json:"Namespace,omitempty"`
+	Name       string `json:"Name,omitempty"`
+}
+
+func (r lookupRequest) String() string {
+	return fmt.Sprintf("apiVersion=%q kind=%q namespace=%q name=%q", r.APIVersion, r.Kind, r.Namespace, r.Name)
+}
+
+type lookupResult struct {
+	lookupRequest
+
+	// Empty if the object is not found, a List object if the name is not specified.
+	Object map[string]interface{} `json:"Object"`
+}
+
+func newLookupFunc(clientProvider engine.ClientProvider) lookupFunc {
+	if clientProvider == nil {
+		return func(string, string, string, string) (map[string]interface{}, error) {
+			return map[string]interface{}{}, nil
+		}
+	}
+
+	return engine.NewClientProviderLookupFunction(clientProvider)
+}
+
+func parseLookupRequest(line string) (*lookupRequest, bool, error) {
+	data, found := strings.CutPrefix(line, common.ChartTSLookupRequestPrefix)
+	if !found {
+		return nil, false, nil
+	}
+
+	var req lookupRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		return nil, true, fmt.Errorf("unmarshal lookup request %q: %w", data, err)
+	}
+
+	if req.APIVersion == "" || req.Kind == "" {
+		return nil, true, fmt.Errorf("lookup request %q must have APIVersion and Kind", data)
+	}
+
+	return &req, true, nil
+}
+
+// Resolve the requests which have no results yet and return all the results. The number of newly
+// resolved requests is returned too.
+func resolveLookupRequests(requests []*lookupRequest, results []*lookupResult, lookup lookupFunc) ([]*lookupResult, int, error) {
+	resolved := make(map[lookupRequest]bool, len(results))
+	for _, result := range results {
+		resolved[result.lookupRequest] = true
+	}
+
+	var newResolved int
+	for _, req := range requests {
+		if resolved[*req] {
+			continue
+		}
+
+		obj, err := lookup(req.APIVersion, req.Kind, req.Namespace, req.Name)
+		if err != nil {
+			return nil, 0, fmt.Errorf("lookup %s: %w", req, err)
+		}
+
+		results = append(results, &lookupResult{
+			lookupRequest: *req,
+			Object:        obj,
+		})
+		resolved[*req] = true
+		newResolved++
+	}
+
+	return results, newResolved, nil
+}
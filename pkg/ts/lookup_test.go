@@ -0,0 +1,95 @@
+package ts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	helmchart "github.com/werf/nelm/pkg/helm/pkg/chart"
+	"github.com/werf/nelm/pkg/helm/pkg/chartutil"
+)
+
+func TestParseLookupRequest(t *testing.T) {
+	req, isLookupRequest, err := parseLookupRequest(`::nelm-lookup::{"APIVersion":"v1","Kind":"Secret","Namespace":"app","Name":"creds"}`)
+	require.NoError(t, err)
+	assert.True(t, isLookupRequest)
+	assert.Equal(t, &lookupRequest{APIVersion: "v1", Kind: "Secret", Namespace: "app", Name: "creds"}, req)
+
+	_, isLookupRequest, err = parseLookupRequest("some log line")
+	require.NoError(t, err)
+	assert.False(t, isLookupRequest)
+
+	_, isLookupRequest, err = parseLookupRequest(`::nelm-lookup::{"Name":"creds"}`)
+	assert.Error(t, err)
+	assert.True(t, isLookupRequest)
+}
+
+func TestResolveLookupRequests(t *testing.T) {
+	var calls int
+	lookup := func(apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+		calls++
+
+		if name == "broken" {
+			return nil, errors.New("forbidden")
+		}
+
+		return map[string]interface{}{"apiVersion": apiVersion, "kind": kind}, nil
+	}
+
+	secretReq := &lookupRequest{APIVersion: "v1", Kind: "Secret", Namespace: "app", Name: "creds"}
+	nsListReq := &lookupRequest{APIVersion: "v1", Kind: "Namespace"}
+
+	results, newResolved, err := resolveLookupRequests([]*lookupRequest{secretReq, secretReq}, nil, lookup)
+	require.NoError(t, err)
+	assert.Equal(t, 1, newResolved)
+	assert.Equal(t, 1, calls)
+
+	results, newResolved, err = resolveLookupRequests([]*lookupRequest{secretReq, nsListReq}, results, lookup)
+	require.NoError(t, err)
+	assert.Equal(t, 1, newResolved)
+	assert.Equal(t, 2, calls)
+	require.Len(t, results, 2)
+	assert.Equal(t, *nsListReq, results[1].lookupRequest)
+	assert.Equal(t, "Namespace", results[1].Object["kind"])
+
+	_, _, err = resolveLookupRequests([]*lookupRequest{{APIVersion: "v1", Kind: "Secret", Name: "broken"}}, results, lookup)
+	assert.ErrorContains(t, err, "forbidden")
+}
+
+func TestWriteInputRenderContextLookups(t *testing.T) {
+	renderDir := t.TempDir()
+	chart := &helmchart.Chart{Metadata: &helmchart.Metadata{Name: "app"}}
+
+	require.NoError(t, writeInputRenderContext(chartutil.Values{"Values": chartutil.Values{}}, chart, []*lookupResult{
+		{
+			lookupRequest: lookupRequest{APIVersion: "v1", Kind: "Secret", Namespace: "app", Name: "missing"},
+			Object:        map[string]interface{}{},
+		},
+	}, renderDir))
+
+	data, err := os.ReadFile(filepath.Join(renderDir, "input.yaml"))
+	require.NoError(t, err)
+
+	var input map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &input))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"APIVersion": "v1",
+			"Kind":       "Secret",
+			"Namespace":  "app",
+			"Name":       "missing",
+			"Object":     map[string]interface{}{},
+		},
+	}, input["Lookups"])
+}
+
+func TestNewLookupFuncWithoutClientProvider(t *testing.T) {
+	obj, err := newLookupFunc(nil)("v1", "Secret", "app", "creds")
+	require.NoError(t, err)
+	assert.Empty(t, obj)
+}
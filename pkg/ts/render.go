@@ -14,10 +14,19 @@ import (
 	"github.com/werf/nelm/pkg/common"
 	helmchart "github.com/werf/nelm/pkg/helm/pkg/chart"
 	"github.com/werf/nelm/pkg/helm/pkg/chartutil"
+	"github.com/werf/nelm/pkg/helm/pkg/engine"
 	"github.com/werf/nelm/pkg/log"
 )
 
-func RenderChart(ctx context.Context, chart *helmchart.Chart, renderedValues chartutil.Values, rebuildBundle bool, chartPath, tempDirPath, denoBinaryPath string) (map[string]string, error) {
+type RenderChartOptions struct {
+	// BundleCache, if not nil, is used to take bundles from and to save bundles to.
+	BundleCache *BundleCache
+	// ClientProvider resolves lookups of the charts. If nil, lookups return empty results.
+	ClientProvider engine.ClientProvider
+	RebuildBundle  bool
+}
+
+func RenderChart(ctx context.Context, chart *helmchart.Chart, renderedValues chartutil.Values, chartPath, tempDirPath, denoBinaryPath string, opts RenderChartOptions) (map[string]string, error) {
 	if !hasTSFiles(chart) {
 		return map[string]string{}, nil
 	}
@@ -27,11 +36,11 @@ func RenderChart(ctx context.Context, chart *helmchart.Chart, renderedValues cha
 		return nil, fmt.Errorf("ensure Deno is available: %w", err)
 	}
 
-	if err := bundleChartsRecursive(ctx, chart, chartPath, rebuildBundle, denoBin); err != nil {
+	if err := bundleChartsRecursive(ctx, chart, chartPath, opts.RebuildBundle, opts.BundleCache, denoBin); err != nil {
 		return nil, fmt.Errorf("bundle chart recursive: %w", err)
 	}
 
-	allRendered, err := renderChartRecursive(ctx, chart, renderedValues, chart.Name(), chartPath, tempDirPath, denoBin)
+	allRendered, err := renderChartRecursive(ctx, chart, renderedValues, chart.Name(), chartPath, tempDirPath, denoBin, newLookupFunc(opts.ClientProvider))
 	if err != nil {
 		return nil, fmt.Errorf("render chart recursive: %w", err)
 	}
@@ -39,14 +48,14 @@ func RenderChart(ctx context.Context, chart *helmchart.Chart, renderedValues cha
 	return allRendered, nil
 }
 
-func renderChartRecursive(ctx context.Context, chart *helmchart.Chart, values chartutil.Values, pathPrefix, chartPath, tempDirPath, denoBin string) (map[string]string, error) {
+func renderChartRecursive(ctx context.Context, chart *helmchart.Chart, values chartutil.Values, pathPrefix, chartPath, tempDirPath, denoBin string, lookup lookupFunc) (map[string]string, error) {
 	log.Default.Debug(ctx, "Rendering TypeScript for chart %q (path prefix: %s)", chart.Name(), pathPrefix)
 
 	results := make(map[string]string)
 	entrypoint, bundle := getEntrypointAndBundle(chart.RuntimeFiles)
 
 	if bundle != nil {
-		content, err := renderChart(ctx, bundle, chart, values, tempDirPath, denoBin)
+		content, err := renderChart(ctx, bundle, chart, values, tempDirPath, denoBin, lookup)
 		if err != nil {
 			return nil, fmt.Errorf("render files for chart %q: %w", chart.Name(), err)
 		}
@@ -69,6 +78,7 @@ func renderChartRecursive(ctx context.Context, chart *helmchart.Chart, values ch
 			filepath.Join(chartPath, "charts", dep.Name()),
 			tempDirPath,
 			denoBin,
+			lookup,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("render dependency %q: %w", dep.Name(), err)
@@ -80,18 +90,38 @@ func renderChartRecursive(ctx context.Context, chart *helmchart.Chart, values ch
 	return results, nil
 }
 
-func renderChart(ctx context.Context, bundle *helmchart.File, chart *helmchart.Chart, renderedValues chartutil.Values, tempDirPath, denoBin string) (string, error) {
+func renderChart(ctx context.Context, bundle *helmchart.File, chart *helmchart.Chart, renderedValues chartutil.Values, tempDirPath, denoBin string, lookup lookupFunc) (string, error) {
 	renderDir := filepath.Join(tempDirPath, "typescript-render", chart.ChartFullPath())
 	if err := os.MkdirAll(renderDir, 0o755); err != nil {
 		return "", fmt.Errorf("create temp dir for render context: %w", err)
 	}
 
-	if err := writeInputRenderContext(renderedValues, chart, renderDir); err != nil {
-		return "", fmt.Errorf("write input render context: %w", err)
-	}
+	lookupResults := []*lookupResult{}
+	for round := 1; ; round++ {
+		if err := writeInputRenderContext(renderedValues, chart, lookupResults, renderDir); err != nil {
+			return "", fmt.Errorf("write input render context: %w", err)
+		}
+
+		lookupRequests, err := runApp(ctx, bundle.Data, renderDir, denoBin)
+		if err != nil {
+			return "", fmt.Errorf("run deno app: %w", err)
+		}
+
+		var newResolved int
+		lookupResults, newResolved, err = resolveLookupRequests(lookupRequests, lookupResults, lookup)
+		if err != nil {
+			return "", fmt.Errorf("resolve lookup requests: %w", err)
+		}
+
+		if newResolved == 0 {
+			break
+		}
+
+		if round == maxLookupRounds {
+			return "", fmt.Errorf("lookup requests are not settled after %d runs of deno app", maxLookupRounds)
+		}
 
-	if err := runApp(ctx, bundle.Data, renderDir, denoBin); err != nil {
-		return "", fmt.Errorf("run deno app: %w", err)
+		log.Default.Debug(ctx, "Resolved %d new lookup requests for chart %q, rerunning deno app", newResolved, chart.Name())
 	}
 
 	resultBytes, err := os.ReadFile(filepath.Join(renderDir, common.ChartTSOutputFile))
@@ -140,7 +170,7 @@ func scopeValuesForSubchart(parentValues chartutil.Values, subchartName string,
 	return scoped
 }
 
-func writeInputRenderContext(renderedValues chartutil.Values, chart *helmchart.Chart, renderDir string) error {
+func writeInputRenderContext(renderedValues chartutil.Values, chart *helmchart.Chart, lookupResults []*lookupResult, renderDir string) error {
 	renderContext := renderedValues.AsMap()
 
 	if valuesInterface, ok := renderContext["Values"]; ok {
@@ -157,6 +187,7 @@ func writeInputRenderContext(renderedValues chartutil.Values, chart *helmchart.C
 	}
 
 	renderContext["Files"] = files
+	renderContext["Lookups"] = lookupResults
 
 	yamlInput, err := yaml.Marshal(renderContext)
 	if err != nil {
@@ -0,0 +1,447 @@
+package ts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/werf/nelm/pkg/common"
+	"github.com/werf/nelm/pkg/helm/pkg/chartutil"
+	"github.com/werf/nelm/pkg/log"
+)
+
+const valuesTypesHeader = "// Code generated by \"nelm chart ts types\". DO NOT EDIT.\n"
+
+var tsIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// CheckTypes type-checks the TypeScript entrypoint of the chart, so that, e.g., access to
+// undeclared values fails before the chart is bundled.
+func CheckTypes(ctx context.Context, chartPath, binaryPath string) error {
+	tsDir := filepath.Join(chartPath, common.ChartTSSourceDir)
+
+	if exists, err := fileExists(filepath.Join(tsDir, common.ChartTSEntryPointTS)); err != nil {
+		return err
+	} else if !exists {
+		return nil
+	}
+
+	denoBin, err := getDenoBinary(ctx, binaryPath)
+	if err != nil {
+		return fmt.Errorf("ensure Deno is available: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, denoBin, "check", common.ChartTSEntryPointTS)
+	cmd.Dir = tsDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			log.Default.Error(ctx, "deno check error: %s", string(output))
+		}
+
+		return fmt.Errorf("run deno check: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateValuesTypes generates the TypeScript Values interface of the chart into
+// ts/src/values.ts. The interface is generated from values.schema.json if it exists, otherwise
+// it is inferred from values.yaml. Returns the path to the generated file.
+func GenerateValuesTypes(ctx context.Context, chartPath string) (string, error) {
+	srcDir := filepath.Join(chartPath, common.ChartTSSourceDir, filepath.Dir(common.ChartTSValuesTypesFile))
+	if _, err := os.Stat(srcDir); err != nil {
+		return "", fmt.Errorf("stat %s: %w", srcDir, err)
+	}
+
+	valuesType, err := buildValuesType(ctx, chartPath)
+	if err != nil {
+		return "", err
+	}
+
+	var content string
+	if strings.HasPrefix(valuesType, "{") {
+		content = fmt.Sprintf("%s\nexport interface Values %s\n", valuesTypesHeader, valuesType)
+	} else {
+		content = fmt.Sprintf("%s\nexport type Values = %s;\n", valuesTypesHeader, valuesType)
+	}
+
+	typesPath := filepath.Join(chartPath, common.ChartTSSourceDir, common.ChartTSValuesTypesFile)
+	if err := os.WriteFile(typesPath, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", typesPath, err)
+	}
+
+	log.Default.Debug(ctx, "Generated %s", typesPath)
+
+	return typesPath, nil
+}
+
+func buildValuesType(ctx context.Context, chartPath string) (string, error) {
+	schemaPath := filepath.Join(chartPath, chartutil.SchemafileName)
+
+	schemaData, err := os.ReadFile(schemaPath)
+	if err == nil {
+		var schema any
+		if err := json.Unmarshal(schemaData, &schema); err != nil {
+			return "", fmt.Errorf("unmarshal %s: %w", schemaPath, err)
+		}
+
+		log.Default.Debug(ctx, "Generating values types from %s", schemaPath)
+
+		return newSchemaTypeConverter(schema).convert(schema, 0), nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("read %s: %w", schemaPath, err)
+	}
+
+	valuesPath := filepath.Join(chartPath, chartutil.ValuesfileName)
+
+	var values map[string]any
+
+	valuesData, err := os.ReadFile(valuesPath)
+	if err == nil {
+		if err := yaml.Unmarshal(valuesData, &values); err != nil {
+			return "", fmt.Errorf("unmarshal %s: %w", valuesPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("read %s: %w", valuesPath, err)
+	}
+
+	log.Default.Debug(ctx, "Inferring values types from %s", valuesPath)
+
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	return inferValueType(values, 0), nil
+}
+
+type schemaTypeConverter struct {
+	root         any
+	resolvingRef map[string]bool
+}
+
+func newSchemaTypeConverter(root any) *schemaTypeConverter {
+	return &schemaTypeConverter{
+		root:         root,
+		resolvingRef: make(map[string]bool),
+	}
+}
+
+func (c *schemaTypeConverter) convert(schema any, depth int) string {
+	switch s := schema.(type) {
+	case bool:
+		if s {
+			return "unknown"
+		}
+
+		return "never"
+	case map[string]any:
+		return c.convertObjectSchema(s, depth)
+	default:
+		return "unknown"
+	}
+}
+
+func (c *schemaTypeConverter) convertObjectSchema(schema map[string]any, depth int) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return c.convertRef(ref, depth)
+	}
+
+	if constVal, ok := schema["const"]; ok {
+		return tsLiteral(constVal)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return tsUnion(lo.Map(enum, func(v any, _ int) string {
+			return tsLiteral(v)
+		}))
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if subschemas, ok := schema[key].([]any); ok && len(subschemas) > 0 {
+			return tsUnion(lo.Map(subschemas, func(s any, _ int) string {
+				return c.convert(s, depth)
+			}))
+		}
+	}
+
+	if subschemas, ok := schema["allOf"].([]any); ok && len(subschemas) > 0 {
+		types := lo.Uniq(lo.Map(subschemas, func(s any, _ int) string {
+			return tsParenthesize(c.convert(s, depth))
+		}))
+
+		return strings.Join(types, " & ")
+	}
+
+	var schemaTypes []string
+	switch t := schema["type"].(type) {
+	case string:
+		schemaTypes = []string{t}
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				schemaTypes = append(schemaTypes, s)
+			}
+		}
+	default:
+		if _, ok := schema["properties"]; ok {
+			schemaTypes = []string{"object"}
+		} else if _, ok := schema["items"]; ok {
+			schemaTypes = []string{"array"}
+		}
+	}
+
+	if len(schemaTypes) == 0 {
+		return "unknown"
+	}
+
+	return tsUnion(lo.Map(schemaTypes, func(schemaType string, _ int) string {
+		switch schemaType {
+		case "string":
+			return "string"
+		case "integer", "number":
+			return "number"
+		case "boolean":
+			return "boolean"
+		case "null":
+			return "null"
+		case "array":
+			itemsType := "unknown"
+			if items, ok := schema["items"]; ok {
+				itemsType = c.convert(items, depth)
+			}
+
+			return tsParenthesize(itemsType) + "[]"
+		case "object":
+			return c.convertObjectType(schema, depth)
+		default:
+			return "unknown"
+		}
+	}))
+}
+
+func (c *schemaTypeConverter) convertObjectType(schema map[string]any, depth int) string {
+	properties, _ := schema["properties"].(map[string]any)
+	additionalProperties, hasAdditionalProperties := schema["additionalProperties"]
+
+	if len(properties) == 0 {
+		if !hasAdditionalProperties {
+			return "Record<string, unknown>"
+		}
+
+		if allowed, ok := additionalProperties.(bool); ok && !allowed {
+			return "Record<string, never>"
+		}
+
+		return fmt.Sprintf("Record<string, %s>", c.convert(additionalProperties, depth))
+	}
+
+	required := map[string]bool{}
+	if requiredList, ok := schema["required"].([]any); ok {
+		for _, r := range requiredList {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+
+	var body strings.Builder
+	body.WriteString("{\n")
+
+	names := lo.Keys(properties)
+	sort.Strings(names)
+
+	for _, name := range names {
+		propSchema := properties[name]
+
+		if description := c.description(propSchema); description != "" {
+			body.WriteString(tsDocComment(description, indent))
+		}
+
+		optional := lo.Ternary(required[name], "", "?")
+		fmt.Fprintf(&body, "%s%s%s: %s;\n", indent, tsPropertyName(name), optional, c.convert(propSchema, depth+1))
+	}
+
+	// Additional properties are allowed by JSON Schema by default, but we only declare them when
+	// explicitly enabled, otherwise typos in value names would type-check just fine.
+	if allowed, ok := additionalProperties.(bool); (ok && allowed) || (!ok && hasAdditionalProperties) {
+		fmt.Fprintf(&body, "%s[key: string]: unknown;\n", indent)
+	}
+
+	body.WriteString(strings.Repeat("  ", depth) + "}")
+
+	return body.String()
+}
+
+func (c *schemaTypeConverter) convertRef(ref string, depth int) string {
+	if c.resolvingRef[ref] {
+		return "unknown"
+	}
+
+	resolved, ok := c.resolveRef(ref)
+	if !ok {
+		return "unknown"
+	}
+
+	c.resolvingRef[ref] = true
+	defer delete(c.resolvingRef, ref)
+
+	return c.convert(resolved, depth)
+}
+
+func (c *schemaTypeConverter) description(schema any) string {
+	schemaMap, ok := schema.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	if description, ok := schemaMap["description"].(string); ok && description != "" {
+		return description
+	}
+
+	if ref, ok := schemaMap["$ref"].(string); ok {
+		if resolved, ok := c.resolveRef(ref); ok {
+			if resolvedMap, ok := resolved.(map[string]any); ok {
+				description, _ := resolvedMap["description"].(string)
+				return description
+			}
+		}
+	}
+
+	return ""
+}
+
+// Only local JSON pointer references are supported.
+func (c *schemaTypeConverter) resolveRef(ref string) (any, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	resolved := c.root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		m, ok := resolved.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if resolved, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+
+	return resolved, true
+}
+
+func inferValueType(value any, depth int) string {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return "Record<string, unknown>"
+		}
+
+		indent := strings.Repeat("  ", depth+1)
+
+		var body strings.Builder
+		body.WriteString("{\n")
+
+		names := lo.Keys(v)
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&body, "%s%s?: %s;\n", indent, tsPropertyName(name), inferValueType(v[name], depth+1))
+		}
+
+		body.WriteString(strings.Repeat("  ", depth) + "}")
+
+		return body.String()
+	case []any:
+		if len(v) == 0 {
+			return "unknown[]"
+		}
+
+		return tsParenthesize(tsUnion(lo.Map(v, func(item any, _ int) string {
+			return inferValueType(item, depth)
+		}))) + "[]"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	default:
+		return "unknown"
+	}
+}
+
+func tsDocComment(description, indent string) string {
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s/** %s */\n", indent, strings.ReplaceAll(lines[0], "*/", "*\\/"))
+	}
+
+	var comment strings.Builder
+	comment.WriteString(indent + "/**\n")
+
+	for _, line := range lines {
+		comment.WriteString(strings.TrimRight(fmt.Sprintf("%s * %s", indent, strings.ReplaceAll(line, "*/", "*\\/")), " ") + "\n")
+	}
+
+	comment.WriteString(indent + " */\n")
+
+	return comment.String()
+}
+
+func tsLiteral(value any) string {
+	literal, err := json.Marshal(value)
+	if err != nil {
+		return "unknown"
+	}
+
+	return string(literal)
+}
+
+func tsParenthesize(tsType string) string {
+	if strings.HasPrefix(tsType, "{") || !strings.ContainsAny(tsType, "|&") {
+		return tsType
+	}
+
+	return "(" + tsType + ")"
+}
+
+func tsPropertyName(name string) string {
+	if tsIdentifierRegexp.MatchString(name) {
+		return name
+	}
+
+	return tsLiteral(name)
+}
+
+func tsUnion(types []string) string {
+	types = lo.Uniq(types)
+	if lo.Contains(types, "unknown") {
+		return "unknown"
+	}
+
+	return strings.Join(lo.Map(types, func(t string, _ int) string {
+		return tsParenthesize(t)
+	}), " | ")
+}
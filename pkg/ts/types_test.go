@@ -0,0 +1,121 @@
+package ts_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/ts"
+)
+
+func TestGenerateValuesTypes(t *testing.T) {
+	t.Run("generates types from values.schema.json", func(t *testing.T) {
+		chartPath := newTypesTestChart(t)
+
+		schema := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image"],
+  "definitions": {
+    "port": {"type": "integer", "description": "Service port."}
+  },
+  "properties": {
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "repository": {"type": "string"},
+        "pullPolicy": {"enum": ["Always", "IfNotPresent"]}
+      }
+    },
+    "service": {
+      "type": "object",
+      "properties": {
+        "port": {"$ref": "#/definitions/port"},
+        "annotations": {"type": "object", "additionalProperties": {"type": "string"}}
+      }
+    },
+    "tolerations": {"type": "array", "items": {"type": "object"}},
+    "nodeName": {"type": ["string", "null"]},
+    "app.kubernetes.io/name": {"type": "string"}
+  }
+}`
+		require.NoError(t, os.WriteFile(filepath.Join(chartPath, "values.schema.json"), []byte(schema), 0o644))
+
+		typesPath, err := ts.GenerateValuesTypes(context.Background(), chartPath)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(typesPath)
+		require.NoError(t, err)
+
+		expected := `// Code generated by "nelm chart ts types". DO NOT EDIT.
+
+export interface Values {
+  "app.kubernetes.io/name"?: string;
+  image: {
+    pullPolicy?: "Always" | "IfNotPresent";
+    repository: string;
+  };
+  nodeName?: string | null;
+  service?: {
+    annotations?: Record<string, string>;
+    /** Service port. */
+    port?: number;
+  };
+  tolerations?: Record<string, unknown>[];
+}
+`
+		assert.Equal(t, expected, string(content))
+	})
+
+	t.Run("infers types from values.yaml", func(t *testing.T) {
+		chartPath := newTypesTestChart(t)
+
+		values := `replicaCount: 1
+image:
+  repository: nginx
+  tag: latest
+podAnnotations: {}
+args: ["--verbose", 1]
+affinity:
+`
+		require.NoError(t, os.WriteFile(filepath.Join(chartPath, "values.yaml"), []byte(values), 0o644))
+
+		typesPath, err := ts.GenerateValuesTypes(context.Background(), chartPath)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(typesPath)
+		require.NoError(t, err)
+
+		expected := `// Code generated by "nelm chart ts types". DO NOT EDIT.
+
+export interface Values {
+  affinity?: unknown;
+  args?: (string | number)[];
+  image?: {
+    repository?: string;
+    tag?: string;
+  };
+  podAnnotations?: Record<string, unknown>;
+  replicaCount?: number;
+}
+`
+		assert.Equal(t, expected, string(content))
+	})
+
+	t.Run("fails without ts sources directory", func(t *testing.T) {
+		_, err := ts.GenerateValuesTypes(context.Background(), t.TempDir())
+		assert.Error(t, err)
+	})
+}
+
+func newTypesTestChart(t *testing.T) string {
+	chartPath := filepath.Join(t.TempDir(), "test-chart")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartPath, "ts", "src"), 0o755))
+
+	return chartPath
+}
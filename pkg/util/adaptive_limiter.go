@@ -0,0 +1,84 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// Limits the number of concurrent operations, adapting the limit to throttling: the limit is
+// halved when an operation was throttled or when the throttling counter grew since the last
+// operation, and it is increased by one after every other operation, up to the max.
+type AdaptiveLimiter struct {
+	maxLimit       int
+	throttledCount func() int64
+
+	mu                 sync.Mutex
+	inUse              int
+	lastThrottledCount int64
+	limit              int
+	released           chan struct{}
+}
+
+// If throttledCount is nil, only throttled operations reported on Release reduce the limit.
+func NewAdaptiveLimiter(maxLimit int, throttledCount func() int64) *AdaptiveLimiter {
+	maxLimit = max(maxLimit, 1)
+
+	if throttledCount == nil {
+		throttledCount = func() int64 { return 0 }
+	}
+
+	return &AdaptiveLimiter{
+		maxLimit:           maxLimit,
+		throttledCount:     throttledCount,
+		lastThrottledCount: throttledCount(),
+		limit:              maxLimit,
+		released:           make(chan struct{}),
+	}
+}
+
+// Blocks until the operation can be started or the context is done.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inUse < l.limit {
+			l.inUse++
+			l.mu.Unlock()
+
+			return nil
+		}
+
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// Must be called once the acquired operation finished.
+func (l *AdaptiveLimiter) Release(throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inUse--
+
+	if count := l.throttledCount(); throttled || count > l.lastThrottledCount {
+		l.lastThrottledCount = count
+		l.limit = max(l.limit/2, 1)
+	} else if l.limit < l.maxLimit {
+		l.limit++
+	}
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
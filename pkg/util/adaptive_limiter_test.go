@@ -0,0 +1,59 @@
+package util_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/util"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	var throttledCount int64
+
+	limiter := util.NewAdaptiveLimiter(8, func() int64 { return throttledCount })
+	assert.Equal(t, 8, limiter.Limit())
+
+	require.NoError(t, limiter.Acquire(ctx))
+	limiter.Release(true)
+	assert.Equal(t, 4, limiter.Limit())
+
+	throttledCount++
+
+	require.NoError(t, limiter.Acquire(ctx))
+	limiter.Release(false)
+	assert.Equal(t, 2, limiter.Limit())
+
+	require.NoError(t, limiter.Acquire(ctx))
+	limiter.Release(false)
+	assert.Equal(t, 3, limiter.Limit())
+
+	for range 3 {
+		require.NoError(t, limiter.Acquire(ctx))
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	require.Error(t, limiter.Acquire(timeoutCtx), "limit must be reached")
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- limiter.Acquire(ctx)
+	}()
+
+	limiter.Release(false)
+	require.NoError(t, <-acquired)
+
+	for range 20 {
+		limiter.Release(false)
+		require.NoError(t, limiter.Acquire(ctx))
+	}
+
+	assert.Equal(t, 8, limiter.Limit())
+}
@@ -0,0 +1,165 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const DefaultFileWatcherPollInterval = 500 * time.Millisecond
+
+// FileWatcher polls files and directories (recursively) for changes. Polling is used instead of
+// inotify and alike, because it works the same on all platforms, in containers and on network
+// filesystems, and the number of files in a chart is small anyway.
+type FileWatcher struct {
+	ignore   func(path string, isDir bool) bool
+	interval time.Duration
+	paths    []string
+	state    map[string]fileWatcherEntry
+}
+
+type FileWatcherOptions struct {
+	// Ignore, if returns true, excludes the file or the whole directory from watching.
+	Ignore       func(path string, isDir bool) bool
+	PollInterval time.Duration
+}
+
+type fileWatcherEntry struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewFileWatcher(paths []string, opts FileWatcherOptions) *FileWatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultFileWatcherPollInterval
+	}
+
+	if opts.Ignore == nil {
+		opts.Ignore = func(string, bool) bool { return false }
+	}
+
+	return &FileWatcher{
+		ignore:   opts.Ignore,
+		interval: opts.PollInterval,
+		paths:    paths,
+	}
+}
+
+// Call onChange with the sorted list of created, modified and deleted files every time changes
+// are detected, until the context is canceled or onChange returns an error. Changes made before
+// the first call of Watch are not reported.
+func (w *FileWatcher) Watch(ctx context.Context, onChange func(ctx context.Context, changedPaths []string) error) error {
+	if w.state == nil {
+		if _, err := w.Changes(); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changedPaths, err := w.Changes()
+		if err != nil {
+			return err
+		}
+
+		if len(changedPaths) == 0 {
+			continue
+		}
+
+		if err := onChange(ctx, changedPaths); err != nil {
+			return err
+		}
+	}
+}
+
+// Rescan the watched files and return the sorted list of files created, modified or deleted
+// since the previous call. The first call only remembers the current state of the files.
+func (w *FileWatcher) Changes() ([]string, error) {
+	newState, err := w.scan()
+	if err != nil {
+		return nil, fmt.Errorf("scan watched files: %w", err)
+	}
+
+	if w.state == nil {
+		w.state = newState
+		return nil, nil
+	}
+
+	var changedPaths []string
+	for path, newEntry := range newState {
+		if oldEntry, found := w.state[path]; !found || oldEntry != newEntry {
+			changedPaths = append(changedPaths, path)
+		}
+	}
+
+	for path := range w.state {
+		if _, found := newState[path]; !found {
+			changedPaths = append(changedPaths, path)
+		}
+	}
+
+	w.state = newState
+	sort.Strings(changedPaths)
+
+	return changedPaths, nil
+}
+
+func (w *FileWatcher) scan() (map[string]fileWatcherEntry, error) {
+	state := map[string]fileWatcherEntry{}
+
+	for _, root := range w.paths {
+		if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if w.ignore(path, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return fmt.Errorf("get file info for %q: %w", path, err)
+			}
+
+			state[path] = fileWatcherEntry{
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("walk %q: %w", root, err)
+		}
+	}
+
+	return state, nil
+}
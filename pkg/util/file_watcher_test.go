@@ -0,0 +1,55 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/werf/nelm/pkg/util"
+)
+
+func TestFileWatcherChanges(t *testing.T) {
+	dir := t.TempDir()
+	modifiedFile := filepath.Join(dir, "templates", "deployment.yaml")
+	deletedFile := filepath.Join(dir, "values.yaml")
+	ignoredFile := filepath.Join(dir, "node_modules", "dep.js")
+
+	for _, file := range []string{modifiedFile, deletedFile, ignoredFile} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	}
+
+	watcher := util.NewFileWatcher([]string{dir, filepath.Join(dir, "missing")}, util.FileWatcherOptions{
+		Ignore: func(path string, isDir bool) bool {
+			return isDir && filepath.Base(path) == "node_modules"
+		},
+	})
+
+	changedPaths, err := watcher.Changes()
+	require.NoError(t, err)
+	assert.Empty(t, changedPaths)
+
+	createdFile := filepath.Join(dir, "templates", "service.yaml")
+	require.NoError(t, os.WriteFile(createdFile, []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(modifiedFile, []byte("ab"), 0o644))
+	require.NoError(t, os.Remove(deletedFile))
+	require.NoError(t, os.WriteFile(ignoredFile, []byte("ab"), 0o644))
+
+	changedPaths, err = watcher.Changes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{modifiedFile, createdFile, deletedFile}, changedPaths)
+
+	changedPaths, err = watcher.Changes()
+	require.NoError(t, err)
+	assert.Empty(t, changedPaths)
+
+	require.NoError(t, os.Chtimes(createdFile, time.Now(), time.Now().Add(time.Hour)))
+
+	changedPaths, err = watcher.Changes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{createdFile}, changedPaths)
+}